@@ -0,0 +1,134 @@
+package address
+
+import (
+	"api/schemas"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestViaCEPProviderLookup(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/80010000/json/":
+			w.Write([]byte(`{"cep":"80010-000","logradouro":"Praça Tiradentes","bairro":"Centro","localidade":"Curitiba","uf":"PR"}`))
+		case "/99999999/json/":
+			w.Write([]byte(`{"erro":"true"}`))
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	provider := NewViaCEPProvider(server.URL + "/").WithHTTPClient(server.Client())
+
+	found, err := provider.Lookup(context.Background(), "80010000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := schemas.Address{ZipCode: "80010000", Address: "Praça Tiradentes", Neighborhood: "Centro", City: "Curitiba", State: "PR"}
+	if found != want {
+		t.Errorf("address = %+v, want %+v", found, want)
+	}
+
+	if _, err := provider.Lookup(context.Background(), "99999999"); err != ErrNotFound {
+		t.Errorf("err = %v, want ErrNotFound", err)
+	}
+	if _, err := provider.Lookup(context.Background(), "11111111"); err == nil || err == ErrNotFound {
+		t.Errorf("err = %v, want provider error", err)
+	}
+}
+
+type countingProvider struct {
+	calls int
+	err   error
+}
+
+func (p *countingProvider) Lookup(ctx context.Context, cep string) (schemas.Address, error) {
+	p.calls++
+	if p.err != nil {
+		return schemas.Address{}, p.err
+	}
+	if cep == "99999999" {
+		return schemas.Address{}, ErrNotFound
+	}
+	return schemas.Address{ZipCode: cep, City: "Curitiba", State: "PR"}, nil
+}
+
+func TestCachedProvider(t *testing.T) {
+	next := &countingProvider{}
+	cached := NewCachedProvider(next, time.Hour)
+	now := time.Now()
+	cached.now = func() time.Time { return now }
+
+	for range 2 {
+		if _, err := cached.Lookup(context.Background(), "80010000"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := cached.Lookup(context.Background(), "99999999"); err != ErrNotFound {
+			t.Fatalf("err = %v, want ErrNotFound", err)
+		}
+	}
+	if next.calls != 2 {
+		t.Errorf("calls = %d, want 2 (cached hit and miss)", next.calls)
+	}
+
+	now = now.Add(2 * time.Hour)
+	cached.Lookup(context.Background(), "80010000")
+	if next.calls != 3 {
+		t.Errorf("calls = %d, want 3 after expiration", next.calls)
+	}
+
+	next.err = errors.New("fora do ar")
+	cached.Lookup(context.Background(), "01001000")
+	cached.Lookup(context.Background(), "01001000")
+	if next.calls != 5 {
+		t.Errorf("calls = %d, want 5 (errors are not cached)", next.calls)
+	}
+}
+
+func TestHandlerCEP(t *testing.T) {
+	CEPProvider = NewFixtureProvider([]schemas.Address{
+		{ZipCode: "80010-000", Address: "Praça Tiradentes", Neighborhood: "Centro", City: "Curitiba", State: "pr"},
+	})
+	t.Cleanup(func() { CEPProvider = nil })
+
+	tests := []struct {
+		cep    string
+		status int
+	}{
+		{"80010-000", http.StatusOK},
+		{"80010000", http.StatusOK},
+		{"99999999", http.StatusNotFound},
+		{"123", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/v1/address/cep/"+tt.cep, nil)
+		r.SetPathValue("cep", tt.cep)
+		w := httptest.NewRecorder()
+		HandlerCEP(w, r)
+
+		if w.Code != tt.status {
+			t.Errorf("%s: status = %d, want %d", tt.cep, w.Code, tt.status)
+			continue
+		}
+		if tt.status != http.StatusOK {
+			continue
+		}
+
+		var response struct {
+			Data schemas.Address `json:"data"`
+		}
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatal(err)
+		}
+		if response.Data.ZipCode != "80010-000" || response.Data.State != "PR" {
+			t.Errorf("%s: address = %+v", tt.cep, response.Data)
+		}
+	}
+}
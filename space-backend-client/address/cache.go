@@ -0,0 +1,73 @@
+package address
+
+import (
+	"api/schemas"
+	"context"
+	"sync"
+	"time"
+)
+
+// CEP_CACHE_MAX_ENTRIES limita a memória do cache; ao atingir o limite, as
+// entradas expiradas são descartadas e, se ainda estiver cheio, o cache é
+// esvaziado.
+const CEP_CACHE_MAX_ENTRIES = 10000
+
+type cacheEntry struct {
+	address   schemas.Address
+	err       error
+	expiresAt time.Time
+}
+
+// CachedProvider guarda as respostas do provedor (endereços e CEPs
+// inexistentes) por ttl. Falhas do provedor não são guardadas.
+type CachedProvider struct {
+	next    Provider
+	ttl     time.Duration
+	now     func() time.Time
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+func NewCachedProvider(next Provider, ttl time.Duration) *CachedProvider {
+	return &CachedProvider{
+		next:    next,
+		ttl:     ttl,
+		now:     time.Now,
+		entries: make(map[string]cacheEntry),
+	}
+}
+
+func (p *CachedProvider) Lookup(ctx context.Context, cep string) (schemas.Address, error) {
+	p.mu.Lock()
+	entry, ok := p.entries[cep]
+	p.mu.Unlock()
+	if ok && p.now().Before(entry.expiresAt) {
+		return entry.address, entry.err
+	}
+
+	address, err := p.next.Lookup(ctx, cep)
+	if err != nil && err != ErrNotFound {
+		return schemas.Address{}, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.entries) >= CEP_CACHE_MAX_ENTRIES {
+		p.evict()
+	}
+	p.entries[cep] = cacheEntry{address: address, err: err, expiresAt: p.now().Add(p.ttl)}
+
+	return address, err
+}
+
+func (p *CachedProvider) evict() {
+	now := p.now()
+	for cep, entry := range p.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(p.entries, cep)
+		}
+	}
+	if len(p.entries) >= CEP_CACHE_MAX_ENTRIES {
+		clear(p.entries)
+	}
+}
@@ -0,0 +1,55 @@
+package address
+
+import (
+	"api/documents"
+	"api/schemas"
+	"context"
+	"encoding/json"
+	"os"
+)
+
+// FixtureProvider responde a partir de um conjunto fixo de endereços, sem
+// rede. É usado nos testes e em desenvolvimento (CEP_PROVIDER=fixture).
+type FixtureProvider struct {
+	addresses map[string]schemas.Address
+}
+
+// NewFixtureProvider indexa os endereços pelo CEP normalizado. Endereços com
+// CEP inválido são ignorados.
+func NewFixtureProvider(addresses []schemas.Address) *FixtureProvider {
+	p := &FixtureProvider{addresses: make(map[string]schemas.Address, len(addresses))}
+	for _, address := range addresses {
+		cep, err := documents.NormalizeCEP(address.ZipCode)
+		if err != nil {
+			continue
+		}
+		address.ZipCode = cep
+		if state, err := documents.NormalizeUF(address.State); err == nil {
+			address.State = state
+		}
+		p.addresses[cep] = address
+	}
+	return p
+}
+
+// LoadFixtureProvider lê um arquivo JSON com uma lista de schemas.Address.
+func LoadFixtureProvider(path string) (*FixtureProvider, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var addresses []schemas.Address
+	if err := json.Unmarshal(data, &addresses); err != nil {
+		return nil, err
+	}
+	return NewFixtureProvider(addresses), nil
+}
+
+func (p *FixtureProvider) Lookup(ctx context.Context, cep string) (schemas.Address, error) {
+	address, ok := p.addresses[cep]
+	if !ok {
+		return schemas.Address{}, ErrNotFound
+	}
+	return address, nil
+}
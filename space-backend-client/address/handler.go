@@ -0,0 +1,65 @@
+package address
+
+import (
+	"api/documents"
+	"api/schemas"
+	"api/utils"
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+)
+
+// CEP_LOOKUP_TIMEOUT limita a espera pelo provedor externo.
+const CEP_LOOKUP_TIMEOUT = 8 * time.Second
+
+// CEPProvider é injetado pelo main (ver NewFromEnv).
+var CEPProvider Provider
+
+// HandlerCEP responde GET /v1/address/cep/{cep} com o endereço do CEP.
+func HandlerCEP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.HTTP_METHOD_NO_ALLOWED),
+		})
+		return
+	}
+
+	cep, err := documents.NormalizeCEP(r.PathValue("cep"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: "CEP inválido",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), CEP_LOOKUP_TIMEOUT)
+	defer cancel()
+
+	found, err := CEPProvider.Lookup(ctx, cep)
+	if err != nil {
+		if err == ErrNotFound {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(schemas.ApiResponse{
+				Message: "CEP não encontrado",
+			})
+			return
+		}
+		log.Printf("[CEP] Erro ao consultar %s: %v", cep, err)
+		w.WriteHeader(http.StatusBadGateway)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: "Serviço de consulta de CEP indisponível",
+		})
+		return
+	}
+
+	found.ZipCode = documents.FormatCEP(found.ZipCode)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(schemas.ApiResponse{
+		Data: found,
+	})
+}
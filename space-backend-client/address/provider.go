@@ -0,0 +1,54 @@
+// Package address consulta endereços pelo CEP. A consulta passa por um
+// Provider (ViaCEP em produção, fixture local em testes e desenvolvimento)
+// envolvido por um cache em memória.
+package address
+
+import (
+	"api/schemas"
+	"api/utils"
+	"context"
+	"errors"
+	"log"
+	"os"
+	"time"
+)
+
+const (
+	CEP_PROVIDER_VIACEP  = "viacep"
+	CEP_PROVIDER_FIXTURE = "fixture"
+
+	// CEP_CACHE_TTL é por quanto tempo uma consulta (inclusive CEP
+	// inexistente) é reaproveitada. Endereços de CEP quase nunca mudam.
+	CEP_CACHE_TTL = 24 * time.Hour
+)
+
+// ErrNotFound indica um CEP válido que não existe na base do provedor.
+var ErrNotFound = errors.New("CEP não encontrado")
+
+// Provider busca o endereço de um CEP já normalizado (8 dígitos). O endereço
+// devolvido também vem normalizado: CEP só com dígitos e UF em maiúsculas.
+type Provider interface {
+	Lookup(ctx context.Context, cep string) (schemas.Address, error)
+}
+
+// NewFromEnv escolhe a implementação pela variável CEP_PROVIDER. Sem valor,
+// usa o ViaCEP (VIACEP_URL permite apontar para outro servidor compatível).
+// Com "fixture", lê os endereços do arquivo JSON em CEP_FIXTURES_FILE.
+func NewFromEnv() Provider {
+	var provider Provider
+	switch os.Getenv(utils.CEP_PROVIDER) {
+	case CEP_PROVIDER_FIXTURE:
+		fixture, err := LoadFixtureProvider(os.Getenv(utils.CEP_FIXTURES_FILE))
+		if err != nil {
+			log.Printf("[CEP] Erro ao carregar %s: %v. Usando fixture vazia", utils.CEP_FIXTURES_FILE, err)
+			fixture = NewFixtureProvider(nil)
+		}
+		provider = fixture
+	case CEP_PROVIDER_VIACEP, "":
+		provider = NewViaCEPProvider(os.Getenv(utils.VIACEP_URL))
+	default:
+		log.Printf("[CEP] Valor desconhecido para CEP_PROVIDER: %s. Usando viacep", os.Getenv(utils.CEP_PROVIDER))
+		provider = NewViaCEPProvider(os.Getenv(utils.VIACEP_URL))
+	}
+	return NewCachedProvider(provider, CEP_CACHE_TTL)
+}
@@ -0,0 +1,97 @@
+package address
+
+import (
+	"api/documents"
+	"api/schemas"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	VIACEP_URL     = "https://viacep.com.br/ws"
+	VIACEP_TIMEOUT = 5 * time.Second
+)
+
+type viaCEPResponse struct {
+	Cep        string `json:"cep"`
+	Logradouro string `json:"logradouro"`
+	Bairro     string `json:"bairro"`
+	Localidade string `json:"localidade"`
+	Uf         string `json:"uf"`
+	// Erro vem como true (ou "true" nas versões mais novas) quando o CEP não existe
+	Erro any `json:"erro"`
+}
+
+// ViaCEPProvider consulta GET {baseURL}/{cep}/json/.
+type ViaCEPProvider struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewViaCEPProvider usa VIACEP_URL quando baseURL está vazio.
+func NewViaCEPProvider(baseURL string) *ViaCEPProvider {
+	if baseURL == "" {
+		baseURL = VIACEP_URL
+	}
+	return &ViaCEPProvider{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: VIACEP_TIMEOUT},
+	}
+}
+
+// WithHTTPClient troca o http.Client usado nas consultas.
+func (p *ViaCEPProvider) WithHTTPClient(httpClient *http.Client) *ViaCEPProvider {
+	p.httpClient = httpClient
+	return p
+}
+
+func (p *ViaCEPProvider) Lookup(ctx context.Context, cep string) (schemas.Address, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.baseURL+"/"+cep+"/json/", nil)
+	if err != nil {
+		return schemas.Address{}, fmt.Errorf("erro ao criar requisição ViaCEP: %v", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return schemas.Address{}, fmt.Errorf("erro ao chamar ViaCEP: %v", err)
+	}
+	defer resp.Body.Close()
+
+	// O ViaCEP responde 400 para formatos inválidos; o CEP chega aqui normalizado
+	if resp.StatusCode == http.StatusBadRequest || resp.StatusCode == http.StatusNotFound {
+		return schemas.Address{}, ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return schemas.Address{}, fmt.Errorf("ViaCEP respondeu com status %d", resp.StatusCode)
+	}
+
+	var body viaCEPResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return schemas.Address{}, fmt.Errorf("erro ao decodificar resposta ViaCEP: %v", err)
+	}
+	if body.Erro == true || body.Erro == "true" {
+		return schemas.Address{}, ErrNotFound
+	}
+
+	zipCode, err := documents.NormalizeCEP(body.Cep)
+	if err != nil {
+		zipCode = cep
+	}
+	state, err := documents.NormalizeUF(body.Uf)
+	if err != nil {
+		return schemas.Address{}, fmt.Errorf("ViaCEP devolveu UF inválida: %q", body.Uf)
+	}
+
+	return schemas.Address{
+		ZipCode:      zipCode,
+		Address:      body.Logradouro,
+		Neighborhood: body.Bairro,
+		City:         body.Localidade,
+		State:        state,
+	}, nil
+}
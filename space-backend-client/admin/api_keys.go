@@ -0,0 +1,280 @@
+package admin
+
+import (
+	"api/audit"
+	"api/database"
+	"api/middlewares"
+	"api/schemas"
+	"api/utils"
+	"api/validation"
+	"context"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// API_KEY_ROTATION_GRACE_PERIOD é o tempo em que a chave antiga continua
+// válida após uma rotação, para a integração trocar a configuração.
+const API_KEY_ROTATION_GRACE_PERIOD = 24 * time.Hour
+
+// issueApiKey gera e grava uma nova chave, retornando a resposta com a chave
+// completa (a única vez em que ela aparece).
+func issueApiKey(ctx context.Context, r *http.Request, name string, scopes []string, expiresAt *time.Time) (schemas.ApiKeyCreateResponse, error) {
+	key, prefix, err := utils.GenerateApiKey()
+	if err != nil {
+		return schemas.ApiKeyCreateResponse{}, err
+	}
+
+	createdBy, _ := r.Context().Value(middlewares.AdminActorKey).(string)
+	apiKey := schemas.ApiKey{
+		Name:      name,
+		Prefix:    prefix,
+		KeyHash:   utils.HashToken(key),
+		Scopes:    scopes,
+		CreatedBy: createdBy,
+		CreatedAt: time.Now(),
+		ExpiresAt: expiresAt,
+	}
+
+	apiKey.ID, err = Repositories.ApiKeys.Create(ctx, apiKey)
+	if err != nil {
+		return schemas.ApiKeyCreateResponse{}, err
+	}
+
+	audit.Record(ctx, Repositories.AuditEvents, r, audit.Entry{
+		Action:     schemas.AUDIT_ACTION_API_KEY_CREATE,
+		TargetType: schemas.AUDIT_TARGET_API_KEY,
+		TargetID:   apiKey.ID.Hex(),
+		After:      apiKey,
+	})
+
+	return schemas.ApiKeyCreateResponse{ApiKey: apiKey, Key: key}, nil
+}
+
+func listApiKeys(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGODB_TIMEOUT)
+	defer cancel()
+
+	keys, err := Repositories.ApiKeys.List(ctx)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.ERROR_TO_TRY_FIND_MONGODB),
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(schemas.ApiResponse{
+		Data: keys,
+	})
+}
+
+func createApiKey(w http.ResponseWriter, r *http.Request) {
+	req := schemas.ApiKeyCreateRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: "Dados inválidos",
+		})
+		return
+	}
+
+	if errs := validation.Validate(req); errs != nil {
+		validation.WriteErrors(w, errs)
+		return
+	}
+
+	for _, scope := range req.Scopes {
+		if !slices.Contains(schemas.ApiKeyScopes, scope) {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(schemas.ApiResponse{
+				Message: "Escopo inválido: " + scope,
+			})
+			return
+		}
+	}
+
+	var expiresAt *time.Time
+	if req.ExpiresInDays > 0 {
+		at := time.Now().AddDate(0, 0, req.ExpiresInDays)
+		expiresAt = &at
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGODB_TIMEOUT)
+	defer cancel()
+
+	response, err := issueApiKey(ctx, r, req.Name, req.Scopes, expiresAt)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.ERROR_TO_GENERATE_TOKEN),
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(schemas.ApiResponse{
+		Message: "Guarde a chave agora: ela não será exibida novamente",
+		Data:    response,
+	})
+}
+
+func revokeApiKey(w http.ResponseWriter, r *http.Request) {
+	id, err := utils.ParseObjectIDFromHex(r.URL.Query().Get("id"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: "ID da chave inválido",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGODB_TIMEOUT)
+	defer cancel()
+
+	err = Repositories.ApiKeys.Revoke(ctx, id, time.Now())
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(schemas.ApiResponse{
+				Message: "Chave não encontrada ou já revogada",
+			})
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.ERROR_TO_UPDATE_CLIENT_TO_MONGODB),
+		})
+		return
+	}
+
+	audit.Record(ctx, Repositories.AuditEvents, r, audit.Entry{
+		Action:     schemas.AUDIT_ACTION_API_KEY_REVOKE,
+		TargetType: schemas.AUDIT_TARGET_API_KEY,
+		TargetID:   id.Hex(),
+	})
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(schemas.ApiResponse{
+		Message: "Chave revogada com sucesso",
+	})
+}
+
+func HandlerApiKeys(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		listApiKeys(w, r)
+	case http.MethodPost:
+		createApiKey(w, r)
+	case http.MethodDelete:
+		revokeApiKey(w, r)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.HTTP_METHOD_NO_ALLOWED),
+		})
+	}
+}
+
+// HandlerApiKeyRotate emite uma chave com o mesmo nome, escopos e validade da
+// chave informada (?id=). A antiga continua aceita por
+// API_KEY_ROTATION_GRACE_PERIOD e depois expira.
+func HandlerApiKeyRotate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.HTTP_METHOD_NO_ALLOWED),
+		})
+		return
+	}
+
+	id, err := utils.ParseObjectIDFromHex(r.URL.Query().Get("id"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: "ID da chave inválido",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGODB_TIMEOUT)
+	defer cancel()
+
+	current, err := Repositories.ApiKeys.FindByID(ctx, id)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(schemas.ApiResponse{
+				Message: "Chave não encontrada",
+			})
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.ERROR_TO_TRY_FIND_MONGODB),
+		})
+		return
+	}
+
+	now := time.Now()
+	if current.RevokedAt != nil || current.RotatedTo != nil || (current.ExpiresAt != nil && !current.ExpiresAt.After(now)) {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: "Apenas chaves ativas podem ser rotacionadas",
+		})
+		return
+	}
+
+	// A nova chave mantém a mesma duração de validade da original
+	var expiresAt *time.Time
+	if current.ExpiresAt != nil {
+		at := now.Add(current.ExpiresAt.Sub(current.CreatedAt))
+		expiresAt = &at
+	}
+
+	response, err := issueApiKey(ctx, r, current.Name, current.Scopes, expiresAt)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.ERROR_TO_GENERATE_TOKEN),
+		})
+		return
+	}
+
+	graceEnd := now.Add(API_KEY_ROTATION_GRACE_PERIOD)
+	if current.ExpiresAt != nil && current.ExpiresAt.Before(graceEnd) {
+		graceEnd = *current.ExpiresAt
+	}
+
+	err = Repositories.ApiKeys.MarkRotated(ctx, current.ID, response.ID, graceEnd)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.ERROR_TO_UPDATE_CLIENT_TO_MONGODB),
+		})
+		return
+	}
+
+	audit.Record(ctx, Repositories.AuditEvents, r, audit.Entry{
+		Action:     schemas.AUDIT_ACTION_API_KEY_ROTATE,
+		TargetType: schemas.AUDIT_TARGET_API_KEY,
+		TargetID:   current.ID.Hex(),
+		Before:     bson.M{"expires_at": current.ExpiresAt, "rotated_to": nil},
+		After:      bson.M{"expires_at": graceEnd, "rotated_to": response.ID},
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(schemas.ApiResponse{
+		Message: "Guarde a chave agora: ela não será exibida novamente",
+		Data:    response,
+	})
+}
@@ -0,0 +1,81 @@
+package admin
+
+import (
+	"api/database"
+	"api/schemas"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestApiKeyRotationKeepsOldKeyDuringGracePeriod(t *testing.T) {
+	repositories := database.NewMemoryRepositories()
+	Repositories = repositories
+	t.Cleanup(func() { Repositories = nil })
+
+	body, _ := json.Marshal(schemas.ApiKeyCreateRequest{
+		Name:          "ERP",
+		Scopes:        []string{schemas.PERMISSION_UNIFORMS_WRITE},
+		ExpiresInDays: 90,
+	})
+	w := httptest.NewRecorder()
+	HandlerApiKeys(w, httptest.NewRequest(http.MethodPost, "/v1/admin/api-keys", bytes.NewReader(body)))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: status = %d, want %d", w.Code, http.StatusCreated)
+	}
+
+	var created struct {
+		Data schemas.ApiKeyCreateResponse `json:"data"`
+	}
+	json.NewDecoder(w.Body).Decode(&created)
+	if created.Data.Key == "" || created.Data.Prefix == "" {
+		t.Fatalf("created = %+v", created.Data)
+	}
+
+	w = httptest.NewRecorder()
+	HandlerApiKeyRotate(w, httptest.NewRequest(http.MethodPost, "/v1/admin/api-keys/rotate?id="+created.Data.ID.Hex(), nil))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("rotate: status = %d, want %d", w.Code, http.StatusCreated)
+	}
+
+	var rotated struct {
+		Data schemas.ApiKeyCreateResponse `json:"data"`
+	}
+	json.NewDecoder(w.Body).Decode(&rotated)
+	if rotated.Data.Key == created.Data.Key || rotated.Data.Scopes[0] != schemas.PERMISSION_UNIFORMS_WRITE {
+		t.Fatalf("rotated = %+v", rotated.Data)
+	}
+
+	old, _ := repositories.ApiKeys.FindByID(t.Context(), created.Data.ID)
+	if old.RotatedTo == nil || *old.RotatedTo != rotated.Data.ID {
+		t.Errorf("rotated_to = %v", old.RotatedTo)
+	}
+	if old.ExpiresAt == nil || old.ExpiresAt.After(time.Now().Add(API_KEY_ROTATION_GRACE_PERIOD)) {
+		t.Errorf("old key expires_at = %v", old.ExpiresAt)
+	}
+
+	// Uma chave já rotacionada não pode ser rotacionada de novo
+	w = httptest.NewRecorder()
+	HandlerApiKeyRotate(w, httptest.NewRequest(http.MethodPost, "/v1/admin/api-keys/rotate?id="+created.Data.ID.Hex(), nil))
+	if w.Code != http.StatusConflict {
+		t.Fatalf("second rotate: status = %d, want %d", w.Code, http.StatusConflict)
+	}
+}
+
+func TestApiKeyCreationRejectsManagementScopes(t *testing.T) {
+	Repositories = database.NewMemoryRepositories()
+	t.Cleanup(func() { Repositories = nil })
+
+	body, _ := json.Marshal(schemas.ApiKeyCreateRequest{
+		Name:   "Integração",
+		Scopes: []string{schemas.PERMISSION_ADMIN_USERS_MANAGE},
+	})
+	w := httptest.NewRecorder()
+	HandlerApiKeys(w, httptest.NewRequest(http.MethodPost, "/v1/admin/api-keys", bytes.NewReader(body)))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,105 @@
+package admin
+
+import (
+	"api/database"
+	"api/schemas"
+	"api/utils"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	AUDIT_EVENTS_DEFAULT_LIMIT = 50
+	AUDIT_EVENTS_MAX_LIMIT     = 200
+)
+
+// HandlerAuditEvents consulta a trilha de auditoria, do evento mais recente
+// para o mais antigo. Filtros: actor_type, actor_id, action, target_type,
+// target_id, from/to (RFC 3339) e before (cursor da página anterior).
+func HandlerAuditEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.HTTP_METHOD_NO_ALLOWED),
+		})
+		return
+	}
+
+	query := r.URL.Query()
+	filter := schemas.AuditEventFilter{
+		ActorType:  query.Get("actor_type"),
+		ActorID:    query.Get("actor_id"),
+		Action:     query.Get("action"),
+		TargetType: query.Get("target_type"),
+		TargetID:   query.Get("target_id"),
+		Limit:      AUDIT_EVENTS_DEFAULT_LIMIT,
+	}
+
+	var err error
+	for _, param := range []struct {
+		name  string
+		value *time.Time
+	}{
+		{"from", &filter.From},
+		{"to", &filter.To},
+	} {
+		if raw := query.Get(param.name); raw != "" {
+			*param.value, err = time.Parse(time.RFC3339, raw)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(schemas.ApiResponse{
+					Message: "Data inválida em " + param.name + ", use o formato RFC 3339",
+				})
+				return
+			}
+		}
+	}
+
+	if before := query.Get("before"); before != "" {
+		filter.BeforeID, err = utils.ParseObjectIDFromHex(before)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(schemas.ApiResponse{
+				Message: "Cursor inválido",
+			})
+			return
+		}
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		parsed, err := strconv.Atoi(limit)
+		if err != nil || parsed <= 0 || parsed > AUDIT_EVENTS_MAX_LIMIT {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(schemas.ApiResponse{
+				Message: "O limite deve estar entre 1 e " + strconv.Itoa(AUDIT_EVENTS_MAX_LIMIT),
+			})
+			return
+		}
+		filter.Limit = int64(parsed)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGODB_TIMEOUT)
+	defer cancel()
+
+	events, err := Repositories.AuditEvents.Find(ctx, filter)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.ERROR_TO_TRY_FIND_MONGODB),
+		})
+		return
+	}
+
+	response := schemas.AuditEventsResponse{Events: events}
+	if int64(len(events)) == filter.Limit {
+		response.NextBefore = events[len(events)-1].ID.Hex()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(schemas.ApiResponse{
+		Data: response,
+	})
+}
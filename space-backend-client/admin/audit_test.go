@@ -0,0 +1,119 @@
+package admin
+
+import (
+	"api/database"
+	"api/middlewares"
+	"api/schemas"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestAdminActionsAreAudited(t *testing.T) {
+	repositories := database.NewMemoryRepositories()
+	Repositories = repositories
+	t.Cleanup(func() { Repositories = nil })
+
+	repositories.Clients.(*database.MemoryClientsRepository).Insert(schemas.ClientFromDB{
+		Contact: schemas.Contact{Email: "time@example.com"},
+	})
+	client, _ := repositories.Clients.FindByEmail(t.Context(), "time@example.com")
+
+	body, _ := json.Marshal(schemas.ClientAddBudgetRequest{Email: "time@example.com", BudgetID: 42})
+	r := httptest.NewRequest(http.MethodPatch, "/v1/admin/clients", bytes.NewReader(body))
+	ctx := context.WithValue(r.Context(), middlewares.AdminActorKey, "admin:abc")
+	ctx = context.WithValue(ctx, middlewares.RequestIDKey, "req-1")
+
+	w := httptest.NewRecorder()
+	HandlerClients(w, r.WithContext(ctx))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	events := repositories.AuditEvents.(*database.MemoryAuditEventsRepository).Events()
+	if len(events) != 1 {
+		t.Fatalf("events = %d, want 1", len(events))
+	}
+
+	event := events[0]
+	if event.Action != schemas.AUDIT_ACTION_CLIENT_BUDGET_ADD || event.ActorType != schemas.AUDIT_ACTOR_ADMIN || event.ActorID != "abc" {
+		t.Errorf("event = %+v, want budget_add by admin abc", event)
+	}
+	if event.TargetID != client.ID.Hex() || event.RequestID != "req-1" {
+		t.Errorf("target/request = %q/%q, want %q/req-1", event.TargetID, event.RequestID, client.ID.Hex())
+	}
+	if len(event.Changes) != 1 || event.Changes[0].Field != "budget_ids" {
+		t.Errorf("changes = %+v, want budget_ids", event.Changes)
+	}
+}
+
+func TestAuditEventsQueryFiltersAndPaginates(t *testing.T) {
+	repositories := database.NewMemoryRepositories()
+	Repositories = repositories
+	t.Cleanup(func() { Repositories = nil })
+
+	now := time.Now()
+	for i, actorId := range []string{"a", "b", "a", "a"} {
+		repositories.AuditEvents.Insert(t.Context(), schemas.AuditEvent{
+			ActorType: schemas.AUDIT_ACTOR_ADMIN,
+			ActorID:   actorId,
+			Action:    schemas.AUDIT_ACTION_CLIENT_UNLOCK,
+			CreatedAt: now.Add(time.Duration(i) * time.Minute),
+		})
+	}
+
+	query := func(url string) schemas.AuditEventsResponse {
+		t.Helper()
+		w := httptest.NewRecorder()
+		HandlerAuditEvents(w, httptest.NewRequest(http.MethodGet, url, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d, want %d", url, w.Code, http.StatusOK)
+		}
+
+		response := struct {
+			Data schemas.AuditEventsResponse `json:"data"`
+		}{}
+		json.NewDecoder(w.Body).Decode(&response)
+		return response.Data
+	}
+
+	first := query("/v1/admin/audit-events?actor_id=a&limit=2")
+	if len(first.Events) != 2 || first.NextBefore == "" {
+		t.Fatalf("first page = %+v, want 2 events and a cursor", first)
+	}
+	if !first.Events[0].CreatedAt.After(first.Events[1].CreatedAt) {
+		t.Errorf("events not sorted newest first")
+	}
+
+	second := query("/v1/admin/audit-events?actor_id=a&limit=2&before=" + first.NextBefore)
+	if len(second.Events) != 1 || second.NextBefore != "" {
+		t.Fatalf("second page = %+v, want 1 event and no cursor", second)
+	}
+
+	from := now.Add(90 * time.Second).Format(time.RFC3339Nano)
+	ranged := query("/v1/admin/audit-events?from=" + from)
+	if len(ranged.Events) != 2 {
+		t.Errorf("from filter = %d events, want 2", len(ranged.Events))
+	}
+}
+
+func TestAuditEventsQueryRejectsInvalidParams(t *testing.T) {
+	Repositories = database.NewMemoryRepositories()
+	t.Cleanup(func() { Repositories = nil })
+
+	for _, url := range []string{
+		"/v1/admin/audit-events?from=ontem",
+		"/v1/admin/audit-events?before=xyz",
+		"/v1/admin/audit-events?limit=1000",
+	} {
+		w := httptest.NewRecorder()
+		HandlerAuditEvents(w, httptest.NewRequest(http.MethodGet, url, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want %d", url, w.Code, http.StatusBadRequest)
+		}
+	}
+}
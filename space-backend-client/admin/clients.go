@@ -0,0 +1,433 @@
+package admin
+
+import (
+	"api/audit"
+	"api/clients"
+	"api/database"
+	"api/documents"
+	"api/middlewares"
+	"api/privacy"
+	"api/schemas"
+	"api/utils"
+	"api/validation"
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// clientResponse traz só os campos seguros do cliente. Contas sem status
+// gravado aparecem como ativas.
+func clientResponse(client schemas.ClientFromDB) schemas.ClientResponse {
+	status := client.Status
+	if status == "" {
+		status = schemas.CLIENT_STATUS_ACTIVE
+	}
+	return schemas.ClientResponse{
+		ID:            client.ID.Hex(),
+		Contact:       documents.FormatContact(client.Contact),
+		EmailVerified: client.EmailVerified,
+		TwoFactor:     client.TwoFactor != nil && client.TwoFactor.Enabled,
+		BudgetIDs:     client.BudgetIDs,
+		Status:        status,
+		AnonymizedAt:  client.AnonymizedAt,
+		CreatedAt:     client.CreatedAt,
+		UpdatedAt:     client.UpdatedAt,
+	}
+}
+
+// rejectAnonymized responde 409 para alterações em contas anonimizadas, que
+// não podem voltar a ter dados pessoais nem ser reativadas.
+func rejectAnonymized(w http.ResponseWriter, client schemas.ClientFromDB) bool {
+	if client.AnonymizedAt == nil {
+		return false
+	}
+	w.WriteHeader(http.StatusConflict)
+	json.NewEncoder(w).Encode(schemas.ApiResponse{
+		Message: "Os dados deste cliente foram anonimizados",
+	})
+	return true
+}
+
+// findClient busca o cliente pelo ID ou, sem ele, pelo email, já escrevendo a
+// resposta de erro quando não encontra.
+func findClient(ctx context.Context, w http.ResponseWriter, idStr string, email string) (schemas.ClientFromDB, bool) {
+	var client schemas.ClientFromDB
+	var err error
+	switch {
+	case idStr != "":
+		id, parseErr := utils.ParseObjectIDFromHex(idStr)
+		if parseErr != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(schemas.ApiResponse{
+				Message: "ID do cliente inválido",
+			})
+			return client, false
+		}
+		client, err = Repositories.Clients.FindByID(ctx, id)
+	case email != "":
+		client, err = Repositories.Clients.FindByEmail(ctx, email)
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: "Informe o id ou o email do cliente",
+		})
+		return client, false
+	}
+
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(schemas.ApiResponse{
+				Message: "Cliente não encontrado",
+			})
+			return client, false
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.ERROR_TO_TRY_FIND_MONGODB),
+		})
+		return client, false
+	}
+
+	return client, true
+}
+
+// getClient mostra um cliente (?id= ou ?email=), com os orçamentos que já
+// têm uniforme.
+func getClient(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGODB_TIMEOUT)
+	defer cancel()
+
+	client, ok := findClient(ctx, w, r.URL.Query().Get("id"), r.URL.Query().Get("email"))
+	if !ok {
+		return
+	}
+
+	response := clientResponse(client)
+	if len(client.BudgetIDs) > 0 {
+		uniforms, err := Repositories.Uniforms.FindByClientIDs(ctx, []string{client.ID.Hex()})
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(schemas.ApiResponse{
+				Message: utils.SendInternalError(utils.ERROR_TO_TRY_FIND_MONGODB),
+			})
+			return
+		}
+
+		response.HasUniform = make(map[int]bool)
+		for _, budgetID := range client.BudgetIDs {
+			response.HasUniform[budgetID] = false
+		}
+		for _, uniform := range uniforms {
+			response.HasUniform[uniform.BudgetID] = true
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(schemas.ApiResponse{
+		Data: response,
+	})
+}
+
+// updateClientContact corrige o contato do cliente (?id=) com o mesmo merge
+// patch do PATCH /v1/clients, incluindo o envio ao Tiny. A senha continua
+// sendo só do cliente.
+func updateClientContact(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	patch := map[string]json.RawMessage{}
+	request := schemas.ClientUpdateRequest{}
+	if err != nil || json.Unmarshal(body, &patch) != nil || json.Unmarshal(body, &request) != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: "Dados inválidos",
+		})
+		return
+	}
+
+	if _, ok := patch["password"]; ok {
+		validation.WriteErrors(w, []schemas.FieldError{
+			{Field: "password", Message: "A senha só pode ser alterada pelo próprio cliente"},
+		})
+		return
+	}
+
+	if errs := validation.Validate(request); errs != nil {
+		validation.WriteErrors(w, errs)
+		return
+	}
+
+	if !clients.HasContactFields(patch) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: "Nenhum campo para atualizar",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGODB_TIMEOUT)
+	defer cancel()
+
+	client, ok := findClient(ctx, w, r.URL.Query().Get("id"), "")
+	if !ok || rejectAnonymized(w, client) {
+		return
+	}
+
+	contact, changedFields, errs, err := clients.PrepareContactPatch(ctx, Repositories, client, patch, request)
+	if err == clients.ErrEmailTaken {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: clients.ErrEmailTaken.Error(),
+		})
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.ERROR_TO_TRY_FIND_MONGODB),
+		})
+		return
+	}
+	if errs != nil {
+		validation.WriteErrors(w, errs)
+		return
+	}
+
+	if len(changedFields) > 0 {
+		err := clients.SaveContact(ctx, Repositories, client.ID, contact, changedFields)
+		if err == clients.ErrEmailTaken {
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(schemas.ApiResponse{
+				Message: clients.ErrEmailTaken.Error(),
+			})
+			return
+		}
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(schemas.ApiResponse{
+				Message: utils.SendInternalError(utils.ERROR_TO_UPDATE_CLIENT_TO_MONGODB),
+			})
+			return
+		}
+
+		audit.Record(ctx, Repositories.AuditEvents, r, audit.Entry{
+			Action:     schemas.AUDIT_ACTION_CLIENT_UPDATE,
+			TargetType: schemas.AUDIT_TARGET_CLIENT,
+			TargetID:   client.ID.Hex(),
+			Before:     bson.M{"contact": client.Contact},
+			After:      bson.M{"contact": contact},
+			Metadata:   map[string]any{"fields": changedFields},
+		})
+		client.Contact = contact
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(schemas.ApiResponse{
+		Data: clientResponse(client),
+	})
+}
+
+// deleteClient exclui a conta (?id=) e encerra as sessões. Clientes com
+// orçamentos ou uniformes não são excluídos, para não perder o vínculo com os
+// pedidos: nesse caso a conta deve ser bloqueada ou anonimizada.
+func deleteClient(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGODB_TIMEOUT)
+	defer cancel()
+
+	client, ok := findClient(ctx, w, r.URL.Query().Get("id"), "")
+	if !ok {
+		return
+	}
+
+	uniforms, err := Repositories.Uniforms.FindByClientIDs(ctx, []string{client.ID.Hex()})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.ERROR_TO_TRY_FIND_MONGODB),
+		})
+		return
+	}
+
+	if len(client.BudgetIDs) > 0 || len(uniforms) > 0 {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: "O cliente tem orçamentos ou uniformes; bloqueie ou anonimize a conta",
+		})
+		return
+	}
+
+	if err := middlewares.RevokeClientSessions(ctx, Repositories, client.ID); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.ERROR_TO_UPDATE_REFRESH_TOKEN),
+		})
+		return
+	}
+
+	err = Repositories.Clients.Delete(ctx, client.ID)
+	if err != nil && err != mongo.ErrNoDocuments {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.ERROR_TO_UPDATE_CLIENT_TO_MONGODB),
+		})
+		return
+	}
+
+	audit.Record(ctx, Repositories.AuditEvents, r, audit.Entry{
+		Action:     schemas.AUDIT_ACTION_CLIENT_DELETE,
+		TargetType: schemas.AUDIT_TARGET_CLIENT,
+		TargetID:   client.ID.Hex(),
+		// Só o necessário para localizar o cadastro: os dados pessoais do
+		// cliente excluído não ficam guardados na auditoria
+		Before: bson.M{"_id": client.ID, "status": client.Status, "contact": bson.M{"tiny_id": client.Contact.TinyID}},
+	})
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(schemas.ApiResponse{
+		Message: "Cliente excluído com sucesso",
+	})
+}
+
+// HandlerClientAccount é a gestão de um cliente pela equipe interna: GET por
+// ?id= ou ?email=, PATCH do contato e DELETE da conta por ?id=.
+func HandlerClientAccount(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		getClient(w, r)
+	case http.MethodPatch:
+		updateClientContact(w, r)
+	case http.MethodDelete:
+		deleteClient(w, r)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.HTTP_METHOD_NO_ALLOWED),
+		})
+	}
+}
+
+// HandlerClientStatus ativa ou bloqueia a conta. O bloqueio encerra as sessões
+// abertas e impede novos logins até a conta ser reativada; o motivo fica só
+// na auditoria.
+func HandlerClientStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.HTTP_METHOD_NO_ALLOWED),
+		})
+		return
+	}
+
+	statusRequest := schemas.AdminClientStatusRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&statusRequest); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: "Dados inválidos",
+		})
+		return
+	}
+
+	if errs := validation.Validate(statusRequest); errs != nil {
+		validation.WriteErrors(w, errs)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGODB_TIMEOUT)
+	defer cancel()
+
+	client, ok := findClient(ctx, w, statusRequest.ID, "")
+	if !ok || rejectAnonymized(w, client) {
+		return
+	}
+
+	current := clientResponse(client).Status
+	if current != statusRequest.Status {
+		err := Repositories.Clients.SetStatus(ctx, client.ID, statusRequest.Status)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(schemas.ApiResponse{
+				Message: utils.SendInternalError(utils.ERROR_TO_UPDATE_CLIENT_TO_MONGODB),
+			})
+			return
+		}
+
+		audit.Record(ctx, Repositories.AuditEvents, r, audit.Entry{
+			Action:     schemas.AUDIT_ACTION_CLIENT_STATUS,
+			TargetType: schemas.AUDIT_TARGET_CLIENT,
+			TargetID:   client.ID.Hex(),
+			Before:     bson.M{"status": current},
+			After:      bson.M{"status": statusRequest.Status},
+			Metadata:   map[string]any{"reason": statusRequest.Reason},
+		})
+	}
+
+	// Repetir o bloqueio também derruba sessões que tenham sobrado
+	if statusRequest.Status == schemas.CLIENT_STATUS_BLOCKED {
+		if err := middlewares.RevokeClientSessions(ctx, Repositories, client.ID); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(schemas.ApiResponse{
+				Message: utils.SendInternalError(utils.ERROR_TO_UPDATE_REFRESH_TOKEN),
+			})
+			return
+		}
+	}
+
+	client.Status = statusRequest.Status
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(schemas.ApiResponse{
+		Data: clientResponse(client),
+	})
+}
+
+// HandlerClientAnonymize executa pela equipe interna o pedido de eliminação
+// dos dados recebido pelo atendimento (?id=), com o mesmo fluxo de
+// POST /v1/clients/me/erasure.
+func HandlerClientAnonymize(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.HTTP_METHOD_NO_ALLOWED),
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGODB_TIMEOUT)
+	defer cancel()
+
+	client, ok := findClient(ctx, w, r.URL.Query().Get("id"), "")
+	if !ok || rejectAnonymized(w, client) {
+		return
+	}
+
+	result, err := privacy.Anonymize(ctx, Repositories, client)
+	if err != nil {
+		log.Printf("[Admin] Erro ao anonimizar o cliente %s: %v", client.ID.Hex(), err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.ERROR_TO_UPDATE_CLIENT_TO_MONGODB),
+		})
+		return
+	}
+
+	audit.Record(ctx, Repositories.AuditEvents, r, audit.Entry{
+		Action:     schemas.AUDIT_ACTION_CLIENT_ANONYMIZE,
+		TargetType: schemas.AUDIT_TARGET_CLIENT,
+		TargetID:   client.ID.Hex(),
+		Metadata: map[string]any{
+			"uniforms":        result.Uniforms,
+			"players":         result.Players,
+			"whatsapp_events": result.WhatsappEvents,
+			"audit_events":    result.AuditEvents,
+		},
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(schemas.ApiResponse{
+		Message: "Dados pessoais anonimizados",
+		Data:    result,
+	})
+}
@@ -0,0 +1,170 @@
+package admin
+
+import (
+	"api/database"
+	"api/documents"
+	"api/schemas"
+	"api/utils"
+	"api/validation"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	CLIENTS_SEARCH_DEFAULT_LIMIT = 20
+	CLIENTS_SEARCH_MAX_LIMIT     = 100
+	CLIENTS_SEARCH_DEFAULT_SORT  = "-" + schemas.CLIENT_SORT_CREATED_AT
+)
+
+// clientsSearchCursor é o conteúdo do cursor opaco devolvido em next_cursor.
+// A ordenação vai junto para que o cursor não seja usado com outra.
+type clientsSearchCursor struct {
+	Sort string `json:"s"`
+	schemas.ClientSearchCursor
+}
+
+func encodeClientsSearchCursor(sort string, cursor schemas.ClientSearchCursor) string {
+	raw, _ := json.Marshal(clientsSearchCursor{Sort: sort, ClientSearchCursor: cursor})
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeClientsSearchCursor(sort string, encoded string) (*schemas.ClientSearchCursor, bool) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, false
+	}
+	cursor := clientsSearchCursor{}
+	if err := json.Unmarshal(raw, &cursor); err != nil || cursor.Sort != sort || cursor.ID == "" {
+		return nil, false
+	}
+	return &cursor.ClientSearchCursor, true
+}
+
+// parseClientsSearch lê os filtros da query string. ?sort= aceita o campo com
+// "-" na frente para ordem decrescente (padrão -created_at).
+func parseClientsSearch(r *http.Request) (schemas.ClientSearchFilter, []schemas.FieldError) {
+	query := r.URL.Query()
+	filter := schemas.ClientSearchFilter{
+		Query:      strings.TrimSpace(query.Get("q")),
+		Name:       strings.TrimSpace(query.Get("name")),
+		Email:      strings.TrimSpace(query.Get("email")),
+		Document:   strings.TrimSpace(query.Get("document")),
+		Phone:      strings.TrimSpace(query.Get("phone")),
+		City:       strings.TrimSpace(query.Get("city")),
+		State:      strings.TrimSpace(query.Get("state")),
+		PersonType: strings.ToUpper(strings.TrimSpace(query.Get("person_type"))),
+		Limit:      CLIENTS_SEARCH_DEFAULT_LIMIT,
+	}
+
+	var errs []schemas.FieldError
+	if filter.Document != "" {
+		digits := documents.Digits(filter.Document)
+		if len(digits) != 11 && len(digits) != 14 {
+			errs = append(errs, schemas.FieldError{Field: "document", Message: "Informe o CPF ou o CNPJ completo"})
+		}
+	}
+	if filter.Phone != "" && len(documents.Digits(filter.Phone)) < 3 {
+		errs = append(errs, schemas.FieldError{Field: "phone", Message: "Informe pelo menos 3 dígitos"})
+	}
+	if filter.State != "" {
+		if _, err := documents.NormalizeUF(filter.State); err != nil {
+			errs = append(errs, schemas.FieldError{Field: "state", Message: err.Error()})
+		}
+	}
+	if filter.PersonType != "" && filter.PersonType != "PF" && filter.PersonType != "PJ" {
+		errs = append(errs, schemas.FieldError{Field: "person_type", Message: "Deve ser PF ou PJ"})
+	}
+	if raw := query.Get("email_verified"); raw != "" {
+		verified, err := strconv.ParseBool(raw)
+		if err != nil {
+			errs = append(errs, schemas.FieldError{Field: "email_verified", Message: "Deve ser true ou false"})
+		}
+		filter.EmailVerified = &verified
+	}
+	if raw := query.Get("budget_id"); raw != "" {
+		budgetID, err := strconv.Atoi(raw)
+		if err != nil || budgetID <= 0 {
+			errs = append(errs, schemas.FieldError{Field: "budget_id", Message: "Deve ser um número positivo"})
+		}
+		filter.BudgetID = budgetID
+	}
+	if raw := query.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit <= 0 || limit > CLIENTS_SEARCH_MAX_LIMIT {
+			errs = append(errs, schemas.FieldError{Field: "limit", Message: "Deve estar entre 1 e " + strconv.Itoa(CLIENTS_SEARCH_MAX_LIMIT)})
+		}
+		filter.Limit = int64(limit)
+	}
+
+	sort := query.Get("sort")
+	if sort == "" {
+		sort = CLIENTS_SEARCH_DEFAULT_SORT
+	}
+	filter.Descending = strings.HasPrefix(sort, "-")
+	filter.Sort = strings.TrimPrefix(sort, "-")
+	if !database.ValidClientSort(filter.Sort) {
+		errs = append(errs, schemas.FieldError{Field: "sort", Message: "Ordenação inválida: " + sort})
+	} else if raw := query.Get("cursor"); raw != "" {
+		cursor, ok := decodeClientsSearchCursor(sort, raw)
+		if !ok {
+			errs = append(errs, schemas.FieldError{Field: "cursor", Message: "Cursor inválido para esta ordenação"})
+		}
+		filter.After = cursor
+	}
+
+	return filter, errs
+}
+
+// HandlerClientsSearch é o diretório de clientes para o atendimento: busca por
+// texto livre (?q=) e filtros por campo, com ordenação e paginação por cursor.
+// A resposta traz só os campos seguros do cliente.
+func HandlerClientsSearch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.HTTP_METHOD_NO_ALLOWED),
+		})
+		return
+	}
+
+	filter, errs := parseClientsSearch(r)
+	if errs != nil {
+		validation.WriteErrors(w, errs)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGODB_TIMEOUT)
+	defer cancel()
+
+	clients, next, err := Repositories.Clients.Search(ctx, filter)
+	if err != nil {
+		log.Printf("[Admin] Erro na busca de clientes: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.ERROR_TO_TRY_FIND_MONGODB),
+		})
+		return
+	}
+
+	response := schemas.ClientSearchResponse{Clients: []schemas.ClientResponse{}}
+	for _, client := range clients {
+		response.Clients = append(response.Clients, clientResponse(client))
+	}
+	if next != nil {
+		sort := filter.Sort
+		if filter.Descending {
+			sort = "-" + sort
+		}
+		response.NextCursor = encodeClientsSearchCursor(sort, *next)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(schemas.ApiResponse{
+		Data: response,
+	})
+}
@@ -0,0 +1,145 @@
+package admin
+
+import (
+	"api/database"
+	"api/schemas"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func setupClientsDirectory(t *testing.T) {
+	t.Helper()
+
+	repositories := database.NewMemoryRepositories()
+	Repositories = repositories
+	t.Cleanup(func() { Repositories = nil })
+
+	clientsRepository := repositories.Clients.(*database.MemoryClientsRepository)
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, contact := range []schemas.Contact{
+		{Name: "Ana Souza", Email: "ana@example.com", PersonType: "PF", CPF: "52998224725", CellPhone: "+5541999998888", City: "Curitiba", State: "PR"},
+		{Name: "Bruno Lima", Email: "bruno@example.com", PersonType: "PJ", CNPJ: "11222333000181", City: "Londrina", State: "PR"},
+		{Name: "Carla Dias", Email: "carla@outro.com", City: "Curitiba", State: "PR"},
+		{Name: "Diego Ramos", Email: "diego@example.com", City: "São Paulo", State: "SP"},
+	} {
+		clientsRepository.Insert(schemas.ClientFromDB{
+			Contact:       contact,
+			PasswordHash:  "hash-secreto",
+			EmailVerified: i != 3,
+			BudgetIDs:     []int{100 + i},
+			CreatedAt:     base.Add(time.Duration(i) * time.Hour),
+		})
+	}
+}
+
+func searchClients(t *testing.T, query string) (int, schemas.ClientSearchResponse, string) {
+	t.Helper()
+
+	w := httptest.NewRecorder()
+	HandlerClientsSearch(w, httptest.NewRequest(http.MethodGet, "/v1/admin/clients/search?"+query, nil))
+	body := w.Body.String()
+
+	var response struct {
+		Data schemas.ClientSearchResponse `json:"data"`
+	}
+	json.NewDecoder(strings.NewReader(body)).Decode(&response)
+	return w.Code, response.Data, body
+}
+
+func names(response schemas.ClientSearchResponse) []string {
+	var result []string
+	for _, client := range response.Clients {
+		result = append(result, client.Contact.Name)
+	}
+	return result
+}
+
+func TestClientsSearchFilters(t *testing.T) {
+	setupClientsDirectory(t)
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"q=souza", "Ana Souza"},
+		{"q=carla@", "Carla Dias"},
+		{"q=529.982.247-25", "Ana Souza"},
+		{"q=99999-8888", "Ana Souza"},
+		{"q=londrina", "Bruno Lima"},
+		{"document=11.222.333/0001-81", "Bruno Lima"},
+		{"city=curitiba&email=outro", "Carla Dias"},
+		{"state=sp", "Diego Ramos"},
+		{"person_type=pj", "Bruno Lima"},
+		{"email_verified=false", "Diego Ramos"},
+		{"budget_id=100", "Ana Souza"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			code, response, _ := searchClients(t, test.query)
+			if code != http.StatusOK || strings.Join(names(response), ",") != test.want {
+				t.Errorf("status = %d, names = %v, want %s", code, names(response), test.want)
+			}
+		})
+	}
+}
+
+func TestClientsSearchPaginatesWithCursor(t *testing.T) {
+	setupClientsDirectory(t)
+
+	var seen []string
+	query := "sort=name&limit=3"
+	for range 3 {
+		code, response, _ := searchClients(t, query)
+		if code != http.StatusOK {
+			t.Fatalf("status = %d", code)
+		}
+		seen = append(seen, names(response)...)
+		if response.NextCursor == "" {
+			break
+		}
+		query = "sort=name&limit=3&cursor=" + response.NextCursor
+	}
+	if strings.Join(seen, ",") != "Ana Souza,Bruno Lima,Carla Dias,Diego Ramos" {
+		t.Errorf("names = %v, want all clients by name", seen)
+	}
+
+	// Padrão: mais recentes primeiro
+	_, response, _ := searchClients(t, "limit=2")
+	if strings.Join(names(response), ",") != "Diego Ramos,Carla Dias" {
+		t.Errorf("names = %v, want newest first", names(response))
+	}
+
+	// O cursor de uma ordenação não vale para outra
+	code, _, _ := searchClients(t, "sort=email&cursor="+response.NextCursor)
+	if code != http.StatusBadRequest {
+		t.Errorf("status = %d, want %d for a cursor from another sort", code, http.StatusBadRequest)
+	}
+}
+
+func TestClientsSearchRejectsInvalidFilters(t *testing.T) {
+	setupClientsDirectory(t)
+
+	code, _, body := searchClients(t, "sort=password_hash&limit=500&document=123")
+	if code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d", code, http.StatusBadRequest)
+	}
+	var response schemas.ApiResponse
+	json.NewDecoder(strings.NewReader(body)).Decode(&response)
+	if len(response.Errors) != 3 {
+		t.Errorf("errors = %+v, want sort, limit and document", response.Errors)
+	}
+}
+
+func TestClientsSearchNeverReturnsSecrets(t *testing.T) {
+	setupClientsDirectory(t)
+
+	_, _, body := searchClients(t, "")
+	if strings.Contains(body, "hash-secreto") || strings.Contains(body, "password") {
+		t.Errorf("body = %s, must not contain password data", body)
+	}
+}
@@ -0,0 +1,233 @@
+package admin
+
+import (
+	"api/database"
+	"api/schemas"
+	"api/utils"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func insertAccountClient(t *testing.T, repositories *database.Repositories, client schemas.ClientFromDB) schemas.ClientFromDB {
+	t.Helper()
+
+	clientsRepository := repositories.Clients.(*database.MemoryClientsRepository)
+	clientsRepository.Insert(client)
+	stored, err := clientsRepository.FindByEmail(t.Context(), client.Contact.Email)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return stored
+}
+
+func TestClientAccountGetByIDOrEmail(t *testing.T) {
+	repositories := database.NewMemoryRepositories()
+	Repositories = repositories
+	t.Cleanup(func() { Repositories = nil })
+
+	client := insertAccountClient(t, repositories, schemas.ClientFromDB{
+		Contact:   schemas.Contact{Name: "Time", Email: "time@example.com"},
+		BudgetIDs: []int{10, 20},
+	})
+	repositories.Uniforms.Create(t.Context(), schemas.UniformToDB{ClientID: client.ID.Hex(), BudgetID: 20})
+
+	for _, target := range []string{"?id=" + client.ID.Hex(), "?email=time@example.com"} {
+		w := httptest.NewRecorder()
+		HandlerClientAccount(w, httptest.NewRequest(http.MethodGet, "/v1/admin/clients/account"+target, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d, want %d", target, w.Code, http.StatusOK)
+		}
+
+		var response struct {
+			Data schemas.ClientResponse `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&response)
+		if response.Data.ID != client.ID.Hex() || response.Data.Status != schemas.CLIENT_STATUS_ACTIVE {
+			t.Errorf("%s: client = %+v", target, response.Data)
+		}
+		if response.Data.HasUniform[10] || !response.Data.HasUniform[20] {
+			t.Errorf("%s: has_uniform = %v, want only 20", target, response.Data.HasUniform)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	HandlerClientAccount(w, httptest.NewRequest(http.MethodGet, "/v1/admin/clients/account?id="+bson.NewObjectID().Hex(), nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("unknown id: status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestClientAccountUpdatesContactAndEnqueuesTinySync(t *testing.T) {
+	repositories := database.NewMemoryRepositories()
+	Repositories = repositories
+	t.Cleanup(func() { Repositories = nil })
+
+	client := insertAccountClient(t, repositories, schemas.ClientFromDB{
+		Contact: schemas.Contact{Name: "Time", Email: "time@example.com", City: "Curitiba"},
+	})
+	insertAccountClient(t, repositories, schemas.ClientFromDB{
+		Contact: schemas.Contact{Name: "Outro", Email: "outro@example.com"},
+	})
+
+	patch := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		HandlerClientAccount(w, httptest.NewRequest(http.MethodPatch, "/v1/admin/clients/account?id="+client.ID.Hex(), strings.NewReader(body)))
+		return w
+	}
+
+	if w := patch(`{"password": "Nova-senha-123"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("password: status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+	if w := patch(`{"email": "outro@example.com"}`); w.Code != http.StatusConflict {
+		t.Errorf("email taken: status = %d, want %d", w.Code, http.StatusConflict)
+	}
+
+	if w := patch(`{"city": "Londrina", "complement": "Sala 2"}`); w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+
+	updated, _ := repositories.Clients.FindByID(t.Context(), client.ID)
+	if updated.Contact.City != "Londrina" || updated.Contact.Complement != "Sala 2" || updated.Contact.Name != "Time" {
+		t.Errorf("contact = %+v", updated.Contact)
+	}
+
+	jobs, _ := repositories.TinySyncJobs.List(t.Context(), schemas.TINY_SYNC_PENDING, 10)
+	if len(jobs) != 1 || jobs[0].ClientID != client.ID {
+		t.Errorf("jobs = %+v, want one for the client", jobs)
+	}
+
+	events, _ := repositories.AuditEvents.Find(t.Context(), schemas.AuditEventFilter{Action: schemas.AUDIT_ACTION_CLIENT_UPDATE})
+	if len(events) != 1 {
+		t.Errorf("audit events = %d, want 1", len(events))
+	}
+}
+
+func TestRemoveBudgetIDFromClient(t *testing.T) {
+	repositories := database.NewMemoryRepositories()
+	Repositories = repositories
+	t.Cleanup(func() { Repositories = nil })
+
+	client := insertAccountClient(t, repositories, schemas.ClientFromDB{
+		Contact:   schemas.Contact{Email: "time@example.com"},
+		BudgetIDs: []int{42, 43},
+	})
+	repositories.Uniforms.Create(t.Context(), schemas.UniformToDB{ClientID: client.ID.Hex(), BudgetID: 43})
+
+	remove := func(budgetID int) int {
+		body, _ := json.Marshal(schemas.ClientAddBudgetRequest{Email: "time@example.com", BudgetID: budgetID})
+		w := httptest.NewRecorder()
+		HandlerClients(w, httptest.NewRequest(http.MethodDelete, "/v1/admin/clients", bytes.NewReader(body)))
+		return w.Code
+	}
+
+	if code := remove(42); code != http.StatusOK {
+		t.Fatalf("first remove: status = %d, want %d", code, http.StatusOK)
+	}
+	if code := remove(42); code != http.StatusNotFound {
+		t.Errorf("second remove: status = %d, want %d", code, http.StatusNotFound)
+	}
+	if code := remove(43); code != http.StatusConflict {
+		t.Errorf("budget with uniform: status = %d, want %d", code, http.StatusConflict)
+	}
+
+	updated, _ := repositories.Clients.FindByID(t.Context(), client.ID)
+	if len(updated.BudgetIDs) != 1 || updated.BudgetIDs[0] != 43 {
+		t.Errorf("budget_ids = %v, want [43]", updated.BudgetIDs)
+	}
+}
+
+func TestClientStatusBlockRevokesSessions(t *testing.T) {
+	repositories := database.NewMemoryRepositories()
+	Repositories = repositories
+	t.Cleanup(func() { Repositories = nil })
+
+	client := insertAccountClient(t, repositories, schemas.ClientFromDB{
+		Contact: schemas.Contact{Email: "time@example.com"},
+	})
+	sessionID := bson.NewObjectID()
+	repositories.Sessions.Create(t.Context(), schemas.Session{
+		ID:        sessionID,
+		ClientID:  client.ID,
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+
+	setStatus := func(status string) int {
+		body, _ := json.Marshal(schemas.AdminClientStatusRequest{ID: client.ID.Hex(), Status: status, Reason: "chargeback"})
+		w := httptest.NewRecorder()
+		HandlerClientStatus(w, httptest.NewRequest(http.MethodPost, "/v1/admin/clients/status", bytes.NewReader(body)))
+		return w.Code
+	}
+
+	if code := setStatus("suspended"); code != http.StatusBadRequest {
+		t.Errorf("invalid status: status = %d, want %d", code, http.StatusBadRequest)
+	}
+	if code := setStatus(schemas.CLIENT_STATUS_BLOCKED); code != http.StatusOK {
+		t.Fatalf("block: status = %d, want %d", code, http.StatusOK)
+	}
+
+	blocked, _ := repositories.Clients.FindByID(t.Context(), client.ID)
+	if !blocked.Blocked() {
+		t.Errorf("status = %q, want blocked", blocked.Status)
+	}
+	if sessions, _ := repositories.Sessions.FindByClientID(t.Context(), client.ID); len(sessions) != 0 {
+		t.Errorf("sessions = %d, want 0", len(sessions))
+	}
+	if revoked, _ := repositories.RevokedTokens.IsRevoked(t.Context(), utils.DenylistKeyForSession(sessionID.Hex())); !revoked {
+		t.Error("session access tokens were not revoked")
+	}
+
+	if code := setStatus(schemas.CLIENT_STATUS_ACTIVE); code != http.StatusOK {
+		t.Fatalf("activate: status = %d, want %d", code, http.StatusOK)
+	}
+	active, _ := repositories.Clients.FindByID(t.Context(), client.ID)
+	if active.Blocked() {
+		t.Error("client is still blocked")
+	}
+}
+
+func TestDeleteClientKeepsClientsWithBudgets(t *testing.T) {
+	repositories := database.NewMemoryRepositories()
+	Repositories = repositories
+	t.Cleanup(func() { Repositories = nil })
+
+	withBudget := insertAccountClient(t, repositories, schemas.ClientFromDB{
+		Contact:   schemas.Contact{Email: "time@example.com"},
+		BudgetIDs: []int{42},
+	})
+	duplicate := insertAccountClient(t, repositories, schemas.ClientFromDB{
+		Contact: schemas.Contact{Email: "duplicado@example.com"},
+	})
+
+	remove := func(id bson.ObjectID) int {
+		w := httptest.NewRecorder()
+		HandlerClientAccount(w, httptest.NewRequest(http.MethodDelete, "/v1/admin/clients/account?id="+id.Hex(), nil))
+		return w.Code
+	}
+
+	if code := remove(withBudget.ID); code != http.StatusConflict {
+		t.Errorf("with budget: status = %d, want %d", code, http.StatusConflict)
+	}
+	if code := remove(duplicate.ID); code != http.StatusOK {
+		t.Fatalf("duplicate: status = %d, want %d", code, http.StatusOK)
+	}
+	if _, err := repositories.Clients.FindByID(t.Context(), duplicate.ID); err == nil {
+		t.Error("client was not deleted")
+	}
+
+	events, _ := repositories.AuditEvents.Find(t.Context(), schemas.AuditEventFilter{Action: schemas.AUDIT_ACTION_CLIENT_DELETE})
+	if len(events) != 1 {
+		t.Fatalf("audit events = %d, want 1", len(events))
+	}
+	for _, change := range events[0].Changes {
+		if change.Field != "_id" && change.Field != "status" && change.Field != "contact.tiny_id" {
+			t.Errorf("audit change %s keeps personal data of the deleted client", change.Field)
+		}
+	}
+}
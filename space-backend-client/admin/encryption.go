@@ -0,0 +1,117 @@
+package admin
+
+import (
+	"api/audit"
+	"api/crypto"
+	"api/database"
+	"api/schemas"
+	"api/utils"
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// REENCRYPT_TIMEOUT limita uma varredura disparada pela rotação. O que ficar
+// para trás é regravado na varredura seguinte, feita a cada inicialização.
+const REENCRYPT_TIMEOUT = 30 * time.Minute
+
+// FieldKeyring é injetado pelo main; nil quando FIELD_ENCRYPTION_KEY não está
+// configurada.
+var FieldKeyring *crypto.Keyring
+
+// reencryption evita varreduras simultâneas: uma rotação durante a varredura
+// agenda outra para quando ela terminar.
+var reencryption struct {
+	sync.Mutex
+	running bool
+	pending bool
+}
+
+// ReencryptContacts regrava com a chave de dados ativa os documentos dos
+// clientes ainda abertos ou cifrados com chaves anteriores.
+func ReencryptContacts(ctx context.Context, clients database.ClientsRepository) {
+	reencryption.Lock()
+	if reencryption.running {
+		reencryption.pending = true
+		reencryption.Unlock()
+		return
+	}
+	reencryption.running = true
+	reencryption.Unlock()
+
+	for {
+		rewritten, err := clients.ReencryptContacts(ctx)
+		if err != nil {
+			log.Printf("[Admin] Erro ao recriptografar os documentos dos clientes: %v", err)
+		} else if rewritten > 0 {
+			log.Printf("[Admin] Documentos de %d clientes recriptografados", rewritten)
+		}
+
+		reencryption.Lock()
+		if !reencryption.pending || ctx.Err() != nil {
+			reencryption.running = false
+			reencryption.Unlock()
+			return
+		}
+		reencryption.pending = false
+		reencryption.Unlock()
+	}
+}
+
+// HandlerDataKeyRotate cria uma chave de dados para os campos cifrados e
+// regrava os documentos existentes com ela em segundo plano. As chaves
+// anteriores continuam abrindo os valores ainda não regravados.
+func HandlerDataKeyRotate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.HTTP_METHOD_NO_ALLOWED),
+		})
+		return
+	}
+
+	if FieldKeyring == nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: "Criptografia de campos não configurada",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGODB_TIMEOUT)
+	defer cancel()
+
+	previous := FieldKeyring.ActiveKeyID()
+	keyID, err := FieldKeyring.Rotate(ctx)
+	if err != nil {
+		log.Printf("[Admin] Erro ao rotacionar a chave de dados: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.ERROR_TO_GENERATE_TOKEN),
+		})
+		return
+	}
+
+	audit.Record(ctx, Repositories.AuditEvents, r, audit.Entry{
+		Action:     schemas.AUDIT_ACTION_DATA_KEY_ROTATE,
+		TargetType: schemas.AUDIT_TARGET_DATA_KEY,
+		TargetID:   keyID,
+		Metadata:   map[string]any{"previous_key_id": previous},
+	})
+
+	clients := Repositories.Clients
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), REENCRYPT_TIMEOUT)
+		defer cancel()
+		ReencryptContacts(ctx, clients)
+	}()
+
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(schemas.ApiResponse{
+		Message: "Chave de dados rotacionada",
+		Data:    schemas.DataKeyRotateResponse{KeyID: keyID},
+	})
+}
@@ -0,0 +1,55 @@
+package admin
+
+import (
+	"api/crypto"
+	"api/database"
+	"api/schemas"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDataKeyRotate(t *testing.T) {
+	repositories := database.NewMemoryRepositories()
+	Repositories = repositories
+	t.Cleanup(func() { Repositories = nil; FieldKeyring = nil })
+
+	w := httptest.NewRecorder()
+	HandlerDataKeyRotate(w, httptest.NewRequest(http.MethodPost, "/v1/admin/encryption/rotate", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status without keyring = %d, want %d", w.Code, http.StatusServiceUnavailable)
+	}
+
+	keyring, err := crypto.NewKeyring(bytes.Repeat([]byte{1}, crypto.KEY_SIZE), database.NewMemoryDataKeysRepository())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := keyring.Load(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+	FieldKeyring = keyring
+	previous := keyring.ActiveKeyID()
+
+	w = httptest.NewRecorder()
+	HandlerDataKeyRotate(w, httptest.NewRequest(http.MethodPost, "/v1/admin/encryption/rotate", nil))
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusAccepted)
+	}
+
+	var response struct {
+		Data schemas.DataKeyRotateResponse `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+	if response.Data.KeyID == previous || response.Data.KeyID != keyring.ActiveKeyID() {
+		t.Errorf("key_id = %s, want the new active key (previous %s)", response.Data.KeyID, previous)
+	}
+
+	events := repositories.AuditEvents.(*database.MemoryAuditEventsRepository).Events()
+	if len(events) != 1 || events[0].Action != schemas.AUDIT_ACTION_DATA_KEY_ROTATE || events[0].TargetID != response.Data.KeyID {
+		t.Errorf("events = %+v, want data_key.rotate", events)
+	}
+}
@@ -1,21 +1,26 @@
 package admin
 
 import (
+	"api/audit"
 	"api/database"
+	"api/documents"
 	"api/schemas"
 	"api/utils"
+	"api/validation"
 	"context"
 	"encoding/json"
 	"net/http"
-	"os"
+	"slices"
 	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo"
-	"go.mongodb.org/mongo-driver/v2/mongo/options"
 )
 
+// Repositories é injetado pelo main com o client MongoDB compartilhado.
+var Repositories *database.Repositories
+
 func addUniformWithBudgetId(w http.ResponseWriter, r *http.Request) {
 	uniformRequest := schemas.AdminUniformCreateRequest{}
 	if err := json.NewDecoder(r.Body).Decode(&uniformRequest); err != nil {
@@ -26,33 +31,15 @@ func addUniformWithBudgetId(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if uniformRequest.ClientEmail == "" || uniformRequest.BudgetID == 0 || len(uniformRequest.Sketches) == 0 {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(schemas.ApiResponse{
-			Message: "Email do cliente, BudgetID e Sketches são obrigatórios",
-		})
+	if errs := validation.Validate(uniformRequest); errs != nil {
+		validation.WriteErrors(w, errs)
 		return
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), database.MONGODB_TIMEOUT)
 	defer cancel()
 
-	mongoURI := os.Getenv(utils.ENV_MONGODB_URI)
-	opts := options.Client().ApplyURI(mongoURI)
-	client, err := mongo.Connect(opts)
-	if err != nil {
-		w.WriteHeader(http.StatusBadGateway)
-		json.NewEncoder(w).Encode(schemas.ApiResponse{
-			Message: utils.SendInternalError(utils.CANNOT_CONNECT_TO_MONGODB),
-		})
-		return
-	}
-	defer client.Disconnect(ctx)
-
-	clientsCollection := client.Database(database.GetDB()).Collection("clients")
-	filter := bson.D{{Key: "contact.email", Value: uniformRequest.ClientEmail}}
-	existingClient := schemas.ClientFromDB{}
-	err = clientsCollection.FindOne(ctx, filter).Decode(&existingClient)
+	existingClient, err := Repositories.Clients.FindByEmail(ctx, uniformRequest.ClientEmail)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			w.WriteHeader(http.StatusNotFound)
@@ -68,15 +55,7 @@ func addUniformWithBudgetId(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	uniformsCollection := client.Database(database.GetDB()).Collection("uniforms")
-
-	existingUniformFilter := bson.D{
-		{Key: "client_id", Value: existingClient.ID.Hex()},
-		{Key: "budget_id", Value: uniformRequest.BudgetID},
-	}
-
-	existingUniform := schemas.UniformFromDB{}
-	err = uniformsCollection.FindOne(ctx, existingUniformFilter).Decode(&existingUniform)
+	_, err = Repositories.Uniforms.FindOneByClientAndBudget(ctx, existingClient.ID.Hex(), uniformRequest.BudgetID)
 	if err == nil {
 		w.WriteHeader(http.StatusConflict)
 		json.NewEncoder(w).Encode(schemas.ApiResponse{
@@ -100,7 +79,7 @@ func addUniformWithBudgetId(w http.ResponseWriter, r *http.Request) {
 		UpdatedAt: time.Now(),
 	}
 
-	_, err = uniformsCollection.InsertOne(ctx, uniformToCreate)
+	uniformId, err := Repositories.Uniforms.Create(ctx, uniformToCreate)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(schemas.ApiResponse{
@@ -109,6 +88,13 @@ func addUniformWithBudgetId(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	audit.Record(ctx, Repositories.AuditEvents, r, audit.Entry{
+		Action:     schemas.AUDIT_ACTION_UNIFORM_CREATE,
+		TargetType: schemas.AUDIT_TARGET_UNIFORM,
+		TargetID:   uniformId.Hex(),
+		After:      uniformToCreate,
+	})
+
 	w.WriteHeader(http.StatusCreated)
 }
 
@@ -123,6 +109,11 @@ func updatePlayersData(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if errs := validation.Validate(uniformRequest); errs != nil {
+		validation.WriteErrors(w, errs)
+		return
+	}
+
 	budgetIDStr := r.URL.Query().Get("budget_id")
 	if budgetIDStr == "" {
 		w.WriteHeader(http.StatusBadRequest)
@@ -144,23 +135,7 @@ func updatePlayersData(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(context.Background(), database.MONGODB_TIMEOUT)
 	defer cancel()
 
-	mongoURI := os.Getenv(utils.ENV_MONGODB_URI)
-	opts := options.Client().ApplyURI(mongoURI)
-	client, err := mongo.Connect(opts)
-	if err != nil {
-		w.WriteHeader(http.StatusBadGateway)
-		json.NewEncoder(w).Encode(schemas.ApiResponse{
-			Message: utils.SendInternalError(utils.CANNOT_CONNECT_TO_MONGODB),
-		})
-		return
-	}
-	defer client.Disconnect(ctx)
-
-	uniformsCollection := client.Database(database.GetDB()).Collection("uniforms")
-	filter := bson.D{{Key: "budget_id", Value: budgetID}}
-
-	uniform := schemas.UniformFromDB{}
-	err = uniformsCollection.FindOne(ctx, filter).Decode(&uniform)
+	uniform, err := Repositories.Uniforms.FindOneByBudgetID(ctx, budgetID)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			w.WriteHeader(http.StatusNotFound)
@@ -176,25 +151,8 @@ func updatePlayersData(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	updateDoc := bson.D{}
-
 	editableParam := r.URL.Query().Get("editable")
-	if editableParam == "true" {
-		updateDoc = append(updateDoc, bson.E{Key: "editable", Value: true})
-	}
-
-	if len(uniformRequest.Updates) > 0 {
-		for _, update := range uniformRequest.Updates {
-			for i, sketch := range uniform.Sketches {
-				if sketch.ID == update.SketchID {
-					uniform.Sketches[i].Players = update.Players
-				}
-			}
-		}
-		updateDoc = append(updateDoc, bson.E{Key: "sketches", Value: uniform.Sketches})
-	}
-
-	if len(updateDoc) == 0 {
+	if editableParam != "true" && len(uniformRequest.Updates) == 0 {
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(schemas.ApiResponse{
 			Message: "Nenhuma atualização fornecida",
@@ -202,11 +160,31 @@ func updatePlayersData(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	updateDoc = append(updateDoc, bson.E{Key: "updated_at", Value: time.Now()})
-	update := bson.D{{Key: "$set", Value: updateDoc}}
+	editable := uniform.Editable
+	if editableParam == "true" {
+		editable = true
+	}
+
+	// Os sketches são alterados no lugar, então o "antes" é copiado aqui
+	before := audit.Snapshot(bson.M{"sketches": uniform.Sketches, "editable": uniform.Editable})
+
+	for _, update := range uniformRequest.Updates {
+		for i, sketch := range uniform.Sketches {
+			if sketch.ID == update.SketchID {
+				uniform.Sketches[i].Players = update.Players
+			}
+		}
+	}
 
-	result, err := uniformsCollection.UpdateOne(ctx, filter, update)
+	err = Repositories.Uniforms.UpdateSketches(ctx, uniform.ID, uniform.Sketches, editable)
 	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(schemas.ApiResponse{
+				Message: "Uniforme não encontrado",
+			})
+			return
+		}
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(schemas.ApiResponse{
 			Message: utils.SendInternalError(utils.ERROR_TO_TRY_FIND_MONGODB),
@@ -214,13 +192,13 @@ func updatePlayersData(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if result.MatchedCount == 0 {
-		w.WriteHeader(http.StatusNotFound)
-		json.NewEncoder(w).Encode(schemas.ApiResponse{
-			Message: "Uniforme não encontrado",
-		})
-		return
-	}
+	audit.Record(ctx, Repositories.AuditEvents, r, audit.Entry{
+		Action:     schemas.AUDIT_ACTION_UNIFORM_UPDATE,
+		TargetType: schemas.AUDIT_TARGET_UNIFORM,
+		TargetID:   uniform.ID.Hex(),
+		Before:     before,
+		After:      bson.M{"sketches": uniform.Sketches, "editable": editable},
+	})
 
 	w.WriteHeader(http.StatusOK)
 }
@@ -247,33 +225,8 @@ func getUniformsByBudgetId(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(context.Background(), database.MONGODB_TIMEOUT)
 	defer cancel()
 
-	mongoURI := os.Getenv(utils.ENV_MONGODB_URI)
-	opts := options.Client().ApplyURI(mongoURI)
-	client, err := mongo.Connect(opts)
+	uniforms, err := Repositories.Uniforms.FindByBudgetID(ctx, budgetID)
 	if err != nil {
-		w.WriteHeader(http.StatusBadGateway)
-		json.NewEncoder(w).Encode(schemas.ApiResponse{
-			Message: utils.SendInternalError(utils.CANNOT_CONNECT_TO_MONGODB),
-		})
-		return
-	}
-	defer client.Disconnect(ctx)
-
-	uniformsCollection := client.Database(database.GetDB()).Collection("uniforms")
-	filter := bson.D{{Key: "budget_id", Value: budgetID}}
-
-	cursor, err := uniformsCollection.Find(ctx, filter)
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(schemas.ApiResponse{
-			Message: utils.SendInternalError(utils.ERROR_TO_TRY_FIND_MONGODB),
-		})
-		return
-	}
-	defer cursor.Close(ctx)
-
-	var uniforms []schemas.UniformFromDB
-	if err = cursor.All(ctx, &uniforms); err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(schemas.ApiResponse{
 			Message: utils.SendInternalError(utils.ERROR_TO_TRY_FIND_MONGODB),
@@ -329,6 +282,8 @@ func HandlerClients(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodPatch:
 		addBudgetIDToClient(w, r)
+	case http.MethodDelete:
+		removeBudgetIDFromClient(w, r)
 	case http.MethodGet:
 		getClientsByBudgetIDs(w, r)
 	default:
@@ -349,34 +304,31 @@ func addBudgetIDToClient(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if budgetRequest.Email == "" || budgetRequest.BudgetID <= 0 {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(schemas.ApiResponse{
-			Message: "Email do cliente e BudgetID são obrigatórios",
-		})
+	if errs := validation.Validate(budgetRequest); errs != nil {
+		validation.WriteErrors(w, errs)
 		return
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), database.MONGODB_TIMEOUT)
 	defer cancel()
 
-	mongoURI := os.Getenv(utils.ENV_MONGODB_URI)
-	opts := options.Client().ApplyURI(mongoURI)
-	client, err := mongo.Connect(opts)
+	client, err := Repositories.Clients.FindByEmail(ctx, budgetRequest.Email)
 	if err != nil {
-		w.WriteHeader(http.StatusBadGateway)
+		if err == mongo.ErrNoDocuments {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(schemas.ApiResponse{
+				Message: "Cliente não encontrado",
+			})
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(schemas.ApiResponse{
-			Message: utils.SendInternalError(utils.CANNOT_CONNECT_TO_MONGODB),
+			Message: utils.SendInternalError(utils.ERROR_TO_TRY_FIND_MONGODB),
 		})
 		return
 	}
-	defer client.Disconnect(ctx)
 
-	clientsCollection := client.Database(database.GetDB()).Collection("clients")
-	filter := bson.D{{Key: "contact.email", Value: budgetRequest.Email}}
-
-	existingClient := schemas.ClientFromDB{}
-	err = clientsCollection.FindOne(ctx, filter).Decode(&existingClient)
+	added, err := Repositories.Clients.AddBudgetID(ctx, budgetRequest.Email, budgetRequest.BudgetID)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			w.WriteHeader(http.StatusNotFound)
@@ -392,17 +344,61 @@ func addBudgetIDToClient(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	update := bson.D{
-		{Key: "$addToSet", Value: bson.D{
-			{Key: "budget_ids", Value: budgetRequest.BudgetID},
-		}},
-		{Key: "$set", Value: bson.D{
-			{Key: "updated_at", Value: time.Now()},
-		}},
+	if !added {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: "Este orçamento já está associado ao cliente",
+		})
+		return
 	}
 
-	result, err := clientsCollection.UpdateOne(ctx, filter, update)
-	if err != nil {
+	audit.Record(ctx, Repositories.AuditEvents, r, audit.Entry{
+		Action:     schemas.AUDIT_ACTION_CLIENT_BUDGET_ADD,
+		TargetType: schemas.AUDIT_TARGET_CLIENT,
+		TargetID:   client.ID.Hex(),
+		Before:     bson.M{"budget_ids": client.BudgetIDs},
+		After:      bson.M{"budget_ids": append(slices.Clone(client.BudgetIDs), budgetRequest.BudgetID)},
+	})
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(schemas.ApiResponse{
+		Message: "Orçamento adicionado com sucesso ao cliente",
+	})
+}
+
+// removeBudgetIDFromClient desfaz uma associação feita por engano. Se já houver
+// uniforme do cliente para o orçamento, a remoção é recusada.
+func removeBudgetIDFromClient(w http.ResponseWriter, r *http.Request) {
+	budgetRequest := schemas.ClientAddBudgetRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&budgetRequest); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: "Dados inválidos",
+		})
+		return
+	}
+
+	if errs := validation.Validate(budgetRequest); errs != nil {
+		validation.WriteErrors(w, errs)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGODB_TIMEOUT)
+	defer cancel()
+
+	client, ok := findClient(ctx, w, "", budgetRequest.Email)
+	if !ok {
+		return
+	}
+
+	_, err := Repositories.Uniforms.FindOneByClientAndBudget(ctx, client.ID.Hex(), budgetRequest.BudgetID)
+	if err == nil {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: "Já existe um uniforme cadastrado para este cliente com este orçamento",
+		})
+		return
+	} else if err != mongo.ErrNoDocuments {
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(schemas.ApiResponse{
 			Message: utils.SendInternalError(utils.ERROR_TO_TRY_FIND_MONGODB),
@@ -410,25 +406,43 @@ func addBudgetIDToClient(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if result.MatchedCount == 0 {
-		w.WriteHeader(http.StatusNotFound)
+	removed, err := Repositories.Clients.RemoveBudgetID(ctx, client.ID, budgetRequest.BudgetID)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(schemas.ApiResponse{
+				Message: "Cliente não encontrado",
+			})
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(schemas.ApiResponse{
-			Message: "Cliente não encontrado",
+			Message: utils.SendInternalError(utils.ERROR_TO_UPDATE_CLIENT_TO_MONGODB),
 		})
 		return
 	}
 
-	if result.ModifiedCount == 0 {
-		w.WriteHeader(http.StatusConflict)
+	if !removed {
+		w.WriteHeader(http.StatusNotFound)
 		json.NewEncoder(w).Encode(schemas.ApiResponse{
-			Message: "Este orçamento já está associado ao cliente",
+			Message: "Este orçamento não está associado ao cliente",
 		})
 		return
 	}
 
+	audit.Record(ctx, Repositories.AuditEvents, r, audit.Entry{
+		Action:     schemas.AUDIT_ACTION_CLIENT_BUDGET_REMOVE,
+		TargetType: schemas.AUDIT_TARGET_CLIENT,
+		TargetID:   client.ID.Hex(),
+		Before:     bson.M{"budget_ids": client.BudgetIDs},
+		After: bson.M{"budget_ids": slices.DeleteFunc(slices.Clone(client.BudgetIDs), func(id int) bool {
+			return id == budgetRequest.BudgetID
+		})},
+	})
+
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(schemas.ApiResponse{
-		Message: "Orçamento adicionado com sucesso ao cliente",
+		Message: "Orçamento removido do cliente",
 	})
 }
 
@@ -448,13 +462,19 @@ func getClientsByBudgetIDs(w http.ResponseWriter, r *http.Request) {
 		}
 	} else {
 		budgetRequest := schemas.ClientsByBudgetIDsRequest{}
-		if err := json.NewDecoder(r.Body).Decode(&budgetRequest); err != nil || len(budgetRequest.BudgetIDs) == 0 {
+		if err := json.NewDecoder(r.Body).Decode(&budgetRequest); err != nil {
 			w.WriteHeader(http.StatusBadRequest)
 			json.NewEncoder(w).Encode(schemas.ApiResponse{
-				Message: "Lista de IDs de orçamento é obrigatória",
+				Message: "Dados inválidos",
 			})
 			return
 		}
+
+		if errs := validation.Validate(budgetRequest); errs != nil {
+			validation.WriteErrors(w, errs)
+			return
+		}
+
 		budgetIDs = budgetRequest.BudgetIDs
 	}
 
@@ -469,23 +489,7 @@ func getClientsByBudgetIDs(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(context.Background(), database.MONGODB_TIMEOUT)
 	defer cancel()
 
-	mongoURI := os.Getenv(utils.ENV_MONGODB_URI)
-	opts := options.Client().ApplyURI(mongoURI)
-	client, err := mongo.Connect(opts)
-	if err != nil {
-		w.WriteHeader(http.StatusBadGateway)
-		json.NewEncoder(w).Encode(schemas.ApiResponse{
-			Message: utils.SendInternalError(utils.CANNOT_CONNECT_TO_MONGODB),
-		})
-		return
-	}
-	defer client.Disconnect(ctx)
-
-	clientsCollection := client.Database(database.GetDB()).Collection("clients")
-
-	filter := bson.D{{Key: "budget_ids", Value: bson.D{{Key: "$in", Value: budgetIDs}}}}
-
-	cursor, err := clientsCollection.Find(ctx, filter)
+	clients, err := Repositories.Clients.FindByBudgetIDs(ctx, budgetIDs)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(schemas.ApiResponse{
@@ -493,16 +497,6 @@ func getClientsByBudgetIDs(w http.ResponseWriter, r *http.Request) {
 		})
 		return
 	}
-	defer cursor.Close(ctx)
-
-	var clients []schemas.ClientFromDB
-	if err = cursor.All(ctx, &clients); err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(schemas.ApiResponse{
-			Message: utils.SendInternalError(utils.ERROR_TO_TRY_FIND_MONGODB),
-		})
-		return
-	}
 
 	if len(clients) == 0 {
 		w.WriteHeader(http.StatusNotFound)
@@ -517,37 +511,31 @@ func getClientsByBudgetIDs(w http.ResponseWriter, r *http.Request) {
 	uniformsMap := make(map[string]map[int]bool)
 
 	if withUniform {
-		uniformsCollection := client.Database(database.GetDB()).Collection("uniforms")
-
-		var clientIDs []bson.D
+		var clientIDs []string
 		for _, client := range clients {
-			clientIDs = append(clientIDs, bson.D{{Key: "client_id", Value: client.ID.Hex()}})
+			clientIDs = append(clientIDs, client.ID.Hex())
 		}
 
-		uniformFilter := bson.D{{Key: "$or", Value: clientIDs}}
-		uniformCursor, err := uniformsCollection.Find(ctx, uniformFilter)
-
+		uniforms, err := Repositories.Uniforms.FindByClientIDs(ctx, clientIDs)
 		if err == nil {
-			var uniforms []schemas.UniformFromDB
-			if err = uniformCursor.All(ctx, &uniforms); err == nil {
-				for _, uniform := range uniforms {
-					if _, exists := uniformsMap[uniform.ClientID]; !exists {
-						uniformsMap[uniform.ClientID] = make(map[int]bool)
-					}
-					uniformsMap[uniform.ClientID][uniform.BudgetID] = true
+			for _, uniform := range uniforms {
+				if _, exists := uniformsMap[uniform.ClientID]; !exists {
+					uniformsMap[uniform.ClientID] = make(map[int]bool)
 				}
+				uniformsMap[uniform.ClientID][uniform.BudgetID] = true
 			}
-			uniformCursor.Close(ctx)
 		}
 	}
 
 	for _, clientFromDB := range clients {
 		clientResponse := schemas.ClientResponse{
-			ID:        clientFromDB.ID.Hex(),
-			Contact:   clientFromDB.Contact,
-			BudgetIDs: clientFromDB.BudgetIDs,
-			CreatedAt: clientFromDB.CreatedAt,
-			UpdatedAt: clientFromDB.UpdatedAt,
+			ID:            clientFromDB.ID.Hex(),
+			Contact:       documents.FormatContact(clientFromDB.Contact),
+			EmailVerified: clientFromDB.EmailVerified,
+			TwoFactor:     clientFromDB.TwoFactor != nil && clientFromDB.TwoFactor.Enabled,
+			BudgetIDs:     clientFromDB.BudgetIDs,
+			CreatedAt:     clientFromDB.CreatedAt,
+			UpdatedAt:     clientFromDB.UpdatedAt,
 		}
 
 		if withUniform {
@@ -574,3 +562,68 @@ func getClientsByBudgetIDs(w http.ResponseWriter, r *http.Request) {
 		Data: clientResponses,
 	})
 }
+
+// HandlerClientUnlock zera as falhas de login do email, liberando uma conta
+// bloqueada. O contador por IP não é alterado.
+func HandlerClientUnlock(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.HTTP_METHOD_NO_ALLOWED),
+		})
+		return
+	}
+
+	unlockRequest := schemas.AdminClientUnlockRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&unlockRequest); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: "Dados inválidos",
+		})
+		return
+	}
+
+	if errs := validation.Validate(unlockRequest); errs != nil {
+		validation.WriteErrors(w, errs)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGODB_TIMEOUT)
+	defer cancel()
+
+	client, err := Repositories.Clients.FindByEmail(ctx, unlockRequest.Email)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(schemas.ApiResponse{
+				Message: "Cliente não encontrado",
+			})
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.ERROR_TO_TRY_FIND_MONGODB),
+		})
+		return
+	}
+
+	err = Repositories.LoginAttempts.Reset(ctx, database.LoginAttemptsEmailKey(unlockRequest.Email))
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.ERROR_TO_UPDATE_CLIENT_TO_MONGODB),
+		})
+		return
+	}
+
+	audit.Record(ctx, Repositories.AuditEvents, r, audit.Entry{
+		Action:     schemas.AUDIT_ACTION_CLIENT_UNLOCK,
+		TargetType: schemas.AUDIT_TARGET_CLIENT,
+		TargetID:   client.ID.Hex(),
+	})
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(schemas.ApiResponse{
+		Message: "Conta desbloqueada com sucesso",
+	})
+}
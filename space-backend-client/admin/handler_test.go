@@ -1 +1,61 @@
 package admin
+
+import (
+	"api/database"
+	"api/schemas"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestAddBudgetIDToClient(t *testing.T) {
+	repositories := database.NewMemoryRepositories()
+	Repositories = repositories
+	t.Cleanup(func() { Repositories = nil })
+
+	repositories.Clients.(*database.MemoryClientsRepository).Insert(schemas.ClientFromDB{
+		Contact: schemas.Contact{Email: "time@example.com"},
+	})
+
+	body, _ := json.Marshal(schemas.ClientAddBudgetRequest{Email: "time@example.com", BudgetID: 42})
+
+	w := httptest.NewRecorder()
+	HandlerClients(w, httptest.NewRequest(http.MethodPatch, "/v1/admin/clients", bytes.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("first add: status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	w = httptest.NewRecorder()
+	HandlerClients(w, httptest.NewRequest(http.MethodPatch, "/v1/admin/clients", bytes.NewReader(body)))
+	if w.Code != http.StatusConflict {
+		t.Fatalf("second add: status = %d, want %d", w.Code, http.StatusConflict)
+	}
+}
+
+func TestClientUnlockResetsLoginAttempts(t *testing.T) {
+	repositories := database.NewMemoryRepositories()
+	Repositories = repositories
+	t.Cleanup(func() { Repositories = nil })
+
+	repositories.Clients.(*database.MemoryClientsRepository).Insert(schemas.ClientFromDB{
+		Contact: schemas.Contact{Email: "time@example.com"},
+	})
+
+	key := database.LoginAttemptsEmailKey("time@example.com")
+	repositories.LoginAttempts.Claim(t.Context(), key, time.Now(), time.Hour)
+
+	body, _ := json.Marshal(schemas.AdminClientUnlockRequest{Email: "time@example.com"})
+
+	w := httptest.NewRecorder()
+	HandlerClientUnlock(w, httptest.NewRequest(http.MethodPost, "/v1/admin/clients/unlock", bytes.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	if attempts, _ := repositories.LoginAttempts.Get(t.Context(), key); attempts.Failures != 0 {
+		t.Errorf("failures = %d, want 0", attempts.Failures)
+	}
+}
@@ -0,0 +1,363 @@
+package admin
+
+import (
+	"api/audit"
+	"api/database"
+	"api/schemas"
+	"api/tinysync"
+	"api/utils"
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+const (
+	TINY_SYNC_JOBS_DEFAULT_LIMIT = 50
+	TINY_SYNC_JOBS_MAX_LIMIT     = 200
+
+	// Cada cliente vinculado custa uma chamada ao Tiny, que limita o ritmo das
+	// requisições; por isso as páginas do relatório de vínculos são menores
+	TINY_LINKS_DEFAULT_LIMIT = 20
+	TINY_LINKS_MAX_LIMIT     = 50
+	TINY_LINKS_TIMEOUT       = 3 * time.Minute
+
+	TINY_RECONCILIATIONS_DEFAULT_LIMIT = 10
+	TINY_RECONCILIATIONS_MAX_LIMIT     = 50
+)
+
+// TinySyncer é injetado pelo main e consulta os contatos do Tiny no relatório
+// de vínculos.
+var TinySyncer tinysync.Syncer
+
+// TinyReconciler é injetado pelo main e executa as reconciliações disparadas
+// pelo admin.
+var TinyReconciler *tinysync.Reconciler
+
+var tinySyncStatuses = []string{
+	schemas.TINY_SYNC_PENDING,
+	schemas.TINY_SYNC_PROCESSING,
+	schemas.TINY_SYNC_DONE,
+	schemas.TINY_SYNC_DEAD,
+	schemas.TINY_SYNC_SUPERSEDED,
+}
+
+// HandlerTinySyncJobs lista a fila de sincronização com o Tiny, do job mais
+// recente para o mais antigo. ?status= filtra (ex.: dead para as falhas
+// definitivas) e ?limit= limita a quantidade.
+func HandlerTinySyncJobs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.HTTP_METHOD_NO_ALLOWED),
+		})
+		return
+	}
+
+	query := r.URL.Query()
+	status := query.Get("status")
+	if status != "" && !slices.Contains(tinySyncStatuses, status) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: "Status inválido: " + status,
+		})
+		return
+	}
+
+	limit := int64(TINY_SYNC_JOBS_DEFAULT_LIMIT)
+	if raw := query.Get("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 || parsed > TINY_SYNC_JOBS_MAX_LIMIT {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(schemas.ApiResponse{
+				Message: "O limite deve estar entre 1 e " + strconv.Itoa(TINY_SYNC_JOBS_MAX_LIMIT),
+			})
+			return
+		}
+		limit = int64(parsed)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGODB_TIMEOUT)
+	defer cancel()
+
+	jobs, err := Repositories.TinySyncJobs.List(ctx, status, limit)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.ERROR_TO_TRY_FIND_MONGODB),
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(schemas.ApiResponse{
+		Data: schemas.TinySyncJobsResponse{Jobs: jobs},
+	})
+}
+
+// HandlerTinySyncReplay devolve à fila um job que esgotou as tentativas
+// (?id=), com as tentativas zeradas.
+func HandlerTinySyncReplay(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.HTTP_METHOD_NO_ALLOWED),
+		})
+		return
+	}
+
+	id, err := utils.ParseObjectIDFromHex(r.URL.Query().Get("id"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: "ID do job inválido",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGODB_TIMEOUT)
+	defer cancel()
+
+	job, err := Repositories.TinySyncJobs.FindByID(ctx, id)
+	if err == nil {
+		err = Repositories.TinySyncJobs.Replay(ctx, id)
+	}
+	if err != nil {
+		switch {
+		case err == mongo.ErrNoDocuments:
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(schemas.ApiResponse{
+				Message: "Job não encontrado ou não está com falha definitiva",
+			})
+		case mongo.IsDuplicateKeyError(err):
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(schemas.ApiResponse{
+				Message: "Já existe uma sincronização pendente para este cliente",
+			})
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(schemas.ApiResponse{
+				Message: utils.SendInternalError(utils.ERROR_TO_UPDATE_CLIENT_TO_MONGODB),
+			})
+		}
+		return
+	}
+
+	audit.Record(ctx, Repositories.AuditEvents, r, audit.Entry{
+		Action:     schemas.AUDIT_ACTION_TINY_SYNC_REPLAY,
+		TargetType: schemas.AUDIT_TARGET_TINY_SYNC,
+		TargetID:   id.Hex(),
+		Before:     bson.M{"status": job.Status, "attempts": job.Attempts},
+		After:      bson.M{"status": schemas.TINY_SYNC_PENDING, "attempts": 0},
+		Metadata:   map[string]any{"client_id": job.ClientID.Hex(), "last_error": job.LastError},
+	})
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(schemas.ApiResponse{
+		Message: "Sincronização reenviada para a fila",
+	})
+}
+
+// HandlerTinyLinks confere o vínculo com o Tiny dos clientes verificados, em
+// ordem de cadastro, e lista os que estão sem tiny_id, apontam para um contato
+// inexistente ou com email/documento diferente, ou dividem o tiny_id com outro
+// cliente. ?limit= é a quantidade de clientes conferidos (não de problemas) e
+// ?after= recebe o next_after da página anterior.
+func HandlerTinyLinks(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.HTTP_METHOD_NO_ALLOWED),
+		})
+		return
+	}
+
+	if TinySyncer == nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: "Integração com o Tiny não configurada",
+		})
+		return
+	}
+
+	query := r.URL.Query()
+	var after bson.ObjectID
+	if raw := query.Get("after"); raw != "" {
+		parsed, err := utils.ParseObjectIDFromHex(raw)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(schemas.ApiResponse{
+				Message: "Cursor inválido",
+			})
+			return
+		}
+		after = parsed
+	}
+
+	limit := int64(TINY_LINKS_DEFAULT_LIMIT)
+	if raw := query.Get("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 || parsed > TINY_LINKS_MAX_LIMIT {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(schemas.ApiResponse{
+				Message: "O limite deve estar entre 1 e " + strconv.Itoa(TINY_LINKS_MAX_LIMIT),
+			})
+			return
+		}
+		limit = int64(parsed)
+	}
+
+	// A conferência passa do WriteTimeout do servidor; o prazo é estendido só
+	// nesta rota
+	http.NewResponseController(w).SetWriteDeadline(time.Now().Add(TINY_LINKS_TIMEOUT + 10*time.Second))
+
+	ctx, cancel := context.WithTimeout(context.Background(), TINY_LINKS_TIMEOUT)
+	defer cancel()
+
+	clients, err := Repositories.Clients.ListVerified(ctx, after, limit)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.ERROR_TO_TRY_FIND_MONGODB),
+		})
+		return
+	}
+
+	response := schemas.TinyLinksResponse{Issues: []schemas.TinyLinkIssue{}}
+	for _, client := range clients {
+		issue, err := tinysync.InspectLink(ctx, Repositories, TinySyncer, client)
+		if err != nil {
+			log.Printf("[TinySync] Erro ao conferir o vínculo do cliente %s: %v", client.ID.Hex(), err)
+			w.WriteHeader(http.StatusBadGateway)
+			json.NewEncoder(w).Encode(schemas.ApiResponse{
+				Message: "Não foi possível consultar o Tiny",
+			})
+			return
+		}
+		if issue != nil {
+			response.Issues = append(response.Issues, *issue)
+		}
+	}
+	if int64(len(clients)) == limit {
+		response.NextAfter = clients[len(clients)-1].ID.Hex()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(schemas.ApiResponse{
+		Data: response,
+	})
+}
+
+// HandlerTinyReconciliations lista os relatórios da reconciliação com o Tiny
+// (GET, do mais recente para o mais antigo) e dispara uma nova execução em
+// segundo plano (POST). ?policy= e ?dry_run= substituem a configuração do
+// agendamento só nesta execução.
+func HandlerTinyReconciliations(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		listTinyReconciliations(w, r)
+	case http.MethodPost:
+		startTinyReconciliation(w, r)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.HTTP_METHOD_NO_ALLOWED),
+		})
+	}
+}
+
+func listTinyReconciliations(w http.ResponseWriter, r *http.Request) {
+	limit := int64(TINY_RECONCILIATIONS_DEFAULT_LIMIT)
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 || parsed > TINY_RECONCILIATIONS_MAX_LIMIT {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(schemas.ApiResponse{
+				Message: "O limite deve estar entre 1 e " + strconv.Itoa(TINY_RECONCILIATIONS_MAX_LIMIT),
+			})
+			return
+		}
+		limit = int64(parsed)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGODB_TIMEOUT)
+	defer cancel()
+
+	reconciliations, err := Repositories.TinyReconciliations.List(ctx, limit)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.ERROR_TO_TRY_FIND_MONGODB),
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(schemas.ApiResponse{
+		Data: schemas.TinyReconciliationsResponse{Reconciliations: reconciliations},
+	})
+}
+
+func startTinyReconciliation(w http.ResponseWriter, r *http.Request) {
+	if TinyReconciler == nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: "Integração com o Tiny não configurada",
+		})
+		return
+	}
+
+	query := r.URL.Query()
+	policy := TinyReconciler.Policy
+	if raw := query.Get("policy"); raw != "" {
+		if !tinysync.ValidPolicy(raw) {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(schemas.ApiResponse{
+				Message: "Política inválida: " + raw,
+			})
+			return
+		}
+		policy = raw
+	}
+
+	dryRun := TinyReconciler.DryRun
+	if raw := query.Get("dry_run"); raw != "" {
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(schemas.ApiResponse{
+				Message: "dry_run deve ser true ou false",
+			})
+			return
+		}
+		dryRun = parsed
+	}
+
+	if err := TinyReconciler.Start(policy, dryRun); err != nil {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: "Já há uma reconciliação em andamento",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGODB_TIMEOUT)
+	defer cancel()
+
+	audit.Record(ctx, Repositories.AuditEvents, r, audit.Entry{
+		Action:     schemas.AUDIT_ACTION_TINY_RECONCILE,
+		TargetType: schemas.AUDIT_TARGET_TINY_RECONCILIATION,
+		Metadata:   map[string]any{"policy": policy, "dry_run": dryRun},
+	})
+
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(schemas.ApiResponse{
+		Message: "Reconciliação iniciada",
+	})
+}
@@ -0,0 +1,209 @@
+package admin
+
+import (
+	"api/database"
+	"api/schemas"
+	"api/tiny"
+	"api/tinysync"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestTinySyncJobsListAndReplay(t *testing.T) {
+	repositories := database.NewMemoryRepositories()
+	Repositories = repositories
+	t.Cleanup(func() { Repositories = nil })
+
+	clientID := bson.NewObjectID()
+	repositories.TinySyncJobs.Enqueue(t.Context(), clientID)
+	job, _ := repositories.TinySyncJobs.Claim(t.Context(), time.Now(), time.Now().Add(time.Minute))
+	repositories.TinySyncJobs.MarkFailed(t.Context(), job.ID, "Tiny fora do ar", time.Now(), true)
+
+	w := httptest.NewRecorder()
+	HandlerTinySyncJobs(w, httptest.NewRequest(http.MethodGet, "/v1/admin/tiny-sync?status=dead", nil))
+
+	var response struct {
+		Data schemas.TinySyncJobsResponse `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+	if len(response.Data.Jobs) != 1 || response.Data.Jobs[0].LastError != "Tiny fora do ar" {
+		t.Fatalf("jobs = %+v, want the dead job", response.Data.Jobs)
+	}
+
+	w = httptest.NewRecorder()
+	HandlerTinySyncReplay(w, httptest.NewRequest(http.MethodPost, "/v1/admin/tiny-sync/replay?id="+job.ID.Hex(), nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("replay status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	job, _ = repositories.TinySyncJobs.FindByID(t.Context(), job.ID)
+	if job.Status != schemas.TINY_SYNC_PENDING || job.Attempts != 0 {
+		t.Errorf("job = %+v, want pending with attempts reset", job)
+	}
+
+	events := repositories.AuditEvents.(*database.MemoryAuditEventsRepository).Events()
+	if len(events) != 1 || events[0].Action != schemas.AUDIT_ACTION_TINY_SYNC_REPLAY {
+		t.Errorf("events = %+v, want tiny_sync.replay", events)
+	}
+
+	// Um job que não está morto não pode ser reenviado
+	w = httptest.NewRecorder()
+	HandlerTinySyncReplay(w, httptest.NewRequest(http.MethodPost, "/v1/admin/tiny-sync/replay?id="+job.ID.Hex(), nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("second replay status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestTinySyncJobsRejectsUnknownStatus(t *testing.T) {
+	Repositories = database.NewMemoryRepositories()
+	t.Cleanup(func() { Repositories = nil })
+
+	w := httptest.NewRecorder()
+	HandlerTinySyncJobs(w, httptest.NewRequest(http.MethodGet, "/v1/admin/tiny-sync?status=failed", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+type fakeTinySyncer struct {
+	contacts map[string]tiny.Contact
+	err      error
+}
+
+func (s fakeTinySyncer) Create(ctx context.Context, contact schemas.Contact) (string, error) {
+	return "", nil
+}
+
+func (s fakeTinySyncer) Update(ctx context.Context, contact schemas.Contact, tinyID string) error {
+	return nil
+}
+
+func (s fakeTinySyncer) Find(ctx context.Context, contact schemas.Contact) ([]tiny.Contact, error) {
+	return nil, nil
+}
+
+func (s fakeTinySyncer) Get(ctx context.Context, tinyID string) (tiny.Contact, error) {
+	if s.err != nil {
+		return tiny.Contact{}, s.err
+	}
+	contact, ok := s.contacts[tinyID]
+	if !ok {
+		return tiny.Contact{}, &tiny.APIError{Code: tiny.CODE_NOT_FOUND}
+	}
+	return contact, nil
+}
+
+func (s fakeTinySyncer) Page(ctx context.Context, page int) (tiny.SearchResult, error) {
+	var contacts []tiny.Contact
+	for _, contact := range s.contacts {
+		contacts = append(contacts, contact)
+	}
+	return tiny.SearchResult{Contacts: contacts, Page: page, Pages: 1}, nil
+}
+
+func TestTinyLinksReport(t *testing.T) {
+	repositories := database.NewMemoryRepositories()
+	Repositories = repositories
+	TinySyncer = fakeTinySyncer{contacts: map[string]tiny.Contact{
+		"1": {ID: "1", Email: "ana@example.com"},
+		"2": {ID: "2", Email: "bia@outro.com"},
+	}}
+	t.Cleanup(func() {
+		Repositories = nil
+		TinySyncer = nil
+	})
+
+	clientsRepository := repositories.Clients.(*database.MemoryClientsRepository)
+	for _, client := range []schemas.ClientFromDB{
+		{Contact: schemas.Contact{Email: "ana@example.com", TinyID: "1"}, EmailVerified: true},
+		{Contact: schemas.Contact{Email: "bia@example.com", TinyID: "2"}, EmailVerified: true},
+		{Contact: schemas.Contact{Email: "caio@example.com"}, EmailVerified: true},
+		{Contact: schemas.Contact{Email: "dani@example.com"}},
+	} {
+		client.ID = bson.NewObjectID()
+		clientsRepository.Insert(client)
+	}
+
+	w := httptest.NewRecorder()
+	HandlerTinyLinks(w, httptest.NewRequest(http.MethodGet, "/v1/admin/tiny-sync/links?limit=2", nil))
+
+	var response struct {
+		Data schemas.TinyLinksResponse `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+	issues := response.Data.Issues
+	if len(issues) != 1 || issues[0].Reason != schemas.TINY_LINK_CONFLICT || response.Data.NextAfter == "" {
+		t.Fatalf("page 1 = %+v, want the email conflict and a cursor", response.Data)
+	}
+
+	w = httptest.NewRecorder()
+	HandlerTinyLinks(w, httptest.NewRequest(http.MethodGet, "/v1/admin/tiny-sync/links?limit=2&after="+response.Data.NextAfter, nil))
+	response.Data = schemas.TinyLinksResponse{}
+	json.NewDecoder(w.Body).Decode(&response)
+	issues = response.Data.Issues
+	// O cliente não verificado fica de fora: ele ainda não deveria estar no Tiny
+	if len(issues) != 1 || issues[0].Reason != schemas.TINY_LINK_MISSING || response.Data.NextAfter != "" {
+		t.Errorf("page 2 = %+v, want only the missing link", response.Data)
+	}
+
+	TinySyncer = fakeTinySyncer{err: tiny.ErrUnavailable}
+	w = httptest.NewRecorder()
+	HandlerTinyLinks(w, httptest.NewRequest(http.MethodGet, "/v1/admin/tiny-sync/links", nil))
+	if w.Code != http.StatusBadGateway {
+		t.Errorf("status = %d, want %d when Tiny is down", w.Code, http.StatusBadGateway)
+	}
+}
+
+func TestTinyReconciliations(t *testing.T) {
+	repositories := database.NewMemoryRepositories()
+	Repositories = repositories
+	TinyReconciler = tinysync.NewReconciler(repositories, fakeTinySyncer{}, schemas.TINY_RECONCILE_REVIEW, false)
+	t.Cleanup(func() {
+		Repositories = nil
+		TinyReconciler = nil
+	})
+
+	w := httptest.NewRecorder()
+	HandlerTinyReconciliations(w, httptest.NewRequest(http.MethodPost, "/v1/admin/tiny-sync/reconciliations?policy=mine", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want %d for an unknown policy", w.Code, http.StatusBadRequest)
+	}
+
+	if _, err := TinyReconciler.Reconcile(t.Context(), schemas.TINY_RECONCILE_OURS, true); err != nil {
+		t.Fatal(err)
+	}
+
+	w = httptest.NewRecorder()
+	HandlerTinyReconciliations(w, httptest.NewRequest(http.MethodGet, "/v1/admin/tiny-sync/reconciliations", nil))
+
+	var response struct {
+		Data schemas.TinyReconciliationsResponse `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+	reconciliations := response.Data.Reconciliations
+	if len(reconciliations) != 1 || reconciliations[0].Policy != schemas.TINY_RECONCILE_OURS || !reconciliations[0].DryRun {
+		t.Errorf("reconciliations = %+v, want the dry-run report", reconciliations)
+	}
+
+	w = httptest.NewRecorder()
+	HandlerTinyReconciliations(w, httptest.NewRequest(http.MethodPost, "/v1/admin/tiny-sync/reconciliations?dry_run=true", nil))
+	if w.Code != http.StatusAccepted {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusAccepted)
+	}
+	events := repositories.AuditEvents.(*database.MemoryAuditEventsRepository).Events()
+	if len(events) != 1 || events[0].Action != schemas.AUDIT_ACTION_TINY_RECONCILE {
+		t.Errorf("events = %+v, want tiny_sync.reconcile", events)
+	}
+}
@@ -0,0 +1,441 @@
+package admin
+
+import (
+	"api/audit"
+	"api/database"
+	"api/middlewares"
+	"api/schemas"
+	"api/utils"
+	"api/validation"
+	"context"
+	"encoding/json"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	ADMIN_LOGIN_ATTEMPTS_WINDOW  = 1 * time.Hour
+	ADMIN_LOGIN_MAX_FAILURES     = 5
+	ADMIN_LOGIN_LOCKOUT_DURATION = 15 * time.Minute
+)
+
+// Signin troca email e senha de um usuário de admin_users por um token de
+// sessão, enviado depois em Authorization: Bearer. Após
+// ADMIN_LOGIN_MAX_FAILURES falhas o email fica bloqueado por
+// ADMIN_LOGIN_LOCKOUT_DURATION.
+func Signin(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.HTTP_METHOD_NO_ALLOWED),
+		})
+		return
+	}
+
+	req := schemas.AdminSigninRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: "Dados inválidos",
+		})
+		return
+	}
+
+	if errs := validation.Validate(req); errs != nil {
+		validation.WriteErrors(w, errs)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGODB_TIMEOUT)
+	defer cancel()
+
+	// A tentativa é contada como falha antes da senha ser conferida, para que
+	// requisições paralelas não passem todas pela mesma contagem
+	attemptsKey := database.LoginAttemptsAdminKey(req.Email)
+	attemptAt := time.Now()
+	attempts, err := Repositories.LoginAttempts.Claim(ctx, attemptsKey, attemptAt, ADMIN_LOGIN_ATTEMPTS_WINDOW)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.ERROR_TO_TRY_FIND_MONGODB),
+		})
+		return
+	}
+
+	if attempts.Failures >= ADMIN_LOGIN_MAX_FAILURES {
+		if retryAfter := time.Until(attempts.LastFailureAt.Add(ADMIN_LOGIN_LOCKOUT_DURATION)); retryAfter > 0 {
+			releaseAdminLoginAttempt(ctx, attemptsKey, attemptAt, attempts)
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			w.WriteHeader(http.StatusTooManyRequests)
+			json.NewEncoder(w).Encode(schemas.ApiResponse{
+				Message: "Muitas tentativas de login. Tente novamente mais tarde",
+			})
+			return
+		}
+	}
+
+	user, err := Repositories.AdminUsers.FindByEmail(ctx, req.Email)
+	if err != nil && err != mongo.ErrNoDocuments {
+		releaseAdminLoginAttempt(ctx, attemptsKey, attemptAt, attempts)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.ERROR_TO_TRY_FIND_MONGODB),
+		})
+		return
+	}
+
+	if err != nil || !user.Active || bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)) != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: "Credenciais inválidas",
+		})
+		return
+	}
+
+	if err := Repositories.LoginAttempts.Reset(ctx, attemptsKey); err != nil {
+		log.Printf("[Admin] Erro ao zerar falhas de login: %v", err)
+	}
+
+	token, err := utils.GenerateAdminKey(user.ID.Hex())
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.ERROR_WHEN_GENERATE_ACCESS_TOKEN),
+		})
+		return
+	}
+
+	if err := Repositories.AdminUsers.SetLastLogin(ctx, user.ID, time.Now()); err != nil {
+		log.Printf("[Admin] Erro ao gravar último login: %v", err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(schemas.ApiResponse{
+		Data: schemas.AuthTokenResponse{AccessToken: token},
+	})
+}
+
+// releaseAdminLoginAttempt desfaz a tentativa contada no Signin quando ela não
+// chegou a ser verificada.
+func releaseAdminLoginAttempt(ctx context.Context, key string, at time.Time, previous schemas.LoginAttempts) {
+	if err := Repositories.LoginAttempts.Release(ctx, key, at, previous); err != nil {
+		log.Printf("[Admin] Erro ao desfazer tentativa de login: %v", err)
+	}
+}
+
+// Signout revoga o token administrativo usado na requisição.
+func Signout(w http.ResponseWriter, r *http.Request) {
+	bearer, _ := utils.BearerToken(r)
+
+	claims, err := utils.ValidateAdminKey(bearer)
+	if err == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), database.MONGODB_TIMEOUT)
+		defer cancel()
+
+		err = Repositories.RevokedTokens.Revoke(ctx, utils.DenylistKeyForToken(claims.ID), claims.ExpiresAt.Time)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(schemas.ApiResponse{
+				Message: utils.SendInternalError(utils.ERROR_TO_UPDATE_REFRESH_TOKEN),
+			})
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(schemas.ApiResponse{
+		Message: "Logout realizado com sucesso",
+	})
+}
+
+// Me retorna o usuário administrativo autenticado, com as permissões do papel.
+func Me(w http.ResponseWriter, r *http.Request) {
+	adminIdStr, _ := r.Context().Value(middlewares.AdminIDKey).(string)
+	adminId, err := utils.ParseObjectIDFromHex(adminIdStr)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.INVALID_USER_ID_FORMAT),
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGODB_TIMEOUT)
+	defer cancel()
+
+	user, err := Repositories.AdminUsers.FindByID(ctx, adminId)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.ERROR_TO_TRY_FIND_MONGODB),
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(schemas.ApiResponse{
+		Data: schemas.AdminUserResponse{
+			AdminUser:   user,
+			Permissions: schemas.AdminRolePermissions[user.Role],
+		},
+	})
+}
+
+func listAdminUsers(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGODB_TIMEOUT)
+	defer cancel()
+
+	users, err := Repositories.AdminUsers.List(ctx)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.ERROR_TO_TRY_FIND_MONGODB),
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(schemas.ApiResponse{
+		Data: users,
+	})
+}
+
+func createAdminUser(w http.ResponseWriter, r *http.Request) {
+	req := schemas.AdminUserCreateRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: "Dados inválidos",
+		})
+		return
+	}
+
+	if errs := validation.Validate(req); errs != nil {
+		validation.WriteErrors(w, errs)
+		return
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.ERROR_TO_CREATE_PASSWORD_HASH),
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGODB_TIMEOUT)
+	defer cancel()
+
+	now := time.Now()
+	user := schemas.AdminUser{
+		Name:         req.Name,
+		Email:        req.Email,
+		PasswordHash: string(hash),
+		Role:         req.Role,
+		Active:       true,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	id, err := Repositories.AdminUsers.Create(ctx, user)
+	if err != nil {
+		if err == database.ErrAdminEmailTaken {
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(schemas.ApiResponse{
+				Message: "Email já cadastrado",
+			})
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.CANNOT_INSERT_CLIENT_TO_MONGODB),
+		})
+		return
+	}
+
+	audit.Record(ctx, Repositories.AuditEvents, r, audit.Entry{
+		Action:     schemas.AUDIT_ACTION_ADMIN_USER_CREATE,
+		TargetType: schemas.AUDIT_TARGET_ADMIN_USER,
+		TargetID:   id.Hex(),
+		After:      user,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(schemas.ApiResponse{
+		Message: "Usuário criado com sucesso",
+		Data:    map[string]string{"id": id.Hex()},
+	})
+}
+
+// updateAdminUser altera papel, status ou senha (?id=). Um superadmin não pode
+// rebaixar nem desativar a si mesmo, para não deixar o sistema sem gestor.
+func updateAdminUser(w http.ResponseWriter, r *http.Request) {
+	id, err := utils.ParseObjectIDFromHex(r.URL.Query().Get("id"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: "ID do usuário inválido",
+		})
+		return
+	}
+
+	req := schemas.AdminUserUpdateRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: "Dados inválidos",
+		})
+		return
+	}
+
+	if errs := validation.Validate(req); errs != nil {
+		validation.WriteErrors(w, errs)
+		return
+	}
+
+	if currentAdminId, _ := r.Context().Value(middlewares.AdminIDKey).(string); currentAdminId == id.Hex() {
+		if (req.Role != nil && *req.Role != schemas.ADMIN_ROLE_SUPERADMIN) || (req.Active != nil && !*req.Active) {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(schemas.ApiResponse{
+				Message: "Não é possível rebaixar ou desativar o próprio usuário",
+			})
+			return
+		}
+	}
+
+	update := schemas.AdminUserUpdate{Role: req.Role, Active: req.Active}
+	if req.Password != nil {
+		hash, err := bcrypt.GenerateFromPassword([]byte(*req.Password), bcrypt.DefaultCost)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(schemas.ApiResponse{
+				Message: utils.SendInternalError(utils.ERROR_TO_CREATE_PASSWORD_HASH),
+			})
+			return
+		}
+		passwordHash := string(hash)
+		update.PasswordHash = &passwordHash
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGODB_TIMEOUT)
+	defer cancel()
+
+	before, err := Repositories.AdminUsers.FindByID(ctx, id)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(schemas.ApiResponse{
+				Message: "Usuário não encontrado",
+			})
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.ERROR_TO_TRY_FIND_MONGODB),
+		})
+		return
+	}
+
+	err = Repositories.AdminUsers.Update(ctx, id, update)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(schemas.ApiResponse{
+				Message: "Usuário não encontrado",
+			})
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.ERROR_TO_UPDATE_CLIENT_TO_MONGODB),
+		})
+		return
+	}
+
+	after := before
+	if update.Role != nil {
+		after.Role = *update.Role
+	}
+	if update.Active != nil {
+		after.Active = *update.Active
+	}
+	if update.PasswordHash != nil {
+		after.PasswordHash = *update.PasswordHash
+	}
+
+	audit.Record(ctx, Repositories.AuditEvents, r, audit.Entry{
+		Action:     schemas.AUDIT_ACTION_ADMIN_USER_UPDATE,
+		TargetType: schemas.AUDIT_TARGET_ADMIN_USER,
+		TargetID:   id.Hex(),
+		Before:     bson.M{"role": before.Role, "active": before.Active, "password_hash": before.PasswordHash},
+		After:      bson.M{"role": after.Role, "active": after.Active, "password_hash": after.PasswordHash},
+	})
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(schemas.ApiResponse{
+		Message: "Usuário atualizado com sucesso",
+	})
+}
+
+func HandlerAdminUsers(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		listAdminUsers(w, r)
+	case http.MethodPost:
+		createAdminUser(w, r)
+	case http.MethodPatch:
+		updateAdminUser(w, r)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.HTTP_METHOD_NO_ALLOWED),
+		})
+	}
+}
+
+// BootstrapSuperadmin cria o primeiro superadmin a partir de
+// ADMIN_BOOTSTRAP_EMAIL e ADMIN_BOOTSTRAP_PASSWORD quando a coleção ainda está
+// vazia. Depois disso as variáveis são ignoradas e podem ser removidas.
+func BootstrapSuperadmin(ctx context.Context) error {
+	email := os.Getenv(utils.ADMIN_BOOTSTRAP_EMAIL)
+	password := os.Getenv(utils.ADMIN_BOOTSTRAP_PASSWORD)
+	if email == "" || password == "" {
+		return nil
+	}
+
+	count, err := Repositories.AdminUsers.Count(ctx)
+	if err != nil || count > 0 {
+		return err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	_, err = Repositories.AdminUsers.Create(ctx, schemas.AdminUser{
+		Name:         "Superadmin",
+		Email:        email,
+		PasswordHash: string(hash),
+		Role:         schemas.ADMIN_ROLE_SUPERADMIN,
+		Active:       true,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	})
+	if err == nil {
+		log.Printf("[Admin] Superadmin inicial criado para %s", email)
+	}
+	return err
+}
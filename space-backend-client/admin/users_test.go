@@ -0,0 +1,84 @@
+package admin
+
+import (
+	"api/database"
+	"api/schemas"
+	"api/utils"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func adminSigninRequest(email, password string) *http.Request {
+	body, _ := json.Marshal(schemas.AdminSigninRequest{Email: email, Password: password})
+	return httptest.NewRequest(http.MethodPost, "/v1/admin/auth/signin", bytes.NewReader(body))
+}
+
+func TestAdminSigninIssuesAdminToken(t *testing.T) {
+	t.Setenv("ACCESS_TOKEN_SECRET", "access")
+
+	repositories := database.NewMemoryRepositories()
+	Repositories = repositories
+	t.Cleanup(func() { Repositories = nil })
+
+	hash, _ := bcrypt.GenerateFromPassword([]byte("senha-da-equipe"), bcrypt.MinCost)
+	id, _ := repositories.AdminUsers.Create(t.Context(), schemas.AdminUser{
+		Email:        "Equipe@Example.com",
+		PasswordHash: string(hash),
+		Role:         schemas.ADMIN_ROLE_SUPPORT,
+		Active:       true,
+	})
+
+	w := httptest.NewRecorder()
+	Signin(w, adminSigninRequest("equipe@example.com", "senha-da-equipe"))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	var response struct {
+		Data schemas.AuthTokenResponse `json:"data"`
+	}
+	json.NewDecoder(w.Body).Decode(&response)
+
+	claims, err := utils.ValidateAdminKey(response.Data.AccessToken)
+	if err != nil || claims.UserId != id.Hex() {
+		t.Fatalf("admin token: claims = %+v, err = %v", claims, err)
+	}
+
+	// O token de admin não vale como access token de cliente
+	if _, err := utils.ValidateAccessKey(response.Data.AccessToken); err == nil {
+		t.Error("admin token accepted as client access token")
+	}
+}
+
+func TestAdminSigninLocksAfterMaxFailures(t *testing.T) {
+	repositories := database.NewMemoryRepositories()
+	Repositories = repositories
+	t.Cleanup(func() { Repositories = nil })
+
+	hash, _ := bcrypt.GenerateFromPassword([]byte("senha-da-equipe"), bcrypt.MinCost)
+	repositories.AdminUsers.Create(t.Context(), schemas.AdminUser{
+		Email:        "equipe@example.com",
+		PasswordHash: string(hash),
+		Role:         schemas.ADMIN_ROLE_SUPPORT,
+		Active:       true,
+	})
+
+	for range ADMIN_LOGIN_MAX_FAILURES {
+		w := httptest.NewRecorder()
+		Signin(w, adminSigninRequest("equipe@example.com", "senha-errada"))
+		if w.Code != http.StatusUnauthorized {
+			t.Fatalf("status = %d, want %d", w.Code, http.StatusUnauthorized)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	Signin(w, adminSigninRequest("equipe@example.com", "senha-da-equipe"))
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusTooManyRequests)
+	}
+}
@@ -0,0 +1,200 @@
+// Package audit grava os eventos de auditoria das operações que alteram
+// estado, com o autor identificado pelo contexto da requisição.
+package audit
+
+import (
+	"api/database"
+	"api/middlewares"
+	"api/schemas"
+	"api/utils"
+	"context"
+	"log"
+	"net/http"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+const REDACTED = "[redacted]"
+
+// redactedFields nunca têm o valor gravado, só o fato de terem mudado. Os
+// documentos do cliente também entram: no cadastro eles ficam cifrados.
+var redactedFields = []string{
+	"cpf",
+	"cnpj",
+	"identity_card",
+	"password_hash",
+	"key_hash",
+	"secret",
+	"token_hash",
+	"refresh_token_hash",
+	"previous_token_hash",
+	"recovery_code_hashes",
+}
+
+// Entry descreve uma ação auditada. Before e After são os documentos antes e
+// depois da alteração (structs com tags bson ou resultados de Snapshot) e
+// viram a lista de campos alterados.
+type Entry struct {
+	// ActorType e ActorID só precisam ser informados quando a rota não é
+	// autenticada (ex.: reset de senha); por padrão vêm do contexto.
+	ActorType  string
+	ActorID    string
+	Action     string
+	TargetType string
+	TargetID   string
+	Before     any
+	After      any
+	Metadata   map[string]any
+}
+
+// Record grava o evento. r é nil nas tarefas em segundo plano: o autor é o
+// sistema e o evento fica sem request_id e IP. Falhas só vão para o log: a
+// operação auditada já foi concluída e não deve ser desfeita por causa da
+// auditoria.
+func Record(ctx context.Context, events database.AuditEventsRepository, r *http.Request, entry Entry) {
+	event := schemas.AuditEvent{
+		ActorType:  entry.ActorType,
+		ActorID:    entry.ActorID,
+		Action:     entry.Action,
+		TargetType: entry.TargetType,
+		TargetID:   entry.TargetID,
+		Changes:    Diff(entry.Before, entry.After),
+		Metadata:   entry.Metadata,
+		CreatedAt:  time.Now(),
+	}
+
+	if r != nil {
+		if event.ActorType == "" {
+			event.ActorType, event.ActorID = Actor(r)
+		}
+		event.RequestID = middlewares.RequestIDFromContext(r.Context())
+		event.IP = utils.ClientIP(r)
+	} else if event.ActorType == "" {
+		event.ActorType = schemas.AUDIT_ACTOR_SYSTEM
+	}
+
+	if err := events.Insert(ctx, event); err != nil {
+		log.Printf("[Audit] Erro ao gravar evento %s em %s/%s: %v", entry.Action, entry.TargetType, entry.TargetID, err)
+	}
+}
+
+// Actor identifica quem fez a requisição a partir do que os middlewares de
+// autenticação colocaram no contexto.
+func Actor(r *http.Request) (string, string) {
+	if actor, ok := r.Context().Value(middlewares.AdminActorKey).(string); ok && actor != "" {
+		kind, id, _ := strings.Cut(actor, ":")
+		switch kind {
+		case "admin":
+			return schemas.AUDIT_ACTOR_ADMIN, id
+		case "api_key":
+			return schemas.AUDIT_ACTOR_API_KEY, id
+		default:
+			return schemas.AUDIT_ACTOR_MACHINE, id
+		}
+	}
+
+	if userId, ok := r.Context().Value(middlewares.UserIDKey).(string); ok && userId != "" {
+		return schemas.AUDIT_ACTOR_CLIENT, userId
+	}
+
+	return schemas.AUDIT_ACTOR_SYSTEM, ""
+}
+
+// Snapshot converte o documento para um mapa no momento da chamada. Use antes
+// de alterar em memória um valor que também será o "depois".
+func Snapshot(v any) map[string]any {
+	if v == nil {
+		return nil
+	}
+
+	data, err := bson.Marshal(v)
+	if err != nil {
+		return nil
+	}
+
+	doc := bson.D{}
+	if err := bson.Unmarshal(data, &doc); err != nil {
+		return nil
+	}
+	return normalize(doc).(map[string]any)
+}
+
+// Diff compara os dois documentos campo a campo, descendo nos subdocumentos.
+// Listas são comparadas inteiras. O resultado é ordenado pelo caminho.
+func Diff(before any, after any) []schemas.AuditChange {
+	if before == nil && after == nil {
+		return nil
+	}
+
+	changes := []schemas.AuditChange{}
+	diffMaps("", Snapshot(before), Snapshot(after), &changes)
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes
+}
+
+func diffMaps(prefix string, before map[string]any, after map[string]any, changes *[]schemas.AuditChange) {
+	keys := map[string]bool{}
+	for key := range before {
+		keys[key] = true
+	}
+	for key := range after {
+		keys[key] = true
+	}
+
+	for key := range keys {
+		field := key
+		if prefix != "" {
+			field = prefix + "." + key
+		}
+
+		beforeValue, afterValue := before[key], after[key]
+		beforeMap, beforeIsMap := beforeValue.(map[string]any)
+		afterMap, afterIsMap := afterValue.(map[string]any)
+		if (beforeIsMap || beforeValue == nil) && (afterIsMap || afterValue == nil) && (beforeIsMap || afterIsMap) {
+			diffMaps(field, beforeMap, afterMap, changes)
+			continue
+		}
+
+		if reflect.DeepEqual(beforeValue, afterValue) {
+			continue
+		}
+
+		if slices.Contains(redactedFields, key) {
+			beforeValue, afterValue = redact(beforeValue), redact(afterValue)
+		}
+		*changes = append(*changes, schemas.AuditChange{Field: field, Before: beforeValue, After: afterValue})
+	}
+}
+
+func redact(v any) any {
+	if v == nil {
+		return nil
+	}
+	return REDACTED
+}
+
+// normalize troca os bson.D aninhados por mapas, para comparar sem depender
+// da ordem dos campos.
+func normalize(v any) any {
+	switch value := v.(type) {
+	case bson.D:
+		m := make(map[string]any, len(value))
+		for _, element := range value {
+			m[element.Key] = normalize(element.Value)
+		}
+		return m
+	case bson.A:
+		list := make([]any, len(value))
+		for i, element := range value {
+			list[i] = normalize(element)
+		}
+		return list
+	default:
+		return value
+	}
+}
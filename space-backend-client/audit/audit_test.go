@@ -0,0 +1,78 @@
+package audit
+
+import (
+	"api/middlewares"
+	"api/schemas"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestDiffReportsNestedChangesAndRedactsSecrets(t *testing.T) {
+	before := bson.M{
+		"contact":       schemas.Contact{Name: "Time A", CPF: "11111111111", City: "Curitiba"},
+		"password_hash": "old-hash",
+	}
+	after := bson.M{
+		"contact":       schemas.Contact{Name: "Time A", CPF: "22222222222", City: "Londrina"},
+		"password_hash": "new-hash",
+	}
+
+	changes := Diff(before, after)
+	if len(changes) != 3 {
+		t.Fatalf("changes = %+v, want 3 entries", changes)
+	}
+
+	if changes[0].Field != "contact.city" || changes[0].Before != "Curitiba" || changes[0].After != "Londrina" {
+		t.Errorf("changes[0] = %+v, want contact.city Curitiba -> Londrina", changes[0])
+	}
+	if changes[1].Field != "contact.cpf" || changes[1].Before != REDACTED || changes[1].After != REDACTED {
+		t.Errorf("changes[1] = %+v, want redacted contact.cpf", changes[1])
+	}
+	if changes[2].Field != "password_hash" || changes[2].Before != REDACTED || changes[2].After != REDACTED {
+		t.Errorf("changes[2] = %+v, want redacted password_hash", changes[2])
+	}
+}
+
+func TestDiffOfCreationListsEveryField(t *testing.T) {
+	changes := Diff(nil, bson.M{"role": "support", "active": true})
+	if len(changes) != 2 || changes[0].Field != "active" || changes[1].Field != "role" {
+		t.Fatalf("changes = %+v, want active and role", changes)
+	}
+	if changes[1].Before != nil || changes[1].After != "support" {
+		t.Errorf("changes[1] = %+v, want nil -> support", changes[1])
+	}
+}
+
+func TestActorFromContext(t *testing.T) {
+	tests := []struct {
+		name      string
+		key       middlewares.ContextKey
+		value     string
+		wantType  string
+		wantActor string
+	}{
+		{"admin user", middlewares.AdminActorKey, "admin:abc", schemas.AUDIT_ACTOR_ADMIN, "abc"},
+		{"api key", middlewares.AdminActorKey, "api_key:def", schemas.AUDIT_ACTOR_API_KEY, "def"},
+		{"legacy admin key", middlewares.AdminActorKey, middlewares.ADMIN_KEY_ACTOR, schemas.AUDIT_ACTOR_MACHINE, "admin_key"},
+		{"client", middlewares.UserIDKey, "123", schemas.AUDIT_ACTOR_CLIENT, "123"},
+		{"anonymous", "", "", schemas.AUDIT_ACTOR_SYSTEM, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.key != "" {
+				r = r.WithContext(context.WithValue(r.Context(), tt.key, tt.value))
+			}
+
+			actorType, actorId := Actor(r)
+			if actorType != tt.wantType || actorId != tt.wantActor {
+				t.Errorf("Actor() = %q, %q, want %q, %q", actorType, actorId, tt.wantType, tt.wantActor)
+			}
+		})
+	}
+}
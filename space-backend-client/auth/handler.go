@@ -1,26 +1,25 @@
 package auth
 
 import (
+	"api/audit"
 	"api/database"
+	"api/middlewares"
 	"api/schemas"
 	"api/utils"
+	"api/validation"
 	"context"
 	"encoding/json"
 	"log"
 	"net/http"
-	"os"
 	"time"
 
 	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo"
-	"go.mongodb.org/mongo-driver/v2/mongo/options"
 	"golang.org/x/crypto/bcrypt"
 )
 
-const (
-	ACCESS_TOKEN_COOKIE_EXPIRATION  = 15 * time.Minute
-	REFRESH_TOKEN_COOKIE_EXPIRATION = 7 * 24 * time.Hour
-)
+// Repositories é injetado pelo main com o client MongoDB compartilhado.
+var Repositories *database.Repositories
 
 func Signin(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -40,43 +39,33 @@ func Signin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if req.Email == "" || req.Password == "" {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(schemas.ApiResponse{
-			Message: "Email e senha são obrigatórios",
-		})
+	if errs := validation.Validate(req); errs != nil {
+		validation.WriteErrors(w, errs)
 		return
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), database.MONGODB_TIMEOUT)
 	defer cancel()
 
-	mongoURI := os.Getenv(utils.ENV_MONGODB_URI)
-	opts := options.Client().ApplyURI(mongoURI)
-	client, err := mongo.Connect(opts)
+	ip := utils.ClientIP(r)
+
+	attempt, retryAfter, err := claimLoginAttempt(ctx, req.Email, ip)
 	if err != nil {
-		w.WriteHeader(http.StatusBadGateway)
+		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(schemas.ApiResponse{
-			Message: utils.SendInternalError(utils.CANNOT_CONNECT_TO_MONGODB),
+			Message: utils.SendInternalError(utils.ERROR_TO_TRY_FIND_MONGODB),
 		})
 		return
 	}
-	defer client.Disconnect(ctx)
 
-	collection := client.Database(database.GetDB()).Collection("clients")
-	filter := bson.D{{Key: "contact.email", Value: req.Email}}
-
-	result := schemas.ClientFromDB{}
+	if retryAfter > 0 {
+		writeTooManyAttempts(w, retryAfter)
+		return
+	}
 
-	err = collection.FindOne(ctx, filter).Decode(&result)
-	if err != nil {
-		if err == mongo.ErrNoDocuments {
-			w.WriteHeader(http.StatusUnauthorized)
-			json.NewEncoder(w).Encode(schemas.ApiResponse{
-				Message: "Credenciais inválidas",
-			})
-			return
-		}
+	result, err := Repositories.Clients.FindByEmail(ctx, req.Email)
+	if err != nil && err != mongo.ErrNoDocuments {
+		attempt.release(ctx)
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(schemas.ApiResponse{
 			Message: utils.SendInternalError(utils.ERROR_TO_TRY_FIND_MONGODB),
@@ -84,8 +73,9 @@ func Signin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = bcrypt.CompareHashAndPassword([]byte(result.PasswordHash), []byte(req.Password))
-	if err != nil {
+	// A tentativa já foi contada como falha em claimLoginAttempt. Emails
+	// inexistentes também contam, para não diferenciar os casos
+	if err == mongo.ErrNoDocuments || bcrypt.CompareHashAndPassword([]byte(result.PasswordHash), []byte(req.Password)) != nil {
 		w.WriteHeader(http.StatusUnauthorized)
 		json.NewEncoder(w).Encode(schemas.ApiResponse{
 			Message: "Credenciais inválidas",
@@ -93,64 +83,76 @@ func Signin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	accessToken, err := utils.GenerateAccessKey(result.ID.Hex())
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(schemas.ApiResponse{
-			Message: utils.SendInternalError(utils.ERROR_WHEN_GENERATE_ACCESS_TOKEN),
-		})
+	// Com 2FA ativo a senha sozinha não zera o contador do email, senão um novo
+	// Signin apagaria as falhas de código; ele é zerado em SigninTwoFactor
+	twoFactorEnabled := result.TwoFactor != nil && result.TwoFactor.Enabled
+	if twoFactorEnabled {
+		attempt.release(ctx)
+	} else {
+		attempt.succeed(ctx)
+	}
+
+	// O bloqueio só é revelado a quem acertou a senha
+	if result.Blocked() {
+		writeAccountBlocked(w)
 		return
 	}
 
-	refreshToken, err := utils.GenerateRefreshKey(result.ID.Hex())
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(schemas.ApiResponse{
-			Message: utils.SendInternalError(utils.ERROR_WHEN_GENERATE_REFRESH_TOKEN),
-		})
+	// Com 2FA ativo a sessão só é aberta depois do código, em SigninTwoFactor
+	if twoFactorEnabled {
+		startTwoFactorChallenge(ctx, w, result)
 		return
 	}
 
-	filter = bson.D{{Key: "_id", Value: result.ID}}
-	update := bson.D{{Key: "$set", Value: bson.D{
-		{Key: "refresh_token", Value: refreshToken},
-		{Key: "updated_at", Value: time.Now()},
-	}}}
+	startSession(ctx, w, r, result)
+}
 
-	_, err = collection.UpdateOne(ctx, filter, update)
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+// writeAccountBlocked responde ao login de uma conta bloqueada pela equipe
+// interna (ver admin.HandlerClientStatus).
+func writeAccountBlocked(w http.ResponseWriter) {
+	w.WriteHeader(http.StatusForbidden)
+	json.NewEncoder(w).Encode(schemas.ApiResponse{
+		Message: "Conta bloqueada. Entre em contato com o atendimento",
+	})
+}
+
+// Signout encerra a sessão atual no servidor: a sessão é removida e os access
+// tokens já emitidos para ela entram na denylist até expirarem.
+func Signout(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
 		json.NewEncoder(w).Encode(schemas.ApiResponse{
-			Message: utils.SendInternalError(utils.ERROR_TO_UPDATE_REFRESH_TOKEN),
+			Message: utils.SendInternalError(utils.HTTP_METHOD_NO_ALLOWED),
 		})
 		return
 	}
 
-	http.SetCookie(w, &http.Cookie{
-		Name:     "access_token",
-		Value:    accessToken,
-		Path:     "/",
-		MaxAge:   int(ACCESS_TOKEN_COOKIE_EXPIRATION),
-		HttpOnly: true,
-		Secure:   true,
-		SameSite: http.SameSiteStrictMode,
-	})
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGODB_TIMEOUT)
+	defer cancel()
 
-	http.SetCookie(w, &http.Cookie{
-		Name:     "refresh_token",
-		Value:    refreshToken,
-		Path:     "/",
-		MaxAge:   int(REFRESH_TOKEN_COOKIE_EXPIRATION),
-		HttpOnly: true,
-		Secure:   true,
-		SameSite: http.SameSiteStrictMode,
-	})
+	// Sem cookies válidos não há sessão para encerrar; só limpamos os cookies
+	caller, ok := identifyCaller(ctx, r)
+	if ok {
+		if err := revokeSession(ctx, caller.clientId, caller.sessionId, caller.access); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(schemas.ApiResponse{
+				Message: utils.SendInternalError(utils.ERROR_TO_UPDATE_REFRESH_TOKEN),
+			})
+			return
+		}
+	}
+
+	middlewares.ClearAuthCookies(w)
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(schemas.ApiResponse{
+		Message: "Logout realizado com sucesso",
+	})
 }
 
-func Signout(w http.ResponseWriter, r *http.Request) {
+// SignoutEverywhere encerra todas as sessões do cliente, em todos os dispositivos.
+func SignoutEverywhere(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		w.WriteHeader(http.StatusMethodNotAllowed)
 		json.NewEncoder(w).Encode(schemas.ApiResponse{
@@ -159,30 +161,50 @@ func Signout(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	http.SetCookie(w, &http.Cookie{
-		Name:     "access_token",
-		Value:    "",
-		Path:     "/",
-		MaxAge:   -1,
-		HttpOnly: true,
-		Secure:   true,
-		SameSite: http.SameSiteStrictMode,
-	})
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGODB_TIMEOUT)
+	defer cancel()
 
-	http.SetCookie(w, &http.Cookie{
-		Name:     "refresh_token",
-		Value:    "",
-		Path:     "/",
-		MaxAge:   -1,
-		HttpOnly: true,
-		Secure:   true,
-		SameSite: http.SameSiteStrictMode,
-	})
+	caller, ok := identifyCaller(ctx, r)
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: "Usuário não autorizado",
+		})
+		return
+	}
+
+	sessions, err := Repositories.Sessions.FindByClientID(ctx, caller.clientId)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.ERROR_TO_TRY_FIND_MONGODB),
+		})
+		return
+	}
+
+	for _, session := range sessions {
+		err = revokeSession(ctx, caller.clientId, session.ID, nil)
+		if err != nil {
+			break
+		}
+	}
+	if err == nil && caller.access != nil {
+		err = revokeSession(ctx, caller.clientId, caller.sessionId, caller.access)
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.ERROR_TO_UPDATE_REFRESH_TOKEN),
+		})
+		return
+	}
+
+	middlewares.ClearAuthCookies(w)
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(schemas.ApiResponse{
-		Message: "Logout realizado com sucesso",
+		Message: "Todas as sessões foram encerradas",
 	})
 }
 
@@ -195,8 +217,14 @@ func Authorize(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	accessToken, err := r.Cookie("access_token")
-	if err != nil || accessToken.Value == "" {
+	accessToken, ok := utils.BearerToken(r)
+	if !ok {
+		if cookie, err := r.Cookie("access_token"); err == nil {
+			accessToken = cookie.Value
+		}
+	}
+
+	if accessToken == "" {
 		w.WriteHeader(http.StatusUnauthorized)
 		json.NewEncoder(w).Encode(schemas.ApiResponse{
 			Message: utils.SendInternalError(utils.MISSING_AUTHORIZATION_HEADER),
@@ -204,7 +232,7 @@ func Authorize(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	_, errValidate := utils.ValidateAccessKey(accessToken.Value)
+	_, errValidate := utils.ValidateAccessKey(accessToken)
 	if errValidate != nil {
 		w.WriteHeader(http.StatusUnauthorized)
 		json.NewEncoder(w).Encode(schemas.ApiResponse{
@@ -226,11 +254,8 @@ func Signup(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if clientFromRequest.Name == "" || clientFromRequest.Email == "" || clientFromRequest.Password == "" {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(schemas.ApiResponse{
-			Message: "Nome, email e senha são obrigatórios",
-		})
+	if errs := validation.Validate(clientFromRequest); errs != nil {
+		validation.WriteErrors(w, errs)
 		return
 	}
 
@@ -249,61 +274,55 @@ func Signup(w http.ResponseWriter, r *http.Request) {
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
-	clientToCreate := schemas.ClientCreateModel{
-		Contact:      contactToCreate,
-		PasswordHash: string(hashedPassword),
-		CreatedAt:    time.Now(),
-		UpdatedAt:    time.Now(),
-	}
-
-	ctx, cancel := context.WithTimeout(context.Background(), database.MONGODB_TIMEOUT)
-	defer cancel()
-
-	mongoURI := os.Getenv(utils.ENV_MONGODB_URI)
-	opts := options.Client().ApplyURI(mongoURI)
-	mongoClient, err := mongo.Connect(opts)
+	// O contato só é criado no Tiny depois da confirmação do email, em VerifyEmail
+	verificationToken, err := utils.GenerateOpaqueToken()
 	if err != nil {
-		w.WriteHeader(http.StatusBadGateway)
+		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(schemas.ApiResponse{
-			Message: utils.SendInternalError(utils.CANNOT_CONNECT_TO_MONGODB),
+			Message: utils.SendInternalError(utils.ERROR_TO_GENERATE_TOKEN),
 		})
 		return
 	}
-	defer mongoClient.Disconnect(ctx)
 
-	collection := mongoClient.Database(database.GetDB()).Collection("clients")
+	clientToCreate := schemas.ClientCreateModel{
+		Contact:       contactToCreate,
+		PasswordHash:  string(hashedPassword),
+		EmailVerified: false,
+		EmailVerification: &schemas.PendingToken{
+			TokenHash: utils.HashToken(verificationToken),
+			ExpiresAt: time.Now().Add(EMAIL_VERIFICATION_TOKEN_EXPIRATION),
+		},
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGODB_TIMEOUT)
+	defer cancel()
 
-	filter := bson.D{{Key: "contact.email", Value: clientFromRequest.Email}}
-	existingClient := schemas.ClientFromDB{}
-	err = collection.FindOne(ctx, filter).Decode(&existingClient)
+	_, err = Repositories.Clients.FindByEmail(ctx, clientFromRequest.Email)
 	if err == nil {
 		w.WriteHeader(http.StatusConflict)
 		json.NewEncoder(w).Encode(schemas.ApiResponse{
 			Message: "Email já cadastrado",
 		})
 		return
-	}
-
-	err = utils.RegisterClientInTinyWithID(&contactToCreate)
-	if err != nil {
+	} else if err != mongo.ErrNoDocuments {
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(schemas.ApiResponse{
-			Message: utils.SendInternalError(utils.ERROR_TINY_API_INTEGRATION),
+			Message: utils.SendInternalError(utils.ERROR_TO_TRY_FIND_MONGODB),
 		})
 		return
 	}
 
-	if contactToCreate.TinyID == "" {
-		w.WriteHeader(http.StatusInternalServerError)
+	// A consulta acima não impede outro cadastro simultâneo; o índice único sim
+	clientId, err := Repositories.Clients.Create(ctx, clientToCreate)
+	if err == database.ErrClientEmailTaken {
+		w.WriteHeader(http.StatusConflict)
 		json.NewEncoder(w).Encode(schemas.ApiResponse{
-			Message: utils.SendInternalError(utils.ERROR_TINY_ID_NOT_GENERATED),
+			Message: "Email já cadastrado",
 		})
 		return
 	}
-
-	clientToCreate.Contact.TinyID = contactToCreate.TinyID
-
-	_, err = collection.InsertOne(ctx, clientToCreate)
 	if err != nil {
 		log.Printf("Erro ao criar cliente: %s", err.Error())
 		w.WriteHeader(http.StatusInternalServerError)
@@ -313,6 +332,17 @@ func Signup(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	audit.Record(ctx, Repositories.AuditEvents, r, audit.Entry{
+		ActorType:  schemas.AUDIT_ACTOR_CLIENT,
+		ActorID:    clientId.Hex(),
+		Action:     schemas.AUDIT_ACTION_CLIENT_SIGNUP,
+		TargetType: schemas.AUDIT_TARGET_CLIENT,
+		TargetID:   clientId.Hex(),
+		After:      bson.M{"contact": contactToCreate},
+	})
+
+	SendEmailVerification(ctx, contactToCreate, verificationToken)
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 }
@@ -1 +1,692 @@
 package auth
+
+import (
+	"api/database"
+	"api/middlewares"
+	"api/notifications"
+	"api/schemas"
+	"api/utils"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func setupRepositories(t *testing.T) *database.Repositories {
+	t.Helper()
+
+	repositories := database.NewMemoryRepositories()
+	Repositories = repositories
+	// RefreshSession, usado em /v1/auth/token/refresh, vive em middlewares
+	middlewares.Repositories = repositories
+	t.Cleanup(func() {
+		Repositories = nil
+		middlewares.Repositories = nil
+	})
+
+	return repositories
+}
+
+func insertClient(t *testing.T, repositories *database.Repositories, email, password string) schemas.ClientFromDB {
+	t.Helper()
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clientsRepository := repositories.Clients.(*database.MemoryClientsRepository)
+	clientsRepository.Insert(schemas.ClientFromDB{
+		Contact:      schemas.Contact{Name: "Cliente", Email: email},
+		PasswordHash: string(hash),
+	})
+
+	client, err := clientsRepository.FindByEmail(t.Context(), email)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func signinRequest(email, password string) *http.Request {
+	body, _ := json.Marshal(schemas.ClientLoginRequest{Email: email, Password: password})
+	return httptest.NewRequest(http.MethodPost, "/v1/auth/signin", bytes.NewReader(body))
+}
+
+func TestSigninOpensOneSessionPerDevice(t *testing.T) {
+	t.Setenv("ACCESS_TOKEN_SECRET", "access")
+	t.Setenv("REFRESH_TOKEN_SECRET", "refresh")
+
+	repositories := setupRepositories(t)
+	client := insertClient(t, repositories, "cliente@example.com", "senha-segura")
+
+	for _, userAgent := range []string{"laptop", "celular"} {
+		req := signinRequest("cliente@example.com", "senha-segura")
+		req.Header.Set("User-Agent", userAgent)
+
+		w := httptest.NewRecorder()
+		Signin(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+		}
+	}
+
+	sessions, _ := repositories.Sessions.FindByClientID(t.Context(), client.ID)
+	if len(sessions) != 2 {
+		t.Fatalf("sessions = %d, want 2", len(sessions))
+	}
+	for _, session := range sessions {
+		if session.RefreshTokenHash == "" {
+			t.Errorf("session %s has no refresh token hash", session.UserAgent)
+		}
+	}
+}
+
+func TestSigninRejectsWrongPassword(t *testing.T) {
+	repositories := setupRepositories(t)
+	insertClient(t, repositories, "cliente@example.com", "senha-segura")
+
+	w := httptest.NewRecorder()
+	Signin(w, signinRequest("cliente@example.com", "senha-errada"))
+
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestSigninRejectsBlockedAccount(t *testing.T) {
+	repositories := setupRepositories(t)
+	client := insertClient(t, repositories, "cliente@example.com", "senha-segura")
+	repositories.Clients.SetStatus(t.Context(), client.ID, schemas.CLIENT_STATUS_BLOCKED)
+
+	w := httptest.NewRecorder()
+	Signin(w, signinRequest("cliente@example.com", "senha-segura"))
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusForbidden)
+	}
+
+	// Com a senha errada a resposta não revela o bloqueio
+	w = httptest.NewRecorder()
+	Signin(w, signinRequest("cliente@example.com", "senha-errada"))
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("wrong password: status = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+
+	if sessions, _ := repositories.Sessions.FindByClientID(t.Context(), client.ID); len(sessions) != 0 {
+		t.Errorf("sessions = %d, want 0", len(sessions))
+	}
+}
+
+type capturingNotifier struct {
+	messages []notifications.Message
+}
+
+func (n *capturingNotifier) Send(ctx context.Context, message notifications.Message) error {
+	n.messages = append(n.messages, message)
+	return nil
+}
+
+func jsonRequest(target string, payload any) *http.Request {
+	body, _ := json.Marshal(payload)
+	return httptest.NewRequest(http.MethodPost, target, bytes.NewReader(body))
+}
+
+func TestPasswordResetIsSingleUse(t *testing.T) {
+	repositories := setupRepositories(t)
+	client := insertClient(t, repositories, "cliente@example.com", "senha-antiga")
+	repositories.Sessions.Create(t.Context(), schemas.Session{
+		ID:        bson.NewObjectID(),
+		ClientID:  client.ID,
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+
+	notifier := &capturingNotifier{}
+	Notifier = notifier
+	t.Cleanup(func() { Notifier = notifications.LogNotifier{} })
+
+	w := httptest.NewRecorder()
+	ForgotPassword(w, jsonRequest("/v1/auth/password/forgot", schemas.PasswordForgotRequest{Email: "cliente@example.com"}))
+	if w.Code != http.StatusOK || len(notifier.messages) != 1 {
+		t.Fatalf("forgot: status = %d, messages = %d", w.Code, len(notifier.messages))
+	}
+
+	lines := strings.Split(notifier.messages[0].Body, "\n")
+	token := lines[len(lines)-1]
+
+	reset := schemas.PasswordResetRequest{Token: token, Password: "senha-nova-123"}
+
+	w = httptest.NewRecorder()
+	ResetPassword(w, jsonRequest("/v1/auth/password/reset", reset))
+	if w.Code != http.StatusOK {
+		t.Fatalf("reset: status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	stored, _ := repositories.Clients.FindByID(t.Context(), client.ID)
+	if bcrypt.CompareHashAndPassword([]byte(stored.PasswordHash), []byte("senha-nova-123")) != nil {
+		t.Error("password hash was not rotated")
+	}
+	if sessions, _ := repositories.Sessions.FindByClientID(t.Context(), client.ID); len(sessions) != 0 {
+		t.Error("sessions were not revoked")
+	}
+
+	w = httptest.NewRecorder()
+	ResetPassword(w, jsonRequest("/v1/auth/password/reset", reset))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("second reset: status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestPasswordResetRevokesAccessTokens(t *testing.T) {
+	t.Setenv("ACCESS_TOKEN_SECRET", "access")
+	t.Setenv("REFRESH_TOKEN_SECRET", "refresh")
+
+	repositories := setupRepositories(t)
+	insertClient(t, repositories, "cliente@example.com", "senha-antiga")
+
+	utils.Denylist = repositories.RevokedTokens
+	t.Cleanup(func() { utils.Denylist = nil })
+
+	notifier := &capturingNotifier{}
+	Notifier = notifier
+	t.Cleanup(func() { Notifier = notifications.LogNotifier{} })
+
+	w := httptest.NewRecorder()
+	Signin(w, signinRequest("cliente@example.com", "senha-antiga"))
+	var accessToken string
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.Name == "access_token" {
+			accessToken = cookie.Value
+		}
+	}
+	if _, err := utils.ValidateAccessKey(accessToken); err != nil {
+		t.Fatalf("access token before reset: %v", err)
+	}
+
+	ForgotPassword(httptest.NewRecorder(), jsonRequest("/v1/auth/password/forgot", schemas.PasswordForgotRequest{Email: "cliente@example.com"}))
+	lines := strings.Split(notifier.messages[0].Body, "\n")
+
+	w = httptest.NewRecorder()
+	ResetPassword(w, jsonRequest("/v1/auth/password/reset", schemas.PasswordResetRequest{Token: lines[len(lines)-1], Password: "senha-nova-123"}))
+	if w.Code != http.StatusOK {
+		t.Fatalf("reset: status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	if _, err := utils.ValidateAccessKey(accessToken); err != utils.ErrAccessTokenRevoked {
+		t.Errorf("ValidateAccessKey error = %v, want %v", err, utils.ErrAccessTokenRevoked)
+	}
+}
+
+func TestForgotPasswordDoesNotRevealUnknownEmail(t *testing.T) {
+	setupRepositories(t)
+
+	notifier := &capturingNotifier{}
+	Notifier = notifier
+	t.Cleanup(func() { Notifier = notifications.LogNotifier{} })
+
+	w := httptest.NewRecorder()
+	ForgotPassword(w, jsonRequest("/v1/auth/password/forgot", schemas.PasswordForgotRequest{Email: "ninguem@example.com"}))
+	if w.Code != http.StatusOK || len(notifier.messages) != 0 {
+		t.Fatalf("status = %d, messages = %d", w.Code, len(notifier.messages))
+	}
+}
+
+func TestSignupRequiresEmailVerification(t *testing.T) {
+	repositories := setupRepositories(t)
+
+	notifier := &capturingNotifier{}
+	Notifier = notifier
+	t.Cleanup(func() { Notifier = notifications.LogNotifier{} })
+
+	signup := schemas.ClientCreateRequest{Name: "Cliente", Email: "novo@example.com", Password: "senha-segura"}
+
+	w := httptest.NewRecorder()
+	Signup(w, jsonRequest("/v1/auth/signup", signup))
+	if w.Code != http.StatusCreated || len(notifier.messages) != 1 {
+		t.Fatalf("signup: status = %d, messages = %d", w.Code, len(notifier.messages))
+	}
+
+	client, _ := repositories.Clients.FindByEmail(t.Context(), "novo@example.com")
+	if client.EmailVerified || client.Contact.TinyID != "" {
+		t.Fatalf("client should start unverified and without TinyID: %+v", client)
+	}
+
+	lines := strings.Split(notifier.messages[0].Body, "\n")
+	verify := schemas.EmailVerifyRequest{Token: lines[len(lines)-1]}
+
+	w = httptest.NewRecorder()
+	VerifyEmail(w, jsonRequest("/v1/auth/verify", verify))
+	if w.Code != http.StatusOK {
+		t.Fatalf("verify: status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	stored, _ := repositories.Clients.FindByID(t.Context(), client.ID)
+	if !stored.EmailVerified || stored.EmailVerification != nil {
+		t.Error("client was not marked as verified")
+	}
+
+	w = httptest.NewRecorder()
+	VerifyEmail(w, jsonRequest("/v1/auth/verify", verify))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("second verify: status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+// emailChangingClients troca o email do cliente logo depois de encontrar o
+// token, como uma mudança de email concorrente com a verificação.
+type emailChangingClients struct {
+	*database.MemoryClientsRepository
+}
+
+func (r emailChangingClients) FindByEmailVerificationToken(ctx context.Context, tokenHash string) (schemas.ClientFromDB, error) {
+	client, err := r.MemoryClientsRepository.FindByEmailVerificationToken(ctx, tokenHash)
+	if err != nil {
+		return client, err
+	}
+
+	contact := client.Contact
+	contact.Email = "novo.endereco@example.com"
+	r.PatchContact(ctx, client.ID, contact, []string{"email"})
+	r.ResetEmailVerification(ctx, client.ID, schemas.PendingToken{
+		TokenHash: utils.HashToken("token-do-novo-endereco"),
+		ExpiresAt: time.Now().Add(EMAIL_VERIFICATION_TOKEN_EXPIRATION),
+	})
+	return client, nil
+}
+
+func TestVerifyEmailRejectsTokenReplacedByEmailChange(t *testing.T) {
+	repositories := setupRepositories(t)
+	clientsRepository := repositories.Clients.(*database.MemoryClientsRepository)
+	repositories.Clients = emailChangingClients{clientsRepository}
+
+	clientsRepository.Insert(schemas.ClientFromDB{
+		Contact: schemas.Contact{Name: "Cliente", Email: "cliente@example.com"},
+		EmailVerification: &schemas.PendingToken{
+			TokenHash: utils.HashToken("token-antigo"),
+			ExpiresAt: time.Now().Add(time.Hour),
+		},
+	})
+	client, _ := clientsRepository.FindByEmail(t.Context(), "cliente@example.com")
+
+	w := httptest.NewRecorder()
+	VerifyEmail(w, jsonRequest("/v1/auth/verify", schemas.EmailVerifyRequest{Token: "token-antigo"}))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+
+	stored, _ := clientsRepository.FindByID(t.Context(), client.ID)
+	if stored.EmailVerified {
+		t.Errorf("new email %s was marked as verified with the old token", stored.Contact.Email)
+	}
+}
+
+func TestSignupReturnsFieldErrors(t *testing.T) {
+	repositories := setupRepositories(t)
+
+	signup := schemas.ClientCreateRequest{Name: "Cliente", Email: "sem-arroba", Password: "curta"}
+
+	w := httptest.NewRecorder()
+	Signup(w, jsonRequest("/v1/auth/signup", signup))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+
+	var response schemas.ApiResponse
+	json.NewDecoder(w.Body).Decode(&response)
+	if len(response.Errors) != 2 || response.Errors[0].Field != "email" || response.Errors[1].Field != "password" {
+		t.Errorf("errors = %+v, want email and password", response.Errors)
+	}
+
+	if _, err := repositories.Clients.FindByEmail(t.Context(), "sem-arroba"); err == nil {
+		t.Error("invalid client was created")
+	}
+}
+
+func TestSignoutRevokesSessionAndAccessToken(t *testing.T) {
+	t.Setenv("ACCESS_TOKEN_SECRET", "access")
+	t.Setenv("REFRESH_TOKEN_SECRET", "refresh")
+
+	repositories := setupRepositories(t)
+	client := insertClient(t, repositories, "cliente@example.com", "senha-segura")
+
+	utils.Denylist = repositories.RevokedTokens
+	t.Cleanup(func() { utils.Denylist = nil })
+
+	w := httptest.NewRecorder()
+	Signin(w, signinRequest("cliente@example.com", "senha-segura"))
+	cookies := w.Result().Cookies()
+
+	var accessToken string
+	req := httptest.NewRequest(http.MethodPost, "/v1/auth/signout", nil)
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+		if cookie.Name == "access_token" {
+			accessToken = cookie.Value
+		}
+	}
+
+	w = httptest.NewRecorder()
+	Signout(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	if sessions, _ := repositories.Sessions.FindByClientID(t.Context(), client.ID); len(sessions) != 0 {
+		t.Error("session was not removed")
+	}
+	if _, err := utils.ValidateAccessKey(accessToken); err != utils.ErrAccessTokenRevoked {
+		t.Errorf("ValidateAccessKey error = %v, want %v", err, utils.ErrAccessTokenRevoked)
+	}
+}
+
+func TestSignoutIgnoresRotatedRefreshToken(t *testing.T) {
+	t.Setenv("ACCESS_TOKEN_SECRET", "access")
+	t.Setenv("REFRESH_TOKEN_SECRET", "refresh")
+
+	repositories := setupRepositories(t)
+	client := insertClient(t, repositories, "cliente@example.com", "senha-segura")
+
+	w := httptest.NewRecorder()
+	Signin(w, signinRequest("cliente@example.com", "senha-segura"))
+
+	var refreshToken string
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.Name == "refresh_token" {
+			refreshToken = cookie.Value
+		}
+	}
+
+	// O token do cookie foi rotacionado duas vezes: nem o atual nem o anterior
+	sessions, _ := repositories.Sessions.FindByClientID(t.Context(), client.ID)
+	expiresAt := time.Now().Add(utils.REFRESH_TOKEN_EXPIRATION)
+	repositories.Sessions.Rotate(t.Context(), sessions[0].ID, utils.HashToken(refreshToken), "segundo", expiresAt)
+	repositories.Sessions.Rotate(t.Context(), sessions[0].ID, "segundo", "terceiro", expiresAt)
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/auth/signout", nil)
+	req.AddCookie(&http.Cookie{Name: "refresh_token", Value: refreshToken})
+
+	w = httptest.NewRecorder()
+	Signout(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	if sessions, _ := repositories.Sessions.FindByClientID(t.Context(), client.ID); len(sessions) != 1 {
+		t.Error("a rotated refresh token must not end the session")
+	}
+}
+
+func TestSignoutWithLegacyTokenKeepsOtherLegacyTokens(t *testing.T) {
+	t.Setenv("ACCESS_TOKEN_SECRET", "access")
+
+	repositories := setupRepositories(t)
+	client := insertClient(t, repositories, "cliente@example.com", "senha-segura")
+	other := insertClient(t, repositories, "outro@example.com", "senha-segura")
+
+	utils.Denylist = repositories.RevokedTokens
+	t.Cleanup(func() { utils.Denylist = nil })
+
+	// Tokens HS512 emitidos antes do jti e das sessões
+	legacyToken := func(clientId bson.ObjectID) string {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS512, utils.Claims{
+			UserId:        clientId.Hex(),
+			EmailVerified: true,
+			RegisteredClaims: jwt.RegisteredClaims{
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(utils.ACCESS_TOKEN_EXPIRATION)),
+			},
+		}).SignedString([]byte("access"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	otherToken := legacyToken(other.ID)
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/auth/signout", nil)
+	req.Header.Set("Authorization", "Bearer "+legacyToken(client.ID))
+
+	w := httptest.NewRecorder()
+	Signout(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	if _, err := utils.ValidateAccessKey(otherToken); err != nil {
+		t.Errorf("ValidateAccessKey of another client = %v, want nil", err)
+	}
+}
+
+func TestSigninThrottlesRepeatedFailures(t *testing.T) {
+	repositories := setupRepositories(t)
+	insertClient(t, repositories, "cliente@example.com", "senha-segura")
+
+	for range LOGIN_FREE_FAILURES {
+		w := httptest.NewRecorder()
+		Signin(w, signinRequest("cliente@example.com", "senha-errada"))
+		if w.Code != http.StatusUnauthorized {
+			t.Fatalf("status = %d, want %d", w.Code, http.StatusUnauthorized)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	Signin(w, signinRequest("cliente@example.com", "senha-segura"))
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusTooManyRequests)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("Retry-After header is missing")
+	}
+}
+
+func TestSigninThrottlesConcurrentFailures(t *testing.T) {
+	repositories := setupRepositories(t)
+	insertClient(t, repositories, "cliente@example.com", "senha-segura")
+
+	const requests = 20
+	codes := make(chan int, requests)
+	var wg sync.WaitGroup
+	for range requests {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w := httptest.NewRecorder()
+			Signin(w, signinRequest("cliente@example.com", "senha-errada"))
+			codes <- w.Code
+		}()
+	}
+	wg.Wait()
+	close(codes)
+
+	verified := 0
+	for code := range codes {
+		if code == http.StatusUnauthorized {
+			verified++
+		}
+	}
+	if verified > LOGIN_FREE_FAILURES {
+		t.Errorf("%d concurrent attempts were verified, want at most %d", verified, LOGIN_FREE_FAILURES)
+	}
+
+	// As tentativas recusadas pela espera não contam como falha
+	attempts, _ := repositories.LoginAttempts.Get(t.Context(), database.LoginAttemptsEmailKey("cliente@example.com"))
+	if attempts.Failures != verified {
+		t.Errorf("failures = %d, want %d", attempts.Failures, verified)
+	}
+}
+
+func TestSigninLocksAccountAfterMaxFailures(t *testing.T) {
+	repositories := setupRepositories(t)
+	insertClient(t, repositories, "cliente@example.com", "senha-segura")
+
+	repositories.LoginAttempts.(*database.MemoryLoginAttemptsRepository).Set(schemas.LoginAttempts{
+		Key:           database.LoginAttemptsEmailKey("cliente@example.com"),
+		Failures:      LOGIN_MAX_FAILURES_PER_EMAIL,
+		LastFailureAt: time.Now().Add(-LOGIN_MAX_DELAY),
+		ExpiresAt:     time.Now().Add(LOGIN_ATTEMPTS_WINDOW),
+	})
+
+	w := httptest.NewRecorder()
+	Signin(w, signinRequest("cliente@example.com", "senha-segura"))
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusTooManyRequests)
+	}
+
+	retryAfter, _ := strconv.Atoi(w.Header().Get("Retry-After"))
+	if retryAfter <= int(LOGIN_MAX_DELAY.Seconds()) {
+		t.Errorf("Retry-After = %d, want the lockout duration", retryAfter)
+	}
+}
+
+func TestSigninFailureAfterExpiredWindowStartsNewCount(t *testing.T) {
+	repositories := setupRepositories(t)
+	insertClient(t, repositories, "cliente@example.com", "senha-segura")
+
+	// Contador expirado que o TTL ainda não removeu
+	key := database.LoginAttemptsEmailKey("cliente@example.com")
+	repositories.LoginAttempts.(*database.MemoryLoginAttemptsRepository).Set(schemas.LoginAttempts{
+		Key:           key,
+		Failures:      LOGIN_MAX_FAILURES_PER_EMAIL - 1,
+		LastFailureAt: time.Now().Add(-2 * LOGIN_ATTEMPTS_WINDOW),
+		ExpiresAt:     time.Now().Add(-LOGIN_ATTEMPTS_WINDOW),
+	})
+
+	w := httptest.NewRecorder()
+	Signin(w, signinRequest("cliente@example.com", "senha-errada"))
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+
+	attempts, _ := repositories.LoginAttempts.Get(t.Context(), key)
+	if attempts.Failures != 1 {
+		t.Errorf("failures = %d, want 1", attempts.Failures)
+	}
+}
+
+func TestSigninWithTwoFactorRequiresCode(t *testing.T) {
+	t.Setenv("ACCESS_TOKEN_SECRET", "access")
+	t.Setenv("REFRESH_TOKEN_SECRET", "refresh")
+
+	repositories := setupRepositories(t)
+	client := insertClient(t, repositories, "cliente@example.com", "senha-segura")
+
+	secret, _ := utils.GenerateTOTPSecret()
+	repositories.Clients.SetTwoFactor(t.Context(), client.ID, schemas.TwoFactor{
+		Secret:             secret,
+		Enabled:            true,
+		RecoveryCodeHashes: []string{utils.HashToken(utils.NormalizeRecoveryCode("abcde-fghij"))},
+	})
+
+	signinChallenge := func() string {
+		w := httptest.NewRecorder()
+		Signin(w, signinRequest("cliente@example.com", "senha-segura"))
+		if w.Code != http.StatusOK || len(w.Result().Cookies()) != 0 {
+			t.Fatalf("signin: status = %d, cookies = %d", w.Code, len(w.Result().Cookies()))
+		}
+
+		var response struct {
+			Data schemas.TwoFactorChallengeResponse `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&response)
+		return response.Data.ChallengeToken
+	}
+
+	code, _ := utils.TOTPCode(secret, utils.TOTPStep(time.Now()))
+	challenge := signinChallenge()
+
+	w := httptest.NewRecorder()
+	SigninTwoFactor(w, jsonRequest("/v1/auth/signin/2fa", schemas.SigninTwoFactorRequest{ChallengeToken: challenge, Code: code}))
+	if w.Code != http.StatusOK {
+		t.Fatalf("2fa: status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	// O mesmo desafio e o mesmo código não podem ser usados de novo
+	w = httptest.NewRecorder()
+	SigninTwoFactor(w, jsonRequest("/v1/auth/signin/2fa", schemas.SigninTwoFactorRequest{ChallengeToken: challenge, Code: code}))
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("reused challenge: status = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+
+	w = httptest.NewRecorder()
+	SigninTwoFactor(w, jsonRequest("/v1/auth/signin/2fa", schemas.SigninTwoFactorRequest{ChallengeToken: signinChallenge(), Code: code}))
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("reused code: status = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+
+	w = httptest.NewRecorder()
+	SigninTwoFactor(w, jsonRequest("/v1/auth/signin/2fa", schemas.SigninTwoFactorRequest{ChallengeToken: signinChallenge(), RecoveryCode: "ABCDEFGHIJ"}))
+	if w.Code != http.StatusOK {
+		t.Fatalf("recovery code: status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	stored, _ := repositories.Clients.FindByID(t.Context(), client.ID)
+	if len(stored.TwoFactor.RecoveryCodeHashes) != 0 {
+		t.Error("recovery code was not consumed")
+	}
+}
+
+func TestSigninWithTwoFactorLocksAfterAlternatingBadCodes(t *testing.T) {
+	repositories := setupRepositories(t)
+	client := insertClient(t, repositories, "cliente@example.com", "senha-segura")
+
+	secret, _ := utils.GenerateTOTPSecret()
+	repositories.Clients.SetTwoFactor(t.Context(), client.ID, schemas.TwoFactor{Secret: secret, Enabled: true})
+
+	attemptsRepository := repositories.LoginAttempts.(*database.MemoryLoginAttemptsRepository)
+	key := database.LoginAttemptsEmailKey("cliente@example.com")
+
+	// Cada rodada vem de um IP diferente e espera o fim da espera do email,
+	// então só o contador do email pode levar ao bloqueio
+	signin := func(round int) *httptest.ResponseRecorder {
+		attempts, _ := attemptsRepository.Get(t.Context(), key)
+		if attempts.Failures > 0 {
+			attempts.LastFailureAt = attempts.LastFailureAt.Add(-LOGIN_MAX_DELAY)
+			attemptsRepository.Set(attempts)
+		}
+
+		w := httptest.NewRecorder()
+		r := signinRequest("cliente@example.com", "senha-segura")
+		r.RemoteAddr = "203.0.113." + strconv.Itoa(round) + ":1234"
+		Signin(w, r)
+		return w
+	}
+
+	for round := range LOGIN_MAX_FAILURES_PER_EMAIL {
+		w := signin(round)
+		if w.Code != http.StatusOK {
+			t.Fatalf("round %d: signin status = %d, want %d", round, w.Code, http.StatusOK)
+		}
+
+		var response struct {
+			Data schemas.TwoFactorChallengeResponse `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&response)
+
+		w = httptest.NewRecorder()
+		r := jsonRequest("/v1/auth/signin/2fa", schemas.SigninTwoFactorRequest{ChallengeToken: response.Data.ChallengeToken, Code: "000000"})
+		r.RemoteAddr = "203.0.113." + strconv.Itoa(round) + ":1234"
+		SigninTwoFactor(w, r)
+		if w.Code != http.StatusUnauthorized {
+			t.Fatalf("round %d: 2fa status = %d, want %d", round, w.Code, http.StatusUnauthorized)
+		}
+	}
+
+	w := signin(LOGIN_MAX_FAILURES_PER_EMAIL)
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusTooManyRequests)
+	}
+
+	retryAfter, _ := strconv.Atoi(w.Header().Get("Retry-After"))
+	if retryAfter <= int(LOGIN_MAX_DELAY.Seconds()) {
+		t.Errorf("Retry-After = %d, want the lockout duration", retryAfter)
+	}
+}
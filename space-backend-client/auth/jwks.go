@@ -0,0 +1,24 @@
+package auth
+
+import (
+	"api/schemas"
+	"api/utils"
+	"encoding/json"
+	"net/http"
+)
+
+// JWKS publica as chaves públicas usadas para assinar os tokens (RFC 7517),
+// para que outros serviços validem os JWTs sem conhecer segredos.
+func JWKS(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.HTTP_METHOD_NO_ALLOWED),
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	json.NewEncoder(w).Encode(utils.CurrentJWKS())
+}
@@ -0,0 +1,192 @@
+package auth
+
+import (
+	"api/audit"
+	"api/database"
+	"api/middlewares"
+	"api/notifications"
+	"api/schemas"
+	"api/utils"
+	"api/validation"
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const PASSWORD_RESET_TOKEN_EXPIRATION = 30 * time.Minute
+
+// Notifier entrega os tokens de reset/verificação ao cliente. É injetado pelo main.
+var Notifier notifications.Notifier = notifications.LogNotifier{}
+
+// ForgotPassword gera um token de reset e o envia ao cliente. A resposta é
+// sempre a mesma para não revelar quais emails estão cadastrados.
+func ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.HTTP_METHOD_NO_ALLOWED),
+		})
+		return
+	}
+
+	req := schemas.PasswordForgotRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: "Dados inválidos",
+		})
+		return
+	}
+
+	if errs := validation.Validate(req); errs != nil {
+		validation.WriteErrors(w, errs)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGODB_TIMEOUT)
+	defer cancel()
+
+	client, err := Repositories.Clients.FindByEmail(ctx, req.Email)
+	if err != nil && err != mongo.ErrNoDocuments {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.ERROR_TO_TRY_FIND_MONGODB),
+		})
+		return
+	}
+
+	if err == nil {
+		token, err := utils.GenerateOpaqueToken()
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(schemas.ApiResponse{
+				Message: utils.SendInternalError(utils.ERROR_TO_GENERATE_TOKEN),
+			})
+			return
+		}
+
+		err = Repositories.Clients.SetPasswordReset(ctx, client.ID, schemas.PendingToken{
+			TokenHash: utils.HashToken(token),
+			ExpiresAt: time.Now().Add(PASSWORD_RESET_TOKEN_EXPIRATION),
+		})
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(schemas.ApiResponse{
+				Message: utils.SendInternalError(utils.ERROR_TO_UPDATE_CLIENT_TO_MONGODB),
+			})
+			return
+		}
+
+		err = Notifier.Send(ctx, notifications.Message{
+			To:      client.Contact,
+			Subject: "Redefinição de senha",
+			Body:    passwordResetBody(token),
+		})
+		if err != nil {
+			log.Printf("[ForgotPassword] Erro ao enviar token de reset: %v", err)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(schemas.ApiResponse{
+		Message: "Se o email estiver cadastrado, você receberá as instruções para redefinir a senha",
+	})
+}
+
+// ResetPassword troca a senha usando um token de reset válido. O token só
+// pode ser usado uma vez e todas as sessões do cliente são encerradas.
+func ResetPassword(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.HTTP_METHOD_NO_ALLOWED),
+		})
+		return
+	}
+
+	req := schemas.PasswordResetRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: "Dados inválidos",
+		})
+		return
+	}
+
+	if errs := validation.Validate(req); errs != nil {
+		validation.WriteErrors(w, errs)
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.ERROR_TO_CREATE_PASSWORD_HASH),
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGODB_TIMEOUT)
+	defer cancel()
+
+	clientId, err := Repositories.Clients.ConsumePasswordReset(ctx, utils.HashToken(req.Token), string(hashedPassword))
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(schemas.ApiResponse{
+				Message: "Token inválido ou expirado",
+			})
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.ERROR_TO_UPDATE_CLIENT_TO_MONGODB),
+		})
+		return
+	}
+
+	// Encerra as sessões e invalida os access tokens já emitidos: quem tinha
+	// acesso à conta perde no reset
+	err = middlewares.RevokeClientSessions(ctx, Repositories, clientId)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.ERROR_TO_UPDATE_REFRESH_TOKEN),
+		})
+		return
+	}
+
+	// A rota não é autenticada: quem age é o dono do token de reset
+	audit.Record(ctx, Repositories.AuditEvents, r, audit.Entry{
+		ActorType:  schemas.AUDIT_ACTOR_CLIENT,
+		ActorID:    clientId.Hex(),
+		Action:     schemas.AUDIT_ACTION_PASSWORD_RESET,
+		TargetType: schemas.AUDIT_TARGET_CLIENT,
+		TargetID:   clientId.Hex(),
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(schemas.ApiResponse{
+		Message: "Senha redefinida com sucesso",
+	})
+}
+
+func passwordResetBody(token string) string {
+	resetURL := os.Getenv(utils.PASSWORD_RESET_URL)
+	if resetURL == "" {
+		return "Use o código abaixo para redefinir sua senha. Ele expira em 30 minutos.\n\n" + token
+	}
+
+	return "Acesse o link abaixo para redefinir sua senha. Ele expira em 30 minutos.\n\n" +
+		resetURL + "?token=" + url.QueryEscape(token)
+}
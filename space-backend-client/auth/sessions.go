@@ -0,0 +1,287 @@
+package auth
+
+import (
+	"api/database"
+	"api/middlewares"
+	"api/schemas"
+	"api/utils"
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+func listSessions(w http.ResponseWriter, r *http.Request) {
+	clientId, err := utils.ParseObjectIDFromHex(r.Context().Value(middlewares.UserIDKey).(string))
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.INVALID_USER_ID_FORMAT),
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGODB_TIMEOUT)
+	defer cancel()
+
+	sessions, err := Repositories.Sessions.FindByClientID(ctx, clientId)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.ERROR_TO_TRY_FIND_MONGODB),
+		})
+		return
+	}
+
+	currentSessionId, _ := r.Context().Value(middlewares.SessionIDKey).(string)
+
+	response := make([]schemas.SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		response = append(response, schemas.SessionResponse{
+			ID:         session.ID.Hex(),
+			UserAgent:  session.UserAgent,
+			IP:         session.IP,
+			Current:    session.ID.Hex() == currentSessionId,
+			CreatedAt:  session.CreatedAt,
+			LastUsedAt: session.LastUsedAt,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(schemas.ApiResponse{
+		Data: response,
+	})
+}
+
+// deleteSession encerra uma sessão do próprio cliente (?id=). Se for a sessão
+// atual, os cookies também são removidos.
+func deleteSession(w http.ResponseWriter, r *http.Request) {
+	clientId, err := utils.ParseObjectIDFromHex(r.Context().Value(middlewares.UserIDKey).(string))
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.INVALID_USER_ID_FORMAT),
+		})
+		return
+	}
+
+	sessionIdStr := r.URL.Query().Get("id")
+	sessionId, err := utils.ParseObjectIDFromHex(sessionIdStr)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: "ID da sessão inválido",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGODB_TIMEOUT)
+	defer cancel()
+
+	err = Repositories.Sessions.Delete(ctx, sessionId, clientId)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(schemas.ApiResponse{
+				Message: "Sessão não encontrada",
+			})
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.ERROR_TO_UPDATE_REFRESH_TOKEN),
+		})
+		return
+	}
+
+	err = denySession(ctx, sessionId)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.ERROR_TO_UPDATE_REFRESH_TOKEN),
+		})
+		return
+	}
+
+	if currentSessionId, _ := r.Context().Value(middlewares.SessionIDKey).(string); currentSessionId == sessionIdStr {
+		middlewares.ClearAuthCookies(w)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(schemas.ApiResponse{
+		Message: "Sessão encerrada",
+	})
+}
+
+func HandlerSessions(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		listSessions(w, r)
+	case http.MethodDelete:
+		deleteSession(w, r)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.HTTP_METHOD_NO_ALLOWED),
+		})
+	}
+}
+
+type caller struct {
+	clientId  bson.ObjectID
+	sessionId bson.ObjectID
+	// access só é preenchido quando o access token ainda é válido
+	access *utils.Claims
+}
+
+// identifyCaller identifica o cliente e a sessão pelo Bearer ou pelos cookies,
+// sem renovar tokens. O refresh token do cookie é usado quando o access token
+// já expirou, e só vale se ainda for o da sessão: um token já rotacionado
+// (fora da janela de tolerância) não encerra a sessão de quem o rotacionou.
+func identifyCaller(ctx context.Context, r *http.Request) (caller, bool) {
+	var claims *utils.Claims
+	var access *utils.Claims
+
+	accessToken, ok := utils.BearerToken(r)
+	if !ok {
+		if cookie, err := r.Cookie("access_token"); err == nil {
+			accessToken = cookie.Value
+		}
+	}
+
+	if accessToken != "" {
+		if accessClaims, err := utils.ValidateAccessKey(accessToken); err == nil {
+			claims = accessClaims
+			access = accessClaims
+		}
+	}
+
+	if claims == nil {
+		cookie, err := r.Cookie("refresh_token")
+		if err != nil {
+			return caller{}, false
+		}
+		claims, err = utils.ValidateRefreshKey(cookie.Value)
+		if err != nil {
+			return caller{}, false
+		}
+
+		// Refresh tokens anteriores às sessões não têm sid e exigem novo login
+		sessionId, err := utils.ParseObjectIDFromHex(claims.SessionId)
+		if err != nil {
+			return caller{}, false
+		}
+		session, err := Repositories.Sessions.FindByID(ctx, sessionId)
+		if err != nil || session.ClientID.Hex() != claims.UserId {
+			return caller{}, false
+		}
+		tokenHash := utils.HashToken(cookie.Value)
+		if tokenHash != session.RefreshTokenHash && !middlewares.RefreshTokenInGracePeriod(session, tokenHash) {
+			return caller{}, false
+		}
+	}
+
+	clientId, err := utils.ParseObjectIDFromHex(claims.UserId)
+	if err != nil {
+		return caller{}, false
+	}
+
+	// Tokens anteriores às sessões não têm sid
+	sessionId, _ := utils.ParseObjectIDFromHex(claims.SessionId)
+
+	return caller{clientId: clientId, sessionId: sessionId, access: access}, true
+}
+
+// revokeSession remove a sessão e coloca na denylist os access tokens dela,
+// além do access token informado (pelo jti). Tokens antigos, sem jti nem sid,
+// só deixam de valer quando expiram.
+func revokeSession(ctx context.Context, clientId bson.ObjectID, sessionId bson.ObjectID, access *utils.Claims) error {
+	if !sessionId.IsZero() {
+		err := Repositories.Sessions.Delete(ctx, sessionId, clientId)
+		if err != nil && err != mongo.ErrNoDocuments {
+			return err
+		}
+
+		if err := denySession(ctx, sessionId); err != nil {
+			return err
+		}
+	}
+
+	if access != nil && access.ID != "" {
+		return Repositories.RevokedTokens.Revoke(ctx, utils.DenylistKeyForToken(access.ID), access.ExpiresAt.Time)
+	}
+
+	return nil
+}
+
+// denySession revoga os access tokens já emitidos para a sessão; depois de
+// ACCESS_TOKEN_EXPIRATION todos teriam expirado de qualquer forma.
+func denySession(ctx context.Context, sessionId bson.ObjectID) error {
+	return Repositories.RevokedTokens.Revoke(ctx, utils.DenylistKeyForSession(sessionId.Hex()), time.Now().Add(utils.ACCESS_TOKEN_EXPIRATION))
+}
+
+// startSession abre uma nova sessão para o cliente e grava os cookies (ou
+// devolve os tokens no corpo, ver wantsTokenResponse). Cada login tem a própria
+// sessão, sem derrubar os outros dispositivos.
+func startSession(ctx context.Context, w http.ResponseWriter, r *http.Request, client schemas.ClientFromDB) {
+	sessionId := bson.NewObjectID()
+
+	accessToken, err := utils.GenerateAccessKey(client.ID.Hex(), sessionId.Hex(), client.EmailVerified)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.ERROR_WHEN_GENERATE_ACCESS_TOKEN),
+		})
+		return
+	}
+
+	refreshToken, err := utils.GenerateRefreshKey(client.ID.Hex(), sessionId.Hex())
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.ERROR_WHEN_GENERATE_REFRESH_TOKEN),
+		})
+		return
+	}
+
+	now := time.Now()
+	err = Repositories.Sessions.Create(ctx, schemas.Session{
+		ID:               sessionId,
+		ClientID:         client.ID,
+		UserAgent:        r.UserAgent(),
+		IP:               utils.ClientIP(r),
+		RefreshTokenHash: utils.HashToken(refreshToken),
+		CreatedAt:        now,
+		LastUsedAt:       now,
+		ExpiresAt:        now.Add(utils.REFRESH_TOKEN_EXPIRATION),
+	})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.ERROR_TO_UPDATE_REFRESH_TOKEN),
+		})
+		return
+	}
+
+	if wantsTokenResponse(r) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Data: schemas.AuthTokenResponse{
+				AccessToken:  accessToken,
+				RefreshToken: refreshToken,
+			},
+		})
+		return
+	}
+
+	middlewares.SetAuthCookies(w, accessToken, refreshToken)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+}
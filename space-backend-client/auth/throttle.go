@@ -0,0 +1,124 @@
+package auth
+
+import (
+	"api/database"
+	"api/schemas"
+	"context"
+	"encoding/json"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// Janela sem falhas após a qual os contadores são descartados
+	LOGIN_ATTEMPTS_WINDOW = 1 * time.Hour
+	// Falhas toleradas antes de começar a exigir espera entre tentativas
+	LOGIN_FREE_FAILURES = 3
+	LOGIN_MAX_DELAY     = 1 * time.Minute
+	// 2^6 segundos já passam de LOGIN_MAX_DELAY
+	LOGIN_MAX_DELAY_EXPONENT = 6
+
+	LOGIN_MAX_FAILURES_PER_EMAIL = 10
+	LOGIN_MAX_FAILURES_PER_IP    = 50
+	LOGIN_LOCKOUT_DURATION       = 15 * time.Minute
+)
+
+// loginRetryAfter calcula quanto tempo falta para uma nova tentativa ser
+// aceita. A espera dobra a cada falha além de LOGIN_FREE_FAILURES e, ao
+// atingir maxFailures, vira um bloqueio de LOGIN_LOCKOUT_DURATION.
+func loginRetryAfter(attempts schemas.LoginAttempts, maxFailures int) time.Duration {
+	var wait time.Duration
+	switch {
+	case attempts.Failures >= maxFailures:
+		wait = LOGIN_LOCKOUT_DURATION
+	case attempts.Failures >= LOGIN_FREE_FAILURES:
+		// O expoente é limitado antes do shift: com o limite por IP, ele
+		// estouraria o Duration e a espera ficaria negativa
+		exponent := min(attempts.Failures-LOGIN_FREE_FAILURES, LOGIN_MAX_DELAY_EXPONENT)
+		wait = min(time.Second<<exponent, LOGIN_MAX_DELAY)
+	default:
+		return 0
+	}
+
+	return max(time.Until(attempts.LastFailureAt.Add(wait)), 0)
+}
+
+// loginAttempt é uma tentativa de login já contada como falha nos contadores
+// do email e do IP, antes de a senha ou o código serem conferidos.
+type loginAttempt struct {
+	email         string
+	ip            string
+	at            time.Time
+	previousEmail schemas.LoginAttempts
+	previousIP    schemas.LoginAttempts
+}
+
+// claimLoginAttempt conta a tentativa nos contadores do email e do IP e
+// retorna a maior espera que já estava pendente antes dela. Como o incremento
+// é atômico, requisições paralelas veem contagens diferentes e não passam
+// todas pela mesma leitura. Quando há espera, a tentativa é desfeita: ela não
+// chegou a ser verificada e não conta como falha.
+func claimLoginAttempt(ctx context.Context, email string, ip string) (loginAttempt, time.Duration, error) {
+	attempt := loginAttempt{email: email, ip: ip, at: time.Now()}
+
+	previousEmail, err := Repositories.LoginAttempts.Claim(ctx, database.LoginAttemptsEmailKey(email), attempt.at, LOGIN_ATTEMPTS_WINDOW)
+	if err != nil {
+		return attempt, 0, err
+	}
+	attempt.previousEmail = previousEmail
+
+	previousIP, err := Repositories.LoginAttempts.Claim(ctx, database.LoginAttemptsIPKey(ip), attempt.at, LOGIN_ATTEMPTS_WINDOW)
+	if err != nil {
+		attempt.releaseEmail(ctx)
+		return attempt, 0, err
+	}
+	attempt.previousIP = previousIP
+
+	retryAfter := max(
+		loginRetryAfter(previousEmail, LOGIN_MAX_FAILURES_PER_EMAIL),
+		loginRetryAfter(previousIP, LOGIN_MAX_FAILURES_PER_IP),
+	)
+	if retryAfter > 0 {
+		attempt.release(ctx)
+	}
+	return attempt, retryAfter, nil
+}
+
+// release desfaz a tentativa nos dois contadores, quando ela não terminou em
+// falha (ex.: erro interno ou senha certa antes do código do 2FA).
+func (a loginAttempt) release(ctx context.Context) {
+	a.releaseEmail(ctx)
+	a.releaseIP(ctx)
+}
+
+// succeed zera o contador do email e desfaz a tentativa no do IP, que é
+// compartilhado com outros clientes e só diminui com o tempo.
+func (a loginAttempt) succeed(ctx context.Context) {
+	if err := Repositories.LoginAttempts.Reset(ctx, database.LoginAttemptsEmailKey(a.email)); err != nil {
+		log.Printf("[LoginAttempts] Erro ao zerar tentativas de login: %v", err)
+	}
+	a.releaseIP(ctx)
+}
+
+func (a loginAttempt) releaseEmail(ctx context.Context) {
+	if err := Repositories.LoginAttempts.Release(ctx, database.LoginAttemptsEmailKey(a.email), a.at, a.previousEmail); err != nil {
+		log.Printf("[LoginAttempts] Erro ao desfazer tentativa de login: %v", err)
+	}
+}
+
+func (a loginAttempt) releaseIP(ctx context.Context) {
+	if err := Repositories.LoginAttempts.Release(ctx, database.LoginAttemptsIPKey(a.ip), a.at, a.previousIP); err != nil {
+		log.Printf("[LoginAttempts] Erro ao desfazer tentativa de login: %v", err)
+	}
+}
+
+func writeTooManyAttempts(w http.ResponseWriter, retryAfter time.Duration) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	w.WriteHeader(http.StatusTooManyRequests)
+	json.NewEncoder(w).Encode(schemas.ApiResponse{
+		Message: "Muitas tentativas de login. Tente novamente mais tarde",
+	})
+}
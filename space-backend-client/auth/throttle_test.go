@@ -0,0 +1,37 @@
+package auth
+
+import (
+	"api/schemas"
+	"testing"
+	"time"
+)
+
+func TestLoginRetryAfterGrowsUntilLockout(t *testing.T) {
+	now := time.Now()
+	previous := time.Duration(0)
+
+	for failures := 1; failures <= LOGIN_MAX_FAILURES_PER_IP; failures++ {
+		wait := loginRetryAfter(schemas.LoginAttempts{Failures: failures, LastFailureAt: now}, LOGIN_MAX_FAILURES_PER_IP)
+
+		switch {
+		case failures < LOGIN_FREE_FAILURES:
+			if wait != 0 {
+				t.Errorf("failures = %d: wait = %v, want 0", failures, wait)
+			}
+		case failures < LOGIN_MAX_FAILURES_PER_IP:
+			if wait <= 0 || wait > LOGIN_MAX_DELAY {
+				t.Errorf("failures = %d: wait = %v, want between 0 and %v", failures, wait, LOGIN_MAX_DELAY)
+			}
+		default:
+			if wait <= LOGIN_MAX_DELAY || wait > LOGIN_LOCKOUT_DURATION {
+				t.Errorf("failures = %d: wait = %v, want the %v lockout", failures, wait, LOGIN_LOCKOUT_DURATION)
+			}
+		}
+
+		// Tolera o tempo gasto entre as iterações
+		if wait < previous-time.Second {
+			t.Errorf("failures = %d: wait = %v, shorter than the previous %v", failures, wait, previous)
+		}
+		previous = wait
+	}
+}
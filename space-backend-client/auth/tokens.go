@@ -0,0 +1,71 @@
+package auth
+
+import (
+	"api/database"
+	"api/middlewares"
+	"api/schemas"
+	"api/utils"
+	"api/validation"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// Clientes sem cookies (app mobile, integrações) enviam "X-Auth-Mode: token"
+// no signin para receber os tokens no corpo da resposta.
+const (
+	AUTH_MODE_HEADER = "X-Auth-Mode"
+	AUTH_MODE_TOKEN  = "token"
+)
+
+func wantsTokenResponse(r *http.Request) bool {
+	return strings.EqualFold(r.Header.Get(AUTH_MODE_HEADER), AUTH_MODE_TOKEN)
+}
+
+// RefreshToken troca o refresh token enviado no corpo por um novo par de
+// tokens, com a mesma rotação e detecção de reuso do fluxo de cookies. Se
+// refresh_token vier vazio na resposta, outra requisição já rotacionou a
+// sessão e o cliente deve manter o token que ela recebeu.
+func RefreshToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.HTTP_METHOD_NO_ALLOWED),
+		})
+		return
+	}
+
+	req := schemas.AuthTokenRefreshRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: "Dados inválidos",
+		})
+		return
+	}
+
+	if errs := validation.Validate(req); errs != nil {
+		validation.WriteErrors(w, errs)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGODB_TIMEOUT)
+	defer cancel()
+
+	refreshed, refreshErr := middlewares.RefreshSession(ctx, r, req.RefreshToken)
+	if refreshErr != nil {
+		refreshErr.Write(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(schemas.ApiResponse{
+		Data: schemas.AuthTokenResponse{
+			AccessToken:  refreshed.AccessToken,
+			RefreshToken: refreshed.RefreshToken,
+		},
+	})
+}
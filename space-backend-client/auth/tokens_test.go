@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"api/schemas"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func signinForTokens(t *testing.T) schemas.AuthTokenResponse {
+	t.Helper()
+
+	req := signinRequest("cliente@example.com", "senha-segura")
+	req.Header.Set(AUTH_MODE_HEADER, AUTH_MODE_TOKEN)
+
+	w := httptest.NewRecorder()
+	Signin(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("signin: status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if len(w.Result().Cookies()) != 0 {
+		t.Error("token mode signin set cookies")
+	}
+
+	var response struct {
+		Data schemas.AuthTokenResponse `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+	if response.Data.AccessToken == "" || response.Data.RefreshToken == "" {
+		t.Fatalf("tokens = %+v", response.Data)
+	}
+	return response.Data
+}
+
+func refreshTokenRequest(refreshToken string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	RefreshToken(w, jsonRequest("/v1/auth/token/refresh", schemas.AuthTokenRefreshRequest{RefreshToken: refreshToken}))
+	return w
+}
+
+func TestRefreshTokenRotatesAndReturnsTokens(t *testing.T) {
+	t.Setenv("ACCESS_TOKEN_SECRET", "access")
+	t.Setenv("REFRESH_TOKEN_SECRET", "refresh")
+
+	repositories := setupRepositories(t)
+	insertClient(t, repositories, "cliente@example.com", "senha-segura")
+
+	tokens := signinForTokens(t)
+
+	w := refreshTokenRequest(tokens.RefreshToken)
+	if w.Code != http.StatusOK {
+		t.Fatalf("refresh: status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	var response struct {
+		Data schemas.AuthTokenResponse `json:"data"`
+	}
+	json.NewDecoder(w.Body).Decode(&response)
+	if response.Data.AccessToken == "" || response.Data.RefreshToken == "" || response.Data.RefreshToken == tokens.RefreshToken {
+		t.Fatalf("refreshed tokens = %+v", response.Data)
+	}
+
+	// O novo refresh token continua a família
+	if w := refreshTokenRequest(response.Data.RefreshToken); w.Code != http.StatusOK {
+		t.Fatalf("second refresh: status = %d, want %d", w.Code, http.StatusOK)
+	}
+}
+
+func TestRefreshTokenRejectsInvalidToken(t *testing.T) {
+	t.Setenv("ACCESS_TOKEN_SECRET", "access")
+	t.Setenv("REFRESH_TOKEN_SECRET", "refresh")
+
+	repositories := setupRepositories(t)
+	insertClient(t, repositories, "cliente@example.com", "senha-segura")
+	tokens := signinForTokens(t)
+
+	// O access token não pode ser usado como refresh token
+	if w := refreshTokenRequest(tokens.AccessToken); w.Code != http.StatusUnauthorized {
+		t.Errorf("access token as refresh: status = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+
+	if w := refreshTokenRequest(""); w.Code != http.StatusBadRequest {
+		t.Errorf("empty token: status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,437 @@
+package auth
+
+import (
+	"api/audit"
+	"api/database"
+	"api/middlewares"
+	"api/schemas"
+	"api/utils"
+	"api/validation"
+	"context"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+const TWO_FACTOR_CHALLENGE_EXPIRATION = 5 * time.Minute
+
+// startTwoFactorChallenge é o primeiro passo do Signin com 2FA: a senha já
+// conferiu e o cliente recebe um desafio de curta duração para enviar junto
+// com o código em SigninTwoFactor.
+func startTwoFactorChallenge(ctx context.Context, w http.ResponseWriter, client schemas.ClientFromDB) {
+	token, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.ERROR_TO_GENERATE_TOKEN),
+		})
+		return
+	}
+
+	expiresAt := time.Now().Add(TWO_FACTOR_CHALLENGE_EXPIRATION)
+	err = Repositories.Clients.SetTwoFactorChallenge(ctx, client.ID, schemas.PendingToken{
+		TokenHash: utils.HashToken(token),
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.ERROR_TO_UPDATE_CLIENT_TO_MONGODB),
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(schemas.ApiResponse{
+		Message: "Informe o código do aplicativo autenticador",
+		Data: schemas.TwoFactorChallengeResponse{
+			TwoFactorRequired: true,
+			ChallengeToken:    token,
+			ExpiresAt:         expiresAt,
+		},
+	})
+}
+
+// SigninTwoFactor é o segundo passo do Signin: troca o desafio e um código
+// TOTP (ou de recuperação) por uma sessão. Códigos errados contam como falha
+// de login, assim como uma senha errada.
+func SigninTwoFactor(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.HTTP_METHOD_NO_ALLOWED),
+		})
+		return
+	}
+
+	req := schemas.SigninTwoFactorRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: "Dados inválidos",
+		})
+		return
+	}
+
+	if errs := validation.Validate(req); errs != nil {
+		validation.WriteErrors(w, errs)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGODB_TIMEOUT)
+	defer cancel()
+
+	challengeHash := utils.HashToken(req.ChallengeToken)
+
+	client, err := Repositories.Clients.FindByTwoFactorChallenge(ctx, challengeHash)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(schemas.ApiResponse{
+				Message: "Desafio inválido ou expirado",
+			})
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.ERROR_TO_TRY_FIND_MONGODB),
+		})
+		return
+	}
+
+	ip := utils.ClientIP(r)
+
+	attempt, retryAfter, err := claimLoginAttempt(ctx, client.Contact.Email, ip)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.ERROR_TO_TRY_FIND_MONGODB),
+		})
+		return
+	}
+
+	if retryAfter > 0 {
+		writeTooManyAttempts(w, retryAfter)
+		return
+	}
+
+	// Um código errado mantém a tentativa contada em claimLoginAttempt
+	twoFactor, ok := verifyTwoFactorCode(client.TwoFactor, req.Code, req.RecoveryCode)
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: "Código inválido",
+		})
+		return
+	}
+
+	err = Repositories.Clients.CompleteTwoFactorChallenge(ctx, client.ID, challengeHash, twoFactor)
+	if err != nil {
+		attempt.release(ctx)
+		if err == mongo.ErrNoDocuments {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(schemas.ApiResponse{
+				Message: "Desafio inválido ou expirado",
+			})
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.ERROR_TO_UPDATE_CLIENT_TO_MONGODB),
+		})
+		return
+	}
+
+	attempt.succeed(ctx)
+
+	// A conta pode ter sido bloqueada entre a senha e o código
+	if client.Blocked() {
+		writeAccountBlocked(w)
+		return
+	}
+
+	startSession(ctx, w, r, client)
+}
+
+// SetupTwoFactor inicia a inscrição: gera um novo segredo, ainda inativo, e
+// devolve a URI otpauth para o QR code.
+func SetupTwoFactor(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.HTTP_METHOD_NO_ALLOWED),
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGODB_TIMEOUT)
+	defer cancel()
+
+	client, ok := currentClient(ctx, w, r)
+	if !ok {
+		return
+	}
+
+	if client.TwoFactor != nil && client.TwoFactor.Enabled {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: "A autenticação em dois fatores já está ativa",
+		})
+		return
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.ERROR_TO_GENERATE_TOKEN),
+		})
+		return
+	}
+
+	err = Repositories.Clients.SetTwoFactor(ctx, client.ID, schemas.TwoFactor{Secret: secret})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.ERROR_TO_UPDATE_CLIENT_TO_MONGODB),
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(schemas.ApiResponse{
+		Data: schemas.TwoFactorSetupResponse{
+			Secret:     secret,
+			OtpauthURI: utils.TOTPURI(secret, client.Contact.Email),
+		},
+	})
+}
+
+// EnableTwoFactor confirma a inscrição com um código do aplicativo e devolve
+// os códigos de recuperação. Eles só são exibidos nesta resposta.
+func EnableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.HTTP_METHOD_NO_ALLOWED),
+		})
+		return
+	}
+
+	req := schemas.TwoFactorCodeRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: "Dados inválidos",
+		})
+		return
+	}
+
+	if errs := validation.Validate(req); errs != nil {
+		validation.WriteErrors(w, errs)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGODB_TIMEOUT)
+	defer cancel()
+
+	client, ok := currentClient(ctx, w, r)
+	if !ok {
+		return
+	}
+
+	if client.TwoFactor == nil || client.TwoFactor.Enabled {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: "Nenhuma inscrição de autenticação em dois fatores pendente",
+		})
+		return
+	}
+
+	step, ok := utils.ValidateTOTP(client.TwoFactor.Secret, req.Code, time.Now(), 0)
+	if !ok {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: "Código inválido",
+		})
+		return
+	}
+
+	recoveryCodes, err := utils.GenerateRecoveryCodes()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.ERROR_TO_GENERATE_TOKEN),
+		})
+		return
+	}
+
+	hashes := make([]string, 0, len(recoveryCodes))
+	for _, code := range recoveryCodes {
+		hashes = append(hashes, utils.HashToken(utils.NormalizeRecoveryCode(code)))
+	}
+
+	err = Repositories.Clients.SetTwoFactor(ctx, client.ID, schemas.TwoFactor{
+		Secret:             client.TwoFactor.Secret,
+		Enabled:            true,
+		RecoveryCodeHashes: hashes,
+		LastUsedStep:       step,
+		EnabledAt:          time.Now(),
+	})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.ERROR_TO_UPDATE_CLIENT_TO_MONGODB),
+		})
+		return
+	}
+
+	audit.Record(ctx, Repositories.AuditEvents, r, audit.Entry{
+		Action:     schemas.AUDIT_ACTION_TWO_FACTOR_ENABLE,
+		TargetType: schemas.AUDIT_TARGET_CLIENT,
+		TargetID:   client.ID.Hex(),
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(schemas.ApiResponse{
+		Message: "Autenticação em dois fatores ativada",
+		Data: schemas.TwoFactorEnableResponse{
+			RecoveryCodes: recoveryCodes,
+		},
+	})
+}
+
+// DisableTwoFactor remove o 2FA mediante um código válido (do aplicativo ou
+// de recuperação).
+func DisableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.HTTP_METHOD_NO_ALLOWED),
+		})
+		return
+	}
+
+	req := schemas.TwoFactorCodeRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: "Dados inválidos",
+		})
+		return
+	}
+
+	if errs := validation.Validate(req); errs != nil {
+		validation.WriteErrors(w, errs)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGODB_TIMEOUT)
+	defer cancel()
+
+	client, ok := currentClient(ctx, w, r)
+	if !ok {
+		return
+	}
+
+	if client.TwoFactor == nil || !client.TwoFactor.Enabled {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: "A autenticação em dois fatores não está ativa",
+		})
+		return
+	}
+
+	if _, ok := verifyTwoFactorCode(client.TwoFactor, req.Code, req.Code); !ok {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: "Código inválido",
+		})
+		return
+	}
+
+	err := Repositories.Clients.RemoveTwoFactor(ctx, client.ID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.ERROR_TO_UPDATE_CLIENT_TO_MONGODB),
+		})
+		return
+	}
+
+	audit.Record(ctx, Repositories.AuditEvents, r, audit.Entry{
+		Action:     schemas.AUDIT_ACTION_TWO_FACTOR_DISABLE,
+		TargetType: schemas.AUDIT_TARGET_CLIENT,
+		TargetID:   client.ID.Hex(),
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(schemas.ApiResponse{
+		Message: "Autenticação em dois fatores desativada",
+	})
+}
+
+// verifyTwoFactorCode confere o código TOTP ou, na falta dele, o código de
+// recuperação, e retorna o estado atualizado a ser salvo: o passo usado ou a
+// lista de códigos de recuperação sem o que foi consumido.
+func verifyTwoFactorCode(twoFactor *schemas.TwoFactor, code string, recoveryCode string) (schemas.TwoFactor, bool) {
+	if twoFactor == nil || !twoFactor.Enabled {
+		return schemas.TwoFactor{}, false
+	}
+
+	updated := *twoFactor
+
+	if code != "" {
+		if step, ok := utils.ValidateTOTP(twoFactor.Secret, code, time.Now(), twoFactor.LastUsedStep); ok {
+			updated.LastUsedStep = step
+			return updated, true
+		}
+	}
+
+	if recoveryCode != "" {
+		hash := utils.HashToken(utils.NormalizeRecoveryCode(recoveryCode))
+		if index := slices.Index(twoFactor.RecoveryCodeHashes, hash); index >= 0 {
+			updated.RecoveryCodeHashes = slices.Delete(slices.Clone(twoFactor.RecoveryCodeHashes), index, index+1)
+			return updated, true
+		}
+	}
+
+	return schemas.TwoFactor{}, false
+}
+
+// currentClient carrega o cliente autenticado pelo AuthMiddleware, escrevendo
+// a resposta de erro quando não for possível.
+func currentClient(ctx context.Context, w http.ResponseWriter, r *http.Request) (schemas.ClientFromDB, bool) {
+	userId, _ := r.Context().Value(middlewares.UserIDKey).(string)
+
+	clientId, err := utils.ParseObjectIDFromHex(userId)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.INVALID_USER_ID_FORMAT),
+		})
+		return schemas.ClientFromDB{}, false
+	}
+
+	client, err := Repositories.Clients.FindByID(ctx, clientId)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(schemas.ApiResponse{
+				Message: "Cliente não encontrado",
+			})
+			return schemas.ClientFromDB{}, false
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.ERROR_TO_TRY_FIND_MONGODB),
+		})
+		return schemas.ClientFromDB{}, false
+	}
+
+	return client, true
+}
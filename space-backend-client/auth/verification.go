@@ -0,0 +1,190 @@
+package auth
+
+import (
+	"api/database"
+	"api/notifications"
+	"api/schemas"
+	"api/utils"
+	"api/validation"
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+const EMAIL_VERIFICATION_TOKEN_EXPIRATION = 24 * time.Hour
+
+// VerifyEmail confirma o email do cliente com o token enviado no cadastro ou
+// na troca de email. Só aqui o contato é enfileirado para o Tiny, para não
+// gerar contatos (nem gravar emails) que nunca foram confirmados.
+func VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.HTTP_METHOD_NO_ALLOWED),
+		})
+		return
+	}
+
+	req := schemas.EmailVerifyRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: "Dados inválidos",
+		})
+		return
+	}
+
+	if errs := validation.Validate(req); errs != nil {
+		validation.WriteErrors(w, errs)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGODB_TIMEOUT)
+	defer cancel()
+
+	tokenHash := utils.HashToken(req.Token)
+
+	client, err := Repositories.Clients.FindByEmailVerificationToken(ctx, tokenHash)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(schemas.ApiResponse{
+				Message: "Token inválido ou expirado",
+			})
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.ERROR_TO_TRY_FIND_MONGODB),
+		})
+		return
+	}
+
+	// O token entra no filtro do update: entre a busca e aqui ele pode ter sido
+	// usado por outra requisição ou trocado por uma nova mudança de email. O
+	// contato é enviado ao Tiny pelo worker de sincronização (tinysync)
+	err = Repositories.Transactions.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := Repositories.Clients.MarkEmailVerified(ctx, client.ID, tokenHash); err != nil {
+			return err
+		}
+		return Repositories.TinySyncJobs.Enqueue(ctx, client.ID)
+	})
+	if err == mongo.ErrNoDocuments {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: "Token inválido ou expirado",
+		})
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.ERROR_TO_UPDATE_CLIENT_TO_MONGODB),
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(schemas.ApiResponse{
+		Message: "Email confirmado com sucesso",
+	})
+}
+
+// ResendVerification gera um novo token de verificação. Assim como no
+// ForgotPassword, a resposta não revela se o email existe ou já foi verificado.
+func ResendVerification(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.HTTP_METHOD_NO_ALLOWED),
+		})
+		return
+	}
+
+	req := schemas.EmailVerifyResendRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: "Dados inválidos",
+		})
+		return
+	}
+
+	if errs := validation.Validate(req); errs != nil {
+		validation.WriteErrors(w, errs)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGODB_TIMEOUT)
+	defer cancel()
+
+	client, err := Repositories.Clients.FindByEmail(ctx, req.Email)
+	if err != nil && err != mongo.ErrNoDocuments {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.ERROR_TO_TRY_FIND_MONGODB),
+		})
+		return
+	}
+
+	if err == nil && !client.EmailVerified {
+		token, err := utils.GenerateOpaqueToken()
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(schemas.ApiResponse{
+				Message: utils.SendInternalError(utils.ERROR_TO_GENERATE_TOKEN),
+			})
+			return
+		}
+
+		err = Repositories.Clients.SetEmailVerification(ctx, client.ID, schemas.PendingToken{
+			TokenHash: utils.HashToken(token),
+			ExpiresAt: time.Now().Add(EMAIL_VERIFICATION_TOKEN_EXPIRATION),
+		})
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(schemas.ApiResponse{
+				Message: utils.SendInternalError(utils.ERROR_TO_UPDATE_CLIENT_TO_MONGODB),
+			})
+			return
+		}
+
+		SendEmailVerification(ctx, client.Contact, token)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(schemas.ApiResponse{
+		Message: "Se o email estiver cadastrado e pendente de confirmação, você receberá um novo link",
+	})
+}
+
+// SendEmailVerification só registra falhas no log: o cliente pode pedir um
+// novo envio em /v1/auth/verify/resend.
+func SendEmailVerification(ctx context.Context, contact schemas.Contact, token string) {
+	err := Notifier.Send(ctx, notifications.Message{
+		To:      contact,
+		Subject: "Confirme seu email",
+		Body:    emailVerificationBody(token),
+	})
+	if err != nil {
+		log.Printf("[EmailVerification] Erro ao enviar token de verificação: %v", err)
+	}
+}
+
+func emailVerificationBody(token string) string {
+	verificationURL := os.Getenv(utils.EMAIL_VERIFICATION_URL)
+	if verificationURL == "" {
+		return "Use o código abaixo para confirmar seu email. Ele expira em 24 horas.\n\n" + token
+	}
+
+	return "Acesse o link abaixo para confirmar seu email. Ele expira em 24 horas.\n\n" +
+		verificationURL + "?token=" + url.QueryEscape(token)
+}
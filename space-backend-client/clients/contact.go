@@ -0,0 +1,97 @@
+package clients
+
+import (
+	"api/auth"
+	"api/database"
+	"api/documents"
+	"api/schemas"
+	"api/utils"
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// ErrEmailTaken indica que o novo email do contato já pertence a outro cliente.
+var ErrEmailTaken = errors.New("Email já cadastrado")
+
+// PrepareContactPatch monta o contato resultante do merge patch: rejeita a
+// remoção de campos obrigatórios, confere se o novo email está livre, completa
+// o endereço pelo CEP e normaliza. Retorna também os campos alterados, vazio
+// quando nada muda. É o mesmo fluxo do PATCH /v1/clients e da edição feita
+// pela equipe interna em /v1/admin/clients/account.
+func PrepareContactPatch(ctx context.Context, repositories *database.Repositories, client schemas.ClientFromDB, patch map[string]json.RawMessage, request schemas.ClientUpdateRequest) (schemas.Contact, []string, []schemas.FieldError, error) {
+	contact, errs := mergeContactPatch(client.Contact, patch)
+	if errs != nil {
+		return schemas.Contact{}, nil, errs, nil
+	}
+
+	if contact.Email != client.Contact.Email {
+		_, err := repositories.Clients.FindByEmail(ctx, contact.Email)
+		if err == nil {
+			return schemas.Contact{}, nil, nil, ErrEmailTaken
+		}
+		// Só a ausência do documento garante que o email está livre
+		if err != mongo.ErrNoDocuments {
+			return schemas.Contact{}, nil, nil, err
+		}
+	}
+
+	if errs := autofillAddress(ctx, request, &contact); errs != nil {
+		return schemas.Contact{}, nil, errs, nil
+	}
+	contact = documents.NormalizeContact(contact)
+
+	// O mesmo contato mesclado alimenta o $set/$unset do MongoDB e, pelo
+	// worker, o payload do Tiny
+	return contact, changedContactFields(client.Contact, contact), nil, nil
+}
+
+// SaveContact grava os campos alterados do contato junto com o job de
+// sincronização com o Tiny; o envio acontece no worker (tinysync). Quando o
+// email muda, o cliente volta a ficar com o email não verificado e recebe um
+// novo token no endereço novo: o Tiny só recebe o contato após a confirmação.
+// Retorna ErrEmailTaken se o email foi ocupado depois de PrepareContactPatch.
+func SaveContact(ctx context.Context, repositories *database.Repositories, id bson.ObjectID, contact schemas.Contact, fields []string) error {
+	verificationToken := ""
+	if slices.Contains(fields, "email") {
+		token, err := utils.GenerateOpaqueToken()
+		if err != nil {
+			return err
+		}
+		verificationToken = token
+	}
+
+	contact.UpdatedAt = time.Now()
+	err := repositories.Transactions.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := repositories.Clients.PatchContact(ctx, id, contact, fields); err != nil {
+			// Outro cliente ficou com o email depois de PrepareContactPatch
+			if err == database.ErrClientEmailTaken {
+				return ErrEmailTaken
+			}
+			return err
+		}
+		if verificationToken != "" {
+			err := repositories.Clients.ResetEmailVerification(ctx, id, schemas.PendingToken{
+				TokenHash: utils.HashToken(verificationToken),
+				ExpiresAt: time.Now().Add(auth.EMAIL_VERIFICATION_TOKEN_EXPIRATION),
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return repositories.TinySyncJobs.Enqueue(ctx, id)
+	})
+	if err != nil {
+		return err
+	}
+
+	if verificationToken != "" {
+		auth.SendEmailVerification(ctx, contact, verificationToken)
+	}
+	return nil
+}
@@ -1,22 +1,72 @@
 package clients
 
 import (
+	"api/address"
+	"api/audit"
 	"api/database"
+	"api/documents"
 	"api/middlewares"
 	"api/schemas"
 	"api/utils"
+	"api/validation"
 	"context"
 	"encoding/json"
+	"io"
+	"log"
 	"net/http"
-	"os"
-	"time"
 
 	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo"
-	"go.mongodb.org/mongo-driver/v2/mongo/options"
 	"golang.org/x/crypto/bcrypt"
 )
 
+// Repositories é injetado pelo main com o client MongoDB compartilhado.
+var Repositories *database.Repositories
+
+// AddressProvider é injetado pelo main e preenche o endereço quando o cliente
+// envia apenas o CEP. Sem provedor, o preenchimento automático fica desligado.
+var AddressProvider address.Provider
+
+// autofillAddress completa logradouro, bairro, cidade e UF a partir do CEP
+// quando a requisição traz o CEP sem nenhum desses campos. Número e
+// complemento nunca são alterados. Se o provedor estiver fora do ar, o
+// cadastro segue com os campos atuais.
+func autofillAddress(ctx context.Context, request schemas.ClientUpdateRequest, contact *schemas.Contact) []schemas.FieldError {
+	if AddressProvider == nil {
+		return nil
+	}
+
+	var errs []schemas.FieldError
+	fill := func(field string, cep string, street, neighborhood, city, state *string) {
+		normalized, err := documents.NormalizeCEP(cep)
+		if err != nil {
+			return
+		}
+		found, err := AddressProvider.Lookup(ctx, normalized)
+		if err != nil {
+			if err == address.ErrNotFound {
+				errs = append(errs, schemas.FieldError{Field: field, Message: address.ErrNotFound.Error()})
+				return
+			}
+			log.Printf("[CEP] Erro ao consultar %s, endereço não preenchido: %v", normalized, err)
+			return
+		}
+		*street = found.Address
+		*neighborhood = found.Neighborhood
+		*city = found.City
+		*state = found.State
+	}
+
+	if request.ZipCode != "" && request.Address == "" && request.Neighborhood == "" && request.City == "" && request.State == "" {
+		fill("zip_code", request.ZipCode, &contact.Address, &contact.Neighborhood, &contact.City, &contact.State)
+	}
+	if request.BillingZipCode != "" && request.BillingAddress == "" && request.BillingNeighborhood == "" && request.BillingCity == "" && request.BillingState == "" {
+		fill("billing_zip_code", request.BillingZipCode, &contact.BillingAddress, &contact.BillingNeighborhood, &contact.BillingCity, &contact.BillingState)
+	}
+
+	return errs
+}
+
 func getById(w http.ResponseWriter, r *http.Request) {
 	userId := r.Context().Value(middlewares.UserIDKey)
 	if userId == nil {
@@ -30,20 +80,6 @@ func getById(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(context.Background(), database.MONGODB_TIMEOUT)
 	defer cancel()
 
-	mongoURI := os.Getenv(utils.ENV_MONGODB_URI)
-	opts := options.Client().ApplyURI(mongoURI)
-	mongoClient, err := mongo.Connect(opts)
-	if err != nil {
-		w.WriteHeader(http.StatusBadGateway)
-		json.NewEncoder(w).Encode(schemas.ApiResponse{
-			Message: utils.SendInternalError(utils.CANNOT_CONNECT_TO_MONGODB),
-		})
-		return
-	}
-	defer mongoClient.Disconnect(ctx)
-
-	collection := mongoClient.Database(database.GetDB()).Collection("clients")
-
 	userIdStr, ok := userId.(string)
 	if !ok {
 		w.WriteHeader(http.StatusInternalServerError)
@@ -62,9 +98,7 @@ func getById(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	filter := bson.D{{Key: "_id", Value: objectId}}
-	client := schemas.ClientFromDB{}
-	err = collection.FindOne(ctx, filter).Decode(&client)
+	client, err := Repositories.Clients.FindByID(ctx, objectId)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			w.WriteHeader(http.StatusNotFound)
@@ -81,10 +115,12 @@ func getById(w http.ResponseWriter, r *http.Request) {
 	}
 
 	clientResponse := schemas.ClientResponse{
-		ID:        client.ID.Hex(),
-		Contact:   client.Contact,
-		CreatedAt: client.CreatedAt,
-		UpdatedAt: client.UpdatedAt,
+		ID:            client.ID.Hex(),
+		Contact:       documents.FormatContact(client.Contact),
+		EmailVerified: client.EmailVerified,
+		TwoFactor:     client.TwoFactor != nil && client.TwoFactor.Enabled,
+		CreatedAt:     client.CreatedAt,
+		UpdatedAt:     client.UpdatedAt,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -103,8 +139,12 @@ func update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// O corpo é lido duas vezes: como mapa, para saber quais chaves vieram
+	// (merge patch), e como ClientUpdateRequest, para conferir tipos e regras
+	body, err := io.ReadAll(r.Body)
+	patch := map[string]json.RawMessage{}
 	clientFromRequest := schemas.ClientUpdateRequest{}
-	if err := json.NewDecoder(r.Body).Decode(&clientFromRequest); err != nil {
+	if err != nil || json.Unmarshal(body, &patch) != nil || json.Unmarshal(body, &clientFromRequest) != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(schemas.ApiResponse{
 			Message: utils.SendInternalError(utils.CLIENTS_INVALID_REQUEST_DATA),
@@ -112,22 +152,13 @@ func update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), database.MONGODB_TIMEOUT)
-	defer cancel()
-
-	mongoURI := os.Getenv(utils.ENV_MONGODB_URI)
-	opts := options.Client().ApplyURI(mongoURI)
-	mongoClient, err := mongo.Connect(opts)
-	if err != nil {
-		w.WriteHeader(http.StatusBadGateway)
-		json.NewEncoder(w).Encode(schemas.ApiResponse{
-			Message: utils.SendInternalError(utils.CANNOT_CONNECT_TO_MONGODB),
-		})
+	if errs := validation.Validate(clientFromRequest); errs != nil {
+		validation.WriteErrors(w, errs)
 		return
 	}
-	defer mongoClient.Disconnect(ctx)
 
-	collection := mongoClient.Database(database.GetDB()).Collection("clients")
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGODB_TIMEOUT)
+	defer cancel()
 
 	userIdStr, ok := userId.(string)
 	if !ok {
@@ -147,9 +178,7 @@ func update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	filter := bson.D{{Key: "_id", Value: objectId}}
-	client := schemas.ClientFromDB{}
-	err = collection.FindOne(ctx, filter).Decode(&client)
+	client, err := Repositories.Clients.FindByID(ctx, objectId)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			w.WriteHeader(http.StatusNotFound)
@@ -165,21 +194,35 @@ func update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if clientFromRequest.Email != "" && clientFromRequest.Email != client.Contact.Email {
-		emailFilter := bson.D{{Key: "contact.email", Value: clientFromRequest.Email}}
-		existingClient := schemas.ClientFromDB{}
-		err = collection.FindOne(ctx, emailFilter).Decode(&existingClient)
-		if err == nil {
-			w.WriteHeader(http.StatusConflict)
-			json.NewEncoder(w).Encode(schemas.ApiResponse{
-				Message: "Email já cadastrado",
-			})
-			return
-		}
+	if !HasContactFields(patch) && clientFromRequest.Password == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: "Nenhum campo para atualizar",
+		})
+		return
 	}
 
-	updateFields := bson.D{}
+	updatedContact, changedFields, errs, err := PrepareContactPatch(ctx, Repositories, client, patch, clientFromRequest)
+	if err == ErrEmailTaken {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: ErrEmailTaken.Error(),
+		})
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.ERROR_TO_TRY_FIND_MONGODB),
+		})
+		return
+	}
+	if errs != nil {
+		validation.WriteErrors(w, errs)
+		return
+	}
 
+	passwordHash := ""
 	if clientFromRequest.Password != "" {
 		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(clientFromRequest.Password), bcrypt.DefaultCost)
 		if err != nil {
@@ -189,195 +232,54 @@ func update(w http.ResponseWriter, r *http.Request) {
 			})
 			return
 		}
-		updateFields = append(updateFields, bson.E{Key: "password_hash", Value: string(hashedPassword)})
-	}
-
-	if clientFromRequest.Name != "" {
-		updateFields = append(updateFields, bson.E{Key: "contact.name", Value: clientFromRequest.Name})
-	}
-	if clientFromRequest.Email != "" {
-		updateFields = append(updateFields, bson.E{Key: "contact.email", Value: clientFromRequest.Email})
-	}
-	if clientFromRequest.PersonType != "" {
-		updateFields = append(updateFields, bson.E{Key: "contact.person_type", Value: clientFromRequest.PersonType})
-	}
-	if clientFromRequest.IdentityCard != "" {
-		updateFields = append(updateFields, bson.E{Key: "contact.identity_card", Value: clientFromRequest.IdentityCard})
-	}
-	if clientFromRequest.CPF != "" {
-		updateFields = append(updateFields, bson.E{Key: "contact.cpf", Value: clientFromRequest.CPF})
-	}
-	if clientFromRequest.CellPhone != "" {
-		updateFields = append(updateFields, bson.E{Key: "contact.cell_phone", Value: clientFromRequest.CellPhone})
-	}
-	if clientFromRequest.ZipCode != "" {
-		updateFields = append(updateFields, bson.E{Key: "contact.zip_code", Value: clientFromRequest.ZipCode})
-	}
-	if clientFromRequest.Address != "" {
-		updateFields = append(updateFields, bson.E{Key: "contact.address", Value: clientFromRequest.Address})
-	}
-	if clientFromRequest.Number != "" {
-		updateFields = append(updateFields, bson.E{Key: "contact.number", Value: clientFromRequest.Number})
-	}
-	if clientFromRequest.Complement != "" {
-		updateFields = append(updateFields, bson.E{Key: "contact.complement", Value: clientFromRequest.Complement})
-	}
-	if clientFromRequest.Neighborhood != "" {
-		updateFields = append(updateFields, bson.E{Key: "contact.neighborhood", Value: clientFromRequest.Neighborhood})
-	}
-	if clientFromRequest.City != "" {
-		updateFields = append(updateFields, bson.E{Key: "contact.city", Value: clientFromRequest.City})
-	}
-	if clientFromRequest.State != "" {
-		updateFields = append(updateFields, bson.E{Key: "contact.state", Value: clientFromRequest.State})
-	}
-	if clientFromRequest.CompanyName != "" {
-		updateFields = append(updateFields, bson.E{Key: "contact.company_name", Value: clientFromRequest.CompanyName})
+		passwordHash = string(hashedPassword)
 	}
-	if clientFromRequest.CNPJ != "" {
-		updateFields = append(updateFields, bson.E{Key: "contact.cnpj", Value: clientFromRequest.CNPJ})
-	}
-	if clientFromRequest.StateRegistration != "" {
-		updateFields = append(updateFields, bson.E{Key: "contact.state_registration", Value: clientFromRequest.StateRegistration})
-	}
-	if clientFromRequest.BillingZipCode != "" {
-		updateFields = append(updateFields, bson.E{Key: "contact.billing_zip_code", Value: clientFromRequest.BillingZipCode})
-	}
-	if clientFromRequest.BillingAddress != "" {
-		updateFields = append(updateFields, bson.E{Key: "contact.billing_address", Value: clientFromRequest.BillingAddress})
-	}
-	if clientFromRequest.BillingNumber != "" {
-		updateFields = append(updateFields, bson.E{Key: "contact.billing_number", Value: clientFromRequest.BillingNumber})
-	}
-	if clientFromRequest.BillingComplement != "" {
-		updateFields = append(updateFields, bson.E{Key: "contact.billing_complement", Value: clientFromRequest.BillingComplement})
-	}
-	if clientFromRequest.BillingNeighborhood != "" {
-		updateFields = append(updateFields, bson.E{Key: "contact.billing_neighborhood", Value: clientFromRequest.BillingNeighborhood})
-	}
-	if clientFromRequest.BillingCity != "" {
-		updateFields = append(updateFields, bson.E{Key: "contact.billing_city", Value: clientFromRequest.BillingCity})
-	}
-	if clientFromRequest.BillingState != "" {
-		updateFields = append(updateFields, bson.E{Key: "contact.billing_state", Value: clientFromRequest.BillingState})
-	}
-	updateFields = append(updateFields, bson.E{Key: "contact.different_billing_address", Value: clientFromRequest.DifferentBillingAddress})
-	if clientFromRequest.Status != "" {
-		updateFields = append(updateFields, bson.E{Key: "contact.status", Value: clientFromRequest.Status})
-	}
-
-	updateFields = append(updateFields, bson.E{Key: "contact.updated_at", Value: time.Now()})
 
-	if len(updateFields) <= 2 {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(schemas.ApiResponse{
-			Message: "Nenhum campo para atualizar",
-		})
+	if len(changedFields) == 0 && passwordHash == "" {
+		w.WriteHeader(http.StatusOK)
 		return
 	}
 
-	update := bson.D{{Key: "$set", Value: updateFields}}
-
-	updatedContact := client.Contact
-
-	if clientFromRequest.Name != "" {
-		updatedContact.Name = clientFromRequest.Name
-	}
-	if clientFromRequest.Email != "" {
-		updatedContact.Email = clientFromRequest.Email
-	}
-	if clientFromRequest.PersonType != "" {
-		updatedContact.PersonType = clientFromRequest.PersonType
-	}
-	if clientFromRequest.IdentityCard != "" {
-		updatedContact.IdentityCard = clientFromRequest.IdentityCard
-	}
-	if clientFromRequest.CPF != "" {
-		updatedContact.CPF = clientFromRequest.CPF
-	}
-	if clientFromRequest.CellPhone != "" {
-		updatedContact.CellPhone = clientFromRequest.CellPhone
-	}
-	if clientFromRequest.ZipCode != "" {
-		updatedContact.ZipCode = clientFromRequest.ZipCode
-	}
-	if clientFromRequest.Address != "" {
-		updatedContact.Address = clientFromRequest.Address
-	}
-	if clientFromRequest.Number != "" {
-		updatedContact.Number = clientFromRequest.Number
-	}
-	if clientFromRequest.Complement != "" {
-		updatedContact.Complement = clientFromRequest.Complement
-	}
-	if clientFromRequest.Neighborhood != "" {
-		updatedContact.Neighborhood = clientFromRequest.Neighborhood
-	}
-	if clientFromRequest.City != "" {
-		updatedContact.City = clientFromRequest.City
-	}
-	if clientFromRequest.State != "" {
-		updatedContact.State = clientFromRequest.State
-	}
-	if clientFromRequest.CompanyName != "" {
-		updatedContact.CompanyName = clientFromRequest.CompanyName
-	}
-	if clientFromRequest.CNPJ != "" {
-		updatedContact.CNPJ = clientFromRequest.CNPJ
-	}
-	if clientFromRequest.StateRegistration != "" {
-		updatedContact.StateRegistration = clientFromRequest.StateRegistration
-	}
-	if clientFromRequest.BillingZipCode != "" {
-		updatedContact.BillingZipCode = clientFromRequest.BillingZipCode
-	}
-	if clientFromRequest.BillingAddress != "" {
-		updatedContact.BillingAddress = clientFromRequest.BillingAddress
-	}
-	if clientFromRequest.BillingNumber != "" {
-		updatedContact.BillingNumber = clientFromRequest.BillingNumber
-	}
-	if clientFromRequest.BillingComplement != "" {
-		updatedContact.BillingComplement = clientFromRequest.BillingComplement
-	}
-	if clientFromRequest.BillingNeighborhood != "" {
-		updatedContact.BillingNeighborhood = clientFromRequest.BillingNeighborhood
-	}
-	if clientFromRequest.BillingCity != "" {
-		updatedContact.BillingCity = clientFromRequest.BillingCity
-	}
-	if clientFromRequest.BillingState != "" {
-		updatedContact.BillingState = clientFromRequest.BillingState
-	}
-	updatedContact.DifferentBillingAddress = clientFromRequest.DifferentBillingAddress
-	if clientFromRequest.Status != "" {
-		updatedContact.Status = clientFromRequest.Status
-	}
-	updatedContact.UpdatedAt = time.Now()
-
-	tinyRequest := utils.UpdateContactFromClient(updatedContact, client.Contact.TinyID)
-	tinyID, err := utils.UpdateTinyContact(tinyRequest)
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(schemas.ApiResponse{
-			Message: utils.SendInternalError(utils.ERROR_TINY_API_INTEGRATION),
-		})
-		return
+	if len(changedFields) > 0 {
+		err = SaveContact(ctx, Repositories, client.ID, updatedContact, changedFields)
+		if err == ErrEmailTaken {
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(schemas.ApiResponse{
+				Message: ErrEmailTaken.Error(),
+			})
+			return
+		}
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(schemas.ApiResponse{
+				Message: utils.SendInternalError(utils.ERROR_TO_UPDATE_CLIENT_TO_MONGODB),
+			})
+			return
+		}
 	}
 
-	if tinyID != "" && tinyID != client.Contact.TinyID {
-		updateFields = append(updateFields, bson.E{Key: "contact.tiny_id", Value: tinyID})
-		update = bson.D{{Key: "$set", Value: updateFields}}
+	if passwordHash != "" {
+		err = Repositories.Clients.UpdatePasswordHash(ctx, client.ID, passwordHash)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(schemas.ApiResponse{
+				Message: utils.SendInternalError(utils.ERROR_TO_UPDATE_CLIENT_TO_MONGODB),
+			})
+			return
+		}
 	}
 
-	_, err = collection.UpdateOne(ctx, filter, update)
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(schemas.ApiResponse{
-			Message: utils.SendInternalError(utils.ERROR_TO_UPDATE_CLIENT_TO_MONGODB),
-		})
-		return
+	after := bson.M{"contact": updatedContact, "password_hash": client.PasswordHash}
+	if passwordHash != "" {
+		after["password_hash"] = passwordHash
 	}
+	audit.Record(ctx, Repositories.AuditEvents, r, audit.Entry{
+		Action:     schemas.AUDIT_ACTION_CLIENT_UPDATE,
+		TargetType: schemas.AUDIT_TARGET_CLIENT,
+		TargetID:   client.ID.Hex(),
+		Before:     bson.M{"contact": client.Contact, "password_hash": client.PasswordHash},
+		After:      after,
+	})
 
 	w.WriteHeader(http.StatusOK)
 }
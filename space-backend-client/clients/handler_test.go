@@ -1 +1,251 @@
 package clients
+
+import (
+	"api/address"
+	"api/auth"
+	"api/database"
+	"api/middlewares"
+	"api/notifications"
+	"api/schemas"
+	"api/utils"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func setupRepositories(t *testing.T) *database.MemoryClientsRepository {
+	t.Helper()
+
+	repositories := database.NewMemoryRepositories()
+	Repositories = repositories
+	t.Cleanup(func() { Repositories = nil })
+
+	return repositories.Clients.(*database.MemoryClientsRepository)
+}
+
+func authenticatedRequest(method string, body []byte, userID string) *http.Request {
+	r := httptest.NewRequest(method, "/v1/clients", bytes.NewReader(body))
+	return r.WithContext(context.WithValue(r.Context(), middlewares.UserIDKey, userID))
+}
+
+func TestGetByIdReturnsContact(t *testing.T) {
+	clientsRepository := setupRepositories(t)
+
+	client := schemas.ClientFromDB{Contact: schemas.Contact{Name: "Maria", Email: "maria@example.com"}}
+	clientsRepository.Insert(client)
+	client, _ = clientsRepository.FindByEmail(context.Background(), "maria@example.com")
+
+	w := httptest.NewRecorder()
+	Handler(w, authenticatedRequest(http.MethodGet, nil, client.ID.Hex()))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	var response struct {
+		Data schemas.ClientResponse `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+	if response.Data.Contact.Email != "maria@example.com" {
+		t.Errorf("email = %q, want %q", response.Data.Contact.Email, "maria@example.com")
+	}
+}
+
+func TestGetByIdFormatsDocuments(t *testing.T) {
+	clientsRepository := setupRepositories(t)
+
+	clientsRepository.Insert(schemas.ClientFromDB{Contact: schemas.Contact{
+		Email:     "ana@example.com",
+		CPF:       "52998224725",
+		ZipCode:   "80010000",
+		CellPhone: "+5541999998888",
+	}})
+	client, _ := clientsRepository.FindByEmail(context.Background(), "ana@example.com")
+
+	w := httptest.NewRecorder()
+	Handler(w, authenticatedRequest(http.MethodGet, nil, client.ID.Hex()))
+
+	var response struct {
+		Data schemas.ClientResponse `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+
+	contact := response.Data.Contact
+	if contact.CPF != "529.982.247-25" || contact.ZipCode != "80010-000" || contact.CellPhone != "(41) 99999-8888" {
+		t.Errorf("contact = %+v, want formatted documents", contact)
+	}
+}
+
+func TestUpdateRejectsInvalidDocuments(t *testing.T) {
+	clientsRepository := setupRepositories(t)
+
+	clientsRepository.Insert(schemas.ClientFromDB{Contact: schemas.Contact{Email: "bia@example.com"}})
+	client, _ := clientsRepository.FindByEmail(context.Background(), "bia@example.com")
+
+	w := httptest.NewRecorder()
+	Handler(w, authenticatedRequest(http.MethodPatch, []byte(`{"cpf": "529.982.247-24", "state": "XX"}`), client.ID.Hex()))
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+
+	var response schemas.ApiResponse
+	json.NewDecoder(w.Body).Decode(&response)
+	if len(response.Errors) != 2 {
+		t.Errorf("errors = %+v, want cpf and state", response.Errors)
+	}
+}
+
+func TestUpdateWithoutFieldsIsRejected(t *testing.T) {
+	clientsRepository := setupRepositories(t)
+
+	clientsRepository.Insert(schemas.ClientFromDB{Contact: schemas.Contact{Email: "joao@example.com"}})
+	client, _ := clientsRepository.FindByEmail(context.Background(), "joao@example.com")
+
+	w := httptest.NewRecorder()
+	Handler(w, authenticatedRequest(http.MethodPatch, []byte(`{"unknown": true}`), client.ID.Hex()))
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+type capturingNotifier struct {
+	messages []notifications.Message
+}
+
+func (n *capturingNotifier) Send(ctx context.Context, message notifications.Message) error {
+	n.messages = append(n.messages, message)
+	return nil
+}
+
+func TestUpdateEmailRequiresVerification(t *testing.T) {
+	clientsRepository := setupRepositories(t)
+
+	notifier := &capturingNotifier{}
+	previous := auth.Notifier
+	auth.Notifier = notifier
+	t.Cleanup(func() { auth.Notifier = previous })
+
+	clientsRepository.Insert(schemas.ClientFromDB{
+		Contact:       schemas.Contact{Name: "Rui", Email: "rui@example.com", TinyID: "123"},
+		EmailVerified: true,
+	})
+	client, _ := clientsRepository.FindByEmail(context.Background(), "rui@example.com")
+
+	w := httptest.NewRecorder()
+	Handler(w, authenticatedRequest(http.MethodPatch, []byte(`{"email": "rui.novo@example.com"}`), client.ID.Hex()))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	stored, _ := clientsRepository.FindByID(context.Background(), client.ID)
+	if stored.EmailVerified || stored.EmailVerification == nil {
+		t.Fatalf("email change should reset the verification: %+v", stored)
+	}
+
+	if len(notifier.messages) != 1 || notifier.messages[0].To.Email != "rui.novo@example.com" {
+		t.Fatalf("messages = %+v, want one verification sent to the new email", notifier.messages)
+	}
+
+	lines := strings.Split(notifier.messages[0].Body, "\n")
+	if stored.EmailVerification.TokenHash != utils.HashToken(lines[len(lines)-1]) {
+		t.Error("sent token does not match the pending verification")
+	}
+
+	// Outros campos não mexem na verificação
+	clientsRepository.MarkEmailVerified(context.Background(), client.ID, stored.EmailVerification.TokenHash)
+
+	w = httptest.NewRecorder()
+	Handler(w, authenticatedRequest(http.MethodPatch, []byte(`{"name": "Rui Souza"}`), client.ID.Hex()))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	stored, _ = clientsRepository.FindByID(context.Background(), client.ID)
+	if !stored.EmailVerified || len(notifier.messages) != 1 {
+		t.Error("name change should keep the email verified")
+	}
+}
+
+func TestSaveContactRejectsEmailTakenConcurrently(t *testing.T) {
+	clientsRepository := setupRepositories(t)
+
+	clientsRepository.Insert(schemas.ClientFromDB{Contact: schemas.Contact{Name: "Rui", Email: "rui@example.com"}})
+	client, _ := clientsRepository.FindByEmail(context.Background(), "rui@example.com")
+
+	contact, fields, errs, err := PrepareContactPatch(context.Background(), Repositories, client, map[string]json.RawMessage{"email": []byte(`"ana@example.com"`)}, schemas.ClientUpdateRequest{})
+	if err != nil || errs != nil {
+		t.Fatalf("PrepareContactPatch: errs = %v, err = %v", errs, err)
+	}
+
+	// Outro cliente fica com o email entre a consulta e a gravação
+	if _, err := clientsRepository.Create(context.Background(), schemas.ClientCreateModel{Contact: schemas.Contact{Name: "Ana", Email: "ana@example.com"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := SaveContact(context.Background(), Repositories, client.ID, contact, fields); err != ErrEmailTaken {
+		t.Fatalf("SaveContact error = %v, want %v", err, ErrEmailTaken)
+	}
+
+	stored, _ := clientsRepository.FindByID(context.Background(), client.ID)
+	if stored.Contact.Email != "rui@example.com" {
+		t.Errorf("email = %q, want the original", stored.Contact.Email)
+	}
+}
+
+func setupAddressProvider(t *testing.T) {
+	t.Helper()
+
+	AddressProvider = address.NewFixtureProvider([]schemas.Address{
+		{ZipCode: "80010000", Address: "Praça Tiradentes", Neighborhood: "Centro", City: "Curitiba", State: "PR"},
+	})
+	t.Cleanup(func() { AddressProvider = nil })
+}
+
+func TestAutofillAddressFromZipCode(t *testing.T) {
+	setupAddressProvider(t)
+
+	contact := schemas.Contact{Address: "Rua Antiga", Number: "12", City: "Londrina", State: "PR", BillingCity: "Maringá"}
+	request := schemas.ClientUpdateRequest{ZipCode: "80010-000", BillingZipCode: "80010000", BillingCity: "Cascavel"}
+
+	if errs := autofillAddress(context.Background(), request, &contact); errs != nil {
+		t.Fatalf("errs = %+v", errs)
+	}
+	if contact.Address != "Praça Tiradentes" || contact.Neighborhood != "Centro" || contact.City != "Curitiba" || contact.Number != "12" {
+		t.Errorf("contact = %+v, want address filled from zip code", contact)
+	}
+	// Com algum campo de cobrança enviado, o endereço de cobrança não é preenchido
+	if contact.BillingAddress != "" || contact.BillingCity != "Maringá" {
+		t.Errorf("billing = %q/%q, want untouched", contact.BillingAddress, contact.BillingCity)
+	}
+}
+
+func TestUpdateRejectsUnknownZipCode(t *testing.T) {
+	clientsRepository := setupRepositories(t)
+	setupAddressProvider(t)
+
+	clientsRepository.Insert(schemas.ClientFromDB{Contact: schemas.Contact{Email: "caio@example.com"}})
+	client, _ := clientsRepository.FindByEmail(context.Background(), "caio@example.com")
+
+	w := httptest.NewRecorder()
+	Handler(w, authenticatedRequest(http.MethodPatch, []byte(`{"zip_code": "99999-999"}`), client.ID.Hex()))
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+
+	var response schemas.ApiResponse
+	json.NewDecoder(w.Body).Decode(&response)
+	if len(response.Errors) != 1 || response.Errors[0].Field != "zip_code" {
+		t.Errorf("errors = %+v, want zip_code", response.Errors)
+	}
+}
@@ -0,0 +1,103 @@
+package clients
+
+import (
+	"api/schemas"
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// PATCH /v1/clients segue o JSON Merge Patch (RFC 7396) sobre o Contact:
+// chave ausente mantém o valor, null (ou string vazia) limpa o campo e
+// qualquer outro valor substitui. Os campos aceitos são os de
+// ClientUpdateRequest, que também define as regras de validação.
+
+// requiredContactFields não podem ser limpos.
+var requiredContactFields = []string{"name", "email"}
+
+// patchableContactFields mapeia a chave JSON de cada campo de
+// ClientUpdateRequest para o índice do campo equivalente no Contact.
+var patchableContactFields = func() map[string]int {
+	contactFields := map[string]int{}
+	contactType := reflect.TypeFor[schemas.Contact]()
+	for i := range contactType.NumField() {
+		contactFields[tagName(contactType.Field(i).Tag.Get("json"))] = i
+	}
+
+	fields := map[string]int{}
+	requestType := reflect.TypeFor[schemas.ClientUpdateRequest]()
+	for i := range requestType.NumField() {
+		key := tagName(requestType.Field(i).Tag.Get("json"))
+		if index, ok := contactFields[key]; ok {
+			fields[key] = index
+		}
+	}
+	return fields
+}()
+
+// HasContactFields indica se o patch traz algum campo do Contact.
+func HasContactFields(patch map[string]json.RawMessage) bool {
+	for key := range patch {
+		if _, ok := patchableContactFields[key]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeContactPatch aplica o patch sobre uma cópia do contato. Os tipos e
+// formatos já foram conferidos ao decodificar e validar o ClientUpdateRequest;
+// aqui só se rejeita a remoção de campos obrigatórios. Chaves desconhecidas
+// são ignoradas.
+func mergeContactPatch(contact schemas.Contact, patch map[string]json.RawMessage) (schemas.Contact, []schemas.FieldError) {
+	var errs []schemas.FieldError
+	target := reflect.ValueOf(&contact).Elem()
+
+	for key, value := range patch {
+		index, ok := patchableContactFields[key]
+		if !ok {
+			continue
+		}
+
+		field := target.Field(index)
+		if string(value) == "null" {
+			field.SetZero()
+		} else if err := json.Unmarshal(value, field.Addr().Interface()); err != nil {
+			errs = append(errs, schemas.FieldError{Field: key, Message: "Valor inválido"})
+			continue
+		}
+
+		if field.IsZero() && slices.Contains(requiredContactFields, key) {
+			errs = append(errs, schemas.FieldError{Field: key, Message: "Campo obrigatório"})
+		}
+	}
+
+	return contact, errs
+}
+
+// changedContactFields lista as chaves BSON do Contact que diferem entre as
+// duas versões. É a partir dela que o repositório monta o $set/$unset.
+func changedContactFields(before, after schemas.Contact) []string {
+	var fields []string
+	beforeValue := reflect.ValueOf(before)
+	afterValue := reflect.ValueOf(after)
+	contactType := beforeValue.Type()
+
+	for i := range contactType.NumField() {
+		key := tagName(contactType.Field(i).Tag.Get("bson"))
+		// updated_at é gravado pelo próprio repositório
+		if key == "updated_at" || key == "created_at" {
+			continue
+		}
+		if !beforeValue.Field(i).Equal(afterValue.Field(i)) {
+			fields = append(fields, key)
+		}
+	}
+	return fields
+}
+
+func tagName(tag string) string {
+	name, _, _ := strings.Cut(tag, ",")
+	return name
+}
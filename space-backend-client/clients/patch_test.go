@@ -0,0 +1,128 @@
+package clients
+
+import (
+	"api/database"
+	"api/schemas"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func decodePatch(t *testing.T, body string) map[string]json.RawMessage {
+	t.Helper()
+
+	patch := map[string]json.RawMessage{}
+	if err := json.Unmarshal([]byte(body), &patch); err != nil {
+		t.Fatal(err)
+	}
+	return patch
+}
+
+func TestMergeContactPatch(t *testing.T) {
+	contact := schemas.Contact{
+		Name:                    "Maria",
+		Email:                   "maria@example.com",
+		Complement:              "Apto 12",
+		CompanyName:             "Maria ME",
+		DifferentBillingAddress: true,
+		TinyID:                  "123",
+	}
+
+	merged, errs := mergeContactPatch(contact, decodePatch(t, `{"complement": null, "city": "Curitiba", "tiny_id": "999", "unknown": 1}`))
+	if errs != nil {
+		t.Fatalf("errs = %+v", errs)
+	}
+
+	if merged.Complement != "" || merged.City != "Curitiba" {
+		t.Errorf("complement/city = %q/%q, want cleared/set", merged.Complement, merged.City)
+	}
+	if merged.CompanyName != "Maria ME" || !merged.DifferentBillingAddress {
+		t.Errorf("absent fields changed: %+v", merged)
+	}
+	if merged.TinyID != "123" {
+		t.Errorf("tiny_id = %q, must not be patchable", merged.TinyID)
+	}
+
+	merged, _ = mergeContactPatch(contact, decodePatch(t, `{"different_billing_address": false}`))
+	if merged.DifferentBillingAddress {
+		t.Error("different_billing_address = true, want false")
+	}
+
+	fields := changedContactFields(contact, merged)
+	if !slices.Equal(fields, []string{"different_billing_address"}) {
+		t.Errorf("changed = %v, want [different_billing_address]", fields)
+	}
+}
+
+func TestMergeContactPatchRejectsClearingRequiredFields(t *testing.T) {
+	contact := schemas.Contact{Name: "Maria", Email: "maria@example.com"}
+
+	_, errs := mergeContactPatch(contact, decodePatch(t, `{"name": null, "email": ""}`))
+	if len(errs) != 2 {
+		t.Errorf("errs = %+v, want name and email", errs)
+	}
+}
+
+func TestUpdateWithoutChangesSkipsSync(t *testing.T) {
+	clientsRepository := setupRepositories(t)
+
+	clientsRepository.Insert(schemas.ClientFromDB{Contact: schemas.Contact{Name: "Rui", Email: "rui@example.com", City: "Curitiba"}})
+	client, _ := clientsRepository.FindByEmail(context.Background(), "rui@example.com")
+
+	// Sem alteração não há chamada ao Tiny, que falharia sem TINY_API_TOKEN
+	w := httptest.NewRecorder()
+	Handler(w, authenticatedRequest(http.MethodPatch, []byte(`{"city": "Curitiba", "complement": null}`), client.ID.Hex()))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+}
+
+func TestUpdateSavesPatchAndEnqueuesTinySync(t *testing.T) {
+	clientsRepository := setupRepositories(t)
+
+	clientsRepository.Insert(schemas.ClientFromDB{Contact: schemas.Contact{Name: "Lia", Email: "lia@example.com", Complement: "Casa 2", TinyID: "10"}})
+	client, _ := clientsRepository.FindByEmail(context.Background(), "lia@example.com")
+
+	w := httptest.NewRecorder()
+	Handler(w, authenticatedRequest(http.MethodPatch, []byte(`{"complement": null, "city": "Curitiba"}`), client.ID.Hex()))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	client, _ = clientsRepository.FindByID(context.Background(), client.ID)
+	if client.Contact.Complement != "" || client.Contact.City != "Curitiba" {
+		t.Errorf("contact = %+v, want complement cleared and city set", client.Contact)
+	}
+
+	jobs, _ := Repositories.TinySyncJobs.List(context.Background(), schemas.TINY_SYNC_PENDING, 0)
+	if len(jobs) != 1 || jobs[0].ClientID != client.ID {
+		t.Errorf("jobs = %+v, want one pending sync for the client", jobs)
+	}
+}
+
+// unavailableEmailLookup simula o banco fora do ar na checagem do email.
+type unavailableEmailLookup struct {
+	database.ClientsRepository
+}
+
+func (r unavailableEmailLookup) FindByEmail(ctx context.Context, email string) (schemas.ClientFromDB, error) {
+	return schemas.ClientFromDB{}, errors.New("mongo indisponível")
+}
+
+func TestPrepareContactPatchReturnsLookupErrors(t *testing.T) {
+	repositories := database.NewMemoryRepositories()
+	repositories.Clients = unavailableEmailLookup{repositories.Clients}
+
+	client := schemas.ClientFromDB{Contact: schemas.Contact{Name: "Ana", Email: "ana@example.com"}}
+	patch := decodePatch(t, `{"email": "ana.nova@example.com"}`)
+
+	_, _, _, err := PrepareContactPatch(context.Background(), repositories, client, patch, schemas.ClientUpdateRequest{})
+	if err == nil || err == ErrEmailTaken {
+		t.Errorf("err = %v, want the lookup error", err)
+	}
+}
@@ -0,0 +1,190 @@
+package clients
+
+import (
+	"api/audit"
+	"api/database"
+	"api/middlewares"
+	"api/privacy"
+	"api/schemas"
+	"api/utils"
+	"api/validation"
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// currentClient carrega o cliente autenticado, já escrevendo a resposta de
+// erro quando não consegue.
+func currentClient(ctx context.Context, w http.ResponseWriter, r *http.Request) (schemas.ClientFromDB, bool) {
+	userIdStr, _ := r.Context().Value(middlewares.UserIDKey).(string)
+	objectId, err := utils.ParseObjectIDFromHex(userIdStr)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.INVALID_USER_ID_FORMAT),
+		})
+		return schemas.ClientFromDB{}, false
+	}
+
+	client, err := Repositories.Clients.FindByID(ctx, objectId)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(schemas.ApiResponse{
+				Message: "Cliente não encontrado",
+			})
+			return client, false
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.ERROR_TO_TRY_FIND_MONGODB),
+		})
+		return client, false
+	}
+
+	return client, true
+}
+
+// HandlerExport entrega ao cliente os próprios dados (portabilidade, LGPD):
+// JSON por padrão ou ZIP com ?format=zip.
+func HandlerExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.HTTP_METHOD_NO_ALLOWED),
+		})
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "json"
+	}
+	if format != "json" && format != "zip" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: "Formato inválido, use json ou zip",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGODB_TIMEOUT)
+	defer cancel()
+
+	client, ok := currentClient(ctx, w, r)
+	if !ok {
+		return
+	}
+
+	export, err := privacy.Export(ctx, Repositories, client)
+	if err != nil {
+		log.Printf("[Export] Erro ao exportar os dados do cliente %s: %v", client.ID.Hex(), err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.ERROR_TO_TRY_FIND_MONGODB),
+		})
+		return
+	}
+
+	audit.Record(ctx, Repositories.AuditEvents, r, audit.Entry{
+		Action:     schemas.AUDIT_ACTION_CLIENT_EXPORT,
+		TargetType: schemas.AUDIT_TARGET_CLIENT,
+		TargetID:   client.ID.Hex(),
+		Metadata:   map[string]any{"format": format},
+	})
+
+	filename := "meus-dados-" + export.GeneratedAt.Format("2006-01-02")
+	w.Header().Set("Cache-Control", "no-store")
+	if format == "zip" {
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`.zip"`)
+		if err := privacy.WriteArchive(w, export); err != nil {
+			log.Printf("[Export] Erro ao gravar o ZIP do cliente %s: %v", client.ID.Hex(), err)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`.json"`)
+	json.NewEncoder(w).Encode(schemas.ApiResponse{
+		Data: export,
+	})
+}
+
+// HandlerErasure atende o pedido de eliminação dos dados (LGPD): confirmada a
+// senha, os dados pessoais são anonimizados, as sessões encerradas e a conta
+// deixa de existir para o login. Os pedidos continuam ligados ao cadastro.
+func HandlerErasure(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.HTTP_METHOD_NO_ALLOWED),
+		})
+		return
+	}
+
+	req := schemas.ClientErasureRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.CLIENTS_INVALID_REQUEST_DATA),
+		})
+		return
+	}
+
+	if errs := validation.Validate(req); errs != nil {
+		validation.WriteErrors(w, errs)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGODB_TIMEOUT)
+	defer cancel()
+
+	client, ok := currentClient(ctx, w, r)
+	if !ok {
+		return
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(client.PasswordHash), []byte(req.Password)) != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: "Senha incorreta",
+		})
+		return
+	}
+
+	result, err := privacy.Anonymize(ctx, Repositories, client)
+	if err != nil {
+		log.Printf("[Erasure] Erro ao anonimizar o cliente %s: %v", client.ID.Hex(), err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.ERROR_TO_UPDATE_CLIENT_TO_MONGODB),
+		})
+		return
+	}
+
+	// Só as contagens: o evento não pode guardar os dados que foram apagados
+	audit.Record(ctx, Repositories.AuditEvents, r, audit.Entry{
+		Action:     schemas.AUDIT_ACTION_CLIENT_ANONYMIZE,
+		TargetType: schemas.AUDIT_TARGET_CLIENT,
+		TargetID:   client.ID.Hex(),
+		Metadata: map[string]any{
+			"uniforms":        result.Uniforms,
+			"players":         result.Players,
+			"whatsapp_events": result.WhatsappEvents,
+			"audit_events":    result.AuditEvents,
+		},
+	})
+
+	middlewares.ClearAuthCookies(w)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(schemas.ApiResponse{
+		Message: "Dados pessoais anonimizados",
+		Data:    result,
+	})
+}
@@ -0,0 +1,56 @@
+package clients
+
+import (
+	"api/schemas"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestErasureRequiresPassword(t *testing.T) {
+	clientsRepository := setupRepositories(t)
+
+	hash, _ := bcrypt.GenerateFromPassword([]byte("senha-segura"), bcrypt.MinCost)
+	clientsRepository.Insert(schemas.ClientFromDB{
+		Contact:      schemas.Contact{Name: "Maria", Email: "maria@example.com", CPF: "52998224725"},
+		PasswordHash: string(hash),
+		BudgetIDs:    []int{42},
+	})
+	client, _ := clientsRepository.FindByEmail(context.Background(), "maria@example.com")
+
+	erase := func(password string) int {
+		body, _ := json.Marshal(schemas.ClientErasureRequest{Password: password})
+		w := httptest.NewRecorder()
+		HandlerErasure(w, authenticatedRequest(http.MethodPost, body, client.ID.Hex()))
+		return w.Code
+	}
+
+	if code := erase("senha-errada"); code != http.StatusUnauthorized {
+		t.Fatalf("wrong password: status = %d, want %d", code, http.StatusUnauthorized)
+	}
+	if code := erase("senha-segura"); code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", code, http.StatusOK)
+	}
+
+	anonymized, _ := clientsRepository.FindByID(context.Background(), client.ID)
+	if anonymized.AnonymizedAt == nil || anonymized.Contact.CPF != "" || len(anonymized.BudgetIDs) != 1 {
+		t.Errorf("client = %+v, want anonymized with the budget kept", anonymized)
+	}
+}
+
+func TestExportRejectsUnknownFormat(t *testing.T) {
+	setupRepositories(t)
+
+	r := authenticatedRequest(http.MethodGet, nil, "")
+	r.URL.RawQuery = "format=pdf"
+	w := httptest.NewRecorder()
+	HandlerExport(w, r)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
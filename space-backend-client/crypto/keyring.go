@@ -0,0 +1,332 @@
+// Package crypto cifra campos sensíveis dos documentos com envelope
+// encryption: cada valor é cifrado em AES-256-GCM por uma chave de dados, e as
+// chaves de dados ficam no banco cifradas pela chave mestra da configuração.
+// Também calcula os blind indexes que permitem buscar por igualdade sem
+// guardar o valor aberto.
+package crypto
+
+import (
+	"api/schemas"
+	"api/utils"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Valores cifrados têm o formato "enc:v1:<id da chave>:<nonce+ciphertext em
+// base64>". Valores sem o prefixo são texto aberto gravado antes da
+// criptografia e são devolvidos como estão.
+const (
+	CIPHERTEXT_PREFIX = "enc:v1:"
+	KEY_SIZE          = 32
+)
+
+var (
+	ErrInvalidMasterKey  = errors.New("a chave mestra deve ter 32 bytes em base64")
+	ErrNoActiveKey       = errors.New("nenhuma chave de dados carregada")
+	ErrUnknownDataKey    = errors.New("chave de dados desconhecida")
+	ErrInvalidCiphertext = errors.New("valor cifrado inválido")
+)
+
+// KeyStore guarda as chaves de dados cifradas.
+type KeyStore interface {
+	FindAll(ctx context.Context) ([]schemas.DataKey, error)
+	Insert(ctx context.Context, key schemas.DataKey) error
+}
+
+// Keyring abre as chaves de dados com a chave mestra e as mantém em memória.
+// Novos valores são cifrados com a chave ativa (a mais recente).
+type Keyring struct {
+	master   cipher.AEAD
+	indexKey []byte
+	store    KeyStore
+
+	mu     sync.RWMutex
+	keys   map[string]cipher.AEAD
+	active string
+}
+
+// LoadKeyringFromEnv carrega o keyring com FIELD_ENCRYPTION_KEY, criando a
+// primeira chave de dados se a coleção estiver vazia. Retorna nil sem erro
+// quando a chave mestra não está configurada.
+func LoadKeyringFromEnv(ctx context.Context, store KeyStore) (*Keyring, error) {
+	encoded := os.Getenv(utils.FIELD_ENCRYPTION_KEY)
+	if encoded == "" {
+		return nil, nil
+	}
+
+	master, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidMasterKey
+	}
+
+	k, err := NewKeyring(master, store)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := k.Load(ctx); err != nil {
+		return nil, err
+	}
+
+	return k, nil
+}
+
+func NewKeyring(master []byte, store KeyStore) (*Keyring, error) {
+	if len(master) != KEY_SIZE {
+		return nil, ErrInvalidMasterKey
+	}
+
+	aead, err := newAEAD(master)
+	if err != nil {
+		return nil, err
+	}
+
+	// A chave dos blind indexes é derivada da mestra e não muda com a rotação
+	// das chaves de dados, senão as buscas deixariam de encontrar os valores
+	// antigos
+	mac := hmac.New(sha256.New, master)
+	mac.Write([]byte("blind-index"))
+
+	return &Keyring{
+		master:   aead,
+		indexKey: mac.Sum(nil),
+		store:    store,
+		keys:     make(map[string]cipher.AEAD),
+	}, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Load lê as chaves de dados do store e cria a primeira se não houver nenhuma.
+func (k *Keyring) Load(ctx context.Context) error {
+	if err := k.reload(ctx); err != nil {
+		return err
+	}
+
+	if k.ActiveKeyID() == "" {
+		_, err := k.Rotate(ctx)
+		return err
+	}
+
+	return nil
+}
+
+func (k *Keyring) reload(ctx context.Context) error {
+	stored, err := k.store.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	keys := make(map[string]cipher.AEAD, len(stored))
+	active, activeAt := "", time.Time{}
+	for _, dataKey := range stored {
+		raw, err := k.unwrap(dataKey)
+		if err != nil {
+			return fmt.Errorf("chave de dados %s: %w", dataKey.ID, err)
+		}
+
+		aead, err := newAEAD(raw)
+		if err != nil {
+			return err
+		}
+
+		keys[dataKey.ID] = aead
+		if active == "" || dataKey.CreatedAt.After(activeAt) {
+			active, activeAt = dataKey.ID, dataKey.CreatedAt
+		}
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.keys = keys
+	k.active = active
+	return nil
+}
+
+// Rotate cria uma chave de dados e passa a usá-la nos novos valores. As
+// anteriores continuam abrindo o que já foi gravado; a troca dos valores
+// antigos fica por conta de quem guarda os documentos.
+func (k *Keyring) Rotate(ctx context.Context) (string, error) {
+	raw := make([]byte, KEY_SIZE)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+
+	dataKey := schemas.DataKey{
+		ID:        hex.EncodeToString(id),
+		CreatedAt: time.Now(),
+	}
+	dataKey.WrappedKey = k.seal(k.master, raw, []byte(dataKey.ID))
+
+	aead, err := newAEAD(raw)
+	if err != nil {
+		return "", err
+	}
+
+	if err := k.store.Insert(ctx, dataKey); err != nil {
+		return "", err
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.keys[dataKey.ID] = aead
+	k.active = dataKey.ID
+	return dataKey.ID, nil
+}
+
+func (k *Keyring) unwrap(dataKey schemas.DataKey) ([]byte, error) {
+	return k.open(k.master, dataKey.WrappedKey, []byte(dataKey.ID))
+}
+
+// ActiveKeyID é o ID da chave usada para cifrar novos valores.
+func (k *Keyring) ActiveKeyID() string {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	return k.active
+}
+
+// Encrypt cifra o valor com a chave ativa. O nome do campo entra como dado
+// associado, então um valor copiado para outro campo não abre. Valores vazios
+// continuam vazios.
+func (k *Keyring) Encrypt(field string, plaintext string) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+
+	k.mu.RLock()
+	id, aead := k.active, k.keys[k.active]
+	k.mu.RUnlock()
+
+	if aead == nil {
+		return "", ErrNoActiveKey
+	}
+
+	sealed := k.seal(aead, []byte(plaintext), []byte(field))
+	return CIPHERTEXT_PREFIX + id + ":" + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt abre um valor gerado por Encrypt. Quando a chave não é conhecida
+// (criada por outra instância depois da carga), as chaves são relidas do
+// store antes de desistir.
+func (k *Keyring) Decrypt(ctx context.Context, field string, value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+
+	id, encoded, ok := strings.Cut(strings.TrimPrefix(value, CIPHERTEXT_PREFIX), ":")
+	if !ok {
+		return "", ErrInvalidCiphertext
+	}
+
+	sealed, err := base64.RawStdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", ErrInvalidCiphertext
+	}
+
+	aead, err := k.dataKey(ctx, id)
+	if err != nil {
+		return "", err
+	}
+
+	plaintext, err := k.open(aead, sealed, []byte(field))
+	if err != nil {
+		return "", err
+	}
+
+	return string(plaintext), nil
+}
+
+func (k *Keyring) dataKey(ctx context.Context, id string) (cipher.AEAD, error) {
+	k.mu.RLock()
+	aead, ok := k.keys[id]
+	k.mu.RUnlock()
+
+	if ok {
+		return aead, nil
+	}
+
+	if err := k.reload(ctx); err != nil {
+		return nil, err
+	}
+
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	aead, ok = k.keys[id]
+	if !ok {
+		return nil, ErrUnknownDataKey
+	}
+	return aead, nil
+}
+
+// seal devolve o nonce seguido do ciphertext.
+func (k *Keyring) seal(aead cipher.AEAD, plaintext []byte, additionalData []byte) []byte {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		panic("crypto/rand falhou: " + err.Error())
+	}
+	return aead.Seal(nonce, nonce, plaintext, additionalData)
+}
+
+func (k *Keyring) open(aead cipher.AEAD, sealed []byte, additionalData []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, ErrInvalidCiphertext
+	}
+
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, additionalData)
+	if err != nil {
+		return nil, ErrInvalidCiphertext
+	}
+	return plaintext, nil
+}
+
+// BlindIndex é o HMAC-SHA256 do valor, separado por campo: o mesmo valor
+// sempre gera o mesmo índice, que não revela o valor sem a chave mestra.
+func (k *Keyring) BlindIndex(field string, value string) string {
+	if value == "" {
+		return ""
+	}
+
+	mac := hmac.New(sha256.New, k.indexKey)
+	mac.Write([]byte(field))
+	mac.Write([]byte{0})
+	mac.Write([]byte(value))
+	return base64.RawStdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// IsEncrypted diz se o valor foi gerado por Encrypt.
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, CIPHERTEXT_PREFIX)
+}
+
+// EncryptedWith diz se o valor foi cifrado com a chave informada.
+func EncryptedWith(value string, keyID string) bool {
+	return strings.HasPrefix(value, CIPHERTEXT_PREFIX+keyID+":")
+}
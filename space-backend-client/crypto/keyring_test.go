@@ -0,0 +1,163 @@
+package crypto
+
+import (
+	"api/schemas"
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type memoryKeyStore struct {
+	mu   sync.Mutex
+	keys []schemas.DataKey
+}
+
+func (s *memoryKeyStore) FindAll(ctx context.Context) ([]schemas.DataKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]schemas.DataKey(nil), s.keys...), nil
+}
+
+func (s *memoryKeyStore) Insert(ctx context.Context, key schemas.DataKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.keys = append(s.keys, key)
+	return nil
+}
+
+func newTestKeyring(t *testing.T, master []byte, store KeyStore) *Keyring {
+	t.Helper()
+
+	k, err := NewKeyring(master, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := k.Load(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+func TestEncryptDecryptRoundTrip(t *testing.T) {
+	k := newTestKeyring(t, bytes.Repeat([]byte{1}, KEY_SIZE), &memoryKeyStore{})
+
+	encrypted, err := k.Encrypt("cpf", "52998224725")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsEncrypted(encrypted) || strings.Contains(encrypted, "52998224725") {
+		t.Fatalf("encrypted = %q, want an opaque value", encrypted)
+	}
+	if again, _ := k.Encrypt("cpf", "52998224725"); again == encrypted {
+		t.Error("encrypting twice gave the same value, want a fresh nonce")
+	}
+
+	plaintext, err := k.Decrypt(t.Context(), "cpf", encrypted)
+	if err != nil || plaintext != "52998224725" {
+		t.Errorf("Decrypt = %q, %v, want the CPF", plaintext, err)
+	}
+
+	// O campo é dado associado: o valor copiado para outro campo não abre
+	if _, err := k.Decrypt(t.Context(), "cnpj", encrypted); !errors.Is(err, ErrInvalidCiphertext) {
+		t.Errorf("Decrypt in another field err = %v, want ErrInvalidCiphertext", err)
+	}
+
+	// Valores gravados antes da criptografia passam direto
+	if plaintext, _ := k.Decrypt(t.Context(), "cpf", "52998224725"); plaintext != "52998224725" {
+		t.Errorf("Decrypt of plaintext = %q, want it unchanged", plaintext)
+	}
+	if encrypted, _ := k.Encrypt("cpf", ""); encrypted != "" {
+		t.Errorf("Encrypt of empty = %q, want empty", encrypted)
+	}
+}
+
+func TestRotateKeepsOldValuesReadable(t *testing.T) {
+	store := &memoryKeyStore{}
+	k := newTestKeyring(t, bytes.Repeat([]byte{2}, KEY_SIZE), store)
+	first := k.ActiveKeyID()
+
+	old, _ := k.Encrypt("identity_card", "12.345.678-9")
+
+	second, err := k.Rotate(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second == first || k.ActiveKeyID() != second || len(store.keys) != 2 {
+		t.Fatalf("active = %s after rotating from %s, keys = %d", k.ActiveKeyID(), first, len(store.keys))
+	}
+
+	if plaintext, err := k.Decrypt(t.Context(), "identity_card", old); err != nil || plaintext != "12.345.678-9" {
+		t.Errorf("Decrypt of old value = %q, %v", plaintext, err)
+	}
+	if encrypted, _ := k.Encrypt("identity_card", "12.345.678-9"); !EncryptedWith(encrypted, second) {
+		t.Errorf("new value %q not encrypted with %s", encrypted, second)
+	}
+
+	// Outra instância carrega as duas chaves e usa a mais recente
+	other := newTestKeyring(t, bytes.Repeat([]byte{2}, KEY_SIZE), store)
+	if other.ActiveKeyID() != second {
+		t.Errorf("other active = %s, want %s", other.ActiveKeyID(), second)
+	}
+}
+
+func TestDecryptReloadsKeysRotatedElsewhere(t *testing.T) {
+	master := bytes.Repeat([]byte{3}, KEY_SIZE)
+	store := &memoryKeyStore{}
+	a := newTestKeyring(t, master, store)
+	b := newTestKeyring(t, master, store)
+
+	if _, err := b.Rotate(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+	encrypted, _ := b.Encrypt("cnpj", "11222333000181")
+
+	if plaintext, err := a.Decrypt(t.Context(), "cnpj", encrypted); err != nil || plaintext != "11222333000181" {
+		t.Errorf("Decrypt = %q, %v, want the key reloaded from the store", plaintext, err)
+	}
+}
+
+func TestLoadRejectsWrongMasterKey(t *testing.T) {
+	store := &memoryKeyStore{}
+	newTestKeyring(t, bytes.Repeat([]byte{4}, KEY_SIZE), store)
+
+	k, err := NewKeyring(bytes.Repeat([]byte{5}, KEY_SIZE), store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := k.Load(t.Context()); !errors.Is(err, ErrInvalidCiphertext) {
+		t.Errorf("Load err = %v, want ErrInvalidCiphertext", err)
+	}
+
+	if _, err := NewKeyring([]byte("curta"), store); err != ErrInvalidMasterKey {
+		t.Errorf("NewKeyring err = %v, want ErrInvalidMasterKey", err)
+	}
+}
+
+func TestBlindIndex(t *testing.T) {
+	master := bytes.Repeat([]byte{6}, KEY_SIZE)
+	k := newTestKeyring(t, master, &memoryKeyStore{})
+	index := k.BlindIndex("cpf", "52998224725")
+
+	if index == "" || strings.Contains(index, "52998224725") {
+		t.Fatalf("index = %q, want an opaque value", index)
+	}
+	if k.BlindIndex("cnpj", "52998224725") == index {
+		t.Error("the same value in another field gave the same index")
+	}
+
+	// O índice não depende da chave de dados ativa
+	k.Rotate(t.Context())
+	if k.BlindIndex("cpf", "52998224725") != index {
+		t.Error("index changed after rotating the data key")
+	}
+
+	other := newTestKeyring(t, bytes.Repeat([]byte{7}, KEY_SIZE), &memoryKeyStore{})
+	if other.BlindIndex("cpf", "52998224725") == index {
+		t.Error("another master key gave the same index")
+	}
+}
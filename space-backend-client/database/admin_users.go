@@ -0,0 +1,117 @@
+package database
+
+import (
+	"api/schemas"
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+var ErrAdminEmailTaken = errors.New("admin email already registered")
+
+// AdminUsersRepository encapsula a coleção "admin_users", com a equipe interna
+// que acessa as rotas /v1/admin. Emails são gravados em minúsculas e são
+// únicos (índice criado em RunMigrations).
+type AdminUsersRepository interface {
+	FindByID(ctx context.Context, id bson.ObjectID) (schemas.AdminUser, error)
+	FindByEmail(ctx context.Context, email string) (schemas.AdminUser, error)
+	List(ctx context.Context) ([]schemas.AdminUser, error)
+	Count(ctx context.Context) (int64, error)
+	// Create retorna ErrAdminEmailTaken se o email já estiver cadastrado.
+	Create(ctx context.Context, user schemas.AdminUser) (bson.ObjectID, error)
+	Update(ctx context.Context, id bson.ObjectID, update schemas.AdminUserUpdate) error
+	SetLastLogin(ctx context.Context, id bson.ObjectID, at time.Time) error
+}
+
+func NormalizeAdminEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+type MongoAdminUsersRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoAdminUsersRepository(collection *mongo.Collection) *MongoAdminUsersRepository {
+	return &MongoAdminUsersRepository{collection: collection}
+}
+
+func (r *MongoAdminUsersRepository) FindByID(ctx context.Context, id bson.ObjectID) (schemas.AdminUser, error) {
+	user := schemas.AdminUser{}
+	err := r.collection.FindOne(ctx, bson.D{{Key: "_id", Value: id}}).Decode(&user)
+	return user, err
+}
+
+func (r *MongoAdminUsersRepository) FindByEmail(ctx context.Context, email string) (schemas.AdminUser, error) {
+	user := schemas.AdminUser{}
+	err := r.collection.FindOne(ctx, bson.D{{Key: "email", Value: NormalizeAdminEmail(email)}}).Decode(&user)
+	return user, err
+}
+
+func (r *MongoAdminUsersRepository) List(ctx context.Context) ([]schemas.AdminUser, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, bson.D{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	users := []schemas.AdminUser{}
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+func (r *MongoAdminUsersRepository) Count(ctx context.Context) (int64, error) {
+	return r.collection.CountDocuments(ctx, bson.D{})
+}
+
+func (r *MongoAdminUsersRepository) Create(ctx context.Context, user schemas.AdminUser) (bson.ObjectID, error) {
+	user.ID = bson.NewObjectID()
+	user.Email = NormalizeAdminEmail(user.Email)
+
+	_, err := r.collection.InsertOne(ctx, user)
+	if mongo.IsDuplicateKeyError(err) {
+		return bson.ObjectID{}, ErrAdminEmailTaken
+	}
+	if err != nil {
+		return bson.ObjectID{}, err
+	}
+	return user.ID, nil
+}
+
+func (r *MongoAdminUsersRepository) Update(ctx context.Context, id bson.ObjectID, update schemas.AdminUserUpdate) error {
+	set := bson.D{{Key: "updated_at", Value: time.Now()}}
+	if update.Role != nil {
+		set = append(set, bson.E{Key: "role", Value: *update.Role})
+	}
+	if update.Active != nil {
+		set = append(set, bson.E{Key: "active", Value: *update.Active})
+	}
+	if update.PasswordHash != nil {
+		set = append(set, bson.E{Key: "password_hash", Value: *update.PasswordHash})
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.D{{Key: "_id", Value: id}}, bson.D{{Key: "$set", Value: set}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (r *MongoAdminUsersRepository) SetLastLogin(ctx context.Context, id bson.ObjectID, at time.Time) error {
+	_, err := r.collection.UpdateOne(ctx,
+		bson.D{{Key: "_id", Value: id}},
+		bson.D{{Key: "$set", Value: bson.D{{Key: "last_login_at", Value: at}}}},
+	)
+	return err
+}
@@ -0,0 +1,112 @@
+package database
+
+import (
+	"api/schemas"
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// ApiKeysRepository encapsula a coleção "api_keys". Chaves revogadas ou
+// expiradas continuam na coleção para auditoria.
+type ApiKeysRepository interface {
+	Create(ctx context.Context, key schemas.ApiKey) (bson.ObjectID, error)
+	FindByID(ctx context.Context, id bson.ObjectID) (schemas.ApiKey, error)
+	FindByPrefix(ctx context.Context, prefix string) (schemas.ApiKey, error)
+	List(ctx context.Context) ([]schemas.ApiKey, error)
+	// Revoke retorna mongo.ErrNoDocuments se a chave não existir ou já estiver
+	// revogada.
+	Revoke(ctx context.Context, id bson.ObjectID, at time.Time) error
+	// MarkRotated antecipa a expiração da chave antiga para expiresAt e
+	// registra a chave que a substituiu.
+	MarkRotated(ctx context.Context, id bson.ObjectID, rotatedTo bson.ObjectID, expiresAt time.Time) error
+	Touch(ctx context.Context, id bson.ObjectID, at time.Time) error
+}
+
+type MongoApiKeysRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoApiKeysRepository(collection *mongo.Collection) *MongoApiKeysRepository {
+	return &MongoApiKeysRepository{collection: collection}
+}
+
+func (r *MongoApiKeysRepository) Create(ctx context.Context, key schemas.ApiKey) (bson.ObjectID, error) {
+	key.ID = bson.NewObjectID()
+	_, err := r.collection.InsertOne(ctx, key)
+	if err != nil {
+		return bson.ObjectID{}, err
+	}
+	return key.ID, nil
+}
+
+func (r *MongoApiKeysRepository) FindByID(ctx context.Context, id bson.ObjectID) (schemas.ApiKey, error) {
+	key := schemas.ApiKey{}
+	err := r.collection.FindOne(ctx, bson.D{{Key: "_id", Value: id}}).Decode(&key)
+	return key, err
+}
+
+func (r *MongoApiKeysRepository) FindByPrefix(ctx context.Context, prefix string) (schemas.ApiKey, error) {
+	key := schemas.ApiKey{}
+	err := r.collection.FindOne(ctx, bson.D{{Key: "prefix", Value: prefix}}).Decode(&key)
+	return key, err
+}
+
+func (r *MongoApiKeysRepository) List(ctx context.Context) ([]schemas.ApiKey, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	cursor, err := r.collection.Find(ctx, bson.D{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	keys := []schemas.ApiKey{}
+	if err := cursor.All(ctx, &keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func (r *MongoApiKeysRepository) Revoke(ctx context.Context, id bson.ObjectID, at time.Time) error {
+	filter := bson.D{
+		{Key: "_id", Value: id},
+		{Key: "revoked_at", Value: bson.D{{Key: "$exists", Value: false}}},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, bson.D{{Key: "$set", Value: bson.D{{Key: "revoked_at", Value: at}}}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (r *MongoApiKeysRepository) MarkRotated(ctx context.Context, id bson.ObjectID, rotatedTo bson.ObjectID, expiresAt time.Time) error {
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: "rotated_to", Value: rotatedTo},
+		{Key: "expires_at", Value: expiresAt},
+	}}}
+
+	result, err := r.collection.UpdateOne(ctx, bson.D{{Key: "_id", Value: id}}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (r *MongoApiKeysRepository) Touch(ctx context.Context, id bson.ObjectID, at time.Time) error {
+	_, err := r.collection.UpdateOne(ctx,
+		bson.D{{Key: "_id", Value: id}},
+		bson.D{{Key: "$set", Value: bson.D{{Key: "last_used_at", Value: at}}}},
+	)
+	return err
+}
@@ -0,0 +1,145 @@
+package database
+
+import (
+	"api/schemas"
+	"context"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// AuditEventsRepository encapsula a coleção "audit_events". Eventos nunca são
+// removidos, e as únicas alterações permitidas são RedactChanges e
+// RedactMetadata.
+type AuditEventsRepository interface {
+	Insert(ctx context.Context, event schemas.AuditEvent) error
+	// Find retorna os eventos do mais recente para o mais antigo.
+	Find(ctx context.Context, filter schemas.AuditEventFilter) ([]schemas.AuditEvent, error)
+	// RedactChanges troca por marker os valores antes/depois dos campos
+	// informados nos eventos do alvo, mantendo o registro de que mudaram. É
+	// usado na anonimização (LGPD) e retorna quantos eventos foram alterados.
+	RedactChanges(ctx context.Context, targetType string, targetID string, fields []string, marker string) (int, error)
+	// RedactMetadata troca por marker as chaves informadas do metadata nos
+	// eventos do autor sobre alvos do tipo informado (ex.: as sessões do
+	// cliente). Chaves ausentes continuam ausentes.
+	RedactMetadata(ctx context.Context, targetType string, actorID string, keys []string, marker string) (int, error)
+}
+
+type MongoAuditEventsRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoAuditEventsRepository(collection *mongo.Collection) *MongoAuditEventsRepository {
+	return &MongoAuditEventsRepository{collection: collection}
+}
+
+func (r *MongoAuditEventsRepository) Insert(ctx context.Context, event schemas.AuditEvent) error {
+	_, err := r.collection.InsertOne(ctx, event)
+	return err
+}
+
+func (r *MongoAuditEventsRepository) Find(ctx context.Context, filter schemas.AuditEventFilter) ([]schemas.AuditEvent, error) {
+	query := bson.D{}
+	for _, field := range []struct{ key, value string }{
+		{"actor_type", filter.ActorType},
+		{"actor_id", filter.ActorID},
+		{"action", filter.Action},
+		{"target_type", filter.TargetType},
+		{"target_id", filter.TargetID},
+	} {
+		if field.value != "" {
+			query = append(query, bson.E{Key: field.key, Value: field.value})
+		}
+	}
+
+	createdAt := bson.D{}
+	if !filter.From.IsZero() {
+		createdAt = append(createdAt, bson.E{Key: "$gte", Value: filter.From})
+	}
+	if !filter.To.IsZero() {
+		createdAt = append(createdAt, bson.E{Key: "$lt", Value: filter.To})
+	}
+	if len(createdAt) > 0 {
+		query = append(query, bson.E{Key: "created_at", Value: createdAt})
+	}
+
+	if !filter.BeforeID.IsZero() {
+		query = append(query, bson.E{Key: "_id", Value: bson.D{{Key: "$lt", Value: filter.BeforeID}}})
+	}
+
+	// O ObjectID cresce com o horário de inserção, então serve de cursor
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: -1}}).SetLimit(filter.Limit)
+
+	cursor, err := r.collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	events := []schemas.AuditEvent{}
+	if err := cursor.All(ctx, &events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func (r *MongoAuditEventsRepository) RedactChanges(ctx context.Context, targetType string, targetID string, fields []string, marker string) (int, error) {
+	filter := bson.D{
+		{Key: "target_type", Value: targetType},
+		{Key: "target_id", Value: targetID},
+		{Key: "changes.field", Value: bson.D{{Key: "$in", Value: fields}}},
+	}
+	// Valores ausentes (campo criado ou removido) continuam ausentes
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: "changes.$[before].before", Value: marker},
+		{Key: "changes.$[after].after", Value: marker},
+	}}}
+	opts := options.UpdateMany().SetArrayFilters([]any{
+		bson.D{
+			{Key: "before.field", Value: bson.D{{Key: "$in", Value: fields}}},
+			{Key: "before.before", Value: bson.D{{Key: "$exists", Value: true}}},
+		},
+		bson.D{
+			{Key: "after.field", Value: bson.D{{Key: "$in", Value: fields}}},
+			{Key: "after.after", Value: bson.D{{Key: "$exists", Value: true}}},
+		},
+	})
+
+	result, err := r.collection.UpdateMany(ctx, filter, update, opts)
+	if err != nil {
+		return 0, err
+	}
+	return int(result.ModifiedCount), nil
+}
+
+func (r *MongoAuditEventsRepository) RedactMetadata(ctx context.Context, targetType string, actorID string, keys []string, marker string) (int, error) {
+	pending := bson.A{}
+	fields := bson.D{}
+	for _, key := range keys {
+		path := "metadata." + key
+		pending = append(pending, bson.D{{Key: path, Value: bson.D{
+			{Key: "$exists", Value: true},
+			{Key: "$ne", Value: marker},
+		}}})
+		// $$REMOVE mantém ausente a chave que o evento não tinha
+		fields = append(fields, bson.E{Key: path, Value: bson.D{{Key: "$cond", Value: bson.A{
+			bson.D{{Key: "$eq", Value: bson.A{bson.D{{Key: "$type", Value: "$" + path}}, "missing"}}},
+			"$$REMOVE",
+			bson.D{{Key: "$literal", Value: marker}},
+		}}}})
+	}
+
+	filter := bson.D{
+		{Key: "target_type", Value: targetType},
+		{Key: "actor_id", Value: actorID},
+		{Key: "$or", Value: pending},
+	}
+	update := mongo.Pipeline{{{Key: "$set", Value: fields}}}
+
+	result, err := r.collection.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return 0, err
+	}
+	return int(result.ModifiedCount), nil
+}
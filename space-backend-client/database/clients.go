@@ -0,0 +1,566 @@
+package database
+
+import (
+	"api/crypto"
+	"api/schemas"
+	"context"
+	"errors"
+	"log"
+	"slices"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// ErrClientEmailTaken é retornado quando o email do contato já pertence a
+// outro cliente (índice único em contact.email).
+var ErrClientEmailTaken = errors.New("client email already registered")
+
+// ClientsRepository encapsula a coleção "clients". Buscas sem resultado
+// retornam mongo.ErrNoDocuments, assim como as atualizações que não encontram
+// o documento. CPF, CNPJ e RG são gravados cifrados e chegam abertos a quem
+// usa o repositório.
+type ClientsRepository interface {
+	FindByID(ctx context.Context, id bson.ObjectID) (schemas.ClientFromDB, error)
+	FindByEmail(ctx context.Context, email string) (schemas.ClientFromDB, error)
+	FindByBudgetIDs(ctx context.Context, budgetIDs []int) ([]schemas.ClientFromDB, error)
+	// FindByTinyID retorna todos os clientes vinculados ao contato do Tiny;
+	// mais de um indica vínculo duplicado.
+	FindByTinyID(ctx context.Context, tinyID string) ([]schemas.ClientFromDB, error)
+	// ListVerified percorre os clientes com email confirmado em ordem de _id,
+	// a partir do cliente seguinte a after (ObjectID zero para o início).
+	ListVerified(ctx context.Context, after bson.ObjectID, limit int64) ([]schemas.ClientFromDB, error)
+	// Search é a busca administrativa. Lê só os campos seguros do cliente e
+	// retorna o cursor da próxima página quando a página vem cheia.
+	Search(ctx context.Context, filter schemas.ClientSearchFilter) ([]schemas.ClientFromDB, *schemas.ClientSearchCursor, error)
+	// Create retorna ErrClientEmailTaken quando o email já está em uso.
+	Create(ctx context.Context, client schemas.ClientCreateModel) (bson.ObjectID, error)
+	// PatchContact grava apenas os campos do contato listados em fields (chaves
+	// BSON): os preenchidos com $set e os vazios com $unset. Retorna
+	// ErrClientEmailTaken quando o novo email já está em uso.
+	PatchContact(ctx context.Context, id bson.ObjectID, contact schemas.Contact, fields []string) error
+	UpdatePasswordHash(ctx context.Context, id bson.ObjectID, passwordHash string) error
+	// AddBudgetID retorna false quando o orçamento já estava associado ao cliente.
+	AddBudgetID(ctx context.Context, email string, budgetID int) (bool, error)
+	// RemoveBudgetID retorna false quando o orçamento não estava associado ao
+	// cliente.
+	RemoveBudgetID(ctx context.Context, id bson.ObjectID, budgetID int) (bool, error)
+	SetStatus(ctx context.Context, id bson.ObjectID, status string) error
+	Delete(ctx context.Context, id bson.ObjectID) error
+	// Anonymize troca o contato pelo informado e apaga senha, tokens pendentes
+	// e 2FA, mantendo orçamentos e datas.
+	Anonymize(ctx context.Context, id bson.ObjectID, contact schemas.Contact, at time.Time) error
+	SetEmailVerification(ctx context.Context, id bson.ObjectID, verification schemas.PendingToken) error
+	// ResetEmailVerification volta o cliente para email não verificado com um
+	// novo token pendente; usado quando o email do contato muda.
+	ResetEmailVerification(ctx context.Context, id bson.ObjectID, verification schemas.PendingToken) error
+	// FindByEmailVerificationToken só encontra tokens ainda não expirados.
+	FindByEmailVerificationToken(ctx context.Context, tokenHash string) (schemas.ClientFromDB, error)
+	// MarkEmailVerified consome a verificação pendente com o token informado.
+	// Retorna mongo.ErrNoDocuments se o token já foi usado, expirou ou foi
+	// trocado (ex.: por uma nova mudança de email).
+	MarkEmailVerified(ctx context.Context, id bson.ObjectID, tokenHash string) error
+	SetPasswordReset(ctx context.Context, id bson.ObjectID, reset schemas.PendingToken) error
+	SetTwoFactor(ctx context.Context, id bson.ObjectID, twoFactor schemas.TwoFactor) error
+	RemoveTwoFactor(ctx context.Context, id bson.ObjectID) error
+	SetTwoFactorChallenge(ctx context.Context, id bson.ObjectID, challenge schemas.PendingToken) error
+	// FindByTwoFactorChallenge só encontra desafios ainda não expirados.
+	FindByTwoFactorChallenge(ctx context.Context, tokenHash string) (schemas.ClientFromDB, error)
+	// CompleteTwoFactorChallenge consome o desafio e grava o estado do TOTP
+	// (último passo usado, códigos de recuperação restantes). Retorna
+	// mongo.ErrNoDocuments se o desafio já tiver sido usado.
+	CompleteTwoFactorChallenge(ctx context.Context, id bson.ObjectID, tokenHash string, twoFactor schemas.TwoFactor) error
+	// ConsumePasswordReset troca a senha do cliente dono do token ainda válido e
+	// remove o reset pendente em uma operação, retornando o ID do cliente para
+	// que as sessões dele sejam encerradas.
+	ConsumePasswordReset(ctx context.Context, tokenHash string, passwordHash string) (bson.ObjectID, error)
+	// ReencryptContacts grava com a chave de dados ativa os documentos ainda
+	// em texto aberto ou cifrados por chaves anteriores, retornando quantos
+	// clientes foram alterados.
+	ReencryptContacts(ctx context.Context) (int, error)
+}
+
+// ensureUniqueClientEmails cria o índice único em contact.email, que garante o
+// email único mesmo com dois cadastros ou trocas de email ao mesmo tempo (a
+// consulta prévia dos handlers não basta). Enquanto houver emails duplicados
+// o índice não é criado: os clientes afetados vão para o log, para correção
+// manual, e o servidor sobe sem ele.
+func ensureUniqueClientEmails(ctx context.Context, collection *mongo.Collection) error {
+	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "contact.email", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if !mongo.IsDuplicateKeyError(err) {
+		return err
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: "$contact.email"},
+			{Key: "ids", Value: bson.D{{Key: "$push", Value: "$_id"}}},
+			{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}},
+		}}},
+		{{Key: "$match", Value: bson.D{{Key: "count", Value: bson.D{{Key: "$gt", Value: 1}}}}}},
+	}
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	duplicates := 0
+	for cursor.Next(ctx) {
+		group := struct {
+			IDs []bson.ObjectID `bson:"ids"`
+		}{}
+		if err := cursor.Decode(&group); err != nil {
+			return err
+		}
+		// Só os _id: o email é dado pessoal e não vai para o log
+		log.Printf("[Migrations] Clientes com o mesmo email: %v", group.IDs)
+		duplicates++
+	}
+	log.Printf("[Migrations] Índice único de email não criado: %d emails duplicados", duplicates)
+	return cursor.Err()
+}
+
+type MongoClientsRepository struct {
+	collection *mongo.Collection
+	keyring    *crypto.Keyring
+}
+
+// NewMongoClientsRepository recebe o keyring dos campos cifrados; com nil os
+// documentos são gravados abertos.
+func NewMongoClientsRepository(collection *mongo.Collection, keyring *crypto.Keyring) *MongoClientsRepository {
+	return &MongoClientsRepository{collection: collection, keyring: keyring}
+}
+
+func (r *MongoClientsRepository) FindByID(ctx context.Context, id bson.ObjectID) (schemas.ClientFromDB, error) {
+	return r.findOne(ctx, bson.D{{Key: "_id", Value: id}})
+}
+
+func (r *MongoClientsRepository) FindByEmail(ctx context.Context, email string) (schemas.ClientFromDB, error) {
+	return r.findOne(ctx, bson.D{{Key: "contact.email", Value: email}})
+}
+
+func (r *MongoClientsRepository) FindByBudgetIDs(ctx context.Context, budgetIDs []int) ([]schemas.ClientFromDB, error) {
+	return r.find(ctx, bson.D{{Key: "budget_ids", Value: bson.D{{Key: "$in", Value: budgetIDs}}}})
+}
+
+func (r *MongoClientsRepository) FindByTinyID(ctx context.Context, tinyID string) ([]schemas.ClientFromDB, error) {
+	return r.find(ctx, bson.D{{Key: "contact.tiny_id", Value: tinyID}})
+}
+
+func (r *MongoClientsRepository) ListVerified(ctx context.Context, after bson.ObjectID, limit int64) ([]schemas.ClientFromDB, error) {
+	filter := bson.D{{Key: "email_verified", Value: true}}
+	if !after.IsZero() {
+		filter = append(filter, bson.E{Key: "_id", Value: bson.D{{Key: "$gt", Value: after}}})
+	}
+	return r.find(ctx, filter, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetLimit(limit))
+}
+
+func (r *MongoClientsRepository) find(ctx context.Context, filter bson.D, opts ...options.Lister[options.FindOptions]) ([]schemas.ClientFromDB, error) {
+	cursor, err := r.collection.Find(ctx, filter, opts...)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var clients []schemas.ClientFromDB
+	if err := cursor.All(ctx, &clients); err != nil {
+		return nil, err
+	}
+	for i := range clients {
+		if err := r.openContact(ctx, &clients[i].Contact); err != nil {
+			return nil, err
+		}
+	}
+	return clients, nil
+}
+
+func (r *MongoClientsRepository) findOne(ctx context.Context, filter bson.D) (schemas.ClientFromDB, error) {
+	client := schemas.ClientFromDB{}
+	if err := r.collection.FindOne(ctx, filter).Decode(&client); err != nil {
+		return client, err
+	}
+	err := r.openContact(ctx, &client.Contact)
+	return client, err
+}
+
+func (r *MongoClientsRepository) Create(ctx context.Context, client schemas.ClientCreateModel) (bson.ObjectID, error) {
+	raw, err := bson.Marshal(client)
+	if err != nil {
+		return bson.ObjectID{}, err
+	}
+	doc := bson.D{}
+	if err := bson.Unmarshal(raw, &doc); err != nil {
+		return bson.ObjectID{}, err
+	}
+
+	contact, err := r.sealContact(client.Contact)
+	if err != nil {
+		return bson.ObjectID{}, err
+	}
+	for i := range doc {
+		if doc[i].Key == "contact" {
+			doc[i].Value = contact
+		}
+	}
+
+	result, err := r.collection.InsertOne(ctx, doc)
+	if mongo.IsDuplicateKeyError(err) {
+		return bson.ObjectID{}, ErrClientEmailTaken
+	}
+	if err != nil {
+		return bson.ObjectID{}, err
+	}
+
+	id, _ := result.InsertedID.(bson.ObjectID)
+	return id, nil
+}
+
+func (r *MongoClientsRepository) PatchContact(ctx context.Context, id bson.ObjectID, contact schemas.Contact, fields []string) error {
+	now := time.Now()
+	contact.UpdatedAt = now
+
+	// O documento serializado já omite os campos vazios (omitempty), então
+	// quem não aparece nele deve ser removido
+	sealed, err := r.sealContact(contact)
+	if err != nil {
+		return err
+	}
+	stored := bson.M{}
+	for _, elem := range sealed {
+		stored[elem.Key] = elem.Value
+	}
+
+	set := bson.D{
+		{Key: "contact.updated_at", Value: now},
+		{Key: "updated_at", Value: now},
+	}
+	unset := bson.D{}
+	for _, field := range fields {
+		keys := []string{field}
+		if slices.Contains(encryptedContactFields, field) {
+			keys = append(keys, field+BLIND_INDEX_SUFFIX)
+		}
+		for _, key := range keys {
+			if value, ok := stored[key]; ok {
+				set = append(set, bson.E{Key: "contact." + key, Value: value})
+			} else {
+				unset = append(unset, bson.E{Key: "contact." + key, Value: ""})
+			}
+		}
+	}
+
+	update := bson.D{{Key: "$set", Value: set}}
+	if len(unset) > 0 {
+		update = append(update, bson.E{Key: "$unset", Value: unset})
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.D{{Key: "_id", Value: id}}, update)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrClientEmailTaken
+	}
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (r *MongoClientsRepository) UpdatePasswordHash(ctx context.Context, id bson.ObjectID, passwordHash string) error {
+	return r.updateOne(ctx, id, bson.D{
+		{Key: "password_hash", Value: passwordHash},
+		{Key: "updated_at", Value: time.Now()},
+	})
+}
+
+func (r *MongoClientsRepository) AddBudgetID(ctx context.Context, email string, budgetID int) (bool, error) {
+	// O $set de updated_at sempre modifica o documento, então o filtro por
+	// budget_ids é o que indica se o orçamento já estava associado.
+	filter := bson.D{
+		{Key: "contact.email", Value: email},
+		{Key: "budget_ids", Value: bson.D{{Key: "$ne", Value: budgetID}}},
+	}
+	update := bson.D{
+		{Key: "$addToSet", Value: bson.D{
+			{Key: "budget_ids", Value: budgetID},
+		}},
+		{Key: "$set", Value: bson.D{
+			{Key: "updated_at", Value: time.Now()},
+		}},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+
+	if result.MatchedCount > 0 {
+		return true, nil
+	}
+
+	count, err := r.collection.CountDocuments(ctx, bson.D{{Key: "contact.email", Value: email}})
+	if err != nil {
+		return false, err
+	}
+
+	if count == 0 {
+		return false, mongo.ErrNoDocuments
+	}
+
+	return false, nil
+}
+
+func (r *MongoClientsRepository) RemoveBudgetID(ctx context.Context, id bson.ObjectID, budgetID int) (bool, error) {
+	// Como em AddBudgetID, o filtro por budget_ids diz se havia o que remover
+	filter := bson.D{
+		{Key: "_id", Value: id},
+		{Key: "budget_ids", Value: budgetID},
+	}
+	update := bson.D{
+		{Key: "$pull", Value: bson.D{
+			{Key: "budget_ids", Value: budgetID},
+		}},
+		{Key: "$set", Value: bson.D{
+			{Key: "updated_at", Value: time.Now()},
+		}},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+
+	if result.MatchedCount > 0 {
+		return true, nil
+	}
+
+	count, err := r.collection.CountDocuments(ctx, bson.D{{Key: "_id", Value: id}})
+	if err != nil {
+		return false, err
+	}
+
+	if count == 0 {
+		return false, mongo.ErrNoDocuments
+	}
+
+	return false, nil
+}
+
+func (r *MongoClientsRepository) SetStatus(ctx context.Context, id bson.ObjectID, status string) error {
+	return r.updateOne(ctx, id, bson.D{
+		{Key: "status", Value: status},
+		{Key: "updated_at", Value: time.Now()},
+	})
+}
+
+func (r *MongoClientsRepository) Delete(ctx context.Context, id bson.ObjectID) error {
+	result, err := r.collection.DeleteOne(ctx, bson.D{{Key: "_id", Value: id}})
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+func (r *MongoClientsRepository) Anonymize(ctx context.Context, id bson.ObjectID, contact schemas.Contact, at time.Time) error {
+	sealed, err := r.sealContact(contact)
+	if err != nil {
+		return err
+	}
+
+	update := bson.D{
+		{Key: "$set", Value: bson.D{
+			{Key: "contact", Value: sealed},
+			{Key: "password_hash", Value: ""},
+			{Key: "email_verified", Value: false},
+			{Key: "anonymized_at", Value: at},
+			{Key: "updated_at", Value: at},
+		}},
+		{Key: "$unset", Value: bson.D{
+			{Key: "email_verification", Value: ""},
+			{Key: "password_reset", Value: ""},
+			{Key: "two_factor", Value: ""},
+			{Key: "two_factor_challenge", Value: ""},
+		}},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.D{{Key: "_id", Value: id}}, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+func (r *MongoClientsRepository) SetEmailVerification(ctx context.Context, id bson.ObjectID, verification schemas.PendingToken) error {
+	return r.updateOne(ctx, id, bson.D{
+		{Key: "email_verification", Value: verification},
+		{Key: "updated_at", Value: time.Now()},
+	})
+}
+
+func (r *MongoClientsRepository) ResetEmailVerification(ctx context.Context, id bson.ObjectID, verification schemas.PendingToken) error {
+	return r.updateOne(ctx, id, bson.D{
+		{Key: "email_verified", Value: false},
+		{Key: "email_verification", Value: verification},
+		{Key: "updated_at", Value: time.Now()},
+	})
+}
+
+func (r *MongoClientsRepository) FindByEmailVerificationToken(ctx context.Context, tokenHash string) (schemas.ClientFromDB, error) {
+	filter := bson.D{
+		{Key: "email_verification.token_hash", Value: tokenHash},
+		{Key: "email_verification.expires_at", Value: bson.D{{Key: "$gt", Value: time.Now()}}},
+	}
+
+	return r.findOne(ctx, filter)
+}
+
+func (r *MongoClientsRepository) MarkEmailVerified(ctx context.Context, id bson.ObjectID, tokenHash string) error {
+	filter := bson.D{
+		{Key: "_id", Value: id},
+		{Key: "email_verification.token_hash", Value: tokenHash},
+		{Key: "email_verification.expires_at", Value: bson.D{{Key: "$gt", Value: time.Now()}}},
+	}
+	update := bson.D{
+		{Key: "$set", Value: bson.D{
+			{Key: "email_verified", Value: true},
+			{Key: "updated_at", Value: time.Now()},
+		}},
+		{Key: "$unset", Value: bson.D{{Key: "email_verification", Value: ""}}},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+func (r *MongoClientsRepository) SetPasswordReset(ctx context.Context, id bson.ObjectID, reset schemas.PendingToken) error {
+	return r.updateOne(ctx, id, bson.D{
+		{Key: "password_reset", Value: reset},
+		{Key: "updated_at", Value: time.Now()},
+	})
+}
+
+func (r *MongoClientsRepository) SetTwoFactor(ctx context.Context, id bson.ObjectID, twoFactor schemas.TwoFactor) error {
+	return r.updateOne(ctx, id, bson.D{
+		{Key: "two_factor", Value: twoFactor},
+		{Key: "updated_at", Value: time.Now()},
+	})
+}
+
+func (r *MongoClientsRepository) RemoveTwoFactor(ctx context.Context, id bson.ObjectID) error {
+	update := bson.D{
+		{Key: "$set", Value: bson.D{{Key: "updated_at", Value: time.Now()}}},
+		{Key: "$unset", Value: bson.D{
+			{Key: "two_factor", Value: ""},
+			{Key: "two_factor_challenge", Value: ""},
+		}},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.D{{Key: "_id", Value: id}}, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+func (r *MongoClientsRepository) SetTwoFactorChallenge(ctx context.Context, id bson.ObjectID, challenge schemas.PendingToken) error {
+	return r.updateOne(ctx, id, bson.D{
+		{Key: "two_factor_challenge", Value: challenge},
+	})
+}
+
+func (r *MongoClientsRepository) FindByTwoFactorChallenge(ctx context.Context, tokenHash string) (schemas.ClientFromDB, error) {
+	filter := bson.D{
+		{Key: "two_factor_challenge.token_hash", Value: tokenHash},
+		{Key: "two_factor_challenge.expires_at", Value: bson.D{{Key: "$gt", Value: time.Now()}}},
+	}
+
+	return r.findOne(ctx, filter)
+}
+
+func (r *MongoClientsRepository) CompleteTwoFactorChallenge(ctx context.Context, id bson.ObjectID, tokenHash string, twoFactor schemas.TwoFactor) error {
+	filter := bson.D{
+		{Key: "_id", Value: id},
+		{Key: "two_factor_challenge.token_hash", Value: tokenHash},
+	}
+	update := bson.D{
+		{Key: "$set", Value: bson.D{{Key: "two_factor", Value: twoFactor}}},
+		{Key: "$unset", Value: bson.D{{Key: "two_factor_challenge", Value: ""}}},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+func (r *MongoClientsRepository) ConsumePasswordReset(ctx context.Context, tokenHash string, passwordHash string) (bson.ObjectID, error) {
+	filter := bson.D{
+		{Key: "password_reset.token_hash", Value: tokenHash},
+		{Key: "password_reset.expires_at", Value: bson.D{{Key: "$gt", Value: time.Now()}}},
+	}
+	update := bson.D{
+		{Key: "$set", Value: bson.D{
+			{Key: "password_hash", Value: passwordHash},
+			{Key: "updated_at", Value: time.Now()},
+		}},
+		{Key: "$unset", Value: bson.D{
+			{Key: "password_reset", Value: ""},
+		}},
+	}
+	opts := options.FindOneAndUpdate().SetProjection(bson.D{{Key: "_id", Value: 1}})
+
+	var result struct {
+		ID bson.ObjectID `bson:"_id"`
+	}
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&result)
+	return result.ID, err
+}
+
+func (r *MongoClientsRepository) updateOne(ctx context.Context, id bson.ObjectID, set bson.D) error {
+	result, err := r.collection.UpdateOne(ctx, bson.D{{Key: "_id", Value: id}}, bson.D{{Key: "$set", Value: set}})
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
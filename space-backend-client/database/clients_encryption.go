@@ -0,0 +1,162 @@
+package database
+
+import (
+	"api/crypto"
+	"api/schemas"
+	"context"
+	"fmt"
+	"regexp"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// BLIND_INDEX_SUFFIX nomeia o campo com o blind index de um campo cifrado
+// (ex.: "contact.cpf_bidx").
+const BLIND_INDEX_SUFFIX = "_bidx"
+
+// encryptedContactFields são os campos do contato gravados cifrados, cada um
+// acompanhado do blind index para as buscas por igualdade.
+var encryptedContactFields = []string{"cpf", "cnpj", "identity_card"}
+
+func contactDocumentFields(contact *schemas.Contact) map[string]*string {
+	return map[string]*string{
+		"cpf":           &contact.CPF,
+		"cnpj":          &contact.CNPJ,
+		"identity_card": &contact.IdentityCard,
+	}
+}
+
+// sealContact serializa o contato como é gravado no banco: documentos
+// cifrados e com blind index. Sem keyring o contato é gravado aberto.
+func (r *MongoClientsRepository) sealContact(contact schemas.Contact) (bson.D, error) {
+	raw, err := bson.Marshal(contact)
+	if err != nil {
+		return nil, err
+	}
+	doc := bson.D{}
+	if err := bson.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+	if r.keyring == nil {
+		return doc, nil
+	}
+
+	values := contactDocumentFields(&contact)
+	sealed := make(bson.D, 0, len(doc))
+	for _, elem := range doc {
+		value, ok := values[elem.Key]
+		if !ok {
+			sealed = append(sealed, elem)
+			continue
+		}
+
+		encrypted, err := r.keyring.Encrypt(elem.Key, *value)
+		if err != nil {
+			return nil, err
+		}
+		sealed = append(sealed,
+			bson.E{Key: elem.Key, Value: encrypted},
+			bson.E{Key: elem.Key + BLIND_INDEX_SUFFIX, Value: r.keyring.BlindIndex(elem.Key, *value)},
+		)
+	}
+	return sealed, nil
+}
+
+// openContact decifra os documentos lidos do banco. Valores ainda em texto
+// aberto (gravados antes da criptografia) passam direto.
+func (r *MongoClientsRepository) openContact(ctx context.Context, contact *schemas.Contact) error {
+	if r.keyring == nil {
+		return nil
+	}
+
+	for field, value := range contactDocumentFields(contact) {
+		plaintext, err := r.keyring.Decrypt(ctx, field, *value)
+		if err != nil {
+			return fmt.Errorf("contact.%s: %w", field, err)
+		}
+		*value = plaintext
+	}
+	return nil
+}
+
+// documentConditions encontra o CPF ou CNPJ pelo blind index e, enquanto a
+// migração não termina, também pelo valor aberto.
+func (r *MongoClientsRepository) documentConditions(digits string) bson.A {
+	conditions := bson.A{
+		bson.D{{Key: "contact.cpf", Value: digits}},
+		bson.D{{Key: "contact.cnpj", Value: digits}},
+	}
+	if r.keyring != nil {
+		conditions = append(conditions,
+			bson.D{{Key: "contact.cpf" + BLIND_INDEX_SUFFIX, Value: r.keyring.BlindIndex("cpf", digits)}},
+			bson.D{{Key: "contact.cnpj" + BLIND_INDEX_SUFFIX, Value: r.keyring.BlindIndex("cnpj", digits)}},
+		)
+	}
+	return conditions
+}
+
+func (r *MongoClientsRepository) ReencryptContacts(ctx context.Context) (int, error) {
+	if r.keyring == nil {
+		return 0, nil
+	}
+
+	current := bson.Regex{Pattern: "^" + regexp.QuoteMeta(crypto.CIPHERTEXT_PREFIX+r.keyring.ActiveKeyID()+":")}
+	stale := bson.A{}
+	for _, field := range encryptedContactFields {
+		stale = append(stale, bson.D{{Key: "contact." + field, Value: bson.D{
+			{Key: "$exists", Value: true},
+			{Key: "$not", Value: current},
+		}}})
+	}
+
+	opts := options.Find().SetProjection(bson.D{{Key: "contact", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.D{{Key: "$or", Value: stale}}, opts)
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	rewritten := 0
+	for cursor.Next(ctx) {
+		client := schemas.ClientFromDB{}
+		if err := cursor.Decode(&client); err != nil {
+			return rewritten, err
+		}
+
+		// O filtro leva os valores lidos para não desfazer uma edição do
+		// contato feita durante a varredura
+		filter := bson.D{{Key: "_id", Value: client.ID}}
+		set := bson.D{}
+		values := contactDocumentFields(&client.Contact)
+		for _, field := range encryptedContactFields {
+			stored := *values[field]
+			if stored == "" {
+				continue
+			}
+
+			plaintext, err := r.keyring.Decrypt(ctx, field, stored)
+			if err != nil {
+				return rewritten, fmt.Errorf("cliente %s, contact.%s: %w", client.ID.Hex(), field, err)
+			}
+			encrypted, err := r.keyring.Encrypt(field, plaintext)
+			if err != nil {
+				return rewritten, err
+			}
+
+			filter = append(filter, bson.E{Key: "contact." + field, Value: stored})
+			set = append(set,
+				bson.E{Key: "contact." + field, Value: encrypted},
+				bson.E{Key: "contact." + field + BLIND_INDEX_SUFFIX, Value: r.keyring.BlindIndex(field, plaintext)},
+			)
+		}
+
+		result, err := r.collection.UpdateOne(ctx, filter, bson.D{{Key: "$set", Value: set}})
+		if err != nil {
+			return rewritten, err
+		}
+		rewritten += int(result.ModifiedCount)
+	}
+
+	return rewritten, cursor.Err()
+}
@@ -0,0 +1,197 @@
+package database
+
+import (
+	"api/documents"
+	"api/schemas"
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// clientSearchProjection são os únicos campos lidos na busca: hashes de senha,
+// tokens pendentes e segredos do 2FA nunca saem do banco.
+var clientSearchProjection = bson.D{
+	{Key: "contact", Value: 1},
+	{Key: "email_verified", Value: 1},
+	{Key: "two_factor.enabled", Value: 1},
+	{Key: "budget_ids", Value: 1},
+	{Key: "status", Value: 1},
+	{Key: "anonymized_at", Value: 1},
+	{Key: "created_at", Value: 1},
+	{Key: "updated_at", Value: 1},
+}
+
+// clientSortKeys liga o campo de ordenação da API ao campo do documento.
+var clientSortKeys = map[string]string{
+	schemas.CLIENT_SORT_CREATED_AT: "created_at",
+	schemas.CLIENT_SORT_UPDATED_AT: "updated_at",
+	schemas.CLIENT_SORT_NAME:       "contact.name",
+	schemas.CLIENT_SORT_EMAIL:      "contact.email",
+}
+
+// ValidClientSort diz se o campo pode ser usado na ordenação da busca.
+func ValidClientSort(sort string) bool {
+	_, ok := clientSortKeys[sort]
+	return ok
+}
+
+// ClientSortValue é o valor do cliente no campo ordenado, no formato do
+// cursor.
+func ClientSortValue(client schemas.ClientFromDB, sort string) string {
+	switch sort {
+	case schemas.CLIENT_SORT_UPDATED_AT:
+		return client.UpdatedAt.UTC().Format(time.RFC3339Nano)
+	case schemas.CLIENT_SORT_NAME:
+		return client.Contact.Name
+	case schemas.CLIENT_SORT_EMAIL:
+		return client.Contact.Email
+	default:
+		return client.CreatedAt.UTC().Format(time.RFC3339Nano)
+	}
+}
+
+// documentQuery diz se o texto livre é um número (CPF, CNPJ ou celular):
+// só dígitos e pontuação, com pelo menos 3 dígitos.
+func documentQuery(query string) (string, bool) {
+	digits := documents.Digits(query)
+	if len(digits) < 3 {
+		return "", false
+	}
+	if strings.Trim(query, "0123456789.-/()+ ") != "" {
+		return "", false
+	}
+	return digits, true
+}
+
+func containsRegex(value string) bson.Regex {
+	return bson.Regex{Pattern: regexp.QuoteMeta(value), Options: "i"}
+}
+
+func (r *MongoClientsRepository) clientSearchQuery(filter schemas.ClientSearchFilter) (bson.D, error) {
+	conditions := bson.A{}
+
+	if query := strings.TrimSpace(filter.Query); query != "" {
+		if strings.Contains(query, "@") {
+			conditions = append(conditions, bson.D{{Key: "contact.email", Value: containsRegex(query)}})
+		} else if digits, ok := documentQuery(query); ok {
+			matches := append(r.documentConditions(digits), bson.D{{Key: "contact.cell_phone", Value: containsRegex(digits)}})
+			conditions = append(conditions, bson.D{{Key: "$or", Value: matches}})
+		} else {
+			conditions = append(conditions, bson.D{{Key: "$or", Value: bson.A{
+				bson.D{{Key: "contact.name", Value: containsRegex(query)}},
+				bson.D{{Key: "contact.email", Value: containsRegex(query)}},
+				bson.D{{Key: "contact.city", Value: containsRegex(query)}},
+			}}})
+		}
+	}
+
+	if filter.Name != "" {
+		conditions = append(conditions, bson.D{{Key: "contact.name", Value: containsRegex(filter.Name)}})
+	}
+	if filter.Email != "" {
+		conditions = append(conditions, bson.D{{Key: "contact.email", Value: containsRegex(filter.Email)}})
+	}
+	if filter.Document != "" {
+		digits := documents.Digits(filter.Document)
+		conditions = append(conditions, bson.D{{Key: "$or", Value: r.documentConditions(digits)}})
+	}
+	if filter.Phone != "" {
+		conditions = append(conditions, bson.D{{Key: "contact.cell_phone", Value: containsRegex(documents.Digits(filter.Phone))}})
+	}
+	if filter.City != "" {
+		conditions = append(conditions, bson.D{{Key: "contact.city", Value: bson.Regex{Pattern: "^" + regexp.QuoteMeta(filter.City) + "$", Options: "i"}}})
+	}
+	if filter.State != "" {
+		conditions = append(conditions, bson.D{{Key: "contact.state", Value: strings.ToUpper(filter.State)}})
+	}
+	if filter.PersonType != "" {
+		conditions = append(conditions, bson.D{{Key: "contact.person_type", Value: filter.PersonType}})
+	}
+	if filter.EmailVerified != nil {
+		conditions = append(conditions, bson.D{{Key: "email_verified", Value: *filter.EmailVerified}})
+	}
+	if filter.BudgetID > 0 {
+		conditions = append(conditions, bson.D{{Key: "budget_ids", Value: filter.BudgetID}})
+	}
+
+	if filter.After != nil {
+		after, err := clientCursorCondition(filter)
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, after)
+	}
+
+	if len(conditions) == 0 {
+		return bson.D{}, nil
+	}
+	return bson.D{{Key: "$and", Value: conditions}}, nil
+}
+
+// clientCursorCondition seleciona os clientes depois do cursor na ordenação
+// (campo, _id).
+func clientCursorCondition(filter schemas.ClientSearchFilter) (bson.D, error) {
+	id, err := bson.ObjectIDFromHex(filter.After.ID)
+	if err != nil {
+		return nil, fmt.Errorf("cursor inválido: %v", err)
+	}
+
+	var value any = filter.After.Value
+	if filter.Sort == schemas.CLIENT_SORT_CREATED_AT || filter.Sort == schemas.CLIENT_SORT_UPDATED_AT {
+		parsed, err := time.Parse(time.RFC3339Nano, filter.After.Value)
+		if err != nil {
+			return nil, fmt.Errorf("cursor inválido: %v", err)
+		}
+		value = parsed
+	}
+
+	operator := "$gt"
+	if filter.Descending {
+		operator = "$lt"
+	}
+	key := clientSortKeys[filter.Sort]
+
+	return bson.D{{Key: "$or", Value: bson.A{
+		bson.D{{Key: key, Value: bson.D{{Key: operator, Value: value}}}},
+		bson.D{{Key: key, Value: value}, {Key: "_id", Value: bson.D{{Key: operator, Value: id}}}},
+	}}}, nil
+}
+
+func (r *MongoClientsRepository) Search(ctx context.Context, filter schemas.ClientSearchFilter) ([]schemas.ClientFromDB, *schemas.ClientSearchCursor, error) {
+	if filter.Sort == "" {
+		filter.Sort = schemas.CLIENT_SORT_CREATED_AT
+	}
+	query, err := r.clientSearchQuery(filter)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	direction := 1
+	if filter.Descending {
+		direction = -1
+	}
+	opts := options.Find().
+		SetProjection(clientSearchProjection).
+		SetSort(bson.D{{Key: clientSortKeys[filter.Sort], Value: direction}, {Key: "_id", Value: direction}}).
+		SetLimit(filter.Limit)
+
+	clients, err := r.find(ctx, query, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	return clients, nextClientCursor(clients, filter), nil
+}
+
+// nextClientCursor aponta para o último cliente quando a página veio cheia.
+func nextClientCursor(clients []schemas.ClientFromDB, filter schemas.ClientSearchFilter) *schemas.ClientSearchCursor {
+	if filter.Limit <= 0 || int64(len(clients)) < filter.Limit {
+		return nil
+	}
+	last := clients[len(clients)-1]
+	return &schemas.ClientSearchCursor{Value: ClientSortValue(last, filter.Sort), ID: last.ID.Hex()}
+}
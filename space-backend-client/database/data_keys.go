@@ -0,0 +1,48 @@
+package database
+
+import (
+	"api/schemas"
+	"context"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// DataKeysRepository encapsula a coleção "data_keys", com as chaves de dados
+// dos campos criptografados, cifradas pela chave mestra. Implementa o
+// crypto.KeyStore.
+type DataKeysRepository interface {
+	// FindAll retorna as chaves da mais antiga para a mais recente.
+	FindAll(ctx context.Context) ([]schemas.DataKey, error)
+	Insert(ctx context.Context, key schemas.DataKey) error
+}
+
+type MongoDataKeysRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoDataKeysRepository(collection *mongo.Collection) *MongoDataKeysRepository {
+	return &MongoDataKeysRepository{collection: collection}
+}
+
+func (r *MongoDataKeysRepository) FindAll(ctx context.Context) ([]schemas.DataKey, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, bson.D{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	keys := []schemas.DataKey{}
+	if err := cursor.All(ctx, &keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func (r *MongoDataKeysRepository) Insert(ctx context.Context, key schemas.DataKey) error {
+	_, err := r.collection.InsertOne(ctx, key)
+	return err
+}
@@ -0,0 +1,115 @@
+package database
+
+import (
+	"api/schemas"
+	"context"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// LoginAttemptsRepository encapsula a coleção "login_attempts", com um
+// contador por email e outro por IP (ver LoginAttemptsEmailKey/IPKey/AdminKey).
+type LoginAttemptsRepository interface {
+	// Get retorna um contador zerado quando não há falhas registradas.
+	Get(ctx context.Context, key string) (schemas.LoginAttempts, error)
+	// Claim conta a tentativa feita em at antes de ela ser verificada, como
+	// uma falha, e retorna o estado anterior a ela (zerado se já expirou). O
+	// incremento é atômico: tentativas simultâneas recebem estados diferentes.
+	Claim(ctx context.Context, key string, at time.Time, window time.Duration) (schemas.LoginAttempts, error)
+	// Release desfaz o Claim de uma tentativa que não terminou em falha. O
+	// last_failure_at só volta a previous se nenhuma outra tentativa foi
+	// contada depois.
+	Release(ctx context.Context, key string, at time.Time, previous schemas.LoginAttempts) error
+	Reset(ctx context.Context, key string) error
+}
+
+func LoginAttemptsEmailKey(email string) string {
+	return "email:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func LoginAttemptsIPKey(ip string) string {
+	return "ip:" + ip
+}
+
+// LoginAttemptsAdminKey separa as falhas do login administrativo das do
+// cliente com o mesmo email.
+func LoginAttemptsAdminKey(email string) string {
+	return "admin:" + NormalizeAdminEmail(email)
+}
+
+type MongoLoginAttemptsRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoLoginAttemptsRepository(collection *mongo.Collection) *MongoLoginAttemptsRepository {
+	return &MongoLoginAttemptsRepository{collection: collection}
+}
+
+func (r *MongoLoginAttemptsRepository) Get(ctx context.Context, key string) (schemas.LoginAttempts, error) {
+	filter := bson.D{
+		{Key: "_id", Value: key},
+		{Key: "expires_at", Value: bson.D{{Key: "$gt", Value: time.Now()}}},
+	}
+
+	attempts := schemas.LoginAttempts{}
+	err := r.collection.FindOne(ctx, filter).Decode(&attempts)
+	if err == mongo.ErrNoDocuments {
+		return schemas.LoginAttempts{Key: key}, nil
+	}
+	return attempts, err
+}
+
+// Claim usa um update em pipeline para recomeçar a contagem quando o
+// documento já expirou: o índice TTL só o remove depois de algum tempo, e um
+// $inc simples herdaria as falhas antigas.
+func (r *MongoLoginAttemptsRepository) Claim(ctx context.Context, key string, at time.Time, window time.Duration) (schemas.LoginAttempts, error) {
+	failures := bson.D{{Key: "$cond", Value: bson.A{
+		bson.D{{Key: "$gt", Value: bson.A{"$expires_at", at}}},
+		bson.D{{Key: "$add", Value: bson.A{bson.D{{Key: "$ifNull", Value: bson.A{"$failures", 0}}}, 1}}},
+		1,
+	}}}
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.D{
+			{Key: "failures", Value: failures},
+			{Key: "last_failure_at", Value: at},
+			{Key: "expires_at", Value: at.Add(window)},
+		}}},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.Before)
+
+	previous := schemas.LoginAttempts{}
+	err := r.collection.FindOneAndUpdate(ctx, bson.D{{Key: "_id", Value: key}}, update, opts).Decode(&previous)
+	if err == mongo.ErrNoDocuments || (err == nil && !previous.ExpiresAt.After(at)) {
+		return schemas.LoginAttempts{Key: key}, nil
+	}
+	return previous, err
+}
+
+func (r *MongoLoginAttemptsRepository) Release(ctx context.Context, key string, at time.Time, previous schemas.LoginAttempts) error {
+	filter := bson.D{
+		{Key: "_id", Value: key},
+		{Key: "failures", Value: bson.D{{Key: "$gt", Value: 0}}},
+	}
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.D{
+			{Key: "failures", Value: bson.D{{Key: "$subtract", Value: bson.A{"$failures", 1}}}},
+			{Key: "last_failure_at", Value: bson.D{{Key: "$cond", Value: bson.A{
+				bson.D{{Key: "$eq", Value: bson.A{"$last_failure_at", at}}},
+				previous.LastFailureAt,
+				"$last_failure_at",
+			}}}},
+		}}},
+	}
+
+	_, err := r.collection.UpdateOne(ctx, filter, update)
+	return err
+}
+
+func (r *MongoLoginAttemptsRepository) Reset(ctx context.Context, key string) error {
+	_, err := r.collection.DeleteOne(ctx, bson.D{{Key: "_id", Value: key}})
+	return err
+}
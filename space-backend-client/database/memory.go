@@ -0,0 +1,1395 @@
+package database
+
+import (
+	"api/documents"
+	"api/schemas"
+	"bytes"
+	"context"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// Implementações em memória dos repositórios, usadas nos testes dos handlers
+// no lugar de um MongoDB real.
+
+func NewMemoryRepositories() *Repositories {
+	return &Repositories{
+		Clients:             NewMemoryClientsRepository(),
+		Uniforms:            NewMemoryUniformsRepository(),
+		WhatsappEvents:      NewMemoryWhatsappEventsRepository(),
+		Sessions:            NewMemorySessionsRepository(),
+		AuditEvents:         NewMemoryAuditEventsRepository(),
+		RevokedTokens:       NewMemoryRevokedTokensRepository(),
+		LoginAttempts:       NewMemoryLoginAttemptsRepository(),
+		AdminUsers:          NewMemoryAdminUsersRepository(),
+		ApiKeys:             NewMemoryApiKeysRepository(),
+		TinySyncJobs:        NewMemoryTinySyncJobsRepository(),
+		TinyReconciliations: NewMemoryTinyReconciliationsRepository(),
+		Transactions:        MemoryTransactions{},
+	}
+}
+
+type MemoryClientsRepository struct {
+	mu      sync.Mutex
+	clients map[bson.ObjectID]schemas.ClientFromDB
+}
+
+func NewMemoryClientsRepository() *MemoryClientsRepository {
+	return &MemoryClientsRepository{clients: make(map[bson.ObjectID]schemas.ClientFromDB)}
+}
+
+func (r *MemoryClientsRepository) FindByID(ctx context.Context, id bson.ObjectID) (schemas.ClientFromDB, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	client, ok := r.clients[id]
+	if !ok {
+		return schemas.ClientFromDB{}, mongo.ErrNoDocuments
+	}
+	return client, nil
+}
+
+func (r *MemoryClientsRepository) FindByEmail(ctx context.Context, email string) (schemas.ClientFromDB, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, client := range r.clients {
+		if client.Contact.Email == email {
+			return client, nil
+		}
+	}
+	return schemas.ClientFromDB{}, mongo.ErrNoDocuments
+}
+
+func (r *MemoryClientsRepository) FindByBudgetIDs(ctx context.Context, budgetIDs []int) ([]schemas.ClientFromDB, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var result []schemas.ClientFromDB
+	for _, client := range r.clients {
+		for _, budgetID := range client.BudgetIDs {
+			if slices.Contains(budgetIDs, budgetID) {
+				result = append(result, client)
+				break
+			}
+		}
+	}
+	return result, nil
+}
+
+func (r *MemoryClientsRepository) FindByTinyID(ctx context.Context, tinyID string) ([]schemas.ClientFromDB, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var result []schemas.ClientFromDB
+	for _, client := range r.clients {
+		if client.Contact.TinyID == tinyID {
+			result = append(result, client)
+		}
+	}
+	return result, nil
+}
+
+func (r *MemoryClientsRepository) ListVerified(ctx context.Context, after bson.ObjectID, limit int64) ([]schemas.ClientFromDB, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var result []schemas.ClientFromDB
+	for _, client := range r.clients {
+		if client.EmailVerified && bytes.Compare(client.ID[:], after[:]) > 0 {
+			result = append(result, client)
+		}
+	}
+	slices.SortFunc(result, func(a, b schemas.ClientFromDB) int {
+		return bytes.Compare(a.ID[:], b.ID[:])
+	})
+	if limit > 0 && int64(len(result)) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+func (r *MemoryClientsRepository) Search(ctx context.Context, filter schemas.ClientSearchFilter) ([]schemas.ClientFromDB, *schemas.ClientSearchCursor, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if filter.Sort == "" {
+		filter.Sort = schemas.CLIENT_SORT_CREATED_AT
+	}
+	var after bson.ObjectID
+	if filter.After != nil {
+		id, err := bson.ObjectIDFromHex(filter.After.ID)
+		if err != nil {
+			return nil, nil, err
+		}
+		after = id
+	}
+
+	// Compara pela posição (valor ordenado, _id), a mesma usada no cursor
+	position := func(value string, id bson.ObjectID, otherValue string, otherID bson.ObjectID) int {
+		var order int
+		if filter.Sort == schemas.CLIENT_SORT_CREATED_AT || filter.Sort == schemas.CLIENT_SORT_UPDATED_AT {
+			order = parseCursorTime(value).Compare(parseCursorTime(otherValue))
+		} else {
+			order = strings.Compare(value, otherValue)
+		}
+		if order == 0 {
+			order = bytes.Compare(id[:], otherID[:])
+		}
+		if filter.Descending {
+			return -order
+		}
+		return order
+	}
+	compare := func(a, b schemas.ClientFromDB) int {
+		return position(ClientSortValue(a, filter.Sort), a.ID, ClientSortValue(b, filter.Sort), b.ID)
+	}
+
+	var result []schemas.ClientFromDB
+	for _, client := range r.clients {
+		if !matchesClientSearch(client, filter) {
+			continue
+		}
+		if filter.After != nil && position(ClientSortValue(client, filter.Sort), client.ID, filter.After.Value, after) <= 0 {
+			continue
+		}
+
+		// Mesma projeção da busca no MongoDB
+		safe := schemas.ClientFromDB{
+			ID:            client.ID,
+			Contact:       client.Contact,
+			EmailVerified: client.EmailVerified,
+			BudgetIDs:     client.BudgetIDs,
+			CreatedAt:     client.CreatedAt,
+			UpdatedAt:     client.UpdatedAt,
+		}
+		if client.TwoFactor != nil {
+			safe.TwoFactor = &schemas.TwoFactor{Enabled: client.TwoFactor.Enabled}
+		}
+		result = append(result, safe)
+	}
+
+	slices.SortFunc(result, compare)
+	if filter.Limit > 0 && int64(len(result)) > filter.Limit {
+		result = result[:filter.Limit]
+	}
+	return result, nextClientCursor(result, filter), nil
+}
+
+func parseCursorTime(value string) time.Time {
+	parsed, _ := time.Parse(time.RFC3339Nano, value)
+	return parsed
+}
+
+func matchesClientSearch(client schemas.ClientFromDB, filter schemas.ClientSearchFilter) bool {
+	contact := client.Contact
+	contains := func(value, part string) bool {
+		return strings.Contains(strings.ToLower(value), strings.ToLower(part))
+	}
+	isDocument := func(digits string) bool {
+		return digits != "" && (contact.CPF == digits || contact.CNPJ == digits)
+	}
+
+	if query := strings.TrimSpace(filter.Query); query != "" {
+		if strings.Contains(query, "@") {
+			if !contains(contact.Email, query) {
+				return false
+			}
+		} else if digits, ok := documentQuery(query); ok {
+			if !isDocument(digits) && !strings.Contains(contact.CellPhone, digits) {
+				return false
+			}
+		} else if !contains(contact.Name, query) && !contains(contact.Email, query) && !contains(contact.City, query) {
+			return false
+		}
+	}
+
+	switch {
+	case filter.Name != "" && !contains(contact.Name, filter.Name),
+		filter.Email != "" && !contains(contact.Email, filter.Email),
+		filter.Document != "" && !isDocument(documents.Digits(filter.Document)),
+		filter.Phone != "" && !strings.Contains(contact.CellPhone, documents.Digits(filter.Phone)),
+		filter.City != "" && !strings.EqualFold(contact.City, filter.City),
+		filter.State != "" && !strings.EqualFold(contact.State, filter.State),
+		filter.PersonType != "" && contact.PersonType != filter.PersonType,
+		filter.EmailVerified != nil && client.EmailVerified != *filter.EmailVerified,
+		filter.BudgetID > 0 && !slices.Contains(client.BudgetIDs, filter.BudgetID):
+		return false
+	}
+	return true
+}
+
+func (r *MemoryClientsRepository) Create(ctx context.Context, client schemas.ClientCreateModel) (bson.ObjectID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.emailTaken(client.Contact.Email, bson.ObjectID{}) {
+		return bson.ObjectID{}, ErrClientEmailTaken
+	}
+
+	id := bson.NewObjectID()
+	r.clients[id] = schemas.ClientFromDB{
+		ID:                id,
+		Contact:           client.Contact,
+		PasswordHash:      client.PasswordHash,
+		EmailVerified:     client.EmailVerified,
+		EmailVerification: client.EmailVerification,
+		CreatedAt:         client.CreatedAt,
+		UpdatedAt:         client.UpdatedAt,
+	}
+	return id, nil
+}
+
+// Insert grava um cliente completo, útil para preparar o estado dos testes.
+func (r *MemoryClientsRepository) Insert(client schemas.ClientFromDB) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if client.ID.IsZero() {
+		client.ID = bson.NewObjectID()
+	}
+	r.clients[client.ID] = client
+}
+
+func (r *MemoryClientsRepository) PatchContact(ctx context.Context, id bson.ObjectID, contact schemas.Contact, fields []string) error {
+	r.mu.Lock()
+	taken := r.emailTaken(contact.Email, id)
+	r.mu.Unlock()
+	if taken {
+		return ErrClientEmailTaken
+	}
+
+	return r.update(id, func(client *schemas.ClientFromDB) {
+		client.Contact = contact
+		client.Contact.UpdatedAt = time.Now()
+	})
+}
+
+// emailTaken imita o índice único em contact.email. Deve ser chamado com o
+// mutex travado.
+func (r *MemoryClientsRepository) emailTaken(email string, except bson.ObjectID) bool {
+	for id, client := range r.clients {
+		if id != except && client.Contact.Email == email {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *MemoryClientsRepository) UpdatePasswordHash(ctx context.Context, id bson.ObjectID, passwordHash string) error {
+	return r.update(id, func(client *schemas.ClientFromDB) {
+		client.PasswordHash = passwordHash
+	})
+}
+
+func (r *MemoryClientsRepository) AddBudgetID(ctx context.Context, email string, budgetID int) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, client := range r.clients {
+		if client.Contact.Email != email {
+			continue
+		}
+		if slices.Contains(client.BudgetIDs, budgetID) {
+			return false, nil
+		}
+		client.BudgetIDs = append(client.BudgetIDs, budgetID)
+		client.UpdatedAt = time.Now()
+		r.clients[id] = client
+		return true, nil
+	}
+	return false, mongo.ErrNoDocuments
+}
+
+func (r *MemoryClientsRepository) RemoveBudgetID(ctx context.Context, id bson.ObjectID, budgetID int) (bool, error) {
+	removed := false
+	err := r.update(id, func(client *schemas.ClientFromDB) {
+		if index := slices.Index(client.BudgetIDs, budgetID); index >= 0 {
+			client.BudgetIDs = slices.Delete(slices.Clone(client.BudgetIDs), index, index+1)
+			removed = true
+		}
+	})
+	return removed, err
+}
+
+func (r *MemoryClientsRepository) SetStatus(ctx context.Context, id bson.ObjectID, status string) error {
+	return r.update(id, func(client *schemas.ClientFromDB) {
+		client.Status = status
+	})
+}
+
+func (r *MemoryClientsRepository) Anonymize(ctx context.Context, id bson.ObjectID, contact schemas.Contact, at time.Time) error {
+	return r.update(id, func(client *schemas.ClientFromDB) {
+		client.Contact = contact
+		client.PasswordHash = ""
+		client.EmailVerified = false
+		client.EmailVerification = nil
+		client.PasswordReset = nil
+		client.TwoFactor = nil
+		client.TwoFactorChallenge = nil
+		client.AnonymizedAt = &at
+	})
+}
+
+// ReencryptContacts não tem o que fazer: a memória guarda os contatos abertos.
+func (r *MemoryClientsRepository) ReencryptContacts(ctx context.Context) (int, error) {
+	return 0, nil
+}
+
+func (r *MemoryClientsRepository) Delete(ctx context.Context, id bson.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.clients[id]; !ok {
+		return mongo.ErrNoDocuments
+	}
+	delete(r.clients, id)
+	return nil
+}
+
+func (r *MemoryClientsRepository) SetEmailVerification(ctx context.Context, id bson.ObjectID, verification schemas.PendingToken) error {
+	return r.update(id, func(client *schemas.ClientFromDB) {
+		client.EmailVerification = &verification
+	})
+}
+
+func (r *MemoryClientsRepository) ResetEmailVerification(ctx context.Context, id bson.ObjectID, verification schemas.PendingToken) error {
+	return r.update(id, func(client *schemas.ClientFromDB) {
+		client.EmailVerified = false
+		client.EmailVerification = &verification
+	})
+}
+
+func (r *MemoryClientsRepository) FindByEmailVerificationToken(ctx context.Context, tokenHash string) (schemas.ClientFromDB, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, client := range r.clients {
+		verification := client.EmailVerification
+		if verification != nil && verification.TokenHash == tokenHash && verification.ExpiresAt.After(time.Now()) {
+			return client, nil
+		}
+	}
+	return schemas.ClientFromDB{}, mongo.ErrNoDocuments
+}
+
+func (r *MemoryClientsRepository) MarkEmailVerified(ctx context.Context, id bson.ObjectID, tokenHash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	client, ok := r.clients[id]
+	if !ok || client.EmailVerification == nil || client.EmailVerification.TokenHash != tokenHash || !client.EmailVerification.ExpiresAt.After(time.Now()) {
+		return mongo.ErrNoDocuments
+	}
+	client.EmailVerified = true
+	client.EmailVerification = nil
+	client.UpdatedAt = time.Now()
+	r.clients[id] = client
+	return nil
+}
+
+func (r *MemoryClientsRepository) SetPasswordReset(ctx context.Context, id bson.ObjectID, reset schemas.PendingToken) error {
+	return r.update(id, func(client *schemas.ClientFromDB) {
+		client.PasswordReset = &reset
+	})
+}
+
+func (r *MemoryClientsRepository) SetTwoFactor(ctx context.Context, id bson.ObjectID, twoFactor schemas.TwoFactor) error {
+	return r.update(id, func(client *schemas.ClientFromDB) {
+		client.TwoFactor = &twoFactor
+	})
+}
+
+func (r *MemoryClientsRepository) RemoveTwoFactor(ctx context.Context, id bson.ObjectID) error {
+	return r.update(id, func(client *schemas.ClientFromDB) {
+		client.TwoFactor = nil
+		client.TwoFactorChallenge = nil
+	})
+}
+
+func (r *MemoryClientsRepository) SetTwoFactorChallenge(ctx context.Context, id bson.ObjectID, challenge schemas.PendingToken) error {
+	return r.update(id, func(client *schemas.ClientFromDB) {
+		client.TwoFactorChallenge = &challenge
+	})
+}
+
+func (r *MemoryClientsRepository) FindByTwoFactorChallenge(ctx context.Context, tokenHash string) (schemas.ClientFromDB, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, client := range r.clients {
+		challenge := client.TwoFactorChallenge
+		if challenge != nil && challenge.TokenHash == tokenHash && challenge.ExpiresAt.After(time.Now()) {
+			return client, nil
+		}
+	}
+	return schemas.ClientFromDB{}, mongo.ErrNoDocuments
+}
+
+func (r *MemoryClientsRepository) CompleteTwoFactorChallenge(ctx context.Context, id bson.ObjectID, tokenHash string, twoFactor schemas.TwoFactor) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	client, ok := r.clients[id]
+	if !ok || client.TwoFactorChallenge == nil || client.TwoFactorChallenge.TokenHash != tokenHash {
+		return mongo.ErrNoDocuments
+	}
+	client.TwoFactor = &twoFactor
+	client.TwoFactorChallenge = nil
+	r.clients[id] = client
+	return nil
+}
+
+func (r *MemoryClientsRepository) ConsumePasswordReset(ctx context.Context, tokenHash string, passwordHash string) (bson.ObjectID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, client := range r.clients {
+		reset := client.PasswordReset
+		if reset == nil || reset.TokenHash != tokenHash || !reset.ExpiresAt.After(time.Now()) {
+			continue
+		}
+		client.PasswordHash = passwordHash
+		client.PasswordReset = nil
+		client.UpdatedAt = time.Now()
+		r.clients[id] = client
+		return id, nil
+	}
+	return bson.ObjectID{}, mongo.ErrNoDocuments
+}
+
+func (r *MemoryClientsRepository) update(id bson.ObjectID, apply func(client *schemas.ClientFromDB)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	client, ok := r.clients[id]
+	if !ok {
+		return mongo.ErrNoDocuments
+	}
+	apply(&client)
+	client.UpdatedAt = time.Now()
+	r.clients[id] = client
+	return nil
+}
+
+type MemoryUniformsRepository struct {
+	mu       sync.Mutex
+	uniforms []schemas.UniformFromDB
+}
+
+func NewMemoryUniformsRepository() *MemoryUniformsRepository {
+	return &MemoryUniformsRepository{}
+}
+
+func (r *MemoryUniformsRepository) FindByID(ctx context.Context, id bson.ObjectID) (schemas.UniformFromDB, error) {
+	return r.findOne(func(u schemas.UniformFromDB) bool { return u.ID == id })
+}
+
+func (r *MemoryUniformsRepository) FindOneByBudgetID(ctx context.Context, budgetID int) (schemas.UniformFromDB, error) {
+	return r.findOne(func(u schemas.UniformFromDB) bool { return u.BudgetID == budgetID })
+}
+
+func (r *MemoryUniformsRepository) FindOneByClientAndBudget(ctx context.Context, clientID string, budgetID int) (schemas.UniformFromDB, error) {
+	return r.findOne(func(u schemas.UniformFromDB) bool { return u.ClientID == clientID && u.BudgetID == budgetID })
+}
+
+func (r *MemoryUniformsRepository) FindByBudgetID(ctx context.Context, budgetID int) ([]schemas.UniformFromDB, error) {
+	return r.find(func(u schemas.UniformFromDB) bool { return u.BudgetID == budgetID }), nil
+}
+
+func (r *MemoryUniformsRepository) FindByClientID(ctx context.Context, clientID string) ([]schemas.UniformFromDB, error) {
+	uniforms := r.find(func(u schemas.UniformFromDB) bool { return u.ClientID == clientID })
+	slices.SortFunc(uniforms, func(a, b schemas.UniformFromDB) int { return b.CreatedAt.Compare(a.CreatedAt) })
+	return uniforms, nil
+}
+
+func (r *MemoryUniformsRepository) FindByClientIDs(ctx context.Context, clientIDs []string) ([]schemas.UniformFromDB, error) {
+	return r.find(func(u schemas.UniformFromDB) bool { return slices.Contains(clientIDs, u.ClientID) }), nil
+}
+
+func (r *MemoryUniformsRepository) Create(ctx context.Context, uniform schemas.UniformToDB) (bson.ObjectID, error) {
+	id := bson.NewObjectID()
+	r.Insert(schemas.UniformFromDB{
+		ID:        id,
+		ClientID:  uniform.ClientID,
+		BudgetID:  uniform.BudgetID,
+		Sketches:  uniform.Sketches,
+		Editable:  uniform.Editable,
+		CreatedAt: uniform.CreatedAt,
+		UpdatedAt: uniform.UpdatedAt,
+	})
+	return id, nil
+}
+
+// Insert grava um uniforme completo, útil para preparar o estado dos testes.
+func (r *MemoryUniformsRepository) Insert(uniform schemas.UniformFromDB) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if uniform.ID.IsZero() {
+		uniform.ID = bson.NewObjectID()
+	}
+	r.uniforms = append(r.uniforms, uniform)
+}
+
+func (r *MemoryUniformsRepository) UpdateSketches(ctx context.Context, id bson.ObjectID, sketches []schemas.Sketch, editable bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.uniforms {
+		if r.uniforms[i].ID == id {
+			r.uniforms[i].Sketches = sketches
+			r.uniforms[i].Editable = editable
+			r.uniforms[i].UpdatedAt = time.Now()
+			return nil
+		}
+	}
+	return mongo.ErrNoDocuments
+}
+
+func (r *MemoryUniformsRepository) findOne(match func(schemas.UniformFromDB) bool) (schemas.UniformFromDB, error) {
+	uniforms := r.find(match)
+	if len(uniforms) == 0 {
+		return schemas.UniformFromDB{}, mongo.ErrNoDocuments
+	}
+	return uniforms[0], nil
+}
+
+func (r *MemoryUniformsRepository) find(match func(schemas.UniformFromDB) bool) []schemas.UniformFromDB {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var result []schemas.UniformFromDB
+	for _, uniform := range r.uniforms {
+		if match(uniform) {
+			uniform.Sketches = slices.Clone(uniform.Sketches)
+			result = append(result, uniform)
+		}
+	}
+	return result
+}
+
+type MemoryWhatsappEventsRepository struct {
+	mu   sync.Mutex
+	docs []bson.Raw
+}
+
+func NewMemoryWhatsappEventsRepository() *MemoryWhatsappEventsRepository {
+	return &MemoryWhatsappEventsRepository{}
+}
+
+func (r *MemoryWhatsappEventsRepository) Insert(ctx context.Context, rawEvent any, receivedAt time.Time) error {
+	doc, err := bson.Marshal(bson.D{
+		{Key: "_id", Value: bson.NewObjectID()},
+		{Key: "raw_event", Value: rawEvent},
+		{Key: "received_at", Value: receivedAt},
+	})
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.docs = append(r.docs, doc)
+	return nil
+}
+
+func (r *MemoryWhatsappEventsRepository) FindAll(ctx context.Context) ([]bson.Raw, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return slices.Clone(r.docs), nil
+}
+
+func (r *MemoryWhatsappEventsRepository) FindByPhones(ctx context.Context, phones []string) ([]schemas.WhatsappEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var events []schemas.WhatsappEvent
+	for _, doc := range r.docs {
+		var event schemas.WhatsappEvent
+		var parties struct {
+			RawEvent whatsappEventParties `bson:"raw_event"`
+		}
+		if err := bson.Unmarshal(doc, &event); err != nil {
+			return nil, err
+		}
+		if err := bson.Unmarshal(doc, &parties); err != nil {
+			return nil, err
+		}
+		if slices.ContainsFunc(parties.RawEvent.phones(), func(phone string) bool {
+			return phone != "" && slices.Contains(phones, phone)
+		}) {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+func (r *MemoryWhatsappEventsRepository) ReplaceRawEvent(ctx context.Context, id bson.ObjectID, rawEvent any) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, doc := range r.docs {
+		var event schemas.WhatsappEvent
+		if err := bson.Unmarshal(doc, &event); err != nil {
+			return err
+		}
+		if event.ID != id {
+			continue
+		}
+
+		replaced, err := bson.Marshal(bson.D{
+			{Key: "_id", Value: id},
+			{Key: "raw_event", Value: rawEvent},
+			{Key: "received_at", Value: event.ReceivedAt},
+		})
+		if err != nil {
+			return err
+		}
+		r.docs[i] = replaced
+		return nil
+	}
+	return mongo.ErrNoDocuments
+}
+
+type MemorySessionsRepository struct {
+	mu       sync.Mutex
+	sessions map[bson.ObjectID]schemas.Session
+}
+
+func NewMemorySessionsRepository() *MemorySessionsRepository {
+	return &MemorySessionsRepository{sessions: make(map[bson.ObjectID]schemas.Session)}
+}
+
+func (r *MemorySessionsRepository) Create(ctx context.Context, session schemas.Session) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.sessions[session.ID] = session
+	return nil
+}
+
+func (r *MemorySessionsRepository) FindByID(ctx context.Context, id bson.ObjectID) (schemas.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	session, ok := r.sessions[id]
+	if !ok || !session.ExpiresAt.After(time.Now()) {
+		return schemas.Session{}, mongo.ErrNoDocuments
+	}
+	return session, nil
+}
+
+func (r *MemorySessionsRepository) FindByClientID(ctx context.Context, clientID bson.ObjectID) ([]schemas.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var result []schemas.Session
+	for _, session := range r.sessions {
+		if session.ClientID == clientID && session.ExpiresAt.After(time.Now()) {
+			result = append(result, session)
+		}
+	}
+	slices.SortFunc(result, func(a, b schemas.Session) int { return b.LastUsedAt.Compare(a.LastUsedAt) })
+	return result, nil
+}
+
+func (r *MemorySessionsRepository) Rotate(ctx context.Context, id bson.ObjectID, currentHash string, newHash string, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	session, ok := r.sessions[id]
+	if !ok || session.RefreshTokenHash != currentHash {
+		return mongo.ErrNoDocuments
+	}
+
+	now := time.Now()
+	session.PreviousTokenHash = currentHash
+	session.RefreshTokenHash = newHash
+	session.RotatedAt = now
+	session.LastUsedAt = now
+	session.ExpiresAt = expiresAt
+	r.sessions[id] = session
+	return nil
+}
+
+func (r *MemorySessionsRepository) Delete(ctx context.Context, id bson.ObjectID, clientID bson.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	session, ok := r.sessions[id]
+	if !ok || session.ClientID != clientID {
+		return mongo.ErrNoDocuments
+	}
+	delete(r.sessions, id)
+	return nil
+}
+
+func (r *MemorySessionsRepository) DeleteByClientID(ctx context.Context, clientID bson.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, session := range r.sessions {
+		if session.ClientID == clientID {
+			delete(r.sessions, id)
+		}
+	}
+	return nil
+}
+
+type MemoryAuditEventsRepository struct {
+	mu     sync.Mutex
+	events []schemas.AuditEvent
+}
+
+func NewMemoryAuditEventsRepository() *MemoryAuditEventsRepository {
+	return &MemoryAuditEventsRepository{}
+}
+
+func (r *MemoryAuditEventsRepository) Insert(ctx context.Context, event schemas.AuditEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if event.ID.IsZero() {
+		event.ID = bson.NewObjectID()
+	}
+	r.events = append(r.events, event)
+	return nil
+}
+
+func (r *MemoryAuditEventsRepository) Find(ctx context.Context, filter schemas.AuditEventFilter) ([]schemas.AuditEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	events := []schemas.AuditEvent{}
+	for _, event := range slices.Backward(r.events) {
+		switch {
+		case filter.ActorType != "" && event.ActorType != filter.ActorType,
+			filter.ActorID != "" && event.ActorID != filter.ActorID,
+			filter.Action != "" && event.Action != filter.Action,
+			filter.TargetType != "" && event.TargetType != filter.TargetType,
+			filter.TargetID != "" && event.TargetID != filter.TargetID,
+			!filter.From.IsZero() && event.CreatedAt.Before(filter.From),
+			!filter.To.IsZero() && !event.CreatedAt.Before(filter.To),
+			!filter.BeforeID.IsZero() && bytes.Compare(event.ID[:], filter.BeforeID[:]) >= 0:
+			continue
+		}
+
+		events = append(events, event)
+		if filter.Limit > 0 && int64(len(events)) == filter.Limit {
+			break
+		}
+	}
+	return events, nil
+}
+
+func (r *MemoryAuditEventsRepository) RedactChanges(ctx context.Context, targetType string, targetID string, fields []string, marker string) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	redacted := 0
+	for i, event := range r.events {
+		if event.TargetType != targetType || event.TargetID != targetID {
+			continue
+		}
+
+		changed := false
+		changes := slices.Clone(event.Changes)
+		for j, change := range changes {
+			if !slices.Contains(fields, change.Field) {
+				continue
+			}
+			if change.Before != nil && change.Before != marker {
+				changes[j].Before, changed = marker, true
+			}
+			if change.After != nil && change.After != marker {
+				changes[j].After, changed = marker, true
+			}
+		}
+		if changed {
+			r.events[i].Changes = changes
+			redacted++
+		}
+	}
+	return redacted, nil
+}
+
+func (r *MemoryAuditEventsRepository) RedactMetadata(ctx context.Context, targetType string, actorID string, keys []string, marker string) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	redacted := 0
+	for i, event := range r.events {
+		if event.TargetType != targetType || event.ActorID != actorID {
+			continue
+		}
+
+		changed := false
+		metadata := maps.Clone(event.Metadata)
+		for _, key := range keys {
+			if value, ok := metadata[key]; ok && value != marker {
+				metadata[key], changed = marker, true
+			}
+		}
+		if changed {
+			r.events[i].Metadata = metadata
+			redacted++
+		}
+	}
+	return redacted, nil
+}
+
+// Events retorna uma cópia dos eventos gravados, para as asserções dos testes.
+func (r *MemoryAuditEventsRepository) Events() []schemas.AuditEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return slices.Clone(r.events)
+}
+
+type MemoryRevokedTokensRepository struct {
+	mu      sync.Mutex
+	revoked map[string]time.Time
+}
+
+func NewMemoryRevokedTokensRepository() *MemoryRevokedTokensRepository {
+	return &MemoryRevokedTokensRepository{revoked: make(map[string]time.Time)}
+}
+
+func (r *MemoryRevokedTokensRepository) Revoke(ctx context.Context, key string, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.revoked[key] = expiresAt
+	return nil
+}
+
+func (r *MemoryRevokedTokensRepository) IsRevoked(ctx context.Context, keys ...string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, key := range keys {
+		if expiresAt, ok := r.revoked[key]; ok && expiresAt.After(time.Now()) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+type MemoryLoginAttemptsRepository struct {
+	mu       sync.Mutex
+	attempts map[string]schemas.LoginAttempts
+}
+
+func NewMemoryLoginAttemptsRepository() *MemoryLoginAttemptsRepository {
+	return &MemoryLoginAttemptsRepository{attempts: make(map[string]schemas.LoginAttempts)}
+}
+
+func (r *MemoryLoginAttemptsRepository) Get(ctx context.Context, key string) (schemas.LoginAttempts, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	attempts, ok := r.attempts[key]
+	if !ok || !attempts.ExpiresAt.After(time.Now()) {
+		return schemas.LoginAttempts{Key: key}, nil
+	}
+	return attempts, nil
+}
+
+func (r *MemoryLoginAttemptsRepository) Claim(ctx context.Context, key string, at time.Time, window time.Duration) (schemas.LoginAttempts, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	previous, ok := r.attempts[key]
+	if !ok || !previous.ExpiresAt.After(at) {
+		previous = schemas.LoginAttempts{Key: key}
+	}
+	r.attempts[key] = schemas.LoginAttempts{
+		Key:           key,
+		Failures:      previous.Failures + 1,
+		LastFailureAt: at,
+		ExpiresAt:     at.Add(window),
+	}
+	return previous, nil
+}
+
+func (r *MemoryLoginAttemptsRepository) Release(ctx context.Context, key string, at time.Time, previous schemas.LoginAttempts) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	attempts, ok := r.attempts[key]
+	if !ok || attempts.Failures == 0 {
+		return nil
+	}
+	attempts.Failures--
+	if attempts.LastFailureAt.Equal(at) {
+		attempts.LastFailureAt = previous.LastFailureAt
+	}
+	r.attempts[key] = attempts
+	return nil
+}
+
+func (r *MemoryLoginAttemptsRepository) Reset(ctx context.Context, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.attempts, key)
+	return nil
+}
+
+// Set sobrescreve um contador, útil para simular falhas antigas nos testes.
+func (r *MemoryLoginAttemptsRepository) Set(attempts schemas.LoginAttempts) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.attempts[attempts.Key] = attempts
+}
+
+type MemoryAdminUsersRepository struct {
+	mu    sync.Mutex
+	users map[bson.ObjectID]schemas.AdminUser
+}
+
+func NewMemoryAdminUsersRepository() *MemoryAdminUsersRepository {
+	return &MemoryAdminUsersRepository{users: make(map[bson.ObjectID]schemas.AdminUser)}
+}
+
+func (r *MemoryAdminUsersRepository) FindByID(ctx context.Context, id bson.ObjectID) (schemas.AdminUser, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return schemas.AdminUser{}, mongo.ErrNoDocuments
+	}
+	return user, nil
+}
+
+func (r *MemoryAdminUsersRepository) FindByEmail(ctx context.Context, email string) (schemas.AdminUser, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	email = NormalizeAdminEmail(email)
+	for _, user := range r.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+	return schemas.AdminUser{}, mongo.ErrNoDocuments
+}
+
+func (r *MemoryAdminUsersRepository) List(ctx context.Context) ([]schemas.AdminUser, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	users := []schemas.AdminUser{}
+	for _, user := range r.users {
+		users = append(users, user)
+	}
+	slices.SortFunc(users, func(a, b schemas.AdminUser) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return users, nil
+}
+
+func (r *MemoryAdminUsersRepository) Count(ctx context.Context) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return int64(len(r.users)), nil
+}
+
+func (r *MemoryAdminUsersRepository) Create(ctx context.Context, user schemas.AdminUser) (bson.ObjectID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user.Email = NormalizeAdminEmail(user.Email)
+	for _, existing := range r.users {
+		if existing.Email == user.Email {
+			return bson.ObjectID{}, ErrAdminEmailTaken
+		}
+	}
+
+	user.ID = bson.NewObjectID()
+	r.users[user.ID] = user
+	return user.ID, nil
+}
+
+func (r *MemoryAdminUsersRepository) Update(ctx context.Context, id bson.ObjectID, update schemas.AdminUserUpdate) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return mongo.ErrNoDocuments
+	}
+	if update.Role != nil {
+		user.Role = *update.Role
+	}
+	if update.Active != nil {
+		user.Active = *update.Active
+	}
+	if update.PasswordHash != nil {
+		user.PasswordHash = *update.PasswordHash
+	}
+	user.UpdatedAt = time.Now()
+	r.users[id] = user
+	return nil
+}
+
+func (r *MemoryAdminUsersRepository) SetLastLogin(ctx context.Context, id bson.ObjectID, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if user, ok := r.users[id]; ok {
+		user.LastLoginAt = &at
+		r.users[id] = user
+	}
+	return nil
+}
+
+type MemoryApiKeysRepository struct {
+	mu   sync.Mutex
+	keys map[bson.ObjectID]schemas.ApiKey
+}
+
+func NewMemoryApiKeysRepository() *MemoryApiKeysRepository {
+	return &MemoryApiKeysRepository{keys: make(map[bson.ObjectID]schemas.ApiKey)}
+}
+
+func (r *MemoryApiKeysRepository) Create(ctx context.Context, key schemas.ApiKey) (bson.ObjectID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key.ID = bson.NewObjectID()
+	r.keys[key.ID] = key
+	return key.ID, nil
+}
+
+func (r *MemoryApiKeysRepository) FindByID(ctx context.Context, id bson.ObjectID) (schemas.ApiKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key, ok := r.keys[id]
+	if !ok {
+		return schemas.ApiKey{}, mongo.ErrNoDocuments
+	}
+	return key, nil
+}
+
+func (r *MemoryApiKeysRepository) FindByPrefix(ctx context.Context, prefix string) (schemas.ApiKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, key := range r.keys {
+		if key.Prefix == prefix {
+			return key, nil
+		}
+	}
+	return schemas.ApiKey{}, mongo.ErrNoDocuments
+}
+
+func (r *MemoryApiKeysRepository) List(ctx context.Context) ([]schemas.ApiKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	keys := []schemas.ApiKey{}
+	for _, key := range r.keys {
+		keys = append(keys, key)
+	}
+	slices.SortFunc(keys, func(a, b schemas.ApiKey) int {
+		return b.CreatedAt.Compare(a.CreatedAt)
+	})
+	return keys, nil
+}
+
+func (r *MemoryApiKeysRepository) Revoke(ctx context.Context, id bson.ObjectID, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key, ok := r.keys[id]
+	if !ok || key.RevokedAt != nil {
+		return mongo.ErrNoDocuments
+	}
+	key.RevokedAt = &at
+	r.keys[id] = key
+	return nil
+}
+
+func (r *MemoryApiKeysRepository) MarkRotated(ctx context.Context, id bson.ObjectID, rotatedTo bson.ObjectID, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key, ok := r.keys[id]
+	if !ok {
+		return mongo.ErrNoDocuments
+	}
+	key.RotatedTo = &rotatedTo
+	key.ExpiresAt = &expiresAt
+	r.keys[id] = key
+	return nil
+}
+
+func (r *MemoryApiKeysRepository) Touch(ctx context.Context, id bson.ObjectID, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if key, ok := r.keys[id]; ok {
+		key.LastUsedAt = &at
+		r.keys[id] = key
+	}
+	return nil
+}
+
+// MemoryTransactions executa fn diretamente: os repositórios em memória não
+// desfazem alterações, então os testes não devem depender de rollback.
+type MemoryTransactions struct{}
+
+func (MemoryTransactions) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+type MemoryTinySyncJobsRepository struct {
+	mu   sync.Mutex
+	jobs []schemas.TinySyncJob
+}
+
+func NewMemoryTinySyncJobsRepository() *MemoryTinySyncJobsRepository {
+	return &MemoryTinySyncJobsRepository{}
+}
+
+func (r *MemoryTinySyncJobsRepository) Enqueue(ctx context.Context, clientID bson.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for i, job := range r.jobs {
+		if job.ClientID == clientID && job.Status == schemas.TINY_SYNC_PENDING {
+			r.jobs[i].NextAttemptAt = now
+			r.jobs[i].UpdatedAt = now
+			return nil
+		}
+	}
+
+	r.jobs = append(r.jobs, schemas.TinySyncJob{
+		ID:            bson.NewObjectID(),
+		ClientID:      clientID,
+		Status:        schemas.TINY_SYNC_PENDING,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	})
+	return nil
+}
+
+func (r *MemoryTinySyncJobsRepository) Claim(ctx context.Context, now time.Time, lockedUntil time.Time) (schemas.TinySyncJob, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	found := -1
+	for i, job := range r.jobs {
+		due := (job.Status == schemas.TINY_SYNC_PENDING && !job.NextAttemptAt.After(now)) ||
+			(job.Status == schemas.TINY_SYNC_PROCESSING && job.LockedUntil != nil && !job.LockedUntil.After(now))
+		if due && (found == -1 || job.NextAttemptAt.Before(r.jobs[found].NextAttemptAt)) {
+			found = i
+		}
+	}
+	if found == -1 {
+		return schemas.TinySyncJob{}, mongo.ErrNoDocuments
+	}
+
+	r.jobs[found].Status = schemas.TINY_SYNC_PROCESSING
+	r.jobs[found].LockedUntil = &lockedUntil
+	r.jobs[found].UpdatedAt = now
+	return r.jobs[found], nil
+}
+
+func (r *MemoryTinySyncJobsRepository) MarkDone(ctx context.Context, id bson.ObjectID) error {
+	return r.update(id, func(job *schemas.TinySyncJob) bool {
+		now := time.Now()
+		job.Status = schemas.TINY_SYNC_DONE
+		job.Attempts++
+		job.CompletedAt = &now
+		job.LockedUntil = nil
+		job.LastError = ""
+		return true
+	})
+}
+
+func (r *MemoryTinySyncJobsRepository) MarkFailed(ctx context.Context, id bson.ObjectID, lastError string, nextAttemptAt time.Time, dead bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	index := slices.IndexFunc(r.jobs, func(job schemas.TinySyncJob) bool { return job.ID == id })
+	if index == -1 {
+		return mongo.ErrNoDocuments
+	}
+	job := &r.jobs[index]
+	now := time.Now()
+
+	job.Status = schemas.TINY_SYNC_PENDING
+	if dead {
+		job.Status = schemas.TINY_SYNC_DEAD
+	}
+	// Imita o índice único de pendentes por cliente: a falha é somada ao
+	// pendente existente, como no repositório do MongoDB
+	if pending := r.pendingIndex(job.ClientID, id); !dead && pending != -1 {
+		other := &r.jobs[pending]
+		other.Attempts = max(other.Attempts, job.Attempts+1)
+		other.NextAttemptAt = maxTime(other.NextAttemptAt, nextAttemptAt)
+		other.LastError = lastError
+		other.UpdatedAt = now
+		job.Status = schemas.TINY_SYNC_SUPERSEDED
+		job.CompletedAt = &now
+	}
+	job.Attempts++
+	job.LastError = lastError
+	job.NextAttemptAt = nextAttemptAt
+	job.LockedUntil = nil
+	job.UpdatedAt = now
+	return nil
+}
+
+// pendingIndex retorna o job pendente do cliente, fora o informado, ou -1.
+// Deve ser chamado com o mutex travado.
+func (r *MemoryTinySyncJobsRepository) pendingIndex(clientID bson.ObjectID, except bson.ObjectID) int {
+	return slices.IndexFunc(r.jobs, func(job schemas.TinySyncJob) bool {
+		return job.ClientID == clientID && job.Status == schemas.TINY_SYNC_PENDING && job.ID != except
+	})
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+func (r *MemoryTinySyncJobsRepository) FindByID(ctx context.Context, id bson.ObjectID) (schemas.TinySyncJob, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, job := range r.jobs {
+		if job.ID == id {
+			return job, nil
+		}
+	}
+	return schemas.TinySyncJob{}, mongo.ErrNoDocuments
+}
+
+func (r *MemoryTinySyncJobsRepository) List(ctx context.Context, status string, limit int64) ([]schemas.TinySyncJob, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	jobs := []schemas.TinySyncJob{}
+	for _, job := range slices.Backward(r.jobs) {
+		if status != "" && job.Status != status {
+			continue
+		}
+		jobs = append(jobs, job)
+		if limit > 0 && int64(len(jobs)) == limit {
+			break
+		}
+	}
+	return jobs, nil
+}
+
+func (r *MemoryTinySyncJobsRepository) Replay(ctx context.Context, id bson.ObjectID) error {
+	r.mu.Lock()
+	index := slices.IndexFunc(r.jobs, func(job schemas.TinySyncJob) bool { return job.ID == id })
+	duplicate := index != -1 && r.jobs[index].Status == schemas.TINY_SYNC_DEAD && r.pendingIndex(r.jobs[index].ClientID, id) != -1
+	r.mu.Unlock()
+	if duplicate {
+		return mongo.WriteException{WriteErrors: []mongo.WriteError{{Code: 11000, Message: "E11000 duplicate key error"}}}
+	}
+
+	return r.update(id, func(job *schemas.TinySyncJob) bool {
+		if job.Status != schemas.TINY_SYNC_DEAD {
+			return false
+		}
+		job.Status = schemas.TINY_SYNC_PENDING
+		job.Attempts = 0
+		job.NextAttemptAt = time.Now()
+		return true
+	})
+}
+
+func (r *MemoryTinySyncJobsRepository) update(id bson.ObjectID, apply func(job *schemas.TinySyncJob) bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.jobs {
+		if r.jobs[i].ID == id {
+			if !apply(&r.jobs[i]) {
+				return mongo.ErrNoDocuments
+			}
+			r.jobs[i].UpdatedAt = time.Now()
+			return nil
+		}
+	}
+	return mongo.ErrNoDocuments
+}
+
+type MemoryTinyReconciliationsRepository struct {
+	mu              sync.Mutex
+	reconciliations []schemas.TinyReconciliation
+}
+
+func NewMemoryTinyReconciliationsRepository() *MemoryTinyReconciliationsRepository {
+	return &MemoryTinyReconciliationsRepository{}
+}
+
+func (r *MemoryTinyReconciliationsRepository) Insert(ctx context.Context, reconciliation schemas.TinyReconciliation) (bson.ObjectID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	reconciliation.ID = bson.NewObjectID()
+	r.reconciliations = append(r.reconciliations, reconciliation)
+	return reconciliation.ID, nil
+}
+
+func (r *MemoryTinyReconciliationsRepository) List(ctx context.Context, limit int64) ([]schemas.TinyReconciliation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	reconciliations := []schemas.TinyReconciliation{}
+	for _, reconciliation := range slices.Backward(r.reconciliations) {
+		reconciliations = append(reconciliations, reconciliation)
+		if limit > 0 && int64(len(reconciliations)) == limit {
+			break
+		}
+	}
+	return reconciliations, nil
+}
+
+type MemoryDataKeysRepository struct {
+	mu   sync.Mutex
+	keys []schemas.DataKey
+}
+
+func NewMemoryDataKeysRepository() *MemoryDataKeysRepository {
+	return &MemoryDataKeysRepository{}
+}
+
+func (r *MemoryDataKeysRepository) FindAll(ctx context.Context) ([]schemas.DataKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return slices.Clone(r.keys), nil
+}
+
+func (r *MemoryDataKeysRepository) Insert(ctx context.Context, key schemas.DataKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.keys = append(r.keys, key)
+	return nil
+}
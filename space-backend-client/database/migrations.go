@@ -0,0 +1,145 @@
+package database
+
+import (
+	"api/schemas"
+	"context"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// RunMigrations aplica ajustes idempotentes nos documentos e índices já
+// existentes. É chamado no main logo após a conexão, antes do servidor aceitar
+// requisições.
+func RunMigrations(ctx context.Context, client *mongo.Client) error {
+	db := client.Database(GetDB())
+	clients := db.Collection(CLIENTS_COLLECTION)
+
+	// Clientes criados antes da verificação de email continuam com acesso completo
+	_, err := clients.UpdateMany(ctx,
+		bson.D{{Key: "email_verified", Value: bson.D{{Key: "$exists", Value: false}}}},
+		bson.D{{Key: "$set", Value: bson.D{{Key: "email_verified", Value: true}}}},
+	)
+	if err != nil {
+		return err
+	}
+
+	// O refresh token único foi substituído pela coleção de sessões
+	_, err = clients.UpdateMany(ctx,
+		bson.D{{Key: "refresh_token", Value: bson.D{{Key: "$exists", Value: true}}}},
+		bson.D{{Key: "$unset", Value: bson.D{{Key: "refresh_token", Value: ""}}}},
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Collection(SESSIONS_COLLECTION).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "client_id", Value: 1}}},
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Collection(ADMIN_USERS_COLLECTION).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "email", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Collection(API_KEYS_COLLECTION).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "prefix", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Collection(AUDIT_EVENTS_COLLECTION).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "actor_type", Value: 1}, {Key: "actor_id", Value: 1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "target_type", Value: 1}, {Key: "target_id", Value: 1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "created_at", Value: -1}}},
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := ensureUniqueClientEmails(ctx, clients); err != nil {
+		return err
+	}
+
+	// Vínculos com o Tiny são consultados na sincronização e no relatório de
+	// vínculos; a maioria dos clientes não verificados ainda não tem tiny_id
+	_, err = clients.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "contact.tiny_id", Value: 1}}, Options: options.Index().SetSparse(true)},
+		{Keys: bson.D{{Key: "email_verified", Value: 1}, {Key: "_id", Value: 1}}},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Busca administrativa de clientes: documentos (pelo blind index e, nos
+	// clientes ainda não migrados, pelo valor aberto) e UF por igualdade, e as
+	// ordenações com _id para desempate do cursor. Nome, email, celular e
+	// cidade usam regex sem âncora e só se beneficiam dos índices na varredura
+	_, err = clients.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "contact.email", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "contact.name", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "updated_at", Value: -1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "contact.cpf", Value: 1}}, Options: options.Index().SetSparse(true)},
+		{Keys: bson.D{{Key: "contact.cnpj", Value: 1}}, Options: options.Index().SetSparse(true)},
+		{Keys: bson.D{{Key: "contact.cpf" + BLIND_INDEX_SUFFIX, Value: 1}}, Options: options.Index().SetSparse(true)},
+		{Keys: bson.D{{Key: "contact.cnpj" + BLIND_INDEX_SUFFIX, Value: 1}}, Options: options.Index().SetSparse(true)},
+		{Keys: bson.D{{Key: "contact.cell_phone", Value: 1}}, Options: options.Index().SetSparse(true)},
+		{Keys: bson.D{{Key: "contact.state", Value: 1}, {Key: "contact.city", Value: 1}}},
+		{Keys: bson.D{{Key: "budget_ids", Value: 1}}},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Um único job pendente por cliente; os concluídos somem depois de 7 dias
+	_, err = db.Collection(TINY_SYNC_JOBS_COLLECTION).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "client_id", Value: 1}},
+			Options: options.Index().SetUnique(true).
+				SetPartialFilterExpression(bson.D{{Key: "status", Value: schemas.TINY_SYNC_PENDING}}),
+		},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "next_attempt_at", Value: 1}}},
+		{Keys: bson.D{{Key: "completed_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(7 * 24 * 60 * 60)},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Os relatórios da reconciliação trazem dados pessoais e ficam 90 dias
+	_, err = db.Collection(TINY_RECONCILIATIONS_COLLECTION).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "finished_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(90 * 24 * 60 * 60),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Relatórios anteriores à máscara ainda têm CPF e CNPJ abertos
+	if err := maskReconciliationDocuments(ctx, db.Collection(TINY_RECONCILIATIONS_COLLECTION)); err != nil {
+		return err
+	}
+
+	// Coleções que só guardam dados temporários expiram pelo campo expires_at
+	for _, collection := range []string{REVOKED_TOKENS_COLLECTION, LOGIN_ATTEMPTS_COLLECTION} {
+		_, err = db.Collection(collection).Indexes().CreateOne(ctx, mongo.IndexModel{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
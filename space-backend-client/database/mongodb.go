@@ -2,8 +2,13 @@ package database
 
 import (
 	"api/utils"
+	"context"
 	"os"
 	"time"
+
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+	"go.mongodb.org/mongo-driver/v2/mongo/readpref"
 )
 
 func GetDB() string {
@@ -22,4 +27,32 @@ func GetDB() string {
 
 const (
 	MONGODB_TIMEOUT = 20 * time.Second
+
+	MONGODB_MAX_POOL_SIZE = 100
+	MONGODB_MIN_POOL_SIZE = 5
 )
+
+// Connect abre o client MongoDB compartilhado pela aplicação. Deve ser chamado
+// uma única vez no main; o driver mantém o pool de conexões internamente.
+func Connect() (*mongo.Client, error) {
+	mongoURI := os.Getenv(utils.ENV_MONGODB_URI)
+	opts := options.Client().
+		ApplyURI(mongoURI).
+		SetMaxPoolSize(MONGODB_MAX_POOL_SIZE).
+		SetMinPoolSize(MONGODB_MIN_POOL_SIZE)
+
+	client, err := mongo.Connect(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), MONGODB_TIMEOUT)
+	defer cancel()
+
+	if err := client.Ping(ctx, readpref.Primary()); err != nil {
+		client.Disconnect(ctx)
+		return nil, err
+	}
+
+	return client, nil
+}
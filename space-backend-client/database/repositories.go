@@ -0,0 +1,60 @@
+package database
+
+import (
+	"api/crypto"
+
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+const (
+	CLIENTS_COLLECTION              = "clients"
+	UNIFORMS_COLLECTION             = "uniforms"
+	WHATSAPP_EVENTS_COLLECTION      = "whatsapp_events"
+	SESSIONS_COLLECTION             = "sessions"
+	AUDIT_EVENTS_COLLECTION         = "audit_events"
+	REVOKED_TOKENS_COLLECTION       = "revoked_tokens"
+	LOGIN_ATTEMPTS_COLLECTION       = "login_attempts"
+	ADMIN_USERS_COLLECTION          = "admin_users"
+	API_KEYS_COLLECTION             = "api_keys"
+	TINY_SYNC_JOBS_COLLECTION       = "tiny_sync_jobs"
+	TINY_RECONCILIATIONS_COLLECTION = "tiny_reconciliations"
+	DATA_KEYS_COLLECTION            = "data_keys"
+)
+
+// Repositories agrupa o acesso a todas as coleções. É criado uma vez no main
+// e injetado nos handlers; nos testes cada campo pode ser trocado por um fake.
+type Repositories struct {
+	Clients             ClientsRepository
+	Uniforms            UniformsRepository
+	WhatsappEvents      WhatsappEventsRepository
+	Sessions            SessionsRepository
+	AuditEvents         AuditEventsRepository
+	RevokedTokens       RevokedTokensRepository
+	LoginAttempts       LoginAttemptsRepository
+	AdminUsers          AdminUsersRepository
+	ApiKeys             ApiKeysRepository
+	TinySyncJobs        TinySyncJobsRepository
+	TinyReconciliations TinyReconciliationsRepository
+	Transactions        Transactions
+}
+
+// NewRepositories recebe o keyring usado para cifrar os documentos dos
+// clientes (nil grava em texto aberto).
+func NewRepositories(client *mongo.Client, keyring *crypto.Keyring) *Repositories {
+	db := client.Database(GetDB())
+
+	return &Repositories{
+		Clients:             NewMongoClientsRepository(db.Collection(CLIENTS_COLLECTION), keyring),
+		Uniforms:            NewMongoUniformsRepository(db.Collection(UNIFORMS_COLLECTION)),
+		WhatsappEvents:      NewMongoWhatsappEventsRepository(db.Collection(WHATSAPP_EVENTS_COLLECTION)),
+		Sessions:            NewMongoSessionsRepository(db.Collection(SESSIONS_COLLECTION)),
+		AuditEvents:         NewMongoAuditEventsRepository(db.Collection(AUDIT_EVENTS_COLLECTION)),
+		RevokedTokens:       NewMongoRevokedTokensRepository(db.Collection(REVOKED_TOKENS_COLLECTION)),
+		LoginAttempts:       NewMongoLoginAttemptsRepository(db.Collection(LOGIN_ATTEMPTS_COLLECTION)),
+		AdminUsers:          NewMongoAdminUsersRepository(db.Collection(ADMIN_USERS_COLLECTION)),
+		ApiKeys:             NewMongoApiKeysRepository(db.Collection(API_KEYS_COLLECTION)),
+		TinySyncJobs:        NewMongoTinySyncJobsRepository(db.Collection(TINY_SYNC_JOBS_COLLECTION)),
+		TinyReconciliations: NewMongoTinyReconciliationsRepository(db.Collection(TINY_RECONCILIATIONS_COLLECTION)),
+		Transactions:        NewMongoTransactions(client),
+	}
+}
@@ -0,0 +1,50 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// RevokedTokensRepository encapsula a coleção "revoked_tokens", a denylist de
+// access tokens consultada por utils.ValidateAccessKey. Cada entrada só vive
+// até o token expirar (índice TTL em expires_at).
+type RevokedTokensRepository interface {
+	Revoke(ctx context.Context, key string, expiresAt time.Time) error
+	IsRevoked(ctx context.Context, keys ...string) (bool, error)
+}
+
+type MongoRevokedTokensRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoRevokedTokensRepository(collection *mongo.Collection) *MongoRevokedTokensRepository {
+	return &MongoRevokedTokensRepository{collection: collection}
+}
+
+func (r *MongoRevokedTokensRepository) Revoke(ctx context.Context, key string, expiresAt time.Time) error {
+	_, err := r.collection.UpdateOne(ctx,
+		bson.D{{Key: "_id", Value: key}},
+		bson.D{{Key: "$set", Value: bson.D{{Key: "expires_at", Value: expiresAt}}}},
+		options.UpdateOne().SetUpsert(true),
+	)
+	return err
+}
+
+func (r *MongoRevokedTokensRepository) IsRevoked(ctx context.Context, keys ...string) (bool, error) {
+	// O TTL do Mongo roda a cada minuto, então a expiração também é filtrada aqui
+	filter := bson.D{
+		{Key: "_id", Value: bson.D{{Key: "$in", Value: keys}}},
+		{Key: "expires_at", Value: bson.D{{Key: "$gt", Value: time.Now()}}},
+	}
+
+	count, err := r.collection.CountDocuments(ctx, filter, options.Count().SetLimit(1))
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
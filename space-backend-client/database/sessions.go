@@ -0,0 +1,119 @@
+package database
+
+import (
+	"api/schemas"
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// SessionsRepository encapsula a coleção "sessions". Sessões expiradas são
+// removidas pelo índice TTL em expires_at (ver RunMigrations).
+type SessionsRepository interface {
+	Create(ctx context.Context, session schemas.Session) error
+	FindByID(ctx context.Context, id bson.ObjectID) (schemas.Session, error)
+	// FindByClientID retorna as sessões ativas, da usada mais recentemente
+	// para a mais antiga.
+	FindByClientID(ctx context.Context, clientID bson.ObjectID) ([]schemas.Session, error)
+	// Rotate troca o refresh token apenas se currentHash ainda for o atual,
+	// retornando mongo.ErrNoDocuments quando outra requisição rotacionou antes.
+	Rotate(ctx context.Context, id bson.ObjectID, currentHash string, newHash string, expiresAt time.Time) error
+	Delete(ctx context.Context, id bson.ObjectID, clientID bson.ObjectID) error
+	DeleteByClientID(ctx context.Context, clientID bson.ObjectID) error
+}
+
+type MongoSessionsRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoSessionsRepository(collection *mongo.Collection) *MongoSessionsRepository {
+	return &MongoSessionsRepository{collection: collection}
+}
+
+func (r *MongoSessionsRepository) Create(ctx context.Context, session schemas.Session) error {
+	_, err := r.collection.InsertOne(ctx, session)
+	return err
+}
+
+func (r *MongoSessionsRepository) FindByID(ctx context.Context, id bson.ObjectID) (schemas.Session, error) {
+	filter := bson.D{
+		{Key: "_id", Value: id},
+		{Key: "expires_at", Value: bson.D{{Key: "$gt", Value: time.Now()}}},
+	}
+
+	session := schemas.Session{}
+	err := r.collection.FindOne(ctx, filter).Decode(&session)
+	return session, err
+}
+
+func (r *MongoSessionsRepository) FindByClientID(ctx context.Context, clientID bson.ObjectID) ([]schemas.Session, error) {
+	filter := bson.D{
+		{Key: "client_id", Value: clientID},
+		{Key: "expires_at", Value: bson.D{{Key: "$gt", Value: time.Now()}}},
+	}
+
+	cursor, err := r.collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "last_used_at", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var sessions []schemas.Session
+	if err := cursor.All(ctx, &sessions); err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+func (r *MongoSessionsRepository) Rotate(ctx context.Context, id bson.ObjectID, currentHash string, newHash string, expiresAt time.Time) error {
+	now := time.Now()
+	filter := bson.D{
+		{Key: "_id", Value: id},
+		{Key: "refresh_token_hash", Value: currentHash},
+	}
+	update := bson.D{
+		{Key: "$set", Value: bson.D{
+			{Key: "refresh_token_hash", Value: newHash},
+			{Key: "previous_token_hash", Value: currentHash},
+			{Key: "rotated_at", Value: now},
+			{Key: "last_used_at", Value: now},
+			{Key: "expires_at", Value: expiresAt},
+		}},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+func (r *MongoSessionsRepository) Delete(ctx context.Context, id bson.ObjectID, clientID bson.ObjectID) error {
+	result, err := r.collection.DeleteOne(ctx, bson.D{
+		{Key: "_id", Value: id},
+		{Key: "client_id", Value: clientID},
+	})
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+func (r *MongoSessionsRepository) DeleteByClientID(ctx context.Context, clientID bson.ObjectID) error {
+	_, err := r.collection.DeleteMany(ctx, bson.D{{Key: "client_id", Value: clientID}})
+	return err
+}
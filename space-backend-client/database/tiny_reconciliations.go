@@ -0,0 +1,108 @@
+package database
+
+import (
+	"api/documents"
+	"api/schemas"
+	"context"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// TinyReconciliationsRepository encapsula a coleção "tiny_reconciliations",
+// com os relatórios das reconciliações entre os clientes e o Tiny.
+type TinyReconciliationsRepository interface {
+	Insert(ctx context.Context, reconciliation schemas.TinyReconciliation) (bson.ObjectID, error)
+	// List retorna os relatórios do mais recente para o mais antigo.
+	List(ctx context.Context, limit int64) ([]schemas.TinyReconciliation, error)
+}
+
+type MongoTinyReconciliationsRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoTinyReconciliationsRepository(collection *mongo.Collection) *MongoTinyReconciliationsRepository {
+	return &MongoTinyReconciliationsRepository{collection: collection}
+}
+
+func (r *MongoTinyReconciliationsRepository) Insert(ctx context.Context, reconciliation schemas.TinyReconciliation) (bson.ObjectID, error) {
+	result, err := r.collection.InsertOne(ctx, reconciliation)
+	if err != nil {
+		return bson.ObjectID{}, err
+	}
+
+	id, _ := result.InsertedID.(bson.ObjectID)
+	return id, nil
+}
+
+func (r *MongoTinyReconciliationsRepository) List(ctx context.Context, limit int64) ([]schemas.TinyReconciliation, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: -1}}).SetLimit(limit)
+
+	cursor, err := r.collection.Find(ctx, bson.D{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	reconciliations := []schemas.TinyReconciliation{}
+	if err := cursor.All(ctx, &reconciliations); err != nil {
+		return nil, err
+	}
+	return reconciliations, nil
+}
+
+// MaskDriftDocuments mascara os valores de CPF e CNPJ das divergências (ver
+// documents.MaskDocument). No cadastro eles ficam cifrados, e o relatório só
+// precisa mostrar que divergem. Retorna se algum valor ainda estava aberto.
+func MaskDriftDocuments(fields []schemas.TinyDriftField) bool {
+	changed := false
+	for i := range fields {
+		if fields[i].Field != "cpf" && fields[i].Field != "cnpj" {
+			continue
+		}
+		ours, theirs := documents.MaskDocument(fields[i].Ours), documents.MaskDocument(fields[i].Theirs)
+		if ours != fields[i].Ours || theirs != fields[i].Theirs {
+			fields[i].Ours, fields[i].Theirs = ours, theirs
+			changed = true
+		}
+	}
+	return changed
+}
+
+// maskReconciliationDocuments regrava com os documentos mascarados os
+// relatórios gravados antes da máscara.
+func maskReconciliationDocuments(ctx context.Context, collection *mongo.Collection) error {
+	filter := bson.D{{Key: "drifts.fields.field", Value: bson.D{{Key: "$in", Value: bson.A{"cpf", "cnpj"}}}}}
+	cursor, err := collection.Find(ctx, filter)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		report := schemas.TinyReconciliation{}
+		if err := cursor.Decode(&report); err != nil {
+			return err
+		}
+
+		changed := false
+		for i := range report.Drifts {
+			if MaskDriftDocuments(report.Drifts[i].Fields) {
+				changed = true
+			}
+		}
+		if !changed {
+			continue
+		}
+
+		_, err := collection.UpdateOne(ctx,
+			bson.D{{Key: "_id", Value: report.ID}},
+			bson.D{{Key: "$set", Value: bson.D{{Key: "drifts", Value: report.Drifts}}}},
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
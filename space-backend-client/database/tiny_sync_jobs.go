@@ -0,0 +1,228 @@
+package database
+
+import (
+	"api/schemas"
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// TinySyncJobsRepository encapsula a coleção "tiny_sync_jobs", a fila
+// (outbox) de contatos a enviar ao Tiny. Os jobs são gravados na mesma
+// transação da alteração do cliente (ver Transactions).
+type TinySyncJobsRepository interface {
+	// Enqueue agenda a sincronização do cliente. Se já houver um job pendente
+	// para ele, apenas antecipa a próxima tentativa.
+	Enqueue(ctx context.Context, clientID bson.ObjectID) error
+	// Claim reserva o próximo job vencido (pendente, ou em processamento com o
+	// prazo esgotado) até lockedUntil. Retorna mongo.ErrNoDocuments se não
+	// houver nenhum.
+	Claim(ctx context.Context, now time.Time, lockedUntil time.Time) (schemas.TinySyncJob, error)
+	MarkDone(ctx context.Context, id bson.ObjectID) error
+	// MarkFailed registra a tentativa; com dead, o job vai para a fila de
+	// mortos, senão volta a pendente em nextAttemptAt. Se o cliente ganhou
+	// outro job pendente enquanto este processava, a falha é somada a ele e
+	// este fica como superseded.
+	MarkFailed(ctx context.Context, id bson.ObjectID, lastError string, nextAttemptAt time.Time, dead bool) error
+	FindByID(ctx context.Context, id bson.ObjectID) (schemas.TinySyncJob, error)
+	// List retorna os jobs do status informado (todos, se vazio), do mais
+	// recente para o mais antigo.
+	List(ctx context.Context, status string, limit int64) ([]schemas.TinySyncJob, error)
+	// Replay devolve um job morto à fila, com as tentativas zeradas. Retorna
+	// mongo.ErrNoDocuments se o job não existir ou não estiver morto.
+	Replay(ctx context.Context, id bson.ObjectID) error
+}
+
+type MongoTinySyncJobsRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoTinySyncJobsRepository(collection *mongo.Collection) *MongoTinySyncJobsRepository {
+	return &MongoTinySyncJobsRepository{collection: collection}
+}
+
+func (r *MongoTinySyncJobsRepository) Enqueue(ctx context.Context, clientID bson.ObjectID) error {
+	now := time.Now()
+	filter := bson.D{
+		{Key: "client_id", Value: clientID},
+		{Key: "status", Value: schemas.TINY_SYNC_PENDING},
+	}
+	update := bson.D{
+		{Key: "$set", Value: bson.D{
+			{Key: "next_attempt_at", Value: now},
+			{Key: "updated_at", Value: now},
+		}},
+		{Key: "$setOnInsert", Value: bson.D{
+			{Key: "attempts", Value: 0},
+			{Key: "created_at", Value: now},
+		}},
+	}
+
+	_, err := r.collection.UpdateOne(ctx, filter, update, options.UpdateOne().SetUpsert(true))
+	return err
+}
+
+func (r *MongoTinySyncJobsRepository) Claim(ctx context.Context, now time.Time, lockedUntil time.Time) (schemas.TinySyncJob, error) {
+	filter := bson.D{{Key: "$or", Value: bson.A{
+		bson.D{
+			{Key: "status", Value: schemas.TINY_SYNC_PENDING},
+			{Key: "next_attempt_at", Value: bson.D{{Key: "$lte", Value: now}}},
+		},
+		bson.D{
+			{Key: "status", Value: schemas.TINY_SYNC_PROCESSING},
+			{Key: "locked_until", Value: bson.D{{Key: "$lte", Value: now}}},
+		},
+	}}}
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: "status", Value: schemas.TINY_SYNC_PROCESSING},
+		{Key: "locked_until", Value: lockedUntil},
+		{Key: "updated_at", Value: now},
+	}}}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "next_attempt_at", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	job := schemas.TinySyncJob{}
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&job)
+	return job, err
+}
+
+func (r *MongoTinySyncJobsRepository) MarkDone(ctx context.Context, id bson.ObjectID) error {
+	now := time.Now()
+	update := bson.D{
+		{Key: "$set", Value: bson.D{
+			{Key: "status", Value: schemas.TINY_SYNC_DONE},
+			{Key: "completed_at", Value: now},
+			{Key: "updated_at", Value: now},
+		}},
+		{Key: "$unset", Value: bson.D{
+			{Key: "locked_until", Value: ""},
+			{Key: "last_error", Value: ""},
+		}},
+		{Key: "$inc", Value: bson.D{{Key: "attempts", Value: 1}}},
+	}
+	return r.update(ctx, bson.D{{Key: "_id", Value: id}}, update)
+}
+
+func (r *MongoTinySyncJobsRepository) MarkFailed(ctx context.Context, id bson.ObjectID, lastError string, nextAttemptAt time.Time, dead bool) error {
+	status := schemas.TINY_SYNC_PENDING
+	if dead {
+		status = schemas.TINY_SYNC_DEAD
+	}
+
+	update := bson.D{
+		{Key: "$set", Value: bson.D{
+			{Key: "status", Value: status},
+			{Key: "last_error", Value: lastError},
+			{Key: "next_attempt_at", Value: nextAttemptAt},
+			{Key: "updated_at", Value: time.Now()},
+		}},
+		{Key: "$unset", Value: bson.D{{Key: "locked_until", Value: ""}}},
+		{Key: "$inc", Value: bson.D{{Key: "attempts", Value: 1}}},
+	}
+	err := r.update(ctx, bson.D{{Key: "_id", Value: id}}, update)
+	if !mongo.IsDuplicateKeyError(err) {
+		return err
+	}
+	return r.supersede(ctx, id, lastError, nextAttemptAt)
+}
+
+// supersede soma a falha do job ao job pendente do mesmo cliente, que o
+// índice único impede de coexistir com outro pendente: o pendente herda as
+// tentativas e a espera, e o job que falhou é encerrado.
+func (r *MongoTinySyncJobsRepository) supersede(ctx context.Context, id bson.ObjectID, lastError string, nextAttemptAt time.Time) error {
+	job, err := r.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	filter := bson.D{
+		{Key: "client_id", Value: job.ClientID},
+		{Key: "status", Value: schemas.TINY_SYNC_PENDING},
+	}
+	update := bson.D{
+		{Key: "$set", Value: bson.D{
+			{Key: "last_error", Value: lastError},
+			{Key: "updated_at", Value: now},
+		}},
+		{Key: "$max", Value: bson.D{
+			{Key: "attempts", Value: job.Attempts + 1},
+			{Key: "next_attempt_at", Value: nextAttemptAt},
+		}},
+	}
+	// Sem pendente (já foi pego por um worker), o job em andamento já envia o
+	// contato mais recente
+	if _, err := r.collection.UpdateOne(ctx, filter, update); err != nil {
+		return err
+	}
+
+	update = bson.D{
+		{Key: "$set", Value: bson.D{
+			{Key: "status", Value: schemas.TINY_SYNC_SUPERSEDED},
+			{Key: "last_error", Value: lastError},
+			{Key: "completed_at", Value: now},
+			{Key: "updated_at", Value: now},
+		}},
+		{Key: "$unset", Value: bson.D{{Key: "locked_until", Value: ""}}},
+		{Key: "$inc", Value: bson.D{{Key: "attempts", Value: 1}}},
+	}
+	return r.update(ctx, bson.D{{Key: "_id", Value: id}}, update)
+}
+
+func (r *MongoTinySyncJobsRepository) FindByID(ctx context.Context, id bson.ObjectID) (schemas.TinySyncJob, error) {
+	job := schemas.TinySyncJob{}
+	err := r.collection.FindOne(ctx, bson.D{{Key: "_id", Value: id}}).Decode(&job)
+	return job, err
+}
+
+func (r *MongoTinySyncJobsRepository) List(ctx context.Context, status string, limit int64) ([]schemas.TinySyncJob, error) {
+	filter := bson.D{}
+	if status != "" {
+		filter = append(filter, bson.E{Key: "status", Value: status})
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: -1}}).SetLimit(limit)
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	jobs := []schemas.TinySyncJob{}
+	if err := cursor.All(ctx, &jobs); err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+func (r *MongoTinySyncJobsRepository) Replay(ctx context.Context, id bson.ObjectID) error {
+	now := time.Now()
+	filter := bson.D{
+		{Key: "_id", Value: id},
+		{Key: "status", Value: schemas.TINY_SYNC_DEAD},
+	}
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: "status", Value: schemas.TINY_SYNC_PENDING},
+		{Key: "attempts", Value: 0},
+		{Key: "next_attempt_at", Value: now},
+		{Key: "updated_at", Value: now},
+	}}}
+	return r.update(ctx, filter, update)
+}
+
+func (r *MongoTinySyncJobsRepository) update(ctx context.Context, filter bson.D, update bson.D) error {
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
@@ -0,0 +1,36 @@
+package database
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// Transactions executa alterações em mais de uma coleção de forma atômica.
+// Os repositórios chamados dentro de fn devem usar o ctx recebido.
+type Transactions interface {
+	WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// MongoTransactions usa transações do MongoDB, que exigem replica set (ou
+// cluster do Atlas).
+type MongoTransactions struct {
+	client *mongo.Client
+}
+
+func NewMongoTransactions(client *mongo.Client) *MongoTransactions {
+	return &MongoTransactions{client: client}
+}
+
+func (t *MongoTransactions) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	session, err := t.client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(ctx context.Context) (any, error) {
+		return nil, fn(ctx)
+	})
+	return err
+}
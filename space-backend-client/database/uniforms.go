@@ -0,0 +1,113 @@
+package database
+
+import (
+	"api/schemas"
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// UniformsRepository encapsula a coleção "uniforms". Buscas sem resultado
+// retornam mongo.ErrNoDocuments.
+type UniformsRepository interface {
+	FindByID(ctx context.Context, id bson.ObjectID) (schemas.UniformFromDB, error)
+	FindOneByBudgetID(ctx context.Context, budgetID int) (schemas.UniformFromDB, error)
+	FindOneByClientAndBudget(ctx context.Context, clientID string, budgetID int) (schemas.UniformFromDB, error)
+	FindByBudgetID(ctx context.Context, budgetID int) ([]schemas.UniformFromDB, error)
+	// FindByClientID retorna os uniformes do cliente do mais novo para o mais antigo.
+	FindByClientID(ctx context.Context, clientID string) ([]schemas.UniformFromDB, error)
+	FindByClientIDs(ctx context.Context, clientIDs []string) ([]schemas.UniformFromDB, error)
+	Create(ctx context.Context, uniform schemas.UniformToDB) (bson.ObjectID, error)
+	UpdateSketches(ctx context.Context, id bson.ObjectID, sketches []schemas.Sketch, editable bool) error
+}
+
+type MongoUniformsRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoUniformsRepository(collection *mongo.Collection) *MongoUniformsRepository {
+	return &MongoUniformsRepository{collection: collection}
+}
+
+func (r *MongoUniformsRepository) FindByID(ctx context.Context, id bson.ObjectID) (schemas.UniformFromDB, error) {
+	return r.findOne(ctx, bson.D{{Key: "_id", Value: id}})
+}
+
+func (r *MongoUniformsRepository) FindOneByBudgetID(ctx context.Context, budgetID int) (schemas.UniformFromDB, error) {
+	return r.findOne(ctx, bson.D{{Key: "budget_id", Value: budgetID}})
+}
+
+func (r *MongoUniformsRepository) FindOneByClientAndBudget(ctx context.Context, clientID string, budgetID int) (schemas.UniformFromDB, error) {
+	return r.findOne(ctx, bson.D{
+		{Key: "client_id", Value: clientID},
+		{Key: "budget_id", Value: budgetID},
+	})
+}
+
+func (r *MongoUniformsRepository) FindByBudgetID(ctx context.Context, budgetID int) ([]schemas.UniformFromDB, error) {
+	return r.find(ctx, bson.D{{Key: "budget_id", Value: budgetID}})
+}
+
+func (r *MongoUniformsRepository) FindByClientID(ctx context.Context, clientID string) ([]schemas.UniformFromDB, error) {
+	findOptions := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	return r.find(ctx, bson.D{{Key: "client_id", Value: clientID}}, findOptions)
+}
+
+func (r *MongoUniformsRepository) FindByClientIDs(ctx context.Context, clientIDs []string) ([]schemas.UniformFromDB, error) {
+	return r.find(ctx, bson.D{{Key: "client_id", Value: bson.D{{Key: "$in", Value: clientIDs}}}})
+}
+
+func (r *MongoUniformsRepository) Create(ctx context.Context, uniform schemas.UniformToDB) (bson.ObjectID, error) {
+	result, err := r.collection.InsertOne(ctx, uniform)
+	if err != nil {
+		return bson.ObjectID{}, err
+	}
+
+	id, _ := result.InsertedID.(bson.ObjectID)
+	return id, nil
+}
+
+func (r *MongoUniformsRepository) UpdateSketches(ctx context.Context, id bson.ObjectID, sketches []schemas.Sketch, editable bool) error {
+	update := bson.D{
+		{Key: "$set", Value: bson.D{
+			{Key: "sketches", Value: sketches},
+			{Key: "editable", Value: editable},
+			{Key: "updated_at", Value: time.Now()},
+		}},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.D{{Key: "_id", Value: id}}, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+func (r *MongoUniformsRepository) findOne(ctx context.Context, filter bson.D) (schemas.UniformFromDB, error) {
+	uniform := schemas.UniformFromDB{}
+	err := r.collection.FindOne(ctx, filter).Decode(&uniform)
+	return uniform, err
+}
+
+func (r *MongoUniformsRepository) find(ctx context.Context, filter bson.D, opts ...options.Lister[options.FindOptions]) ([]schemas.UniformFromDB, error) {
+	cursor, err := r.collection.Find(ctx, filter, opts...)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var uniforms []schemas.UniformFromDB
+	if err := cursor.All(ctx, &uniforms); err != nil {
+		return nil, err
+	}
+
+	return uniforms, nil
+}
@@ -0,0 +1,143 @@
+package database
+
+import (
+	"api/schemas"
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// WhatsappEventsRepository encapsula a coleção "whatsapp_events", que guarda
+// os payloads brutos do webhook e as mensagens enviadas.
+type WhatsappEventsRepository interface {
+	Insert(ctx context.Context, rawEvent any, receivedAt time.Time) error
+	// FindAll retorna os documentos em ordem de received_at; cada handler de
+	// histórico decodifica o raw_event no formato que precisa.
+	FindAll(ctx context.Context) ([]bson.Raw, error)
+	// FindByPhones retorna, em ordem de received_at, os eventos em que algum
+	// dos wa_id aparece como remetente, destinatário ou contato.
+	FindByPhones(ctx context.Context, phones []string) ([]schemas.WhatsappEvent, error)
+	ReplaceRawEvent(ctx context.Context, id bson.ObjectID, rawEvent any) error
+}
+
+// whatsappPhonePaths são os campos do payload do WhatsApp que guardam o
+// telefone (wa_id) de quem conversa com a empresa.
+var whatsappPhonePaths = []string{
+	"raw_event.entry.changes.value.messages.from",
+	"raw_event.entry.changes.value.messages.to",
+	"raw_event.entry.changes.value.contacts.wa_id",
+	"raw_event.entry.changes.value.statuses.recipient_id",
+}
+
+// whatsappEventParties lê dos mesmos campos de whatsappPhonePaths.
+type whatsappEventParties struct {
+	Entry []struct {
+		Changes []struct {
+			Value struct {
+				Messages []struct {
+					From string
+					To   string
+				}
+				Contacts []struct {
+					WaID string `bson:"wa_id"`
+				}
+				Statuses []struct {
+					RecipientID string `bson:"recipient_id"`
+				}
+			}
+		}
+	}
+}
+
+func (p whatsappEventParties) phones() []string {
+	var phones []string
+	for _, entry := range p.Entry {
+		for _, change := range entry.Changes {
+			for _, message := range change.Value.Messages {
+				phones = append(phones, message.From, message.To)
+			}
+			for _, contact := range change.Value.Contacts {
+				phones = append(phones, contact.WaID)
+			}
+			for _, status := range change.Value.Statuses {
+				phones = append(phones, status.RecipientID)
+			}
+		}
+	}
+	return phones
+}
+
+type MongoWhatsappEventsRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoWhatsappEventsRepository(collection *mongo.Collection) *MongoWhatsappEventsRepository {
+	return &MongoWhatsappEventsRepository{collection: collection}
+}
+
+func (r *MongoWhatsappEventsRepository) Insert(ctx context.Context, rawEvent any, receivedAt time.Time) error {
+	doc := bson.D{
+		{Key: "raw_event", Value: rawEvent},
+		{Key: "received_at", Value: receivedAt},
+	}
+
+	_, err := r.collection.InsertOne(ctx, doc)
+	return err
+}
+
+func (r *MongoWhatsappEventsRepository) FindAll(ctx context.Context) ([]bson.Raw, error) {
+	cursor, err := r.collection.Find(ctx, bson.D{}, options.Find().SetSort(bson.D{{Key: "received_at", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var docs []bson.Raw
+	for cursor.Next(ctx) {
+		// cursor.Current só é válido até a próxima chamada de Next
+		docs = append(docs, bson.Raw(append([]byte(nil), cursor.Current...)))
+	}
+
+	return docs, cursor.Err()
+}
+
+// FindByPhones percorre a coleção: os campos ficam dentro do payload bruto e
+// não têm índice, o que é aceitável para exportações e anonimizações.
+func (r *MongoWhatsappEventsRepository) FindByPhones(ctx context.Context, phones []string) ([]schemas.WhatsappEvent, error) {
+	if len(phones) == 0 {
+		return nil, nil
+	}
+
+	or := bson.A{}
+	for _, path := range whatsappPhonePaths {
+		or = append(or, bson.D{{Key: path, Value: bson.D{{Key: "$in", Value: phones}}}})
+	}
+
+	cursor, err := r.collection.Find(ctx, bson.D{{Key: "$or", Value: or}}, options.Find().SetSort(bson.D{{Key: "received_at", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var events []schemas.WhatsappEvent
+	err = cursor.All(ctx, &events)
+	return events, err
+}
+
+func (r *MongoWhatsappEventsRepository) ReplaceRawEvent(ctx context.Context, id bson.ObjectID, rawEvent any) error {
+	result, err := r.collection.UpdateOne(ctx, bson.D{{Key: "_id", Value: id}}, bson.D{
+		{Key: "$set", Value: bson.D{{Key: "raw_event", Value: rawEvent}}},
+	})
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
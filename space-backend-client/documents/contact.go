@@ -0,0 +1,36 @@
+package documents
+
+import "api/schemas"
+
+// NormalizeContact grava CPF, CNPJ, CEPs, UFs e celular no formato
+// normalizado. Campos vazios ou inválidos (já rejeitados pela validação da
+// requisição) ficam como estão.
+func NormalizeContact(contact schemas.Contact) schemas.Contact {
+	normalize(&contact.CPF, NormalizeCPF)
+	normalize(&contact.CNPJ, NormalizeCNPJ)
+	normalize(&contact.ZipCode, NormalizeCEP)
+	normalize(&contact.BillingZipCode, NormalizeCEP)
+	normalize(&contact.State, NormalizeUF)
+	normalize(&contact.BillingState, NormalizeUF)
+	normalize(&contact.CellPhone, NormalizePhone)
+	return contact
+}
+
+// FormatContact formata os documentos para exibição.
+func FormatContact(contact schemas.Contact) schemas.Contact {
+	contact.CPF = FormatCPF(contact.CPF)
+	contact.CNPJ = FormatCNPJ(contact.CNPJ)
+	contact.ZipCode = FormatCEP(contact.ZipCode)
+	contact.BillingZipCode = FormatCEP(contact.BillingZipCode)
+	contact.CellPhone = FormatPhone(contact.CellPhone)
+	return contact
+}
+
+func normalize(value *string, normalizer func(string) (string, error)) {
+	if *value == "" {
+		return
+	}
+	if normalized, err := normalizer(*value); err == nil {
+		*value = normalized
+	}
+}
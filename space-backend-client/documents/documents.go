@@ -0,0 +1,239 @@
+// Package documents valida e normaliza os documentos e códigos brasileiros do
+// cadastro de clientes: CPF, CNPJ, CEP, UF e telefone.
+//
+// Os valores são gravados normalizados (CPF, CNPJ e CEP só com dígitos, UF em
+// maiúsculas e telefone em E.164) e formatados apenas na saída, nas respostas
+// da API e no envio ao Tiny.
+package documents
+
+import (
+	"errors"
+	"slices"
+	"strings"
+)
+
+var (
+	ErrInvalidCPF   = errors.New("CPF inválido")
+	ErrInvalidCNPJ  = errors.New("CNPJ inválido")
+	ErrInvalidCEP   = errors.New("CEP inválido")
+	ErrInvalidUF    = errors.New("UF inválida")
+	ErrInvalidPhone = errors.New("telefone inválido")
+)
+
+const BRAZIL_COUNTRY_CODE = "55"
+
+// DOCUMENT_VISIBLE_DIGITS são os dígitos que MaskDocument deixa à mostra
+const DOCUMENT_VISIBLE_DIGITS = 2
+
+var states = []string{
+	"AC", "AL", "AP", "AM", "BA", "CE", "DF", "ES", "GO", "MA", "MT", "MS", "MG", "PA",
+	"PB", "PR", "PE", "PI", "RJ", "RN", "RS", "RO", "RR", "SC", "SP", "SE", "TO",
+}
+
+// Digits remove tudo o que não é dígito.
+func Digits(s string) string {
+	var b strings.Builder
+	for _, c := range s {
+		if c >= '0' && c <= '9' {
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
+
+// punctuated aceita apenas dígitos e a pontuação usual dos documentos, para
+// não transformar um texto qualquer em um número válido.
+func punctuated(s string) bool {
+	for _, c := range s {
+		if (c < '0' || c > '9') && !strings.ContainsRune(".-/ ()+", c) {
+			return false
+		}
+	}
+	return true
+}
+
+// NormalizeCPF confere os dígitos verificadores e retorna o CPF só com dígitos.
+func NormalizeCPF(cpf string) (string, error) {
+	digits := Digits(cpf)
+	if !punctuated(cpf) || len(digits) != 11 || repeated(digits) {
+		return "", ErrInvalidCPF
+	}
+
+	if checkDigit(digits[:9], 10) != digits[9] || checkDigit(digits[:10], 11) != digits[10] {
+		return "", ErrInvalidCPF
+	}
+	return digits, nil
+}
+
+// NormalizeCNPJ confere os dígitos verificadores e retorna o CNPJ só com dígitos.
+func NormalizeCNPJ(cnpj string) (string, error) {
+	digits := Digits(cnpj)
+	if !punctuated(cnpj) || len(digits) != 14 || repeated(digits) {
+		return "", ErrInvalidCNPJ
+	}
+
+	if cnpjCheckDigit(digits[:12]) != digits[12] || cnpjCheckDigit(digits[:13]) != digits[13] {
+		return "", ErrInvalidCNPJ
+	}
+	return digits, nil
+}
+
+// NormalizeCEP retorna o CEP com os 8 dígitos.
+func NormalizeCEP(cep string) (string, error) {
+	digits := Digits(cep)
+	if !punctuated(cep) || len(digits) != 8 || digits == "00000000" {
+		return "", ErrInvalidCEP
+	}
+	return digits, nil
+}
+
+// NormalizeUF retorna a sigla do estado em maiúsculas.
+func NormalizeUF(uf string) (string, error) {
+	uf = strings.ToUpper(strings.TrimSpace(uf))
+	if !slices.Contains(states, uf) {
+		return "", ErrInvalidUF
+	}
+	return uf, nil
+}
+
+// NormalizePhone converte um telefone brasileiro com DDD (fixo ou celular, com
+// ou sem o código do país) para E.164, ex.: "+5541999998888".
+func NormalizePhone(phone string) (string, error) {
+	digits := Digits(phone)
+	if !punctuated(phone) {
+		return "", ErrInvalidPhone
+	}
+
+	if (len(digits) == 12 || len(digits) == 13) && strings.HasPrefix(digits, BRAZIL_COUNTRY_CODE) {
+		digits = digits[len(BRAZIL_COUNTRY_CODE):]
+	}
+
+	// DDD de 11 a 99; celulares têm 9 dígitos começando com 9 e fixos, 8
+	// dígitos começando de 2 a 5
+	if len(digits) != 10 && len(digits) != 11 {
+		return "", ErrInvalidPhone
+	}
+	if digits[0] == '0' || digits[1] == '0' {
+		return "", ErrInvalidPhone
+	}
+	if len(digits) == 11 && digits[2] != '9' {
+		return "", ErrInvalidPhone
+	}
+	if len(digits) == 10 && (digits[2] < '2' || digits[2] > '5') {
+		return "", ErrInvalidPhone
+	}
+
+	return "+" + BRAZIL_COUNTRY_CODE + digits, nil
+}
+
+// WhatsappIDs lista os wa_id com que o telefone pode aparecer nos eventos do
+// WhatsApp: o E.164 sem o "+" e, para celulares, também sem o nono dígito,
+// forma ainda usada pelas contas mais antigas.
+func WhatsappIDs(phone string) []string {
+	e164, err := NormalizePhone(phone)
+	if err != nil {
+		return nil
+	}
+
+	id := e164[1:]
+	ids := []string{id}
+	if len(id) == 13 {
+		ids = append(ids, id[:4]+id[5:])
+	}
+	return ids
+}
+
+// FormatCPF formata como 000.000.000-00. Valores inválidos voltam sem
+// alteração, para não esconder dados antigos gravados fora do padrão.
+func FormatCPF(cpf string) string {
+	digits, err := NormalizeCPF(cpf)
+	if err != nil {
+		return cpf
+	}
+	return digits[:3] + "." + digits[3:6] + "." + digits[6:9] + "-" + digits[9:]
+}
+
+// FormatCNPJ formata como 00.000.000/0000-00.
+func FormatCNPJ(cnpj string) string {
+	digits, err := NormalizeCNPJ(cnpj)
+	if err != nil {
+		return cnpj
+	}
+	return digits[:2] + "." + digits[2:5] + "." + digits[5:8] + "/" + digits[8:12] + "-" + digits[12:]
+}
+
+// FormatCEP formata como 00000-000.
+func FormatCEP(cep string) string {
+	digits, err := NormalizeCEP(cep)
+	if err != nil {
+		return cep
+	}
+	return digits[:5] + "-" + digits[5:]
+}
+
+// FormatPhone formata no padrão nacional, ex.: "(41) 99999-8888".
+func FormatPhone(phone string) string {
+	e164, err := NormalizePhone(phone)
+	if err != nil {
+		return phone
+	}
+
+	national := e164[1+len(BRAZIL_COUNTRY_CODE):]
+	split := len(national) - 4
+	return "(" + national[:2] + ") " + national[2:split] + "-" + national[split:]
+}
+
+// MaskDocument troca por "*" os dígitos do CPF ou CNPJ, menos os dois
+// verificadores, mantendo a pontuação: "529.982.247-25" vira "***.***.***-25".
+// Aplicar de novo não muda o resultado.
+func MaskDocument(document string) string {
+	visible := DOCUMENT_VISIBLE_DIGITS
+	masked := []rune(document)
+	for i := len(masked) - 1; i >= 0; i-- {
+		if masked[i] < '0' || masked[i] > '9' {
+			continue
+		}
+		if visible > 0 {
+			visible--
+			continue
+		}
+		masked[i] = '*'
+	}
+	return string(masked)
+}
+
+func repeated(digits string) bool {
+	return strings.Count(digits, digits[:1]) == len(digits)
+}
+
+// checkDigit calcula o dígito verificador do CPF com pesos decrescentes a
+// partir de weight.
+func checkDigit(digits string, weight int) byte {
+	sum := 0
+	for i := range len(digits) {
+		sum += int(digits[i]-'0') * (weight - i)
+	}
+	return mod11(sum)
+}
+
+// cnpjCheckDigit usa os pesos 2 a 9 repetidos da direita para a esquerda.
+func cnpjCheckDigit(digits string) byte {
+	sum := 0
+	weight := 2
+	for i := len(digits) - 1; i >= 0; i-- {
+		sum += int(digits[i]-'0') * weight
+		weight++
+		if weight > 9 {
+			weight = 2
+		}
+	}
+	return mod11(sum)
+}
+
+func mod11(sum int) byte {
+	rest := sum % 11
+	if rest < 2 {
+		return '0'
+	}
+	return byte('0' + 11 - rest)
+}
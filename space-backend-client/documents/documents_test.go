@@ -0,0 +1,143 @@
+package documents
+
+import (
+	"api/schemas"
+	"slices"
+	"testing"
+)
+
+func TestNormalizeCPF(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+		ok   bool
+	}{
+		{"529.982.247-25", "52998224725", true},
+		{"52998224725", "52998224725", true},
+		{"529.982.247-24", "", false},
+		{"111.111.111-11", "", false},
+		{"5299822472", "", false},
+		{"529a98224725", "", false},
+	}
+
+	for _, tt := range tests {
+		got, err := NormalizeCPF(tt.in)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("NormalizeCPF(%q) = %q, %v; want %q, ok=%v", tt.in, got, err, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestNormalizeCNPJ(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+		ok   bool
+	}{
+		{"11.222.333/0001-81", "11222333000181", true},
+		{"11222333000181", "11222333000181", true},
+		{"11.222.333/0001-80", "", false},
+		{"00.000.000/0000-00", "", false},
+	}
+
+	for _, tt := range tests {
+		got, err := NormalizeCNPJ(tt.in)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("NormalizeCNPJ(%q) = %q, %v; want %q, ok=%v", tt.in, got, err, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestNormalizePhone(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+		ok   bool
+	}{
+		{"(41) 99999-8888", "+5541999998888", true},
+		{"+55 41 99999-8888", "+5541999998888", true},
+		{"5541999998888", "+5541999998888", true},
+		{"(41) 3333-4444", "+554133334444", true},
+		{"(41) 89999-8888", "", false},
+		{"(01) 99999-8888", "", false},
+		{"99999-8888", "", false},
+	}
+
+	for _, tt := range tests {
+		got, err := NormalizePhone(tt.in)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("NormalizePhone(%q) = %q, %v; want %q, ok=%v", tt.in, got, err, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestWhatsappIDs(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"(41) 99999-8888", []string{"5541999998888", "554199998888"}},
+		{"(41) 3333-4444", []string{"554133334444"}},
+		{"99999-8888", nil},
+	}
+
+	for _, tt := range tests {
+		if got := WhatsappIDs(tt.in); !slices.Equal(got, tt.want) {
+			t.Errorf("WhatsappIDs(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeCEPAndUF(t *testing.T) {
+	if got, err := NormalizeCEP("80.010-000"); err != nil || got != "80010000" {
+		t.Errorf("NormalizeCEP = %q, %v", got, err)
+	}
+	if _, err := NormalizeCEP("8001000"); err == nil {
+		t.Error("7-digit CEP accepted")
+	}
+	if got, err := NormalizeUF(" pr "); err != nil || got != "PR" {
+		t.Errorf("NormalizeUF = %q, %v", got, err)
+	}
+	if _, err := NormalizeUF("XX"); err == nil {
+		t.Error("unknown UF accepted")
+	}
+}
+
+func TestContactRoundTrip(t *testing.T) {
+	contact := NormalizeContact(schemas.Contact{
+		CPF:       "529.982.247-25",
+		ZipCode:   "80010-000",
+		State:     "pr",
+		CellPhone: "(41) 99999-8888",
+	})
+
+	if contact.CPF != "52998224725" || contact.ZipCode != "80010000" || contact.State != "PR" || contact.CellPhone != "+5541999998888" {
+		t.Fatalf("NormalizeContact = %+v", contact)
+	}
+
+	formatted := FormatContact(contact)
+	if formatted.CPF != "529.982.247-25" || formatted.ZipCode != "80010-000" || formatted.CellPhone != "(41) 99999-8888" {
+		t.Errorf("FormatContact = %+v", formatted)
+	}
+
+	// Dados antigos fora do padrão são exibidos como estão
+	if legacy := FormatCPF("000"); legacy != "000" {
+		t.Errorf("FormatCPF(legacy) = %q", legacy)
+	}
+}
+
+func TestMaskDocument(t *testing.T) {
+	tests := map[string]string{
+		"529.982.247-25":     "***.***.***-25",
+		"52998224725":        "*********25",
+		"11.222.333/0001-81": "**.***.***/****-81",
+		"***.***.***-25":     "***.***.***-25",
+		"":                   "",
+	}
+
+	for document, want := range tests {
+		if got := MaskDocument(document); got != want {
+			t.Errorf("MaskDocument(%q) = %q, want %q", document, got, want)
+		}
+	}
+}
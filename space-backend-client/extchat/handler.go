@@ -10,17 +10,15 @@ import (
 	"io"
 	"log"
 	"net/http"
-	"os"
 	"strconv"
 	"time"
-
-	"go.mongodb.org/mongo-driver/v2/bson"
-	"go.mongodb.org/mongo-driver/v2/mongo"
-	"go.mongodb.org/mongo-driver/v2/mongo/options"
 )
 
 var Hub *ws.Hub
 
+// Repositories é injetado pelo main com o client MongoDB compartilhado.
+var Repositories *database.Repositories
+
 func FormatRawPayload(data []byte, receivedAt time.Time) ([]SimpleEvent, error) {
 	// 1) Unmarshal data em RawEvent
 	var raw RawEvent
@@ -109,27 +107,8 @@ func HandlerWhatsapp(w http.ResponseWriter, r *http.Request) {
 		ctx, cancel := context.WithTimeout(context.Background(), database.MONGODB_TIMEOUT)
 		defer cancel()
 
-		// Configura e conecta ao cliente MongoDB
-		mongoURI := os.Getenv(utils.ENV_MONGODB_URI)
-		clientOpts := options.Client().ApplyURI(mongoURI)
-		client, err := mongo.Connect(clientOpts)
-		if err != nil {
-			log.Printf("[Webhook-Async] Erro ao conectar no MongoDB: %v", err)
-			return
-		}
-		defer func() {
-			if err := client.Disconnect(ctx); err != nil {
-				log.Printf("[Webhook-Async] Erro ao desconectar MongoDB: %v", err)
-			}
-		}()
-
-		// Seleciona coleção e insere documento com raw_event
-		col := client.Database(database.GetDB()).Collection("whatsapp_events")
-		doc := bson.D{
-			{Key: "raw_event", Value: rawEvent},
-			{Key: "received_at", Value: time.Now()},
-		}
-		if _, err := col.InsertOne(ctx, doc); err != nil {
+		// Insere documento com raw_event
+		if err := Repositories.WhatsappEvents.Insert(ctx, rawEvent, time.Now()); err != nil {
 			log.Printf("[Webhook-Async] Erro ao inserir documento no MongoDB: %v", err)
 		}
 	}(payloadBytes)
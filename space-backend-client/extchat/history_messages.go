@@ -2,18 +2,14 @@ package extchat
 
 import (
 	"api/database"
-	"api/utils"
 	"context"
 	"encoding/json"
 	"log"
 	"net/http"
-	"os"
 	"strconv"
 	"time"
 
 	"go.mongodb.org/mongo-driver/v2/bson"
-	"go.mongodb.org/mongo-driver/v2/mongo"
-	"go.mongodb.org/mongo-driver/v2/mongo/options"
 )
 
 func HandlerHistory(w http.ResponseWriter, r *http.Request) {
@@ -25,29 +21,12 @@ func HandlerHistory(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), database.MONGODB_TIMEOUT)
 	defer cancel()
 
-	// Configura e conecta ao cliente MongoDB
-	mongoURI := os.Getenv(utils.ENV_MONGODB_URI)
-	clientOpts := options.Client().ApplyURI(mongoURI)
-	client, err := mongo.Connect(clientOpts)
-	if err != nil {
-		log.Printf("[History] Erro ao conectar no MongoDB: %v", err)
-		http.Error(w, "Erro de conexão ao banco", http.StatusInternalServerError)
-		return
-	}
-	defer func() {
-		if err := client.Disconnect(ctx); err != nil {
-			log.Printf("[History] Erro ao desconectar MongoDB: %v", err)
-		}
-	}()
-
-	col := client.Database(database.GetDB()).Collection("whatsapp_events")
-	cursor, err := col.Find(ctx, bson.D{}, options.Find().SetSort(bson.D{{Key: "received_at", Value: 1}}))
+	docs, err := Repositories.WhatsappEvents.FindAll(ctx)
 	if err != nil {
 		log.Printf("[History] Erro ao buscar histórico: %v", err)
 		http.Error(w, "Erro ao buscar histórico", http.StatusInternalServerError)
 		return
 	}
-	defer cursor.Close(ctx)
 
 	type Event struct {
 		RawEvent   map[string]interface{} `json:"raw_event"`
@@ -55,12 +34,12 @@ func HandlerHistory(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var history []Event
-	for cursor.Next(ctx) {
+	for _, raw := range docs {
 		var doc struct {
 			RawEvent   map[string]interface{} `bson:"raw_event"`
 			ReceivedAt time.Time              `bson:"received_at"`
 		}
-		if err := cursor.Decode(&doc); err != nil {
+		if err := bson.Unmarshal(raw, &doc); err != nil {
 			log.Printf("[History] Erro ao decodificar documento: %v", err)
 			continue
 		}
@@ -129,40 +108,23 @@ func HandlerHistory2(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), database.MONGODB_TIMEOUT)
 	defer cancel()
 
-	// Conexão ao MongoDB
-	mongoURI := os.Getenv(utils.ENV_MONGODB_URI)
-	clientOpts := options.Client().ApplyURI(mongoURI)
-	client, err := mongo.Connect(clientOpts)
-	if err != nil {
-		log.Printf("[History] Erro ao conectar no MongoDB: %v", err)
-		http.Error(w, "Erro de conexão ao banco", http.StatusInternalServerError)
-		return
-	}
-	defer func() {
-		if err := client.Disconnect(ctx); err != nil {
-			log.Printf("[History] Erro ao desconectar MongoDB: %v", err)
-		}
-	}()
-
 	// Faz a consulta e ordena por received_at
-	col := client.Database(database.GetDB()).Collection("whatsapp_events")
-	cursor, err := col.Find(ctx, bson.D{}, options.Find().SetSort(bson.D{{Key: "received_at", Value: 1}}))
+	docs, err := Repositories.WhatsappEvents.FindAll(ctx)
 	if err != nil {
 		log.Printf("[History] Erro ao buscar histórico: %v", err)
 		http.Error(w, "Erro ao buscar histórico", http.StatusInternalServerError)
 		return
 	}
-	defer cursor.Close(ctx)
 
 	var result []SimpleEvent
 
 	// Itera pelos documentos
-	for cursor.Next(ctx) {
+	for _, raw := range docs {
 		var doc struct {
 			RawEvent   RawEvent  `bson:"raw_event"`
 			ReceivedAt time.Time `bson:"received_at"`
 		}
-		if err := cursor.Decode(&doc); err != nil {
+		if err := bson.Unmarshal(raw, &doc); err != nil {
 			log.Printf("[History] Erro ao decodificar documento: %v", err)
 			continue
 		}
@@ -196,10 +158,6 @@ func HandlerHistory2(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 	}
-	if err := cursor.Err(); err != nil {
-		log.Printf("[History] Erro durante iteração do cursor: %v", err)
-	}
-
 	// Serializa JSON de saída
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(result); err != nil {
@@ -3,18 +3,14 @@ package extchat
 
 import (
 	"api/database"
-	"api/utils"
 	"context"
 	"encoding/json"
 	"log"
 	"net/http"
-	"os"
 	"strconv"
 	"time"
 
 	"go.mongodb.org/mongo-driver/v2/bson"
-	"go.mongodb.org/mongo-driver/v2/mongo"
-	"go.mongodb.org/mongo-driver/v2/mongo/options"
 )
 
 // StatusRegistro mapeia cada item em value.statuses
@@ -59,40 +55,23 @@ func HandlerStatusHistory(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), database.MONGODB_TIMEOUT)
 	defer cancel()
 
-	// Conectar ao MongoDB
-	mongoURI := os.Getenv(utils.ENV_MONGODB_URI)
-	clientOpts := options.Client().ApplyURI(mongoURI)
-	client, err := mongo.Connect(clientOpts)
-	if err != nil {
-		log.Printf("[StatusHistory] Erro ao conectar no MongoDB: %v", err)
-		http.Error(w, "Erro de conexão ao banco", http.StatusInternalServerError)
-		return
-	}
-	defer func() {
-		if err := client.Disconnect(ctx); err != nil {
-			log.Printf("[StatusHistory] Erro ao desconectar MongoDB: %v", err)
-		}
-	}()
-
 	// Buscar todos os eventos ordenados por received_at
-	col := client.Database(database.GetDB()).Collection("whatsapp_events")
-	cursor, err := col.Find(ctx, bson.D{}, options.Find().SetSort(bson.D{{Key: "received_at", Value: 1}}))
+	docs, err := Repositories.WhatsappEvents.FindAll(ctx)
 	if err != nil {
 		log.Printf("[StatusHistory] Erro ao buscar histórico: %v", err)
 		http.Error(w, "Erro ao buscar histórico", http.StatusInternalServerError)
 		return
 	}
-	defer cursor.Close(ctx)
 
 	var results []SimpleStatusEvent
 
 	// Iterar pelos documentos
-	for cursor.Next(ctx) {
+	for _, raw := range docs {
 		var doc struct {
 			RawEvent   RawEventStatus `bson:"raw_event"`
 			ReceivedAt time.Time      `bson:"received_at"`
 		}
-		if err := cursor.Decode(&doc); err != nil {
+		if err := bson.Unmarshal(raw, &doc); err != nil {
 			log.Printf("[StatusHistory] Erro ao decodificar documento: %v", err)
 			continue
 		}
@@ -124,10 +103,6 @@ func HandlerStatusHistory(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 	}
-	if err := cursor.Err(); err != nil {
-		log.Printf("[StatusHistory] Erro durante iteração do cursor: %v", err)
-	}
-
 	// Serializar e enviar resposta
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(results); err != nil {
@@ -16,8 +16,6 @@ import (
 	"api/utils"
 
 	"go.mongodb.org/mongo-driver/v2/bson"
-	"go.mongodb.org/mongo-driver/v2/mongo"
-	"go.mongodb.org/mongo-driver/v2/mongo/options"
 )
 
 type SendMessageRequest struct {
@@ -116,17 +114,6 @@ func HandlerSendMessage(w http.ResponseWriter, r *http.Request) {
 		ctx, cancel := context.WithTimeout(context.Background(), database.MONGODB_TIMEOUT)
 		defer cancel()
 
-		mongoURI := os.Getenv(utils.ENV_MONGODB_URI)
-		clientOpts := options.Client().ApplyURI(mongoURI)
-		dbClient, err := mongo.Connect(clientOpts)
-		if err != nil {
-			log.Printf("[SendMessage-Async] erro conectar MongoDB: %v", err)
-			return
-		}
-		defer func() {
-			_ = dbClient.Disconnect(ctx)
-		}()
-
 		// constrói raw_event similar ao histórico
 		now := time.Now().UTC()
 		raw := bson.M{
@@ -151,12 +138,7 @@ func HandlerSendMessage(w http.ResponseWriter, r *http.Request) {
 			},
 		}
 
-		col := dbClient.Database(database.GetDB()).Collection("whatsapp_events")
-		doc := bson.D{
-			{Key: "raw_event", Value: raw},
-			{Key: "received_at", Value: now},
-		}
-		if _, err := col.InsertOne(ctx, doc); err != nil {
+		if err := Repositories.WhatsappEvents.Insert(ctx, raw, now); err != nil {
 			log.Printf("[SendMessage-Async] erro ao inserir no MongoDB: %v", err)
 		}
 	}()
@@ -13,6 +13,30 @@ echo "TINY_API_TOKEN=$TINY_API_TOKEN" >> .env
 echo "SPACE_ERP_URI=$SPACE_ERP_URI" >> .env
 echo "EXTCHAT_WEBHOOK_X_API_KEY=$EXTCHAT_WEBHOOK_X_API_KEY" >> .env
 echo "D360_API_KEY=$D360_API_KEY" >> .env
+echo "NOTIFIER=$NOTIFIER" >> .env
+echo "SMTP_HOST=$SMTP_HOST" >> .env
+echo "SMTP_PORT=$SMTP_PORT" >> .env
+echo "SMTP_USER=$SMTP_USER" >> .env
+echo "SMTP_PASSWORD=$SMTP_PASSWORD" >> .env
+echo "SMTP_FROM=$SMTP_FROM" >> .env
+echo "PASSWORD_RESET_URL=$PASSWORD_RESET_URL" >> .env
+echo "EMAIL_VERIFICATION_URL=$EMAIL_VERIFICATION_URL" >> .env
+echo "JWT_KEYS_DIR=$JWT_KEYS_DIR" >> .env
+echo "JWT_SIGNING_KEY_ID=$JWT_SIGNING_KEY_ID" >> .env
+echo "ADMIN_KEY_SCOPES=$ADMIN_KEY_SCOPES" >> .env
+echo "ADMIN_BOOTSTRAP_EMAIL=$ADMIN_BOOTSTRAP_EMAIL" >> .env
+echo "ADMIN_BOOTSTRAP_PASSWORD=$ADMIN_BOOTSTRAP_PASSWORD" >> .env
+echo "SPACE_ERP_API_KEY=$SPACE_ERP_API_KEY" >> .env
+echo "CEP_PROVIDER=$CEP_PROVIDER" >> .env
+echo "VIACEP_URL=$VIACEP_URL" >> .env
+echo "CEP_FIXTURES_FILE=$CEP_FIXTURES_FILE" >> .env
+echo "TINY_API_URL=$TINY_API_URL" >> .env
+echo "TINY_RATE_LIMIT=$TINY_RATE_LIMIT" >> .env
+echo "TINY_RECONCILE_INTERVAL=$TINY_RECONCILE_INTERVAL" >> .env
+echo "TINY_RECONCILE_POLICY=$TINY_RECONCILE_POLICY" >> .env
+echo "TINY_RECONCILE_DRY_RUN=$TINY_RECONCILE_DRY_RUN" >> .env
+echo "FIELD_ENCRYPTION_KEY=$FIELD_ENCRYPTION_KEY" >> .env
+echo "TRUSTED_PROXIES=$TRUSTED_PROXIES" >> .env
 
 
 echo "[arte arena security] Configurando variáveis de ambiente..."
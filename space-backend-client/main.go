@@ -1,13 +1,19 @@
 package main
 
 import (
+	"api/address"
 	"api/admin"
 	"api/auth"
 	"api/clients"
+	"api/crypto"
+	"api/database"
 	"api/extchat"
 	"api/middlewares"
+	"api/notifications"
 	"api/orders"
 	"api/schemas"
+	"api/tiny"
+	"api/tinysync"
 	"api/uniforms"
 	"api/utils"
 	"api/ws"
@@ -28,17 +34,96 @@ func setupRouter(hub *ws.Hub) http.Handler {
 
 	// 2) Roteador para as demais rotas REST, com middlewares aplicados
 	apiMux := http.NewServeMux()
+	apiMux.HandleFunc("/.well-known/jwks.json", auth.JWKS)
 	apiMux.HandleFunc("/v1/auth/signin", auth.Signin)
+	apiMux.HandleFunc("/v1/auth/signin/2fa", auth.SigninTwoFactor)
 	apiMux.HandleFunc("/v1/auth/signup", auth.Signup)
 	apiMux.HandleFunc("/v1/auth/authorize", auth.Authorize)
+	apiMux.HandleFunc("/v1/auth/token/refresh", auth.RefreshToken)
 	apiMux.HandleFunc("/v1/auth/signout", auth.Signout)
+	apiMux.HandleFunc("/v1/auth/signout/all", auth.SignoutEverywhere)
+	apiMux.HandleFunc("/v1/auth/password/forgot", auth.ForgotPassword)
+	apiMux.HandleFunc("/v1/auth/password/reset", auth.ResetPassword)
+	apiMux.HandleFunc("/v1/auth/verify", auth.VerifyEmail)
+	apiMux.HandleFunc("/v1/auth/verify/resend", auth.ResendVerification)
+	apiMux.HandleFunc("/v1/auth/sessions", middlewares.AuthMiddleware(auth.HandlerSessions))
+	apiMux.HandleFunc("/v1/auth/2fa/setup", middlewares.AuthMiddleware(auth.SetupTwoFactor))
+	apiMux.HandleFunc("/v1/auth/2fa/enable", middlewares.AuthMiddleware(auth.EnableTwoFactor))
+	apiMux.HandleFunc("/v1/auth/2fa/disable", middlewares.AuthMiddleware(auth.DisableTwoFactor))
 
-	apiMux.HandleFunc("/v1/admin/uniforms", middlewares.AdminMiddleware(admin.HandlerUniforms))
-	apiMux.HandleFunc("/v1/admin/clients", middlewares.AdminMiddleware(admin.HandlerClients))
+	apiMux.HandleFunc("/v1/admin/auth/signin", admin.Signin)
+	apiMux.HandleFunc("/v1/admin/auth/signout", middlewares.AdminMiddleware(middlewares.RoutePermissions{
+		http.MethodPost: "",
+	}, admin.Signout))
+	apiMux.HandleFunc("/v1/admin/auth/me", middlewares.AdminMiddleware(middlewares.RoutePermissions{
+		http.MethodGet: "",
+	}, admin.Me))
+	apiMux.HandleFunc("/v1/admin/users", middlewares.AdminMiddleware(middlewares.RoutePermissions{
+		http.MethodGet:   schemas.PERMISSION_ADMIN_USERS_MANAGE,
+		http.MethodPost:  schemas.PERMISSION_ADMIN_USERS_MANAGE,
+		http.MethodPatch: schemas.PERMISSION_ADMIN_USERS_MANAGE,
+	}, admin.HandlerAdminUsers))
+	apiMux.HandleFunc("/v1/admin/api-keys", middlewares.AdminMiddleware(middlewares.RoutePermissions{
+		http.MethodGet:    schemas.PERMISSION_API_KEYS_MANAGE,
+		http.MethodPost:   schemas.PERMISSION_API_KEYS_MANAGE,
+		http.MethodDelete: schemas.PERMISSION_API_KEYS_MANAGE,
+	}, admin.HandlerApiKeys))
+	apiMux.HandleFunc("/v1/admin/api-keys/rotate", middlewares.AdminMiddleware(middlewares.RoutePermissions{
+		http.MethodPost: schemas.PERMISSION_API_KEYS_MANAGE,
+	}, admin.HandlerApiKeyRotate))
+	apiMux.HandleFunc("/v1/admin/audit-events", middlewares.AdminMiddleware(middlewares.RoutePermissions{
+		http.MethodGet: schemas.PERMISSION_AUDIT_READ,
+	}, admin.HandlerAuditEvents))
+	apiMux.HandleFunc("/v1/admin/tiny-sync", middlewares.AdminMiddleware(middlewares.RoutePermissions{
+		http.MethodGet: schemas.PERMISSION_TINY_SYNC_MANAGE,
+	}, admin.HandlerTinySyncJobs))
+	apiMux.HandleFunc("/v1/admin/tiny-sync/replay", middlewares.AdminMiddleware(middlewares.RoutePermissions{
+		http.MethodPost: schemas.PERMISSION_TINY_SYNC_MANAGE,
+	}, admin.HandlerTinySyncReplay))
+	apiMux.HandleFunc("/v1/admin/tiny-sync/links", middlewares.AdminMiddleware(middlewares.RoutePermissions{
+		http.MethodGet: schemas.PERMISSION_TINY_SYNC_MANAGE,
+	}, admin.HandlerTinyLinks))
+	apiMux.HandleFunc("/v1/admin/tiny-sync/reconciliations", middlewares.AdminMiddleware(middlewares.RoutePermissions{
+		http.MethodGet:  schemas.PERMISSION_TINY_SYNC_MANAGE,
+		http.MethodPost: schemas.PERMISSION_TINY_SYNC_MANAGE,
+	}, admin.HandlerTinyReconciliations))
+	apiMux.HandleFunc("/v1/admin/encryption/rotate", middlewares.AdminMiddleware(middlewares.RoutePermissions{
+		http.MethodPost: schemas.PERMISSION_ENCRYPTION_MANAGE,
+	}, admin.HandlerDataKeyRotate))
+	apiMux.HandleFunc("/v1/admin/uniforms", middlewares.AdminMiddleware(middlewares.RoutePermissions{
+		http.MethodGet:   schemas.PERMISSION_UNIFORMS_READ,
+		http.MethodPost:  schemas.PERMISSION_UNIFORMS_WRITE,
+		http.MethodPatch: schemas.PERMISSION_UNIFORMS_WRITE,
+	}, admin.HandlerUniforms))
+	apiMux.HandleFunc("/v1/admin/clients", middlewares.AdminMiddleware(middlewares.RoutePermissions{
+		http.MethodGet:    schemas.PERMISSION_CLIENTS_READ,
+		http.MethodPatch:  schemas.PERMISSION_CLIENTS_WRITE,
+		http.MethodDelete: schemas.PERMISSION_CLIENTS_WRITE,
+	}, admin.HandlerClients))
+	apiMux.HandleFunc("/v1/admin/clients/account", middlewares.AdminMiddleware(middlewares.RoutePermissions{
+		http.MethodGet:    schemas.PERMISSION_CLIENTS_READ,
+		http.MethodPatch:  schemas.PERMISSION_CLIENTS_WRITE,
+		http.MethodDelete: schemas.PERMISSION_CLIENTS_DELETE,
+	}, admin.HandlerClientAccount))
+	apiMux.HandleFunc("/v1/admin/clients/anonymize", middlewares.AdminMiddleware(middlewares.RoutePermissions{
+		http.MethodPost: schemas.PERMISSION_CLIENTS_DELETE,
+	}, admin.HandlerClientAnonymize))
+	apiMux.HandleFunc("/v1/admin/clients/status", middlewares.AdminMiddleware(middlewares.RoutePermissions{
+		http.MethodPost: schemas.PERMISSION_CLIENTS_DELETE,
+	}, admin.HandlerClientStatus))
+	apiMux.HandleFunc("/v1/admin/clients/search", middlewares.AdminMiddleware(middlewares.RoutePermissions{
+		http.MethodGet: schemas.PERMISSION_CLIENTS_READ,
+	}, admin.HandlerClientsSearch))
+	apiMux.HandleFunc("/v1/admin/clients/unlock", middlewares.AdminMiddleware(middlewares.RoutePermissions{
+		http.MethodPost: schemas.PERMISSION_CLIENTS_WRITE,
+	}, admin.HandlerClientUnlock))
 
 	apiMux.HandleFunc("/v1/clients", middlewares.AuthMiddleware(clients.Handler))
+	apiMux.HandleFunc("/v1/clients/me/export", middlewares.AuthMiddleware(clients.HandlerExport))
+	apiMux.HandleFunc("/v1/clients/me/erasure", middlewares.AuthMiddleware(clients.HandlerErasure))
 	apiMux.HandleFunc("/v1/uniforms", middlewares.AuthMiddleware(uniforms.Handler))
 	apiMux.HandleFunc("/v1/orders", middlewares.AuthMiddleware(orders.Handler))
+	apiMux.HandleFunc("/v1/address/cep/{cep}", middlewares.AuthMiddleware(address.HandlerCEP))
 
 	// apiMux.HandleFunc("/v1/webhook/whatsapp", middlewares.ExtChatMiddleware(extchat.HandlerWhatsapp))
 	apiMux.HandleFunc("/v1/webhook/whatsapp", extchat.HandlerWhatsapp)
@@ -51,9 +136,11 @@ func setupRouter(hub *ws.Hub) http.Handler {
 		if strings.HasPrefix(r.URL.Path, "/v1/ws/") {
 			wsMux.ServeHTTP(w, r)
 		} else {
-			handler := middlewares.Logging(
-				middlewares.SecurityHeaders(
-					middlewares.Cors(apiMux),
+			handler := middlewares.RequestID(
+				middlewares.Logging(
+					middlewares.SecurityHeaders(
+						middlewares.Cors(apiMux),
+					),
 				),
 			)
 			handler.ServeHTTP(w, r)
@@ -64,6 +151,94 @@ func setupRouter(hub *ws.Hub) http.Handler {
 func main() {
 	utils.LoadEnvVariables()
 
+	// Sem JWT_KEYS_DIR os tokens continuam assinados em HS512
+	keyring, err := utils.LoadKeyringFromEnv()
+	if err != nil {
+		log.Fatalf("Error loading JWT keys: %v", err)
+	}
+	utils.SetKeyring(keyring)
+
+	// Sem TRUSTED_PROXIES o IP do cliente é o da conexão e o X-Forwarded-For
+	// é ignorado
+	utils.TrustedProxies, err = utils.ParseTrustedProxies(os.Getenv(utils.TRUSTED_PROXIES))
+	if err != nil {
+		log.Fatalf("Error parsing TRUSTED_PROXIES: %v", err)
+	}
+
+	// Abre o client MongoDB compartilhado (com pool) usado por todos os handlers
+	mongoClient, err := database.Connect()
+	if err != nil {
+		log.Fatalf("Error connecting to MongoDB: %v", err)
+	}
+
+	migrationCtx, cancelMigration := context.WithTimeout(context.Background(), database.MONGODB_TIMEOUT)
+	err = database.RunMigrations(migrationCtx, mongoClient)
+	cancelMigration()
+	if err != nil {
+		log.Fatalf("Error running MongoDB migrations: %v", err)
+	}
+
+	// Chaves dos campos cifrados do cliente (CPF, CNPJ e RG). Sem
+	// FIELD_ENCRYPTION_KEY os documentos ficam abertos, o que só é aceito em
+	// desenvolvimento
+	dataKeys := database.NewMongoDataKeysRepository(mongoClient.Database(database.GetDB()).Collection(database.DATA_KEYS_COLLECTION))
+	keysCtx, cancelKeys := context.WithTimeout(context.Background(), database.MONGODB_TIMEOUT)
+	fieldKeyring, err := crypto.LoadKeyringFromEnv(keysCtx, dataKeys)
+	cancelKeys()
+	if err != nil {
+		log.Fatalf("Error loading field encryption keys: %v", err)
+	}
+	if fieldKeyring == nil {
+		if os.Getenv(utils.ENV) == utils.ENV_RELEASE {
+			log.Fatalf("FIELD_ENCRYPTION_KEY is required in production")
+		}
+		log.Println("FIELD_ENCRYPTION_KEY not set: client documents are stored in plaintext")
+	}
+
+	repositories := database.NewRepositories(mongoClient, fieldKeyring)
+	auth.Repositories = repositories
+	admin.Repositories = repositories
+	clients.Repositories = repositories
+	uniforms.Repositories = repositories
+	orders.Repositories = repositories
+	extchat.Repositories = repositories
+	middlewares.Repositories = repositories
+	admin.FieldKeyring = fieldKeyring
+	utils.Denylist = repositories.RevokedTokens
+
+	auth.Notifier = notifications.NewFromEnv()
+
+	addressProvider := address.NewFromEnv()
+	address.CEPProvider = addressProvider
+	clients.AddressProvider = addressProvider
+
+	bootstrapCtx, cancelBootstrap := context.WithTimeout(context.Background(), database.MONGODB_TIMEOUT)
+	err = admin.BootstrapSuperadmin(bootstrapCtx)
+	cancelBootstrap()
+	if err != nil {
+		log.Fatalf("Error creating initial superadmin: %v", err)
+	}
+
+	// Worker e relatório de vínculos dividem o mesmo cliente do Tiny, e com
+	// ele o limite de requisições por minuto
+	tinySyncer := tinysync.TinySyncer{Client: tiny.NewFromEnv()}
+	admin.TinySyncer = tinySyncer
+
+	// Envia ao Tiny os contatos enfileirados pelos handlers
+	workerCtx, stopWorker := context.WithCancel(context.Background())
+	go tinysync.NewWorker(repositories, tinySyncer).Run(workerCtx)
+
+	// Reconciliação periódica entre os clientes e os contatos do Tiny
+	reconciler, reconcileInterval := tinysync.ReconcilerFromEnv(repositories, tinySyncer)
+	admin.TinyReconciler = reconciler
+	go reconciler.Schedule(workerCtx, reconcileInterval)
+
+	// Cifra os documentos gravados antes da criptografia ou com chaves
+	// anteriores; só altera quem ainda não está com a chave ativa
+	if fieldKeyring != nil {
+		go admin.ReencryptContacts(workerCtx, repositories.Clients)
+	}
+
 	// Inicializa e dispara o Hub de WebSocket
 	hub := ws.NewHub()
 	go hub.Run()
@@ -99,6 +274,7 @@ func main() {
 	signal.Notify(stop, os.Interrupt)
 	<-stop
 	log.Println("Shutdown initiated...")
+	stopWorker()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -106,5 +282,9 @@ func main() {
 		log.Fatalf("Error shutting down server: %v", err)
 	}
 
+	if err := mongoClient.Disconnect(ctx); err != nil {
+		log.Printf("Error disconnecting from MongoDB: %v", err)
+	}
+
 	log.Println("Server successfully terminated")
 }
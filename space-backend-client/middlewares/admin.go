@@ -1,33 +1,172 @@
 package middlewares
 
 import (
+	"api/database"
 	"api/schemas"
 	"api/utils"
+	"context"
+	"crypto/subtle"
 	"encoding/json"
 	"net/http"
 	"os"
+	"slices"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+const (
+	AdminIDKey    ContextKey = "adminId"
+	AdminActorKey ContextKey = "adminActor"
+
+	// ADMIN_KEY_ACTOR identifica nas auditorias as chamadas feitas com o
+	// X-Admin-Key legado (integração com o ERP).
+	ADMIN_KEY_ACTOR = "machine:admin_key"
 )
 
-func AdminMiddleware(next http.HandlerFunc) http.HandlerFunc {
+// defaultAdminKeyScopes são as permissões do X-Admin-Key quando
+// ADMIN_KEY_SCOPES não está configurado: apenas o que o ERP já usava.
+var defaultAdminKeyScopes = []string{
+	schemas.PERMISSION_UNIFORMS_READ,
+	schemas.PERMISSION_UNIFORMS_WRITE,
+	schemas.PERMISSION_CLIENTS_READ,
+	schemas.PERMISSION_CLIENTS_WRITE,
+}
+
+// RoutePermissions associa cada método aceito pela rota à permissão exigida.
+// Uma permissão vazia só exige um usuário administrativo autenticado.
+type RoutePermissions map[string]string
+
+// AdminMiddleware autentica a equipe interna pelo token de admin_users
+// (Authorization: Bearer) e confere a permissão do papel para o método da
+// requisição. Integrações usam chaves de API (X-API-Key, ver apiKeyAuth); o
+// X-Admin-Key legado continua aceito como credencial de máquina, limitado a
+// ADMIN_KEY_SCOPES e nunca com acesso à gestão de usuários.
+func AdminMiddleware(permissions RoutePermissions, next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		adminKey := r.Header.Get("X-Admin-Key")
-		if adminKey == "" {
+		permission, ok := permissions[r.Method]
+		if !ok {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(schemas.ApiResponse{
+				Message: utils.SendInternalError(utils.HTTP_METHOD_NO_ALLOWED),
+			})
+			return
+		}
+
+		if key := r.Header.Get(API_KEY_HEADER); key != "" {
+			apiKeyAuth(next, w, r, key, permission)
+			return
+		}
+
+		if adminKey := r.Header.Get("X-Admin-Key"); adminKey != "" {
+			adminKeyAuth(next, w, r, adminKey, permission)
+			return
+		}
+
+		bearer, ok := utils.BearerToken(r)
+		if !ok || bearer == "" {
 			w.WriteHeader(http.StatusUnauthorized)
 			json.NewEncoder(w).Encode(schemas.ApiResponse{
-				Message: "Chave de administrador não fornecida",
+				Message: "Credenciais de administrador não fornecidas",
 			})
 			return
 		}
 
-		envAdminKey := os.Getenv(utils.ADMIN_KEY)
-		if adminKey != envAdminKey {
+		claims, err := utils.ValidateAdminKey(bearer)
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
 			w.WriteHeader(http.StatusUnauthorized)
 			json.NewEncoder(w).Encode(schemas.ApiResponse{
-				Message: "Chave de administrador inválida",
+				Message: utils.SendInternalError(utils.ACCESS_TOKEN_INVALID_OR_EXPIRED),
 			})
 			return
 		}
 
-		next.ServeHTTP(w, r)
+		adminId, err := utils.ParseObjectIDFromHex(claims.UserId)
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(schemas.ApiResponse{
+				Message: utils.SendInternalError(utils.ACCESS_TOKEN_INVALID_OR_EXPIRED),
+			})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), database.MONGODB_TIMEOUT)
+		defer cancel()
+
+		user, err := Repositories.AdminUsers.FindByID(ctx, adminId)
+		if err != nil {
+			if err == mongo.ErrNoDocuments {
+				w.WriteHeader(http.StatusUnauthorized)
+				json.NewEncoder(w).Encode(schemas.ApiResponse{
+					Message: utils.SendInternalError(utils.ACCESS_TOKEN_INVALID_OR_EXPIRED),
+				})
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(schemas.ApiResponse{
+				Message: utils.SendInternalError(utils.ERROR_TO_TRY_FIND_MONGODB),
+			})
+			return
+		}
+
+		if !user.Active {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(schemas.ApiResponse{
+				Message: "Usuário administrativo desativado",
+			})
+			return
+		}
+
+		if permission != "" && !slices.Contains(schemas.AdminRolePermissions[user.Role], permission) {
+			writeAdminForbidden(w)
+			return
+		}
+
+		reqCtx := context.WithValue(r.Context(), AdminIDKey, user.ID.Hex())
+		reqCtx = context.WithValue(reqCtx, AdminActorKey, "admin:"+user.ID.Hex())
+		next.ServeHTTP(w, r.WithContext(reqCtx))
+	}
+}
+
+func adminKeyAuth(next http.HandlerFunc, w http.ResponseWriter, r *http.Request, adminKey string, permission string) {
+	envAdminKey := os.Getenv(utils.ADMIN_KEY)
+	if envAdminKey == "" || subtle.ConstantTimeCompare([]byte(adminKey), []byte(envAdminKey)) != 1 {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: "Chave de administrador inválida",
+		})
+		return
+	}
+
+	// Rotas que exigem um usuário (permissão vazia) não aceitam a chave
+	if permission == "" || !slices.Contains(schemas.ApiKeyScopes, permission) || !slices.Contains(adminKeyScopes(), permission) {
+		writeAdminForbidden(w)
+		return
 	}
+
+	ctx := context.WithValue(r.Context(), AdminActorKey, ADMIN_KEY_ACTOR)
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
+
+func adminKeyScopes() []string {
+	configured := os.Getenv(utils.ADMIN_KEY_SCOPES)
+	if configured == "" {
+		return defaultAdminKeyScopes
+	}
+
+	var scopes []string
+	for scope := range strings.SplitSeq(configured, ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes
+}
+
+func writeAdminForbidden(w http.ResponseWriter) {
+	w.WriteHeader(http.StatusForbidden)
+	json.NewEncoder(w).Encode(schemas.ApiResponse{
+		Message: "Permissão insuficiente para este recurso",
+	})
 }
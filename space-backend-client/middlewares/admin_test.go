@@ -0,0 +1,140 @@
+package middlewares
+
+import (
+	"api/database"
+	"api/schemas"
+	"api/utils"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func setupAdmin(t *testing.T, role string, active bool) (*database.Repositories, string) {
+	t.Helper()
+	t.Setenv("ACCESS_TOKEN_SECRET", "access")
+	t.Setenv("REFRESH_TOKEN_SECRET", "refresh")
+	t.Setenv(utils.ADMIN_KEY, "chave-do-erp")
+
+	repositories := database.NewMemoryRepositories()
+	Repositories = repositories
+	t.Cleanup(func() { Repositories = nil })
+
+	id, err := repositories.AdminUsers.Create(t.Context(), schemas.AdminUser{
+		Email:  "equipe@example.com",
+		Role:   role,
+		Active: active,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := utils.GenerateAdminKey(id.Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return repositories, token
+}
+
+var uniformsPermissions = RoutePermissions{
+	http.MethodGet:  schemas.PERMISSION_UNIFORMS_READ,
+	http.MethodPost: schemas.PERMISSION_UNIFORMS_WRITE,
+}
+
+func adminRequest(method string, token string) *http.Request {
+	req := httptest.NewRequest(method, "/v1/admin/uniforms", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	return req
+}
+
+func TestAdminMiddlewareChecksRolePermissions(t *testing.T) {
+	_, token := setupAdmin(t, schemas.ADMIN_ROLE_FINANCE, true)
+
+	var actor string
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		actor, _ = r.Context().Value(AdminActorKey).(string)
+	}
+
+	w := httptest.NewRecorder()
+	AdminMiddleware(uniformsPermissions, handler)(w, adminRequest(http.MethodGet, token))
+	if w.Code != http.StatusOK || actor == "" {
+		t.Fatalf("read: status = %d, actor = %q", w.Code, actor)
+	}
+
+	w = httptest.NewRecorder()
+	AdminMiddleware(uniformsPermissions, handler)(w, adminRequest(http.MethodPost, token))
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("write: status = %d, want %d", w.Code, http.StatusForbidden)
+	}
+}
+
+func TestAdminMiddlewareRejectsDeactivatedUser(t *testing.T) {
+	_, token := setupAdmin(t, schemas.ADMIN_ROLE_SUPERADMIN, false)
+
+	w := httptest.NewRecorder()
+	AdminMiddleware(uniformsPermissions, okHandler)(w, adminRequest(http.MethodGet, token))
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestAdminMiddlewareRejectsClientAccessToken(t *testing.T) {
+	setupAdmin(t, schemas.ADMIN_ROLE_SUPERADMIN, true)
+
+	clientToken, err := utils.GenerateAccessKey("cliente", "sessao", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	AdminMiddleware(uniformsPermissions, okHandler)(w, adminRequest(http.MethodGet, clientToken))
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestLegacyAdminKeyIsScoped(t *testing.T) {
+	setupAdmin(t, schemas.ADMIN_ROLE_SUPERADMIN, true)
+	t.Setenv(utils.ADMIN_KEY_SCOPES, schemas.PERMISSION_UNIFORMS_READ)
+
+	keyRequest := func(method string, key string) *http.Request {
+		req := httptest.NewRequest(method, "/v1/admin/uniforms", nil)
+		req.Header.Set("X-Admin-Key", key)
+		return req
+	}
+
+	w := httptest.NewRecorder()
+	AdminMiddleware(uniformsPermissions, okHandler)(w, keyRequest(http.MethodGet, "chave-do-erp"))
+	if w.Code != http.StatusOK {
+		t.Fatalf("scoped read: status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	w = httptest.NewRecorder()
+	AdminMiddleware(uniformsPermissions, okHandler)(w, keyRequest(http.MethodPost, "chave-do-erp"))
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("out of scope: status = %d, want %d", w.Code, http.StatusForbidden)
+	}
+
+	w = httptest.NewRecorder()
+	AdminMiddleware(uniformsPermissions, okHandler)(w, keyRequest(http.MethodGet, "chave-errada"))
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("wrong key: status = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+
+	// Exclusão, bloqueio e anonimização de clientes não valem para a chave
+	// nem quando configuradas em ADMIN_KEY_SCOPES
+	deletePermissions := RoutePermissions{http.MethodDelete: schemas.PERMISSION_CLIENTS_DELETE}
+	t.Setenv(utils.ADMIN_KEY_SCOPES, schemas.PERMISSION_CLIENTS_WRITE+","+schemas.PERMISSION_CLIENTS_DELETE)
+	w = httptest.NewRecorder()
+	AdminMiddleware(deletePermissions, okHandler)(w, keyRequest(http.MethodDelete, "chave-do-erp"))
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("client deletion: status = %d, want %d", w.Code, http.StatusForbidden)
+	}
+
+	usersPermissions := RoutePermissions{http.MethodGet: schemas.PERMISSION_ADMIN_USERS_MANAGE}
+	t.Setenv(utils.ADMIN_KEY_SCOPES, schemas.PERMISSION_ADMIN_USERS_MANAGE)
+	w = httptest.NewRecorder()
+	AdminMiddleware(usersPermissions, okHandler)(w, keyRequest(http.MethodGet, "chave-do-erp"))
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("user management: status = %d, want %d", w.Code, http.StatusForbidden)
+	}
+}
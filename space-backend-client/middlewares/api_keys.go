@@ -0,0 +1,81 @@
+package middlewares
+
+import (
+	"api/database"
+	"api/schemas"
+	"api/utils"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"log"
+	"net/http"
+	"slices"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+const (
+	ApiKeyIDKey ContextKey = "apiKeyId"
+
+	API_KEY_HEADER = "X-API-Key"
+	// API_KEY_LAST_USED_RESOLUTION evita uma escrita no banco a cada requisição
+	API_KEY_LAST_USED_RESOLUTION = 1 * time.Minute
+)
+
+// apiKeyAuth autentica a chave de API (header X-API-Key) recebida por
+// AdminMiddleware, conferindo o escopo exigido pelo método.
+func apiKeyAuth(next http.HandlerFunc, w http.ResponseWriter, r *http.Request, key string, permission string) {
+	prefix, ok := utils.ApiKeyPrefix(key)
+	if !ok {
+		writeInvalidApiKey(w)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), database.MONGODB_TIMEOUT)
+	defer cancel()
+
+	apiKey, err := Repositories.ApiKeys.FindByPrefix(ctx, prefix)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			writeInvalidApiKey(w)
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.ERROR_TO_TRY_FIND_MONGODB),
+		})
+		return
+	}
+
+	now := time.Now()
+	if subtle.ConstantTimeCompare([]byte(utils.HashToken(key)), []byte(apiKey.KeyHash)) != 1 ||
+		apiKey.RevokedAt != nil ||
+		(apiKey.ExpiresAt != nil && !apiKey.ExpiresAt.After(now)) {
+		writeInvalidApiKey(w)
+		return
+	}
+
+	// Rotas que exigem um usuário (permissão vazia) não aceitam chaves
+	if permission == "" || !slices.Contains(schemas.ApiKeyScopes, permission) || !slices.Contains(apiKey.Scopes, permission) {
+		writeAdminForbidden(w)
+		return
+	}
+
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) >= API_KEY_LAST_USED_RESOLUTION {
+		if err := Repositories.ApiKeys.Touch(ctx, apiKey.ID, now); err != nil {
+			log.Printf("[AdminMiddleware] Erro ao registrar uso da chave %s: %v", apiKey.Prefix, err)
+		}
+	}
+
+	reqCtx := context.WithValue(r.Context(), ApiKeyIDKey, apiKey.ID.Hex())
+	reqCtx = context.WithValue(reqCtx, AdminActorKey, "api_key:"+apiKey.ID.Hex())
+	next.ServeHTTP(w, r.WithContext(reqCtx))
+}
+
+func writeInvalidApiKey(w http.ResponseWriter) {
+	w.WriteHeader(http.StatusUnauthorized)
+	json.NewEncoder(w).Encode(schemas.ApiResponse{
+		Message: "Chave de API inválida, expirada ou revogada",
+	})
+}
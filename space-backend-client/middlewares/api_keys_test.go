@@ -0,0 +1,93 @@
+package middlewares
+
+import (
+	"api/database"
+	"api/schemas"
+	"api/utils"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func createApiKey(t *testing.T, repositories *database.Repositories, apiKey schemas.ApiKey) (bson.ObjectID, string) {
+	t.Helper()
+
+	key, prefix, err := utils.GenerateApiKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	apiKey.Prefix = prefix
+	apiKey.KeyHash = utils.HashToken(key)
+
+	id, err := repositories.ApiKeys.Create(t.Context(), apiKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id, key
+}
+
+func apiKeyRequest(method string, key string) *http.Request {
+	req := httptest.NewRequest(method, "/v1/admin/uniforms", nil)
+	req.Header.Set(API_KEY_HEADER, key)
+	return req
+}
+
+func TestApiKeyScopesAndLastUsed(t *testing.T) {
+	repositories := database.NewMemoryRepositories()
+	Repositories = repositories
+	t.Cleanup(func() { Repositories = nil })
+
+	id, key := createApiKey(t, repositories, schemas.ApiKey{Scopes: []string{schemas.PERMISSION_UNIFORMS_READ}})
+
+	var actor string
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		actor, _ = r.Context().Value(AdminActorKey).(string)
+	}
+
+	w := httptest.NewRecorder()
+	AdminMiddleware(uniformsPermissions, handler)(w, apiKeyRequest(http.MethodGet, key))
+	if w.Code != http.StatusOK || actor != "api_key:"+id.Hex() {
+		t.Fatalf("status = %d, actor = %q", w.Code, actor)
+	}
+
+	stored, _ := repositories.ApiKeys.FindByID(t.Context(), id)
+	if stored.LastUsedAt == nil {
+		t.Error("last_used_at was not recorded")
+	}
+
+	w = httptest.NewRecorder()
+	AdminMiddleware(uniformsPermissions, okHandler)(w, apiKeyRequest(http.MethodPost, key))
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("out of scope: status = %d, want %d", w.Code, http.StatusForbidden)
+	}
+}
+
+func TestApiKeyRejectsRevokedExpiredAndTampered(t *testing.T) {
+	repositories := database.NewMemoryRepositories()
+	Repositories = repositories
+	t.Cleanup(func() { Repositories = nil })
+
+	scopes := []string{schemas.PERMISSION_UNIFORMS_READ}
+	past := time.Now().Add(-time.Minute)
+
+	revokedId, revoked := createApiKey(t, repositories, schemas.ApiKey{Scopes: scopes})
+	repositories.ApiKeys.Revoke(t.Context(), revokedId, time.Now())
+	_, expired := createApiKey(t, repositories, schemas.ApiKey{Scopes: scopes, ExpiresAt: &past})
+	_, valid := createApiKey(t, repositories, schemas.ApiKey{Scopes: scopes})
+
+	for name, key := range map[string]string{
+		"revoked":  revoked,
+		"expired":  expired,
+		"tampered": valid + "x",
+		"format":   "qualquer-coisa",
+	} {
+		w := httptest.NewRecorder()
+		AdminMiddleware(uniformsPermissions, okHandler)(w, apiKeyRequest(http.MethodGet, key))
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("%s: status = %d, want %d", name, w.Code, http.StatusUnauthorized)
+		}
+	}
+}
@@ -7,32 +7,63 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
-	"os"
+	"slices"
 	"time"
-
-	"go.mongodb.org/mongo-driver/v2/bson"
-	"go.mongodb.org/mongo-driver/v2/mongo"
-	"go.mongodb.org/mongo-driver/v2/mongo/options"
 )
 
 type ContextKey string
 
 const (
-	UserIDKey ContextKey = "userId"
+	UserIDKey        ContextKey = "userId"
+	SessionIDKey     ContextKey = "sessionId"
+	EmailVerifiedKey ContextKey = "emailVerified"
 
 	ACCESS_TOKEN_COOKIE_EXPIRATION  = 15 * time.Minute
 	REFRESH_TOKEN_COOKIE_EXPIRATION = 7 * 24 * time.Hour
 )
 
+// Repositories é injetado pelo main com o client MongoDB compartilhado.
+var Repositories *database.Repositories
+
+// unverifiedAllowedRoutes lista o que um cliente com email ainda não
+// verificado pode acessar: a leitura do próprio perfil e as próprias sessões,
+// além da exportação e da anonimização dos dados (LGPD).
+var unverifiedAllowedRoutes = map[string][]string{
+	"/v1/clients":            {http.MethodGet},
+	"/v1/auth/sessions":      {http.MethodGet, http.MethodDelete},
+	"/v1/clients/me/export":  {http.MethodGet},
+	"/v1/clients/me/erasure": {http.MethodPost},
+}
+
+func allowedWhileUnverified(r *http.Request) bool {
+	return slices.Contains(unverifiedAllowedRoutes[r.URL.Path], r.Method)
+}
+
+func writeEmailNotVerified(w http.ResponseWriter) {
+	w.WriteHeader(http.StatusForbidden)
+	json.NewEncoder(w).Encode(schemas.ApiResponse{
+		Message: "Confirme seu email para acessar este recurso",
+	})
+}
+
+// AuthMiddleware aceita o access token no header Authorization: Bearer (app
+// mobile e integrações) ou nos cookies do app web. Com Bearer não há renovação
+// automática: o cliente troca o refresh token em /v1/auth/token/refresh.
 func AuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		if bearer, ok := utils.BearerToken(r); ok {
+			bearerAuth(next, w, r, bearer)
+			return
+		}
+
 		accessCookie, err := r.Cookie("access_token")
 		if err == nil {
 			claims, err := utils.ValidateAccessKey(accessCookie.Value)
-			if err == nil {
-				ctx := context.WithValue(r.Context(), UserIDKey, claims.UserId)
-				r = r.WithContext(ctx)
-				next.ServeHTTP(w, r)
+			// Um access token de cliente não verificado só serve para as rotas
+			// liberadas; nas demais o status é reconsultado pelo refresh token,
+			// pois o email pode ter sido confirmado depois da emissão.
+			if err == nil && (claims.EmailVerified || allowedWhileUnverified(r)) {
+				serveAuthenticated(next, w, r, claims.UserId, claims.SessionId, claims.EmailVerified)
 				return
 			}
 		}
@@ -46,125 +77,63 @@ func AuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
 			return
 		}
 
-		refreshClaims, err := utils.ValidateRefreshKey(refreshCookie.Value)
-		if err != nil {
-			w.WriteHeader(http.StatusUnauthorized)
-			json.NewEncoder(w).Encode(schemas.ApiResponse{
-				Message: utils.SendInternalError(utils.MIDDLEWARE_REFRESH_TOKEN_INVALID_OR_EXPIRED),
-			})
-			return
-		}
-
 		ctx, cancel := context.WithTimeout(context.Background(), database.MONGODB_TIMEOUT)
 		defer cancel()
 
-		mongoURI := os.Getenv(utils.ENV_MONGODB_URI)
-		opts := options.Client().ApplyURI(mongoURI)
-		client, err := mongo.Connect(opts)
-		if err != nil {
-			w.WriteHeader(http.StatusBadGateway)
-			json.NewEncoder(w).Encode(schemas.ApiResponse{
-				Message: utils.SendInternalError(utils.CANNOT_CONNECT_TO_MONGODB),
-			})
-			return
-		}
-		defer client.Disconnect(ctx)
-
-		collection := client.Database(database.GetDB()).Collection("clients")
-
-		userId, err := utils.ParseObjectIDFromHex(refreshClaims.UserId)
-		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			json.NewEncoder(w).Encode(schemas.ApiResponse{
-				Message: utils.SendInternalError(utils.INVALID_USER_ID_FORMAT),
-			})
-			return
-		}
-
-		filter := bson.D{{Key: "_id", Value: userId}}
-
-		var result struct {
-			RefreshToken string `bson:"refresh_token"`
-		}
-
-		err = collection.FindOne(ctx, filter).Decode(&result)
-		if err != nil {
-			if err == mongo.ErrNoDocuments {
-				w.WriteHeader(http.StatusUnauthorized)
-				json.NewEncoder(w).Encode(schemas.ApiResponse{
-					Message: utils.SendInternalError(utils.MIDDLEWARE_REFRESH_TOKEN_INVALID_OR_EXPIRED),
-				})
-				return
+		refreshed, refreshErr := RefreshSession(ctx, r, refreshCookie.Value)
+		if refreshErr != nil {
+			if refreshErr.Code == utils.REFRESH_TOKEN_REUSE_DETECTED {
+				ClearAuthCookies(w)
 			}
-			w.WriteHeader(http.StatusInternalServerError)
-			json.NewEncoder(w).Encode(schemas.ApiResponse{
-				Message: utils.SendInternalError(utils.ERROR_TO_TRY_FIND_MONGODB),
-			})
-			return
-		}
-
-		if refreshCookie.Value != result.RefreshToken {
-			w.WriteHeader(http.StatusUnauthorized)
-			json.NewEncoder(w).Encode(schemas.ApiResponse{
-				Message: utils.SendInternalError(utils.REFRESH_TOKEN_NOT_MATCHING_DATABASE),
-			})
+			refreshErr.Write(w)
 			return
 		}
 
-		newAccessToken, err := utils.GenerateAccessKey(refreshClaims.UserId)
-		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			json.NewEncoder(w).Encode(schemas.ApiResponse{
-				Message: utils.SendInternalError(utils.ERROR_WHEN_GENERATE_ACCESS_TOKEN),
-			})
-			return
-		}
+		// Dentro da janela de tolerância o cookie de refresh já foi atualizado
+		// pela requisição concorrente que rotacionou a sessão
+		SetAuthCookies(w, refreshed.AccessToken, refreshed.RefreshToken)
 
-		newRefreshToken, err := utils.GenerateRefreshKey(refreshClaims.UserId)
-		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			json.NewEncoder(w).Encode(schemas.ApiResponse{
-				Message: utils.SendInternalError(utils.ERROR_WHEN_GENERATE_REFRESH_TOKEN),
-			})
+		if !refreshed.Client.EmailVerified && !allowedWhileUnverified(r) {
+			writeEmailNotVerified(w)
 			return
 		}
 
-		update := bson.D{{Key: "$set", Value: bson.D{
-			{Key: "refresh_token", Value: newRefreshToken},
-			{Key: "updated_at", Value: time.Now()},
-		}}}
-
-		_, err = collection.UpdateOne(ctx, filter, update)
-		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			json.NewEncoder(w).Encode(schemas.ApiResponse{
-				Message: utils.SendInternalError(utils.ERROR_TO_UPDATE_REFRESH_TOKEN),
-			})
-			return
-		}
+		serveAuthenticated(next, w, r, refreshed.Claims.UserId, refreshed.Claims.SessionId, refreshed.Client.EmailVerified)
+	}
+}
 
-		http.SetCookie(w, &http.Cookie{
-			Name:     "access_token",
-			Value:    newAccessToken,
-			Path:     "/",
-			MaxAge:   int(ACCESS_TOKEN_COOKIE_EXPIRATION.Seconds()),
-			HttpOnly: true,
-			Secure:   true,
-			SameSite: http.SameSiteStrictMode,
+func bearerAuth(next http.HandlerFunc, w http.ResponseWriter, r *http.Request, bearer string) {
+	if bearer == "" {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.WRONG_AUTHORIZATION_HEADER_FORMAT),
 		})
+		return
+	}
 
-		http.SetCookie(w, &http.Cookie{
-			Name:     "refresh_token",
-			Value:    newRefreshToken,
-			Path:     "/",
-			MaxAge:   int(REFRESH_TOKEN_COOKIE_EXPIRATION.Seconds()),
-			HttpOnly: true,
-			Secure:   true,
-			SameSite: http.SameSiteStrictMode,
+	claims, err := utils.ValidateAccessKey(bearer)
+	if err != nil {
+		w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(schemas.ApiResponse{
+			Message: utils.SendInternalError(utils.ACCESS_TOKEN_INVALID_OR_EXPIRED),
 		})
+		return
+	}
 
-		ctx = context.WithValue(r.Context(), UserIDKey, refreshClaims.UserId)
-		r = r.WithContext(ctx)
-		next.ServeHTTP(w, r)
+	// O status de verificação vem do token; depois de confirmar o email o
+	// cliente precisa renovar o access token para acessar as demais rotas.
+	if !claims.EmailVerified && !allowedWhileUnverified(r) {
+		writeEmailNotVerified(w)
+		return
 	}
+
+	serveAuthenticated(next, w, r, claims.UserId, claims.SessionId, claims.EmailVerified)
+}
+
+func serveAuthenticated(next http.HandlerFunc, w http.ResponseWriter, r *http.Request, userId string, sessionId string, emailVerified bool) {
+	ctx := context.WithValue(r.Context(), UserIDKey, userId)
+	ctx = context.WithValue(ctx, SessionIDKey, sessionId)
+	ctx = context.WithValue(ctx, EmailVerifiedKey, emailVerified)
+	next.ServeHTTP(w, r.WithContext(ctx))
 }
@@ -0,0 +1,158 @@
+package middlewares
+
+import (
+	"api/database"
+	"api/schemas"
+	"api/utils"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func setupSession(t *testing.T) (*database.Repositories, schemas.Session, string) {
+	t.Helper()
+	t.Setenv("ACCESS_TOKEN_SECRET", "access")
+	t.Setenv("REFRESH_TOKEN_SECRET", "refresh")
+
+	repositories := database.NewMemoryRepositories()
+	Repositories = repositories
+	t.Cleanup(func() { Repositories = nil })
+
+	client := schemas.ClientFromDB{ID: bson.NewObjectID(), EmailVerified: true}
+	repositories.Clients.(*database.MemoryClientsRepository).Insert(client)
+
+	session := schemas.Session{
+		ID:        bson.NewObjectID(),
+		ClientID:  client.ID,
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+	refreshToken, err := utils.GenerateRefreshKey(client.ID.Hex(), session.ID.Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	session.RefreshTokenHash = utils.HashToken(refreshToken)
+	repositories.Sessions.Create(t.Context(), session)
+
+	return repositories, session, refreshToken
+}
+
+func refreshRequest(refreshToken string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/v1/uniforms", nil)
+	req.AddCookie(&http.Cookie{Name: "refresh_token", Value: refreshToken})
+	return req
+}
+
+func okHandler(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+}
+
+func TestRefreshRotatesSessionAndToleratesConcurrentRequest(t *testing.T) {
+	repositories, session, refreshToken := setupSession(t)
+
+	w := httptest.NewRecorder()
+	AuthMiddleware(okHandler)(w, refreshRequest(refreshToken))
+	if w.Code != http.StatusOK {
+		t.Fatalf("first refresh: status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	stored, _ := repositories.Sessions.FindByID(t.Context(), session.ID)
+	if stored.RefreshTokenHash == session.RefreshTokenHash {
+		t.Fatal("refresh token was not rotated")
+	}
+
+	// Uma aba concorrente ainda com o token anterior dentro da janela
+	w = httptest.NewRecorder()
+	AuthMiddleware(okHandler)(w, refreshRequest(refreshToken))
+	if w.Code != http.StatusOK {
+		t.Fatalf("concurrent refresh: status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	again, _ := repositories.Sessions.FindByID(t.Context(), session.ID)
+	if again.RefreshTokenHash != stored.RefreshTokenHash {
+		t.Error("concurrent refresh rotated the session again")
+	}
+}
+
+func TestRefreshTokenReuseRevokesSession(t *testing.T) {
+	repositories, session, refreshToken := setupSession(t)
+	utils.Denylist = repositories.RevokedTokens
+	t.Cleanup(func() { utils.Denylist = nil })
+
+	// Access token emitido na rotação que o atacante fez com o token roubado
+	accessToken, err := utils.GenerateAccessKey(session.ClientID.Hex(), session.ID.Hex(), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = repositories.Sessions.Rotate(t.Context(), session.ID, session.RefreshTokenHash, "novo", time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Simula uma rotação antiga, fora da janela de tolerância
+	memory := repositories.Sessions.(*database.MemorySessionsRepository)
+	stored, _ := memory.FindByID(t.Context(), session.ID)
+	stored.RotatedAt = time.Now().Add(-2 * SESSION_REFRESH_GRACE_PERIOD)
+	memory.Create(t.Context(), stored)
+
+	w := httptest.NewRecorder()
+	AuthMiddleware(okHandler)(w, refreshRequest(refreshToken))
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+
+	if _, err := memory.FindByID(t.Context(), session.ID); err == nil {
+		t.Error("session was not revoked")
+	}
+
+	if _, err := utils.ValidateAccessKey(accessToken); err != utils.ErrAccessTokenRevoked {
+		t.Errorf("access token of the revoked session: err = %v, want %v", err, utils.ErrAccessTokenRevoked)
+	}
+
+	events := repositories.AuditEvents.(*database.MemoryAuditEventsRepository).Events()
+	if len(events) != 1 || events[0].Action != schemas.AUDIT_ACTION_REFRESH_TOKEN_REUSE {
+		t.Errorf("audit events = %+v", events)
+	}
+}
+
+func TestBearerAccessTokenAuthenticatesWithoutCookies(t *testing.T) {
+	_, session, _ := setupSession(t)
+
+	accessToken, err := utils.GenerateAccessKey(session.ClientID.Hex(), session.ID.Hex(), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var userId string
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		userId, _ = r.Context().Value(UserIDKey).(string)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/uniforms", nil)
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	w := httptest.NewRecorder()
+	AuthMiddleware(handler)(w, req)
+
+	if w.Code != http.StatusOK || userId != session.ClientID.Hex() {
+		t.Fatalf("status = %d, userId = %q", w.Code, userId)
+	}
+	if len(w.Result().Cookies()) != 0 {
+		t.Error("bearer request received cookies")
+	}
+}
+
+func TestInvalidBearerDoesNotFallBackToCookies(t *testing.T) {
+	_, _, refreshToken := setupSession(t)
+
+	req := refreshRequest(refreshToken)
+	req.Header.Set("Authorization", "Bearer invalido")
+	w := httptest.NewRecorder()
+	AuthMiddleware(okHandler)(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+}
@@ -2,12 +2,105 @@ package middlewares
 
 import (
 	"bytes"
+	"encoding/json"
+	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"net/url"
+	"slices"
+	"strings"
 	"time"
 )
 
+// loggedRedactedFields are JSON keys whose values never reach the logs:
+// client documents (stored encrypted), passwords, tokens and 2FA codes.
+var loggedRedactedFields = []string{
+	"cpf",
+	"cnpj",
+	"identity_card",
+	"password",
+	"token",
+	"refresh_token",
+	"challenge_token",
+	"code",
+	"secret",
+}
+
+// loggedRedactedQueryParams are query string parameters masked in the request
+// line, besides loggedRedactedFields: the admin client search filters by
+// document, email, phone and free text.
+var loggedRedactedQueryParams = []string{
+	"document",
+	"email",
+	"phone",
+	"q",
+}
+
+// redactRequestURI returns the path and query string as they should be
+// logged, with the values of sensitive parameters replaced. Parameters keep
+// their original order and encoding.
+func redactRequestURI(u *url.URL) string {
+	if u.RawQuery == "" {
+		return u.EscapedPath()
+	}
+
+	params := strings.Split(u.RawQuery, "&")
+	for i, param := range params {
+		rawKey, _, hasValue := strings.Cut(param, "=")
+		key, err := url.QueryUnescape(rawKey)
+		if err != nil {
+			key = rawKey
+		}
+		key = strings.ToLower(key)
+		if hasValue && (slices.Contains(loggedRedactedFields, key) || slices.Contains(loggedRedactedQueryParams, key)) {
+			params[i] = rawKey + "=[redacted]"
+		}
+	}
+	return u.EscapedPath() + "?" + strings.Join(params, "&")
+}
+
+// redactPayload returns the request body as it should be logged, with the
+// values of loggedRedactedFields replaced at any depth. Bodies that are not
+// JSON are logged only by size, since their fields cannot be told apart.
+func redactPayload(body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var payload any
+	if err := decoder.Decode(&payload); err != nil {
+		return fmt.Sprintf("[%d bytes não JSON]", len(body))
+	}
+
+	redacted, err := json.Marshal(redactValue(payload))
+	if err != nil {
+		return fmt.Sprintf("[%d bytes]", len(body))
+	}
+	return string(redacted)
+}
+
+func redactValue(v any) any {
+	switch value := v.(type) {
+	case map[string]any:
+		for key, field := range value {
+			if field != nil && slices.Contains(loggedRedactedFields, strings.ToLower(key)) {
+				value[key] = "[redacted]"
+			} else {
+				value[key] = redactValue(field)
+			}
+		}
+	case []any:
+		for i := range value {
+			value[i] = redactValue(value[i])
+		}
+	}
+	return v
+}
+
 // statusResponseWriter wraps http.ResponseWriter to capture status codes
 // and ensures a default of 200 if WriteHeader is not explicitly called.
 type statusResponseWriter struct {
@@ -30,8 +123,15 @@ func (w *statusResponseWriter) Write(b []byte) (int, error) {
 	return w.ResponseWriter.Write(b)
 }
 
-// Logging middleware reads and logs request payloads, then wraps
-// the ResponseWriter to capture and log the response status code.
+// Unwrap exposes the underlying ResponseWriter to http.ResponseController,
+// so handlers can extend their own deadlines.
+func (w *statusResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Logging middleware reads and logs request payloads (with sensitive
+// fields redacted), then wraps the ResponseWriter to capture and log the
+// response status code.
 func Logging(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
@@ -41,7 +141,7 @@ func Logging(next http.Handler) http.Handler {
 		if err != nil {
 			log.Printf("{04} - [Logging] erro ao ler body: %v", err)
 		} else {
-			log.Printf("{05} - [Logging] payload recebido: %s", string(bodyBytes))
+			log.Printf("{05} - [Logging] payload recebido: %s", redactPayload(bodyBytes))
 		}
 		// Reset r.Body for further handlers
 		r.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
@@ -52,14 +152,15 @@ func Logging(next http.Handler) http.Handler {
 		// Call the next handler
 		next.ServeHTTP(srw, r)
 
-		// Final log: method, URI, remote address, status code, and duration
+		// Final log: method, URI, remote address, status code, duration and request id
 		log.Printf(
-			"%s %s %s %d %s",
+			"%s %s %s %d %s %s",
 			r.Method,
-			r.RequestURI,
+			redactRequestURI(r.URL),
 			r.RemoteAddr,
 			srw.statusCode,
 			time.Since(start),
+			RequestIDFromContext(r.Context()),
 		)
 	})
 }
@@ -0,0 +1,65 @@
+package middlewares
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRedactPayloadHidesDocumentsAndSecrets(t *testing.T) {
+	body := `{"name":"Maria","cpf":"529.982.247-25","password":"segredo123","contact":{"cnpj":"11222333000181","identity_card":"12.345.678-9"},"players":[{"token":"abc"}],"budget_id":10}`
+
+	got := redactPayload([]byte(body))
+	for _, leaked := range []string{"529.982.247-25", "segredo123", "11222333000181", "12.345.678-9", "abc"} {
+		if strings.Contains(got, leaked) {
+			t.Errorf("payload = %s, leaks %q", got, leaked)
+		}
+	}
+	if !strings.Contains(got, `"name":"Maria"`) || !strings.Contains(got, `"budget_id":10`) {
+		t.Errorf("payload = %s, want the other fields untouched", got)
+	}
+
+	if got := redactPayload([]byte("cpf=52998224725")); got != "[15 bytes não JSON]" {
+		t.Errorf("form payload = %q, want only its size", got)
+	}
+}
+
+func TestLoggingKeepsBodyForHandler(t *testing.T) {
+	var logs bytes.Buffer
+	output := log.Writer()
+	log.SetOutput(&logs)
+	t.Cleanup(func() { log.SetOutput(output) })
+
+	body := `{"cpf":"52998224725"}`
+	var received string
+	handler := Logging(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, _ := io.ReadAll(r.Body)
+		received = string(raw)
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPatch, "/v1/clients", strings.NewReader(body)))
+
+	if received != body {
+		t.Errorf("handler body = %q, want %q", received, body)
+	}
+	if strings.Contains(logs.String(), "52998224725") {
+		t.Errorf("logs = %s, want the CPF redacted", logs.String())
+	}
+}
+
+func TestRedactRequestURIHidesSearchFilters(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/v1/admin/clients/search?document=529.982.247-25&Email=maria%40example.com&phone=41999998888&q=Maria&state=PR&limit=10", nil)
+
+	got := redactRequestURI(r.URL)
+	want := "/v1/admin/clients/search?document=[redacted]&Email=[redacted]&phone=[redacted]&q=[redacted]&state=PR&limit=10"
+	if got != want {
+		t.Errorf("uri = %q, want %q", got, want)
+	}
+
+	if got := redactRequestURI(httptest.NewRequest(http.MethodGet, "/v1/uniforms", nil).URL); got != "/v1/uniforms" {
+		t.Errorf("uri = %q, want the path alone", got)
+	}
+}
@@ -0,0 +1,48 @@
+package middlewares
+
+import (
+	"api/utils"
+	"context"
+	"net/http"
+	"regexp"
+)
+
+const (
+	RequestIDKey ContextKey = "requestId"
+
+	REQUEST_ID_HEADER = "X-Request-ID"
+)
+
+// validRequestID limita o id aceito do proxy/cliente, já que ele vai para os
+// logs e para a auditoria.
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,128}$`)
+
+// RequestID garante um identificador por requisição, reaproveitando o
+// X-Request-ID recebido quando válido. O id volta no header da resposta e fica
+// no contexto para os logs e eventos de auditoria.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestId := r.Header.Get(REQUEST_ID_HEADER)
+		if !validRequestID.MatchString(requestId) {
+			generated, err := utils.GenerateOpaqueToken()
+			if err == nil {
+				requestId = generated
+			} else {
+				requestId = ""
+			}
+		}
+
+		if requestId != "" {
+			w.Header().Set(REQUEST_ID_HEADER, requestId)
+			r = r.WithContext(context.WithValue(r.Context(), RequestIDKey, requestId))
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequestIDFromContext retorna o id gravado por RequestID, ou vazio.
+func RequestIDFromContext(ctx context.Context) string {
+	requestId, _ := ctx.Value(RequestIDKey).(string)
+	return requestId
+}
@@ -0,0 +1,272 @@
+package middlewares
+
+import (
+	"api/database"
+	"api/schemas"
+	"api/utils"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// SESSION_REFRESH_GRACE_PERIOD é o tempo em que o refresh token anterior
+// ainda é aceito após uma rotação, para abas que renovaram ao mesmo tempo.
+const SESSION_REFRESH_GRACE_PERIOD = 30 * time.Second
+
+var errRefreshTokenReused = errors.New("refresh token already rotated")
+
+// RefreshError é a falha de RefreshSession, com o status HTTP e o código
+// interno que vão na resposta.
+type RefreshError struct {
+	Status int
+	Code   int
+}
+
+func (e *RefreshError) Error() string {
+	return utils.SendInternalError(e.Code)
+}
+
+// Write grava a resposta de erro no formato padrão da API.
+func (e *RefreshError) Write(w http.ResponseWriter) {
+	w.WriteHeader(e.Status)
+	json.NewEncoder(w).Encode(schemas.ApiResponse{
+		Message: e.Error(),
+	})
+}
+
+// RefreshedSession é o resultado de uma troca de refresh token bem-sucedida.
+// RefreshToken fica vazio quando o token apresentado era o anterior, dentro da
+// janela de tolerância: o novo já foi entregue à requisição que rotacionou.
+type RefreshedSession struct {
+	Claims       *utils.Claims
+	Client       schemas.ClientFromDB
+	AccessToken  string
+	RefreshToken string
+}
+
+// RefreshSession valida o refresh token, rotaciona a sessão e emite um novo
+// access token. É usado tanto pelo fluxo de cookies do AuthMiddleware quanto
+// pelo endpoint /v1/auth/token/refresh dos clientes com Bearer.
+func RefreshSession(ctx context.Context, r *http.Request, refreshToken string) (RefreshedSession, *RefreshError) {
+	refreshClaims, err := utils.ValidateRefreshKey(refreshToken)
+	if err != nil {
+		return RefreshedSession{}, &RefreshError{http.StatusUnauthorized, utils.MIDDLEWARE_REFRESH_TOKEN_INVALID_OR_EXPIRED}
+	}
+
+	userId, err := utils.ParseObjectIDFromHex(refreshClaims.UserId)
+	if err != nil {
+		return RefreshedSession{}, &RefreshError{http.StatusInternalServerError, utils.INVALID_USER_ID_FORMAT}
+	}
+
+	// Refresh tokens emitidos antes das sessões não têm sid e exigem novo login
+	sessionId, err := utils.ParseObjectIDFromHex(refreshClaims.SessionId)
+	if err != nil {
+		return RefreshedSession{}, &RefreshError{http.StatusUnauthorized, utils.MIDDLEWARE_REFRESH_TOKEN_INVALID_OR_EXPIRED}
+	}
+
+	session, err := Repositories.Sessions.FindByID(ctx, sessionId)
+	if err != nil || session.ClientID != userId {
+		if err == nil || err == mongo.ErrNoDocuments {
+			return RefreshedSession{}, &RefreshError{http.StatusUnauthorized, utils.MIDDLEWARE_REFRESH_TOKEN_INVALID_OR_EXPIRED}
+		}
+		return RefreshedSession{}, &RefreshError{http.StatusInternalServerError, utils.ERROR_TO_TRY_FIND_MONGODB}
+	}
+
+	client, err := Repositories.Clients.FindByID(ctx, userId)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return RefreshedSession{}, &RefreshError{http.StatusUnauthorized, utils.MIDDLEWARE_REFRESH_TOKEN_INVALID_OR_EXPIRED}
+		}
+		return RefreshedSession{}, &RefreshError{http.StatusInternalServerError, utils.ERROR_TO_TRY_FIND_MONGODB}
+	}
+
+	// O bloqueio já encerra as sessões; isto cobre uma renovação concorrente
+	if client.Blocked() {
+		return RefreshedSession{}, &RefreshError{http.StatusUnauthorized, utils.MIDDLEWARE_REFRESH_TOKEN_INVALID_OR_EXPIRED}
+	}
+
+	newRefreshToken, err := utils.GenerateRefreshKey(refreshClaims.UserId, refreshClaims.SessionId)
+	if err != nil {
+		return RefreshedSession{}, &RefreshError{http.StatusInternalServerError, utils.ERROR_WHEN_GENERATE_REFRESH_TOKEN}
+	}
+
+	rotated, err := rotateSession(ctx, session, utils.HashToken(refreshToken), newRefreshToken)
+	if err != nil {
+		if err == errRefreshTokenReused {
+			if err := revokeSessionFamily(ctx, r, session, refreshClaims); err != nil {
+				return RefreshedSession{}, &RefreshError{http.StatusInternalServerError, utils.ERROR_TO_UPDATE_REFRESH_TOKEN}
+			}
+			return RefreshedSession{}, &RefreshError{http.StatusUnauthorized, utils.REFRESH_TOKEN_REUSE_DETECTED}
+		}
+		return RefreshedSession{}, &RefreshError{http.StatusInternalServerError, utils.ERROR_TO_UPDATE_REFRESH_TOKEN}
+	}
+
+	newAccessToken, err := utils.GenerateAccessKey(refreshClaims.UserId, refreshClaims.SessionId, client.EmailVerified)
+	if err != nil {
+		return RefreshedSession{}, &RefreshError{http.StatusInternalServerError, utils.ERROR_WHEN_GENERATE_ACCESS_TOKEN}
+	}
+
+	refreshed := RefreshedSession{
+		Claims:      refreshClaims,
+		Client:      client,
+		AccessToken: newAccessToken,
+	}
+	if rotated {
+		refreshed.RefreshToken = newRefreshToken
+	}
+
+	return refreshed, nil
+}
+
+// rotateSession troca o refresh token da sessão por newRefreshToken. Retorna
+// false sem erro quando o token apresentado é o anterior e ainda está dentro
+// da janela de tolerância: nesse caso a sessão continua válida, mas quem
+// rotacionou foi outra requisição. Qualquer outro token da sessão já foi
+// rotacionado e indica reuso (errRefreshTokenReused).
+func rotateSession(ctx context.Context, session schemas.Session, tokenHash string, newRefreshToken string) (bool, error) {
+	if tokenHash == session.RefreshTokenHash {
+		expiresAt := time.Now().Add(utils.REFRESH_TOKEN_EXPIRATION)
+		err := Repositories.Sessions.Rotate(ctx, session.ID, tokenHash, utils.HashToken(newRefreshToken), expiresAt)
+		if err == nil {
+			return true, nil
+		}
+		if err != mongo.ErrNoDocuments {
+			return false, err
+		}
+
+		// Outra requisição rotacionou entre a leitura e o update
+		session, err = Repositories.Sessions.FindByID(ctx, session.ID)
+		if err == mongo.ErrNoDocuments {
+			return false, errRefreshTokenReused
+		}
+		if err != nil {
+			return false, err
+		}
+	}
+
+	if RefreshTokenInGracePeriod(session, tokenHash) {
+		return false, nil
+	}
+
+	return false, errRefreshTokenReused
+}
+
+// RefreshTokenInGracePeriod diz se tokenHash é o refresh token anterior da
+// sessão, rotacionado há menos de SESSION_REFRESH_GRACE_PERIOD.
+func RefreshTokenInGracePeriod(session schemas.Session, tokenHash string) bool {
+	return tokenHash != "" && tokenHash == session.PreviousTokenHash && time.Since(session.RotatedAt) <= SESSION_REFRESH_GRACE_PERIOD
+}
+
+// revokeSessionFamily encerra a sessão inteira (a família de refresh tokens
+// gerados a partir do mesmo login) quando um token já rotacionado é
+// reapresentado, já que não dá para saber se quem o usa é o cliente ou quem
+// o roubou. O evento é auditado; falhas na auditoria só vão para o log.
+func revokeSessionFamily(ctx context.Context, r *http.Request, session schemas.Session, claims *utils.Claims) error {
+	// Sem o sid na denylist, o access token já emitido para quem roubou o
+	// refresh token continuaria válido até expirar
+	err := Repositories.RevokedTokens.Revoke(ctx, utils.DenylistKeyForSession(session.ID.Hex()), time.Now().Add(utils.ACCESS_TOKEN_EXPIRATION))
+	if err != nil {
+		return err
+	}
+
+	err = Repositories.Sessions.Delete(ctx, session.ID, session.ClientID)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return err
+	}
+
+	err = Repositories.AuditEvents.Insert(ctx, schemas.AuditEvent{
+		ActorType:  schemas.AUDIT_ACTOR_CLIENT,
+		ActorID:    session.ClientID.Hex(),
+		Action:     schemas.AUDIT_ACTION_REFRESH_TOKEN_REUSE,
+		TargetType: schemas.AUDIT_TARGET_SESSION,
+		TargetID:   session.ID.Hex(),
+		RequestID:  RequestIDFromContext(r.Context()),
+		IP:         utils.ClientIP(r),
+		Metadata: map[string]any{
+			"jti":                claims.ID,
+			"user_agent":         r.UserAgent(),
+			"session_user_agent": session.UserAgent,
+			"session_ip":         session.IP,
+		},
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		log.Printf("[AuthMiddleware] Erro ao auditar reuso de refresh token: %v", err)
+	}
+
+	return nil
+}
+
+// RevokeClientSessions encerra todas as sessões do cliente e coloca na
+// denylist os access tokens já emitidos para elas. Usado no reset de senha e
+// quando a conta é bloqueada ou anonimizada.
+func RevokeClientSessions(ctx context.Context, repositories *database.Repositories, clientID bson.ObjectID) error {
+	sessions, err := repositories.Sessions.FindByClientID(ctx, clientID)
+	if err != nil {
+		return err
+	}
+
+	for _, session := range sessions {
+		err := repositories.RevokedTokens.Revoke(ctx, utils.DenylistKeyForSession(session.ID.Hex()), time.Now().Add(utils.ACCESS_TOKEN_EXPIRATION))
+		if err != nil {
+			return err
+		}
+	}
+
+	return repositories.Sessions.DeleteByClientID(ctx, clientID)
+}
+
+// SetAuthCookies grava os cookies de sessão do fluxo web. O refresh token só
+// é gravado quando informado.
+func SetAuthCookies(w http.ResponseWriter, accessToken string, refreshToken string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     "access_token",
+		Value:    accessToken,
+		Path:     "/",
+		MaxAge:   int(ACCESS_TOKEN_COOKIE_EXPIRATION.Seconds()),
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteStrictMode,
+	})
+
+	if refreshToken != "" {
+		http.SetCookie(w, &http.Cookie{
+			Name:     "refresh_token",
+			Value:    refreshToken,
+			Path:     "/",
+			MaxAge:   int(REFRESH_TOKEN_COOKIE_EXPIRATION.Seconds()),
+			HttpOnly: true,
+			Secure:   true,
+			SameSite: http.SameSiteStrictMode,
+		})
+	}
+}
+
+// ClearAuthCookies remove os cookies de sessão, forçando um novo login.
+func ClearAuthCookies(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     "access_token",
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteStrictMode,
+	})
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     "refresh_token",
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
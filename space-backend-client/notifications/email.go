@@ -0,0 +1,58 @@
+package notifications
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+)
+
+type EmailNotifier struct {
+	host     string
+	port     string
+	user     string
+	password string
+	from     string
+}
+
+func NewEmailNotifier(host, port, user, password, from string) *EmailNotifier {
+	if port == "" {
+		port = "587"
+	}
+
+	return &EmailNotifier{
+		host:     host,
+		port:     port,
+		user:     user,
+		password: password,
+		from:     from,
+	}
+}
+
+func (n *EmailNotifier) Send(ctx context.Context, message Message) error {
+	if message.To.Email == "" {
+		return fmt.Errorf("contato sem email para notificação")
+	}
+
+	headers := []string{
+		"From: " + n.from,
+		"To: " + message.To.Email,
+		"Subject: " + message.Subject,
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+	}
+	body := strings.Join(headers, "\r\n") + "\r\n\r\n" + message.Body
+
+	var auth smtp.Auth
+	if n.user != "" {
+		auth = smtp.PlainAuth("", n.user, n.password, n.host)
+	}
+
+	addr := net.JoinHostPort(n.host, n.port)
+	if err := smtp.SendMail(addr, auth, n.from, []string{message.To.Email}, []byte(body)); err != nil {
+		return fmt.Errorf("erro ao enviar email: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,57 @@
+package notifications
+
+import (
+	"api/schemas"
+	"api/utils"
+	"context"
+	"log"
+	"os"
+)
+
+const (
+	NOTIFIER_LOG      = "log"
+	NOTIFIER_EMAIL    = "email"
+	NOTIFIER_WHATSAPP = "whatsapp"
+)
+
+// Message é uma notificação destinada a um cliente. Cada implementação
+// escolhe o canal (email, WhatsApp...) a partir do Contact.
+type Message struct {
+	To      schemas.Contact
+	Subject string
+	Body    string
+}
+
+type Notifier interface {
+	Send(ctx context.Context, message Message) error
+}
+
+// NewFromEnv escolhe a implementação pela variável NOTIFIER. Sem valor, usa o
+// LogNotifier, que só registra a mensagem no log (útil em desenvolvimento).
+func NewFromEnv() Notifier {
+	switch os.Getenv(utils.NOTIFIER) {
+	case NOTIFIER_EMAIL:
+		return NewEmailNotifier(
+			os.Getenv(utils.SMTP_HOST),
+			os.Getenv(utils.SMTP_PORT),
+			os.Getenv(utils.SMTP_USER),
+			os.Getenv(utils.SMTP_PASSWORD),
+			os.Getenv(utils.SMTP_FROM),
+		)
+	case NOTIFIER_WHATSAPP:
+		return NewWhatsappNotifier(os.Getenv(utils.D360_API_KEY))
+	case NOTIFIER_LOG, "":
+		return LogNotifier{}
+	default:
+		log.Printf("[Notifier] Valor desconhecido para NOTIFIER: %s. Usando log", os.Getenv(utils.NOTIFIER))
+		return LogNotifier{}
+	}
+}
+
+// LogNotifier apenas registra a mensagem no log.
+type LogNotifier struct{}
+
+func (LogNotifier) Send(ctx context.Context, message Message) error {
+	log.Printf("[Notifier] para %s <%s>: %s\n%s", message.To.Name, message.To.Email, message.Subject, message.Body)
+	return nil
+}
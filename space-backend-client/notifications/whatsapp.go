@@ -0,0 +1,69 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const (
+	D360_MESSAGES_URL = "https://waba-v2.360dialog.io/messages"
+	D360_TIMEOUT      = 10 * time.Second
+)
+
+// WhatsappNotifier envia a mensagem como texto pela 360Dialog para o
+// cell_phone do contato.
+type WhatsappNotifier struct {
+	apiKey     string
+	httpClient *http.Client
+}
+
+func NewWhatsappNotifier(apiKey string) *WhatsappNotifier {
+	return &WhatsappNotifier{
+		apiKey:     apiKey,
+		httpClient: &http.Client{Timeout: D360_TIMEOUT},
+	}
+}
+
+func (n *WhatsappNotifier) Send(ctx context.Context, message Message) error {
+	if message.To.CellPhone == "" {
+		return fmt.Errorf("contato sem celular para notificação")
+	}
+
+	payload := map[string]interface{}{
+		"messaging_product": "whatsapp",
+		"recipient_type":    "individual",
+		"to":                message.To.CellPhone,
+		"type":              "text",
+		"text": map[string]string{
+			"body": message.Subject + "\n\n" + message.Body,
+		},
+	}
+	bodyBytes, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("erro ao serializar payload: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, D360_MESSAGES_URL, bytes.NewReader(bodyBytes))
+	if err != nil {
+		return fmt.Errorf("erro ao criar requisição 360Dialog: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("D360-API-KEY", n.apiKey)
+
+	resp, err := n.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("erro ao chamar 360Dialog: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("360Dialog respondeu com status %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -13,11 +13,12 @@ import (
 	"strconv"
 	"strings"
 
-	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo"
-	"go.mongodb.org/mongo-driver/v2/mongo/options"
 )
 
+// Repositories é injetado pelo main com o client MongoDB compartilhado.
+var Repositories *database.Repositories
+
 func getAllOrders(w http.ResponseWriter, r *http.Request) {
 	userId := r.Context().Value(middlewares.UserIDKey)
 	if userId == nil {
@@ -49,23 +50,7 @@ func getAllOrders(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(context.Background(), database.MONGODB_TIMEOUT)
 	defer cancel()
 
-	mongoURI := os.Getenv(utils.ENV_MONGODB_URI)
-	opts := options.Client().ApplyURI(mongoURI)
-	mongoClient, err := mongo.Connect(opts)
-	if err != nil {
-		w.WriteHeader(http.StatusBadGateway)
-		json.NewEncoder(w).Encode(schemas.ApiResponse{
-			Message: utils.SendInternalError(utils.CANNOT_CONNECT_TO_MONGODB),
-		})
-		return
-	}
-	defer mongoClient.Disconnect(ctx)
-
-	collection := mongoClient.Database(database.GetDB()).Collection("clients")
-	filter := bson.D{{Key: "_id", Value: objectId}}
-
-	clientData := schemas.ClientFromDB{}
-	err = collection.FindOne(ctx, filter).Decode(&clientData)
+	clientData, err := Repositories.Clients.FindByID(ctx, objectId)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			w.WriteHeader(http.StatusNotFound)
@@ -111,7 +96,12 @@ func getAllOrders(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	adminKey := os.Getenv(utils.ADMIN_KEY)
+	// A chave enviada ao ERP é independente das credenciais aceitas aqui; o
+	// ADMIN_KEY só é usado enquanto SPACE_ERP_API_KEY não estiver configurada.
+	adminKey := os.Getenv(utils.SPACE_ERP_API_KEY)
+	if adminKey == "" {
+		adminKey = os.Getenv(utils.ADMIN_KEY)
+	}
 	if adminKey == "" {
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(schemas.ApiResponse{
@@ -0,0 +1,191 @@
+package privacy
+
+import (
+	"api/audit"
+	"api/database"
+	"api/documents"
+	"api/middlewares"
+	"api/schemas"
+	"context"
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// anonymizedKeys são os campos de texto livre e de identificação no payload
+// do WhatsApp: corpo e legenda das mensagens, nome do perfil, contatos e
+// endereços compartilhados, nomes de arquivos e localização.
+var anonymizedKeys = map[string]bool{
+	"body":           true,
+	"caption":        true,
+	"name":           true,
+	"formatted_name": true,
+	"first_name":     true,
+	"middle_name":    true,
+	"last_name":      true,
+	"phone":          true,
+	"email":          true,
+	"street":         true,
+	"zip":            true,
+	"address":        true,
+	"filename":       true,
+	"latitude":       true,
+	"longitude":      true,
+}
+
+// auditedSessionMetadata são as chaves com o IP e o navegador do cliente nos
+// eventos de sessão, como o de reuso de refresh token.
+var auditedSessionMetadata = []string{"user_agent", "session_ip", "session_user_agent"}
+
+// Anonymize apaga os dados pessoais do cliente a pedido do titular (LGPD),
+// mantendo os vínculos com os pedidos: o _id, os orçamentos, o tiny_id e os
+// uniformes continuam no lugar. O contato é substituído por marcadores, os
+// nomes e observações dos jogadores e os eventos do WhatsApp do celular do
+// cliente são anonimizados, os valores do contato, os sketches dos uniformes
+// e o IP e o navegador das sessões saem dos eventos de auditoria, e as sessões
+// são encerradas.
+//
+// O cadastro é o último passo porque é pelo celular dele que os eventos são
+// encontrados: se algo falhar no meio, basta repetir.
+func Anonymize(ctx context.Context, repositories *database.Repositories, client schemas.ClientFromDB) (schemas.AnonymizationResult, error) {
+	result := schemas.AnonymizationResult{}
+
+	phones := documents.WhatsappIDs(client.Contact.CellPhone)
+	events, err := repositories.WhatsappEvents.FindByPhones(ctx, phones)
+	if err != nil {
+		return result, err
+	}
+	for _, event := range events {
+		rawEvent, err := anonymizeRawEvent(event.RawEvent, phones)
+		if err != nil {
+			return result, err
+		}
+		if err := repositories.WhatsappEvents.ReplaceRawEvent(ctx, event.ID, rawEvent); err != nil {
+			return result, err
+		}
+		result.WhatsappEvents++
+	}
+
+	uniforms, err := repositories.Uniforms.FindByClientIDs(ctx, []string{client.ID.Hex()})
+	if err != nil {
+		return result, err
+	}
+	for _, uniform := range uniforms {
+		for i := range uniform.Sketches {
+			for j := range uniform.Sketches[i].Players {
+				player := &uniform.Sketches[i].Players[j]
+				if player.Name != "" {
+					player.Name = schemas.ANONYMIZED_VALUE
+				}
+				player.Observations = ""
+				result.Players++
+			}
+		}
+		if err := repositories.Uniforms.UpdateSketches(ctx, uniform.ID, uniform.Sketches, uniform.Editable); err != nil {
+			return result, err
+		}
+		result.Uniforms++
+
+		// Os sketches são auditados inteiros, com os nomes e observações
+		auditEvents, err := repositories.AuditEvents.RedactChanges(ctx, schemas.AUDIT_TARGET_UNIFORM, uniform.ID.Hex(), []string{"sketches"}, audit.REDACTED)
+		if err != nil {
+			return result, err
+		}
+		result.AuditEvents += auditEvents
+	}
+
+	// Os eventos de auditoria continuam, com os campos alterados, mas sem os
+	// valores do contato que seriam apagados aqui
+	auditEvents, err := repositories.AuditEvents.RedactChanges(ctx, schemas.AUDIT_TARGET_CLIENT, client.ID.Hex(), auditedContactFields(), audit.REDACTED)
+	if err != nil {
+		return result, err
+	}
+	result.AuditEvents += auditEvents
+
+	auditEvents, err = repositories.AuditEvents.RedactMetadata(ctx, schemas.AUDIT_TARGET_SESSION, client.ID.Hex(), auditedSessionMetadata, audit.REDACTED)
+	if err != nil {
+		return result, err
+	}
+	result.AuditEvents += auditEvents
+
+	if err := middlewares.RevokeClientSessions(ctx, repositories, client.ID); err != nil {
+		return result, err
+	}
+
+	now := time.Now()
+	contact := schemas.Contact{
+		Name: schemas.ANONYMIZED_NAME,
+		// O email leva o _id, então não colide com outros cadastros, e nunca
+		// recebe mensagens
+		Email:     "cliente-" + client.ID.Hex() + "@" + schemas.ANONYMIZED_EMAIL_DOMAIN,
+		TinyID:    client.Contact.TinyID,
+		CreatedAt: client.Contact.CreatedAt,
+		UpdatedAt: now,
+	}
+	err = repositories.Clients.Anonymize(ctx, client.ID, contact, now)
+	return result, err
+}
+
+// auditedContactFields são os caminhos dos dados pessoais do contato nos
+// eventos de auditoria ("contact.name", "contact.email"...). Ficam de fora o
+// tiny_id e as datas, que não identificam o titular.
+func auditedContactFields() []string {
+	var fields []string
+	contactType := reflect.TypeOf(schemas.Contact{})
+	for i := range contactType.NumField() {
+		key, _, _ := strings.Cut(contactType.Field(i).Tag.Get("bson"), ",")
+		if key == "tiny_id" || key == "created_at" || key == "updated_at" {
+			continue
+		}
+		fields = append(fields, "contact."+key)
+	}
+	return fields
+}
+
+// anonymizeRawEvent devolve o payload com os telefones do cliente e os campos
+// de anonymizedKeys trocados pelo marcador. O webhook grava o JSON do provedor
+// decodificado, então o caminho de volta pelo Extended JSON relaxado mantém os
+// mesmos tipos.
+func anonymizeRawEvent(raw bson.Raw, phones []string) (any, error) {
+	extJSON, err := bson.MarshalExtJSON(raw, false, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var event map[string]any
+	if err := json.Unmarshal(extJSON, &event); err != nil {
+		return nil, err
+	}
+	return anonymizeValue(event, phones), nil
+}
+
+func anonymizeValue(value any, phones []string) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, item := range v {
+			switch item.(type) {
+			case map[string]any, []any:
+				v[key] = anonymizeValue(item, phones)
+			default:
+				if anonymizedKeys[key] {
+					v[key] = schemas.ANONYMIZED_VALUE
+				} else {
+					v[key] = anonymizeValue(item, phones)
+				}
+			}
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = anonymizeValue(item, phones)
+		}
+	case string:
+		if slices.Contains(phones, v) {
+			return schemas.ANONYMIZED_VALUE
+		}
+	}
+	return value
+}
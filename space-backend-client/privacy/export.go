@@ -0,0 +1,119 @@
+package privacy
+
+import (
+	"api/database"
+	"api/documents"
+	"api/schemas"
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"io"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Export reúne os dados pessoais do cliente: o cadastro, as sessões, os
+// uniformes com os jogadores e os eventos do WhatsApp ligados ao celular.
+// Hashes de senha, tokens e segredos do 2FA não fazem parte da exportação.
+func Export(ctx context.Context, repositories *database.Repositories, client schemas.ClientFromDB) (schemas.ClientExport, error) {
+	export := schemas.ClientExport{
+		GeneratedAt: time.Now(),
+		Client: schemas.ClientResponse{
+			ID:            client.ID.Hex(),
+			Contact:       documents.FormatContact(client.Contact),
+			EmailVerified: client.EmailVerified,
+			TwoFactor:     client.TwoFactor != nil && client.TwoFactor.Enabled,
+			BudgetIDs:     client.BudgetIDs,
+			Status:        client.Status,
+			AnonymizedAt:  client.AnonymizedAt,
+			CreatedAt:     client.CreatedAt,
+			UpdatedAt:     client.UpdatedAt,
+		},
+		Sessions:       []schemas.SessionResponse{},
+		Uniforms:       []schemas.UniformResponse{},
+		WhatsappEvents: []schemas.WhatsappEventExport{},
+	}
+
+	sessions, err := repositories.Sessions.FindByClientID(ctx, client.ID)
+	if err != nil {
+		return export, err
+	}
+	for _, session := range sessions {
+		export.Sessions = append(export.Sessions, schemas.SessionResponse{
+			ID:         session.ID.Hex(),
+			UserAgent:  session.UserAgent,
+			IP:         session.IP,
+			CreatedAt:  session.CreatedAt,
+			LastUsedAt: session.LastUsedAt,
+		})
+	}
+
+	uniforms, err := repositories.Uniforms.FindByClientIDs(ctx, []string{client.ID.Hex()})
+	if err != nil {
+		return export, err
+	}
+	for _, uniform := range uniforms {
+		export.Uniforms = append(export.Uniforms, schemas.UniformResponse{
+			ID:        uniform.ID.Hex(),
+			ClientID:  uniform.ClientID,
+			BudgetID:  uniform.BudgetID,
+			Sketches:  uniform.Sketches,
+			Editable:  uniform.Editable,
+			CreatedAt: uniform.CreatedAt,
+			UpdatedAt: uniform.UpdatedAt,
+		})
+	}
+
+	events, err := repositories.WhatsappEvents.FindByPhones(ctx, documents.WhatsappIDs(client.Contact.CellPhone))
+	if err != nil {
+		return export, err
+	}
+	for _, event := range events {
+		// O payload veio em JSON do provedor; o Extended JSON relaxado o
+		// devolve no mesmo formato
+		raw, err := bson.MarshalExtJSON(event.RawEvent, false, false)
+		if err != nil {
+			return export, err
+		}
+		export.WhatsappEvents = append(export.WhatsappEvents, schemas.WhatsappEventExport{
+			RawEvent:   raw,
+			ReceivedAt: event.ReceivedAt,
+		})
+	}
+
+	return export, nil
+}
+
+// WriteArchive grava a exportação como ZIP, com um arquivo JSON por parte.
+func WriteArchive(w io.Writer, export schemas.ClientExport) error {
+	archive := zip.NewWriter(w)
+
+	files := []struct {
+		name    string
+		content any
+	}{
+		{"cliente.json", export.Client},
+		{"sessoes.json", export.Sessions},
+		{"uniformes.json", export.Uniforms},
+		{"whatsapp.json", export.WhatsappEvents},
+	}
+	for _, file := range files {
+		writer, err := archive.CreateHeader(&zip.FileHeader{
+			Name:     file.name,
+			Method:   zip.Deflate,
+			Modified: export.GeneratedAt,
+		})
+		if err != nil {
+			return err
+		}
+
+		encoder := json.NewEncoder(writer)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(file.content); err != nil {
+			return err
+		}
+	}
+
+	return archive.Close()
+}
@@ -0,0 +1,307 @@
+package privacy
+
+import (
+	"api/audit"
+	"api/database"
+	"api/schemas"
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"maps"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// whatsappMessage monta um evento do webhook como o HandlerWhatsapp grava:
+// o JSON do provedor decodificado em map.
+func whatsappMessage(t *testing.T, from string, body string) map[string]any {
+	t.Helper()
+
+	payload := `{"entry": [{"changes": [{"field": "messages", "value": {
+		"contacts": [{"profile": {"name": "Maria Souza"}, "wa_id": "` + from + `"}],
+		"messages": [{"from": "` + from + `", "id": "wamid.1", "timestamp": "1760000000", "text": {"body": "` + body + `"}, "type": "text"}]
+	}}]}]}`
+
+	var event map[string]any
+	if err := json.Unmarshal([]byte(payload), &event); err != nil {
+		t.Fatal(err)
+	}
+	return event
+}
+
+func setupClient(t *testing.T) (*database.Repositories, schemas.ClientFromDB) {
+	t.Helper()
+
+	repositories := database.NewMemoryRepositories()
+	clientsRepository := repositories.Clients.(*database.MemoryClientsRepository)
+	clientsRepository.Insert(schemas.ClientFromDB{
+		Contact: schemas.Contact{
+			Name:      "Maria Souza",
+			Email:     "maria@example.com",
+			CPF:       "52998224725",
+			CellPhone: "+5541999998888",
+			Address:   "Rua das Flores",
+			TinyID:    "123",
+		},
+		PasswordHash:  "hash",
+		EmailVerified: true,
+		BudgetIDs:     []int{42},
+	})
+	client, err := clientsRepository.FindByEmail(t.Context(), "maria@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repositories.Uniforms.Create(t.Context(), schemas.UniformToDB{
+		ClientID: client.ID.Hex(),
+		BudgetID: 42,
+		Sketches: []schemas.Sketch{{ID: "a", Players: []schemas.Player{
+			{Name: "João", Number: "10", ShirtSize: "M", Observations: "goleiro"},
+		}}},
+	})
+	repositories.Sessions.Create(t.Context(), schemas.Session{
+		ID:        bson.NewObjectID(),
+		ClientID:  client.ID,
+		IP:        "200.1.2.3",
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+
+	// Conta antiga do WhatsApp, sem o nono dígito, e uma conversa de outra pessoa
+	repositories.WhatsappEvents.Insert(t.Context(), whatsappMessage(t, "554199998888", "Meu CPF é 529.982.247-25"), time.Now())
+	repositories.WhatsappEvents.Insert(t.Context(), whatsappMessage(t, "5511988887777", "Oi"), time.Now())
+
+	return repositories, client
+}
+
+func TestExportCollectsClientData(t *testing.T) {
+	repositories, client := setupClient(t)
+
+	export, err := Export(t.Context(), repositories, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if export.Client.Contact.Email != "maria@example.com" {
+		t.Errorf("email = %q", export.Client.Contact.Email)
+	}
+	if len(export.Sessions) != 1 || len(export.Uniforms) != 1 {
+		t.Errorf("sessions = %d, uniforms = %d, want 1 and 1", len(export.Sessions), len(export.Uniforms))
+	}
+	if len(export.WhatsappEvents) != 1 || !strings.Contains(string(export.WhatsappEvents[0].RawEvent), "Meu CPF") {
+		t.Fatalf("whatsapp events = %+v, want only the client's message", export.WhatsappEvents)
+	}
+
+	var archive bytes.Buffer
+	if err := WriteArchive(&archive, export); err != nil {
+		t.Fatal(err)
+	}
+	reader, err := zip.NewReader(bytes.NewReader(archive.Bytes()), int64(archive.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, file := range reader.File {
+		names = append(names, file.Name)
+	}
+	if strings.Join(names, ",") != "cliente.json,sessoes.json,uniformes.json,whatsapp.json" {
+		t.Errorf("files = %v", names)
+	}
+}
+
+func TestAnonymizeKeepsOrderReferences(t *testing.T) {
+	repositories, client := setupClient(t)
+
+	result, err := Anonymize(t.Context(), repositories, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result != (schemas.AnonymizationResult{Uniforms: 1, Players: 1, WhatsappEvents: 1}) {
+		t.Errorf("result = %+v", result)
+	}
+
+	anonymized, _ := repositories.Clients.FindByID(t.Context(), client.ID)
+	contact := anonymized.Contact
+	if contact.Name != schemas.ANONYMIZED_NAME || contact.CPF != "" || contact.CellPhone != "" || contact.Address != "" {
+		t.Errorf("contact = %+v, want only markers", contact)
+	}
+	if !strings.HasSuffix(contact.Email, "@"+schemas.ANONYMIZED_EMAIL_DOMAIN) {
+		t.Errorf("email = %q", contact.Email)
+	}
+	if contact.TinyID != "123" || len(anonymized.BudgetIDs) != 1 || anonymized.BudgetIDs[0] != 42 {
+		t.Errorf("tiny_id = %q, budget_ids = %v, want the order references kept", contact.TinyID, anonymized.BudgetIDs)
+	}
+	if anonymized.PasswordHash != "" || anonymized.EmailVerified || anonymized.AnonymizedAt == nil {
+		t.Errorf("client = %+v, want no password, unverified and anonymized_at", anonymized)
+	}
+
+	uniforms, _ := repositories.Uniforms.FindByClientIDs(t.Context(), []string{client.ID.Hex()})
+	player := uniforms[0].Sketches[0].Players[0]
+	if player.Name != schemas.ANONYMIZED_VALUE || player.Observations != "" || player.Number != "10" || player.ShirtSize != "M" {
+		t.Errorf("player = %+v, want name and observations removed", player)
+	}
+	if uniforms[0].BudgetID != 42 {
+		t.Errorf("uniform budget_id = %d, want 42", uniforms[0].BudgetID)
+	}
+
+	if sessions, _ := repositories.Sessions.FindByClientID(t.Context(), client.ID); len(sessions) != 0 {
+		t.Errorf("sessions = %d, want 0", len(sessions))
+	}
+
+	docs, _ := repositories.WhatsappEvents.FindAll(t.Context())
+	clientEvent, otherEvent := docs[0].String(), docs[1].String()
+	for _, pii := range []string{"554199998888", "Maria Souza", "529.982.247-25"} {
+		if strings.Contains(clientEvent, pii) {
+			t.Errorf("client event still has %q: %s", pii, clientEvent)
+		}
+	}
+	if !strings.Contains(clientEvent, "wamid.1") {
+		t.Errorf("client event lost the message id: %s", clientEvent)
+	}
+	if !strings.Contains(otherEvent, "5511988887777") || !strings.Contains(otherEvent, "Oi") {
+		t.Errorf("unrelated event was changed: %s", otherEvent)
+	}
+}
+
+func TestAnonymizeRedactsContactInAuditEvents(t *testing.T) {
+	repositories, client := setupClient(t)
+
+	update := schemas.AuditEvent{
+		Action:     schemas.AUDIT_ACTION_CLIENT_UPDATE,
+		TargetType: schemas.AUDIT_TARGET_CLIENT,
+		TargetID:   client.ID.Hex(),
+		Changes: []schemas.AuditChange{
+			{Field: "contact.address", After: "Rua das Flores"},
+			{Field: "contact.email", Before: "maria.antiga@example.com", After: "maria@example.com"},
+			{Field: "contact.tiny_id", After: "123"},
+		},
+	}
+	other := update
+	other.TargetID = bson.NewObjectID().Hex()
+	repositories.AuditEvents.Insert(t.Context(), update)
+	repositories.AuditEvents.Insert(t.Context(), other)
+
+	result, err := Anonymize(t.Context(), repositories, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.AuditEvents != 1 {
+		t.Errorf("audit events = %d, want 1", result.AuditEvents)
+	}
+
+	events := repositories.AuditEvents.(*database.MemoryAuditEventsRepository).Events()
+	want := []schemas.AuditChange{
+		{Field: "contact.address", After: audit.REDACTED},
+		{Field: "contact.email", Before: audit.REDACTED, After: audit.REDACTED},
+		{Field: "contact.tiny_id", After: "123"},
+	}
+	if !reflect.DeepEqual(events[0].Changes, want) {
+		t.Errorf("changes = %+v, want %+v", events[0].Changes, want)
+	}
+	if !reflect.DeepEqual(events[1].Changes, other.Changes) {
+		t.Errorf("changes of another client = %+v, want untouched", events[1].Changes)
+	}
+}
+
+func TestAnonymizeRedactsSketchesInUniformAuditEvents(t *testing.T) {
+	repositories, client := setupClient(t)
+
+	uniforms, err := repositories.Uniforms.FindByClientIDs(t.Context(), []string{client.ID.Hex()})
+	if err != nil || len(uniforms) != 1 {
+		t.Fatalf("uniforms = %d, err = %v", len(uniforms), err)
+	}
+	sketches := []any{map[string]any{"id": "a", "players": []any{map[string]any{"name": "João", "observations": "goleiro"}}}}
+
+	create := schemas.AuditEvent{
+		Action:     schemas.AUDIT_ACTION_UNIFORM_CREATE,
+		TargetType: schemas.AUDIT_TARGET_UNIFORM,
+		TargetID:   uniforms[0].ID.Hex(),
+		Changes: []schemas.AuditChange{
+			{Field: "budget_id", After: 42},
+			{Field: "sketches", After: sketches},
+		},
+	}
+	update := schemas.AuditEvent{
+		Action:     schemas.AUDIT_ACTION_UNIFORM_UPDATE,
+		TargetType: schemas.AUDIT_TARGET_UNIFORM,
+		TargetID:   uniforms[0].ID.Hex(),
+		Changes:    []schemas.AuditChange{{Field: "sketches", Before: sketches, After: sketches}},
+	}
+	other := update
+	other.TargetID = bson.NewObjectID().Hex()
+	repositories.AuditEvents.Insert(t.Context(), create)
+	repositories.AuditEvents.Insert(t.Context(), update)
+	repositories.AuditEvents.Insert(t.Context(), other)
+
+	result, err := Anonymize(t.Context(), repositories, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.AuditEvents != 2 {
+		t.Errorf("audit events = %d, want 2", result.AuditEvents)
+	}
+
+	events := repositories.AuditEvents.(*database.MemoryAuditEventsRepository).Events()
+	want := []schemas.AuditChange{
+		{Field: "budget_id", After: 42},
+		{Field: "sketches", After: audit.REDACTED},
+	}
+	if !reflect.DeepEqual(events[0].Changes, want) {
+		t.Errorf("create changes = %+v, want %+v", events[0].Changes, want)
+	}
+	want = []schemas.AuditChange{{Field: "sketches", Before: audit.REDACTED, After: audit.REDACTED}}
+	if !reflect.DeepEqual(events[1].Changes, want) {
+		t.Errorf("update changes = %+v, want %+v", events[1].Changes, want)
+	}
+	if !reflect.DeepEqual(events[2].Changes, other.Changes) {
+		t.Errorf("changes of another uniform = %+v, want untouched", events[2].Changes)
+	}
+}
+
+func TestAnonymizeRedactsSessionAuditMetadata(t *testing.T) {
+	repositories, client := setupClient(t)
+
+	reuse := schemas.AuditEvent{
+		ActorType:  schemas.AUDIT_ACTOR_CLIENT,
+		ActorID:    client.ID.Hex(),
+		Action:     schemas.AUDIT_ACTION_REFRESH_TOKEN_REUSE,
+		TargetType: schemas.AUDIT_TARGET_SESSION,
+		TargetID:   bson.NewObjectID().Hex(),
+		Metadata: map[string]any{
+			"jti":                "abc",
+			"user_agent":         "Mozilla/5.0",
+			"session_user_agent": "Mozilla/5.0",
+			"session_ip":         "200.1.2.3",
+		},
+	}
+	other := reuse
+	other.ActorID = bson.NewObjectID().Hex()
+	other.Metadata = maps.Clone(reuse.Metadata)
+	repositories.AuditEvents.Insert(t.Context(), reuse)
+	repositories.AuditEvents.Insert(t.Context(), other)
+
+	result, err := Anonymize(t.Context(), repositories, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.AuditEvents != 1 {
+		t.Errorf("audit events = %d, want 1", result.AuditEvents)
+	}
+
+	events := repositories.AuditEvents.(*database.MemoryAuditEventsRepository).Events()
+	want := map[string]any{
+		"jti":                "abc",
+		"user_agent":         audit.REDACTED,
+		"session_user_agent": audit.REDACTED,
+		"session_ip":         audit.REDACTED,
+	}
+	if !reflect.DeepEqual(events[0].Metadata, want) {
+		t.Errorf("metadata = %+v, want %+v", events[0].Metadata, want)
+	}
+	if !reflect.DeepEqual(events[1].Metadata, other.Metadata) {
+		t.Errorf("metadata of another client = %+v, want untouched", events[1].Metadata)
+	}
+}
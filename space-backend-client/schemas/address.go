@@ -0,0 +1,11 @@
+package schemas
+
+// Address é o endereço devolvido pela consulta de CEP. Os nomes dos campos
+// seguem os do Contact para o preenchimento automático do cadastro.
+type Address struct {
+	ZipCode      string `json:"zip_code"`
+	Address      string `json:"address,omitempty"`
+	Neighborhood string `json:"neighborhood,omitempty"`
+	City         string `json:"city"`
+	State        string `json:"state"`
+}
@@ -1,12 +1,123 @@
 package schemas
 
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
 type AdminUniformCreateRequest struct {
-	ClientEmail string   `json:"client_email"`
-	BudgetID    int      `json:"budget_id"`
-	Sketches    []Sketch `json:"sketches"`
+	ClientEmail string   `json:"client_email" validate:"required,email"`
+	BudgetID    int      `json:"budget_id" validate:"required,gt=0"`
+	Sketches    []Sketch `json:"sketches" validate:"required"`
 }
 
 type UpdatePlayersDataRequest struct {
-	BudgetID int      `json:"budget_id"`
+	BudgetID int      `json:"budget_id" validate:"required,gt=0"`
 	Players  []Player `json:"players,omitempty"`
 }
+
+type AdminClientUnlockRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type AdminClientStatusRequest struct {
+	ID     string `json:"id" validate:"required"`
+	Status string `json:"status" validate:"required,oneof=active blocked"`
+	Reason string `json:"reason,omitempty" validate:"max=200"`
+}
+
+// Papéis da equipe interna. As permissões de cada um estão em
+// AdminRolePermissions.
+const (
+	ADMIN_ROLE_SUPPORT    = "support"
+	ADMIN_ROLE_PRODUCTION = "production"
+	ADMIN_ROLE_FINANCE    = "finance"
+	ADMIN_ROLE_SUPERADMIN = "superadmin"
+)
+
+// Permissões exigidas pelas rotas administrativas, no formato recurso:ação.
+const (
+	PERMISSION_UNIFORMS_READ  = "uniforms:read"
+	PERMISSION_UNIFORMS_WRITE = "uniforms:write"
+	PERMISSION_CLIENTS_READ   = "clients:read"
+	PERMISSION_CLIENTS_WRITE  = "clients:write"
+	// PERMISSION_CLIENTS_DELETE cobre exclusão, bloqueio e anonimização de
+	// contas. Fica fora de ApiKeyScopes: só pessoas podem executá-las.
+	PERMISSION_CLIENTS_DELETE     = "clients:delete"
+	PERMISSION_ADMIN_USERS_MANAGE = "admin_users:manage"
+	PERMISSION_API_KEYS_MANAGE    = "api_keys:manage"
+	PERMISSION_AUDIT_READ         = "audit:read"
+	PERMISSION_TINY_SYNC_MANAGE   = "tiny_sync:manage"
+	PERMISSION_ENCRYPTION_MANAGE  = "encryption:manage"
+)
+
+// ApiKeyScopes são as permissões que podem ser concedidas a chaves de API. A
+// gestão de usuários e de chaves, a auditoria e as operações destrutivas sobre
+// contas de clientes ficam restritas a pessoas.
+var ApiKeyScopes = []string{
+	PERMISSION_UNIFORMS_READ,
+	PERMISSION_UNIFORMS_WRITE,
+	PERMISSION_CLIENTS_READ,
+	PERMISSION_CLIENTS_WRITE,
+}
+
+var AdminRolePermissions = map[string][]string{
+	ADMIN_ROLE_SUPPORT:    {PERMISSION_CLIENTS_READ, PERMISSION_CLIENTS_WRITE, PERMISSION_CLIENTS_DELETE, PERMISSION_UNIFORMS_READ},
+	ADMIN_ROLE_PRODUCTION: {PERMISSION_UNIFORMS_READ, PERMISSION_UNIFORMS_WRITE, PERMISSION_CLIENTS_READ},
+	ADMIN_ROLE_FINANCE:    {PERMISSION_CLIENTS_READ, PERMISSION_UNIFORMS_READ},
+	ADMIN_ROLE_SUPERADMIN: {
+		PERMISSION_UNIFORMS_READ, PERMISSION_UNIFORMS_WRITE,
+		PERMISSION_CLIENTS_READ, PERMISSION_CLIENTS_WRITE, PERMISSION_CLIENTS_DELETE,
+		PERMISSION_ADMIN_USERS_MANAGE, PERMISSION_API_KEYS_MANAGE,
+		PERMISSION_AUDIT_READ, PERMISSION_TINY_SYNC_MANAGE,
+		PERMISSION_ENCRYPTION_MANAGE,
+	},
+}
+
+type AdminUser struct {
+	ID           bson.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name         string        `bson:"name" json:"name"`
+	Email        string        `bson:"email" json:"email"`
+	PasswordHash string        `bson:"password_hash" json:"-"`
+	Role         string        `bson:"role" json:"role"`
+	// Active desativa o acesso sem apagar o histórico de ações do usuário.
+	Active      bool       `bson:"active" json:"active"`
+	LastLoginAt *time.Time `bson:"last_login_at,omitempty" json:"last_login_at,omitempty"`
+	CreatedAt   time.Time  `bson:"created_at" json:"created_at"`
+	UpdatedAt   time.Time  `bson:"updated_at" json:"updated_at"`
+}
+
+type AdminSigninRequest struct {
+	Email    string `json:"email" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
+
+// A senha administrativa tem no mínimo 12 caracteres e no máximo 72, o limite
+// do bcrypt.
+type AdminUserCreateRequest struct {
+	Name     string `json:"name" validate:"required,name"`
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required,min=12,max=72"`
+	Role     string `json:"role" validate:"required,oneof=support production finance superadmin"`
+}
+
+// AdminUserUpdateRequest só altera os campos informados.
+type AdminUserUpdateRequest struct {
+	Role     *string `json:"role,omitempty" validate:"oneof=support production finance superadmin"`
+	Active   *bool   `json:"active,omitempty"`
+	Password *string `json:"password,omitempty" validate:"min=12,max=72"`
+}
+
+// AdminUserUpdate são as alterações aplicadas por AdminUsersRepository.Update;
+// campos nil não são alterados.
+type AdminUserUpdate struct {
+	Role         *string
+	Active       *bool
+	PasswordHash *string
+}
+
+type AdminUserResponse struct {
+	AdminUser
+	Permissions []string `json:"permissions"`
+}
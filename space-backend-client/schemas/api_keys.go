@@ -0,0 +1,38 @@
+package schemas
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// ApiKey é uma credencial de máquina (ERP, integrações internas) com escopos
+// próprios. Só o hash da chave é salvo; Prefix é a parte pública usada para
+// localizar a chave e identificá-la nas listagens e auditorias.
+type ApiKey struct {
+	ID         bson.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name       string        `bson:"name" json:"name"`
+	Prefix     string        `bson:"prefix" json:"prefix"`
+	KeyHash    string        `bson:"key_hash" json:"-"`
+	Scopes     []string      `bson:"scopes" json:"scopes"`
+	CreatedBy  string        `bson:"created_by" json:"created_by"`
+	CreatedAt  time.Time     `bson:"created_at" json:"created_at"`
+	ExpiresAt  *time.Time    `bson:"expires_at,omitempty" json:"expires_at,omitempty"`
+	LastUsedAt *time.Time    `bson:"last_used_at,omitempty" json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time    `bson:"revoked_at,omitempty" json:"revoked_at,omitempty"`
+	// RotatedTo aponta para a chave que substituiu esta em uma rotação.
+	RotatedTo *bson.ObjectID `bson:"rotated_to,omitempty" json:"rotated_to,omitempty"`
+}
+
+type ApiKeyCreateRequest struct {
+	Name   string   `json:"name" validate:"required,max=100"`
+	Scopes []string `json:"scopes" validate:"required"`
+	// ExpiresInDays zero cria uma chave sem expiração.
+	ExpiresInDays int `json:"expires_in_days,omitempty" validate:"min=0,max=3650"`
+}
+
+// ApiKeyCreateResponse é a única resposta que contém a chave completa.
+type ApiKeyCreateResponse struct {
+	ApiKey
+	Key string `json:"key"`
+}
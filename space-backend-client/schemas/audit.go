@@ -0,0 +1,94 @@
+package schemas
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+const (
+	AUDIT_ACTOR_CLIENT  = "client"
+	AUDIT_ACTOR_ADMIN   = "admin"
+	AUDIT_ACTOR_API_KEY = "api_key"
+	// AUDIT_ACTOR_MACHINE identifica credenciais de máquina sem cadastro,
+	// como o X-Admin-Key legado.
+	AUDIT_ACTOR_MACHINE = "machine"
+	AUDIT_ACTOR_SYSTEM  = "system"
+
+	AUDIT_ACTION_REFRESH_TOKEN_REUSE  = "auth.refresh_token_reuse"
+	AUDIT_ACTION_PASSWORD_RESET       = "auth.password_reset"
+	AUDIT_ACTION_TWO_FACTOR_ENABLE    = "auth.two_factor_enable"
+	AUDIT_ACTION_TWO_FACTOR_DISABLE   = "auth.two_factor_disable"
+	AUDIT_ACTION_CLIENT_SIGNUP        = "client.signup"
+	AUDIT_ACTION_CLIENT_UPDATE        = "client.update"
+	AUDIT_ACTION_CLIENT_BUDGET_ADD    = "client.budget_add"
+	AUDIT_ACTION_CLIENT_BUDGET_REMOVE = "client.budget_remove"
+	AUDIT_ACTION_CLIENT_STATUS        = "client.status"
+	AUDIT_ACTION_CLIENT_DELETE        = "client.delete"
+	AUDIT_ACTION_CLIENT_EXPORT        = "client.export"
+	AUDIT_ACTION_CLIENT_ANONYMIZE     = "client.anonymize"
+	AUDIT_ACTION_CLIENT_UNLOCK        = "client.unlock"
+	AUDIT_ACTION_UNIFORM_CREATE       = "uniform.create"
+	AUDIT_ACTION_UNIFORM_UPDATE       = "uniform.update"
+	AUDIT_ACTION_ADMIN_USER_CREATE    = "admin_user.create"
+	AUDIT_ACTION_ADMIN_USER_UPDATE    = "admin_user.update"
+	AUDIT_ACTION_API_KEY_CREATE       = "api_key.create"
+	AUDIT_ACTION_API_KEY_REVOKE       = "api_key.revoke"
+	AUDIT_ACTION_API_KEY_ROTATE       = "api_key.rotate"
+	AUDIT_ACTION_TINY_SYNC_REPLAY     = "tiny_sync.replay"
+	AUDIT_ACTION_TINY_RECONCILE       = "tiny_sync.reconcile"
+	AUDIT_ACTION_DATA_KEY_ROTATE      = "data_key.rotate"
+
+	AUDIT_TARGET_CLIENT              = "client"
+	AUDIT_TARGET_UNIFORM             = "uniform"
+	AUDIT_TARGET_SESSION             = "session"
+	AUDIT_TARGET_ADMIN_USER          = "admin_user"
+	AUDIT_TARGET_API_KEY             = "api_key"
+	AUDIT_TARGET_TINY_SYNC           = "tiny_sync_job"
+	AUDIT_TARGET_TINY_RECONCILIATION = "tiny_reconciliation"
+	AUDIT_TARGET_DATA_KEY            = "data_key"
+)
+
+// AuditChange é um campo alterado pela ação, com o caminho em notação de ponto
+// (ex.: "contact.cpf"). Valores sensíveis são gravados como "[redacted]".
+type AuditChange struct {
+	Field  string `bson:"field" json:"field"`
+	Before any    `bson:"before,omitempty" json:"before,omitempty"`
+	After  any    `bson:"after,omitempty" json:"after,omitempty"`
+}
+
+// AuditEvent é gravado na coleção "audit_events", que só recebe inserções.
+type AuditEvent struct {
+	ID         bson.ObjectID  `bson:"_id,omitempty" json:"id"`
+	ActorType  string         `bson:"actor_type" json:"actor_type"`
+	ActorID    string         `bson:"actor_id,omitempty" json:"actor_id,omitempty"`
+	Action     string         `bson:"action" json:"action"`
+	TargetType string         `bson:"target_type,omitempty" json:"target_type,omitempty"`
+	TargetID   string         `bson:"target_id,omitempty" json:"target_id,omitempty"`
+	Changes    []AuditChange  `bson:"changes,omitempty" json:"changes,omitempty"`
+	RequestID  string         `bson:"request_id,omitempty" json:"request_id,omitempty"`
+	IP         string         `bson:"ip,omitempty" json:"ip,omitempty"`
+	Metadata   map[string]any `bson:"metadata,omitempty" json:"metadata,omitempty"`
+	CreatedAt  time.Time      `bson:"created_at" json:"created_at"`
+}
+
+// AuditEventFilter são os filtros da consulta administrativa. Campos vazios
+// não filtram; BeforeID pagina a partir do último evento da página anterior.
+type AuditEventFilter struct {
+	ActorType  string
+	ActorID    string
+	Action     string
+	TargetType string
+	TargetID   string
+	From       time.Time
+	To         time.Time
+	BeforeID   bson.ObjectID
+	Limit      int64
+}
+
+// AuditEventsResponse é uma página da consulta. NextBefore é o cursor da
+// próxima página (parâmetro "before"), vazio quando não há mais eventos.
+type AuditEventsResponse struct {
+	Events     []AuditEvent `json:"events"`
+	NextBefore string       `json:"next_before,omitempty"`
+}
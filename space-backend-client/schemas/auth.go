@@ -1,11 +1,126 @@
 package schemas
 
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// PendingToken fica no documento do cliente enquanto há um reset de senha ou
+// uma verificação de email pendente. Só o hash do token é salvo; ele é
+// removido ao ser usado.
+type PendingToken struct {
+	TokenHash string    `bson:"token_hash"`
+	ExpiresAt time.Time `bson:"expires_at"`
+}
+
 type AuthTokenResponse struct {
 	AccessToken  string `json:"access_token"`
 	RefreshToken string `json:"refresh_token,omitempty"`
 }
 
+type AuthTokenRefreshRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
 type AuthValidationResponse struct {
 	UserID string `json:"user_id"`
 	Valid  bool   `json:"valid"`
 }
+
+type PasswordForgotRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type PasswordResetRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,password"`
+}
+
+type EmailVerifyRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type EmailVerifyResendRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// Session representa um dispositivo logado. Cada sessão tem o próprio refresh
+// token, rotacionado a cada uso; o hash anterior é mantido por uma janela
+// curta para que abas concorrentes não derrubem a sessão.
+type Session struct {
+	ID                bson.ObjectID `bson:"_id"`
+	ClientID          bson.ObjectID `bson:"client_id"`
+	UserAgent         string        `bson:"user_agent"`
+	IP                string        `bson:"ip"`
+	RefreshTokenHash  string        `bson:"refresh_token_hash"`
+	PreviousTokenHash string        `bson:"previous_token_hash,omitempty"`
+	RotatedAt         time.Time     `bson:"rotated_at,omitempty"`
+	CreatedAt         time.Time     `bson:"created_at"`
+	LastUsedAt        time.Time     `bson:"last_used_at"`
+	ExpiresAt         time.Time     `bson:"expires_at"`
+}
+
+type SessionResponse struct {
+	ID         string    `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	Current    bool      `json:"current"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+}
+
+// LoginAttempts conta as falhas de login recentes de um email ou IP. O
+// documento expira sozinho (TTL em expires_at) após a janela sem falhas.
+type LoginAttempts struct {
+	Key           string    `bson:"_id"`
+	Failures      int       `bson:"failures"`
+	LastFailureAt time.Time `bson:"last_failure_at"`
+	ExpiresAt     time.Time `bson:"expires_at"`
+}
+
+// TwoFactor guarda o TOTP do cliente. Enquanto Enabled for false o segredo é
+// só uma inscrição pendente, confirmada em /v1/auth/2fa/enable. Com o 2FA
+// ativo, o Signin grava um two_factor_challenge no cliente que só é trocado
+// por uma sessão em /v1/auth/signin/2fa.
+type TwoFactor struct {
+	Secret             string    `bson:"secret"`
+	Enabled            bool      `bson:"enabled"`
+	RecoveryCodeHashes []string  `bson:"recovery_code_hashes,omitempty"`
+	LastUsedStep       int64     `bson:"last_used_step,omitempty"`
+	EnabledAt          time.Time `bson:"enabled_at,omitempty"`
+}
+
+type TwoFactorSetupResponse struct {
+	Secret     string `json:"secret"`
+	OtpauthURI string `json:"otpauth_uri"`
+}
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+type TwoFactorEnableResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type TwoFactorChallengeResponse struct {
+	TwoFactorRequired bool      `json:"two_factor_required"`
+	ChallengeToken    string    `json:"challenge_token"`
+	ExpiresAt         time.Time `json:"expires_at"`
+}
+
+// SigninTwoFactorRequest aceita o código do app autenticador ou um dos
+// códigos de recuperação.
+type SigninTwoFactorRequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	Code           string `json:"code,omitempty"`
+	RecoveryCode   string `json:"recovery_code,omitempty"`
+}
+
+func (r SigninTwoFactorRequest) Validate() []FieldError {
+	if r.Code == "" && r.RecoveryCode == "" {
+		return []FieldError{{Field: "code", Message: "Informe o código ou um código de recuperação"}}
+	}
+	return nil
+}
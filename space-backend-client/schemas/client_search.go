@@ -0,0 +1,48 @@
+package schemas
+
+// Campos aceitos na ordenação da busca de clientes.
+const (
+	CLIENT_SORT_CREATED_AT = "created_at"
+	CLIENT_SORT_UPDATED_AT = "updated_at"
+	CLIENT_SORT_NAME       = "name"
+	CLIENT_SORT_EMAIL      = "email"
+)
+
+// ClientSearchFilter são os filtros da busca administrativa de clientes.
+// Filtros vazios são ignorados e os preenchidos se somam.
+type ClientSearchFilter struct {
+	// Query é o texto livre: email quando tem "@", CPF/CNPJ ou celular
+	// quando só tem dígitos e pontuação, e nome, email ou cidade nos demais
+	// casos
+	Query    string
+	Name     string
+	Email    string
+	Document string
+	Phone    string
+	City     string
+	State    string
+	// PersonType é "PF" ou "PJ"
+	PersonType    string
+	EmailVerified *bool
+	BudgetID      int
+
+	Sort       string
+	Descending bool
+	// After é a posição do último cliente da página anterior
+	After *ClientSearchCursor
+	Limit int64
+}
+
+// ClientSearchCursor é a posição de um cliente na ordenação: o valor do campo
+// ordenado (datas em RFC 3339) e o _id para desempate.
+type ClientSearchCursor struct {
+	Value string `json:"v"`
+	ID    string `json:"id"`
+}
+
+type ClientSearchResponse struct {
+	Clients []ClientResponse `json:"clients"`
+	// NextCursor é enviado em cursor para buscar a próxima página; vazio
+	// quando não há mais resultados
+	NextCursor string `json:"next_cursor,omitempty"`
+}
@@ -7,8 +7,8 @@ import (
 )
 
 type ClientLoginRequest struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email    string `json:"email" validate:"required"`
+	Password string `json:"password" validate:"required"`
 }
 
 type ClientRefreshTokenUpdate struct {
@@ -18,72 +18,100 @@ type ClientRefreshTokenUpdate struct {
 type ClientLogoutRequest struct {
 }
 
+// Situação da conta do cliente. Contas sem o campo são ativas; bloqueadas
+// não conseguem entrar nem renovar a sessão.
+const (
+	CLIENT_STATUS_ACTIVE  = "active"
+	CLIENT_STATUS_BLOCKED = "blocked"
+)
+
 type ClientFromDB struct {
-	ID           bson.ObjectID `bson:"_id"`
-	Contact      Contact       `bson:"contact,omitempty"`
-	PasswordHash string        `bson:"password_hash"`
-	RefreshToken string        `bson:"refresh_token,omitempty"`
-	BudgetIDs    []int         `bson:"budget_ids,omitempty"`
-	CreatedAt    time.Time     `bson:"created_at"`
-	UpdatedAt    time.Time     `bson:"updated_at"`
+	ID                 bson.ObjectID `bson:"_id"`
+	Contact            Contact       `bson:"contact,omitempty"`
+	PasswordHash       string        `bson:"password_hash"`
+	EmailVerified      bool          `bson:"email_verified"`
+	EmailVerification  *PendingToken `bson:"email_verification,omitempty"`
+	PasswordReset      *PendingToken `bson:"password_reset,omitempty"`
+	TwoFactor          *TwoFactor    `bson:"two_factor,omitempty"`
+	TwoFactorChallenge *PendingToken `bson:"two_factor_challenge,omitempty"`
+	BudgetIDs          []int         `bson:"budget_ids,omitempty"`
+	Status             string        `bson:"status,omitempty"`
+	// AnonymizedAt marca contas cujos dados pessoais foram apagados a pedido
+	// do titular (LGPD); o documento fica só pelos vínculos com os pedidos.
+	AnonymizedAt *time.Time `bson:"anonymized_at,omitempty"`
+	CreatedAt    time.Time  `bson:"created_at"`
+	UpdatedAt    time.Time  `bson:"updated_at"`
+}
+
+// Blocked indica se a conta foi bloqueada pela equipe interna.
+func (c ClientFromDB) Blocked() bool {
+	return c.Status == CLIENT_STATUS_BLOCKED
 }
 
 type ClientCreateRequest struct {
-	Name     string `json:"name" bson:"name"`
-	Email    string `json:"email" bson:"email"`
-	Password string `json:"password" bson:"-"`
+	Name     string `json:"name" bson:"name" validate:"required,name"`
+	Email    string `json:"email" bson:"email" validate:"required,email"`
+	Password string `json:"password" bson:"-" validate:"required,password"`
 }
 
 type ClientUpdateRequest struct {
-	Name                    string `json:"name,omitempty"`
-	Email                   string `json:"email,omitempty"`
-	Password                string `json:"password,omitempty"`
-	PersonType              string `json:"person_type,omitempty"`
-	IdentityCard            string `json:"identity_card,omitempty"`
-	CPF                     string `json:"cpf,omitempty"`
-	CellPhone               string `json:"cell_phone,omitempty"`
-	ZipCode                 string `json:"zip_code,omitempty"`
-	Address                 string `json:"address,omitempty"`
-	Number                  string `json:"number,omitempty"`
-	Complement              string `json:"complement,omitempty"`
-	Neighborhood            string `json:"neighborhood,omitempty"`
-	City                    string `json:"city,omitempty"`
-	State                   string `json:"state,omitempty"`
-	CompanyName             string `json:"company_name,omitempty"`
-	CNPJ                    string `json:"cnpj,omitempty"`
-	StateRegistration       string `json:"state_registration,omitempty"`
-	BillingZipCode          string `json:"billing_zip_code,omitempty"`
-	BillingAddress          string `json:"billing_address,omitempty"`
-	BillingNumber           string `json:"billing_number,omitempty"`
-	BillingComplement       string `json:"billing_complement,omitempty"`
-	BillingNeighborhood     string `json:"billing_neighborhood,omitempty"`
-	BillingCity             string `json:"billing_city,omitempty"`
-	BillingState            string `json:"billing_state,omitempty"`
+	Name                    string `json:"name,omitempty" validate:"name"`
+	Email                   string `json:"email,omitempty" validate:"email"`
+	Password                string `json:"password,omitempty" validate:"password"`
+	PersonType              string `json:"person_type,omitempty" validate:"oneof=PF PJ"`
+	IdentityCard            string `json:"identity_card,omitempty" validate:"max=20"`
+	CPF                     string `json:"cpf,omitempty" validate:"cpf"`
+	CellPhone               string `json:"cell_phone,omitempty" validate:"phone"`
+	ZipCode                 string `json:"zip_code,omitempty" validate:"cep"`
+	Address                 string `json:"address,omitempty" validate:"max=100"`
+	Number                  string `json:"number,omitempty" validate:"max=10"`
+	Complement              string `json:"complement,omitempty" validate:"max=100"`
+	Neighborhood            string `json:"neighborhood,omitempty" validate:"max=60"`
+	City                    string `json:"city,omitempty" validate:"max=60"`
+	State                   string `json:"state,omitempty" validate:"uf"`
+	CompanyName             string `json:"company_name,omitempty" validate:"max=100"`
+	CNPJ                    string `json:"cnpj,omitempty" validate:"cnpj"`
+	StateRegistration       string `json:"state_registration,omitempty" validate:"max=20"`
+	BillingZipCode          string `json:"billing_zip_code,omitempty" validate:"cep"`
+	BillingAddress          string `json:"billing_address,omitempty" validate:"max=100"`
+	BillingNumber           string `json:"billing_number,omitempty" validate:"max=10"`
+	BillingComplement       string `json:"billing_complement,omitempty" validate:"max=100"`
+	BillingNeighborhood     string `json:"billing_neighborhood,omitempty" validate:"max=60"`
+	BillingCity             string `json:"billing_city,omitempty" validate:"max=60"`
+	BillingState            string `json:"billing_state,omitempty" validate:"uf"`
 	DifferentBillingAddress bool   `json:"different_billing_address,omitempty"`
-	Status                  string `json:"status,omitempty"`
+	Status                  string `json:"status,omitempty" validate:"max=20"`
 }
 
 type ClientCreateModel struct {
-	Contact      Contact   `bson:"contact"`
-	PasswordHash string    `bson:"password_hash"`
-	CreatedAt    time.Time `bson:"created_at"`
-	UpdatedAt    time.Time `bson:"updated_at"`
+	Contact           Contact       `bson:"contact"`
+	PasswordHash      string        `bson:"password_hash"`
+	EmailVerified     bool          `bson:"email_verified"`
+	EmailVerification *PendingToken `bson:"email_verification,omitempty"`
+	CreatedAt         time.Time     `bson:"created_at"`
+	UpdatedAt         time.Time     `bson:"updated_at"`
 }
 
 type ClientResponse struct {
-	ID         string       `json:"id"`
-	Contact    Contact      `json:"contact"`
-	BudgetIDs  []int        `json:"budget_ids,omitempty"`
-	HasUniform map[int]bool `json:"has_uniform,omitempty"`
-	CreatedAt  time.Time    `json:"created_at"`
-	UpdatedAt  time.Time    `json:"updated_at"`
+	ID            string       `json:"id"`
+	Contact       Contact      `json:"contact"`
+	EmailVerified bool         `json:"email_verified"`
+	TwoFactor     bool         `json:"two_factor_enabled"`
+	BudgetIDs     []int        `json:"budget_ids,omitempty"`
+	HasUniform    map[int]bool `json:"has_uniform,omitempty"`
+	Status        string       `json:"status,omitempty"`
+	AnonymizedAt  *time.Time   `json:"anonymized_at,omitempty"`
+	CreatedAt     time.Time    `json:"created_at"`
+	UpdatedAt     time.Time    `json:"updated_at"`
 }
 
+// ClientAddBudgetRequest também é o corpo do DELETE em /v1/admin/clients,
+// que desassocia o orçamento.
 type ClientAddBudgetRequest struct {
-	Email    string `json:"email"`
-	BudgetID int    `json:"budget_id"`
+	Email    string `json:"email" validate:"required,email"`
+	BudgetID int    `json:"budget_id" validate:"required,gt=0"`
 }
 
 type ClientsByBudgetIDsRequest struct {
-	BudgetIDs []int `json:"budget_ids"`
+	BudgetIDs []int `json:"budget_ids" validate:"required,gt=0"`
 }
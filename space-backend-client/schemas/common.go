@@ -41,8 +41,17 @@ type Timestamps struct {
 }
 
 type ApiResponse struct {
-	Message string `json:"message,omitempty"`
-	Data    any    `json:"data,omitempty"`
+	Message string       `json:"message,omitempty"`
+	Data    any          `json:"data,omitempty"`
+	Errors  []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError é um erro de validação de um campo da requisição. Field usa os
+// nomes do JSON, com notação de ponto e índices para campos aninhados
+// (ex.: "updates[0].players[1].name").
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
 }
 
 type Config struct {
@@ -0,0 +1,16 @@
+package schemas
+
+import "time"
+
+// DataKey é uma chave de dados dos campos criptografados, guardada cifrada
+// pela chave mestra (FIELD_ENCRYPTION_KEY). A mais recente é a ativa; as
+// anteriores continuam na coleção para abrir os valores antigos.
+type DataKey struct {
+	ID         string    `bson:"_id" json:"id"`
+	WrappedKey []byte    `bson:"wrapped_key" json:"-"`
+	CreatedAt  time.Time `bson:"created_at" json:"created_at"`
+}
+
+type DataKeyRotateResponse struct {
+	KeyID string `json:"key_id"`
+}
@@ -0,0 +1,50 @@
+package schemas
+
+import (
+	"encoding/json"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Marcadores gravados no lugar dos dados pessoais apagados.
+const (
+	ANONYMIZED_NAME         = "Cliente anonimizado"
+	ANONYMIZED_EMAIL_DOMAIN = "anonimizado.invalid"
+	ANONYMIZED_VALUE        = "[anonimizado]"
+)
+
+// WhatsappEvent é um evento do webhook do WhatsApp como foi gravado, com o
+// payload bruto do provedor.
+type WhatsappEvent struct {
+	ID         bson.ObjectID `bson:"_id"`
+	RawEvent   bson.Raw      `bson:"raw_event"`
+	ReceivedAt time.Time     `bson:"received_at"`
+}
+
+type WhatsappEventExport struct {
+	RawEvent   json.RawMessage `json:"raw_event"`
+	ReceivedAt time.Time       `json:"received_at"`
+}
+
+// ClientExport reúne os dados pessoais do cliente para a portabilidade
+// (/v1/clients/me/export).
+type ClientExport struct {
+	GeneratedAt    time.Time             `json:"generated_at"`
+	Client         ClientResponse        `json:"client"`
+	Sessions       []SessionResponse     `json:"sessions"`
+	Uniforms       []UniformResponse     `json:"uniforms"`
+	WhatsappEvents []WhatsappEventExport `json:"whatsapp_events"`
+}
+
+type ClientErasureRequest struct {
+	Password string `json:"password" validate:"required"`
+}
+
+// AnonymizationResult conta o que foi anonimizado além do próprio cadastro.
+type AnonymizationResult struct {
+	Uniforms       int `json:"uniforms"`
+	Players        int `json:"players"`
+	WhatsappEvents int `json:"whatsapp_events"`
+	AuditEvents    int `json:"audit_events"`
+}
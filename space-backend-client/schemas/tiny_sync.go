@@ -0,0 +1,132 @@
+package schemas
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Estados de um job da fila de sincronização com o Tiny.
+const (
+	TINY_SYNC_PENDING    = "pending"
+	TINY_SYNC_PROCESSING = "processing"
+	TINY_SYNC_DONE       = "done"
+	// TINY_SYNC_DEAD indica que as tentativas se esgotaram; o job só volta a
+	// rodar por um replay manual.
+	TINY_SYNC_DEAD = "dead"
+	// TINY_SYNC_SUPERSEDED indica um job que falhou enquanto outro já estava
+	// pendente para o mesmo cliente; a falha é somada ao job pendente.
+	TINY_SYNC_SUPERSEDED = "superseded"
+)
+
+// Motivos de um cliente aparecer no relatório de vínculos com o Tiny.
+const (
+	// TINY_LINK_MISSING: cliente verificado ainda sem tiny_id.
+	TINY_LINK_MISSING = "missing"
+	// TINY_LINK_NOT_FOUND: o tiny_id não existe mais no Tiny.
+	TINY_LINK_NOT_FOUND = "not_found"
+	// TINY_LINK_CONFLICT: o contato do Tiny tem email ou documento diferente.
+	TINY_LINK_CONFLICT = "conflict"
+	// TINY_LINK_SHARED: outro cliente aponta para o mesmo tiny_id.
+	TINY_LINK_SHARED = "shared"
+)
+
+// Políticas da reconciliação para contatos que divergem entre o cadastro e o
+// Tiny.
+const (
+	// TINY_RECONCILE_OURS reenvia o contato do cadastro ao Tiny.
+	TINY_RECONCILE_OURS = "ours"
+	// TINY_RECONCILE_THEIRS grava no cadastro os valores do Tiny.
+	TINY_RECONCILE_THEIRS = "theirs"
+	// TINY_RECONCILE_REVIEW só registra a divergência.
+	TINY_RECONCILE_REVIEW = "review"
+)
+
+// Resultado de cada divergência encontrada na reconciliação. Em dry-run o
+// resultado é o que seria feito.
+const (
+	TINY_DRIFT_PUSHED  = "pushed"
+	TINY_DRIFT_PULLED  = "pulled"
+	TINY_DRIFT_FLAGGED = "flagged"
+	TINY_DRIFT_FAILED  = "failed"
+)
+
+// TINY_SYNC DATABASE MODELS
+
+// TinySyncJob pede que o contato do cliente seja enviado ao Tiny. O job não
+// guarda o payload: o worker lê o contato atual ao processar, então vários
+// pedidos seguidos resultam em um único envio com o estado mais recente.
+type TinySyncJob struct {
+	ID            bson.ObjectID `json:"id" bson:"_id,omitempty"`
+	ClientID      bson.ObjectID `json:"client_id" bson:"client_id"`
+	Status        string        `json:"status" bson:"status"`
+	Attempts      int           `json:"attempts" bson:"attempts"`
+	LastError     string        `json:"last_error,omitempty" bson:"last_error,omitempty"`
+	NextAttemptAt time.Time     `json:"next_attempt_at" bson:"next_attempt_at"`
+	// LockedUntil é o prazo do worker que pegou o job; depois dele o job pode
+	// ser retomado por outro worker.
+	LockedUntil *time.Time `json:"locked_until,omitempty" bson:"locked_until,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty" bson:"completed_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at" bson:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at" bson:"updated_at"`
+}
+
+// TinyReconciliation é o relatório de uma execução da reconciliação entre os
+// clientes e os contatos do Tiny.
+type TinyReconciliation struct {
+	ID     bson.ObjectID `json:"id" bson:"_id,omitempty"`
+	Policy string        `json:"policy" bson:"policy"`
+	DryRun bool          `json:"dry_run" bson:"dry_run"`
+	// Scanned conta os contatos do Tiny vinculados a algum cliente
+	Scanned    int         `json:"scanned" bson:"scanned"`
+	Drifts     []TinyDrift `json:"drifts" bson:"drifts"`
+	Error      string      `json:"error,omitempty" bson:"error,omitempty"`
+	StartedAt  time.Time   `json:"started_at" bson:"started_at"`
+	FinishedAt time.Time   `json:"finished_at" bson:"finished_at"`
+}
+
+// TinyDrift é um cliente cujo contato diverge do cadastrado no Tiny.
+type TinyDrift struct {
+	ClientID   bson.ObjectID    `json:"client_id" bson:"client_id"`
+	TinyID     string           `json:"tiny_id" bson:"tiny_id"`
+	Fields     []TinyDriftField `json:"fields" bson:"fields"`
+	Resolution string           `json:"resolution" bson:"resolution"`
+	Error      string           `json:"error,omitempty" bson:"error,omitempty"`
+}
+
+// TinyDriftField traz os dois valores de um campo divergente, no formato
+// enviado ao Tiny.
+type TinyDriftField struct {
+	Field  string `json:"field" bson:"field"`
+	Ours   string `json:"ours" bson:"ours"`
+	Theirs string `json:"theirs" bson:"theirs"`
+}
+
+// TINY_SYNC API REQUESTS/RESPONSES
+
+type TinySyncJobsResponse struct {
+	Jobs []TinySyncJob `json:"jobs"`
+}
+
+// TinyLinkIssue é um cliente cujo vínculo com o Tiny precisa de revisão.
+type TinyLinkIssue struct {
+	ClientID string `json:"client_id"`
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	TinyID   string `json:"tiny_id,omitempty"`
+	Reason   string `json:"reason"`
+	// Fields são os campos divergentes quando Reason é conflict
+	Fields []string `json:"fields,omitempty"`
+	// SharedWith são os outros clientes com o mesmo tiny_id
+	SharedWith []string `json:"shared_with,omitempty"`
+}
+
+type TinyLinksResponse struct {
+	Issues []TinyLinkIssue `json:"issues"`
+	// NextAfter é o cursor da próxima página; vazio quando não há mais clientes
+	NextAfter string `json:"next_after,omitempty"`
+}
+
+type TinyReconciliationsResponse struct {
+	Reconciliations []TinyReconciliation `json:"reconciliations"`
+}
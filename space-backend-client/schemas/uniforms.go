@@ -18,18 +18,18 @@ const (
 )
 
 type Player struct {
-	Gender       string `json:"gender" bson:"gender"`
-	Name         string `json:"name" bson:"name"`
-	ShirtSize    string `json:"shirt_size" bson:"shirt_size"`
-	Number       string `json:"number" bson:"number"`
-	ShortsSize   string `json:"shorts_size" bson:"shorts_size"`
+	Gender       string `json:"gender" bson:"gender" validate:"max=20"`
+	Name         string `json:"name" bson:"name" validate:"name"`
+	ShirtSize    string `json:"shirt_size" bson:"shirt_size" validate:"max=10"`
+	Number       string `json:"number" bson:"number" validate:"max=10"`
+	ShortsSize   string `json:"shorts_size" bson:"shorts_size" validate:"max=10"`
 	Ready        bool   `json:"ready" bson:"ready"`
-	Observations string `json:"observations,omitempty" bson:"observations,omitempty"`
+	Observations string `json:"observations,omitempty" bson:"observations,omitempty" validate:"max=500"`
 }
 
 type Sketch struct {
-	ID          string      `json:"id" bson:"id"`
-	PlayerCount int         `json:"player_count" bson:"player_count"`
+	ID          string      `json:"id" bson:"id" validate:"required"`
+	PlayerCount int         `json:"player_count" bson:"player_count" validate:"min=0"`
 	PackageType PackageType `json:"package_type" bson:"package_type"`
 	Players     []Player    `json:"players" bson:"players"`
 }
@@ -54,15 +54,15 @@ type UniformToDB struct {
 }
 
 type UniformCreateRequest struct {
-	ClientID string   `json:"client_id"`
-	BudgetID int      `json:"budget_id"`
-	Sketches []Sketch `json:"sketches"`
+	ClientID string   `json:"client_id" validate:"required"`
+	BudgetID int      `json:"budget_id" validate:"required,gt=0"`
+	Sketches []Sketch `json:"sketches" validate:"required"`
 	Editable bool     `json:"editable"`
 }
 
 type UniformUpdateRequest struct {
 	ClientID string   `json:"client_id,omitempty"`
-	BudgetID int      `json:"budget_id,omitempty"`
+	BudgetID int      `json:"budget_id,omitempty" validate:"min=0"`
 	Sketches []Sketch `json:"sketches,omitempty"`
 	Editable bool     `json:"editable,omitempty"`
 }
@@ -78,7 +78,7 @@ type UniformResponse struct {
 }
 
 type SketchPlayersUpdate struct {
-	SketchID string   `json:"sketch_id"`
+	SketchID string   `json:"sketch_id" validate:"required"`
 	Players  []Player `json:"players"`
 }
 
@@ -0,0 +1,247 @@
+// Package tiny é o cliente da API v2 do Tiny ERP. Todas as chamadas passam
+// por Client.call, que envia o token, respeita o limite de requisições por
+// minuto e converte o retorno do Tiny em erros tipados (ver APIError).
+package tiny
+
+import (
+	"api/utils"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DEFAULT_BASE_URL = "https://api.tiny.com.br/api2"
+	DEFAULT_TIMEOUT  = 10 * time.Second
+	// DEFAULT_RATE_LIMIT é o limite de requisições por minuto do plano mais
+	// simples do Tiny; TINY_RATE_LIMIT ajusta para o plano contratado.
+	DEFAULT_RATE_LIMIT = 30
+	// RATE_LIMIT_COOLDOWN é a pausa após o Tiny recusar uma chamada por
+	// excesso de requisições (o bloqueio dura até o fim do minuto).
+	RATE_LIMIT_COOLDOWN = time.Minute
+)
+
+type Client struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+
+	mu sync.Mutex
+	// interval é o espaço mínimo entre duas chamadas; zero desliga o limite
+	interval time.Duration
+	next     time.Time
+}
+
+// NewClient usa DEFAULT_BASE_URL quando baseURL está vazio e um http.Client
+// com DEFAULT_TIMEOUT quando httpClient é nil. requestsPerMinute zero não
+// limita as chamadas.
+func NewClient(baseURL string, token string, httpClient *http.Client, requestsPerMinute int) *Client {
+	if baseURL == "" {
+		baseURL = DEFAULT_BASE_URL
+	}
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: DEFAULT_TIMEOUT}
+	}
+
+	client := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		token:      token,
+		httpClient: httpClient,
+	}
+	if requestsPerMinute > 0 {
+		client.interval = time.Minute / time.Duration(requestsPerMinute)
+	}
+	return client
+}
+
+// NewFromEnv lê TINY_API_TOKEN e, opcionalmente, TINY_API_URL e
+// TINY_RATE_LIMIT (requisições por minuto).
+func NewFromEnv() *Client {
+	rateLimit := DEFAULT_RATE_LIMIT
+	if raw := os.Getenv(utils.TINY_RATE_LIMIT); raw != "" {
+		if parsed, err := strconv.Atoi(raw); err == nil && parsed >= 0 {
+			rateLimit = parsed
+		}
+	}
+	return NewClient(os.Getenv(utils.TINY_API_URL), os.Getenv(utils.TINY_API_TOKEN), nil, rateLimit)
+}
+
+// status é a parte comum de todo retorno do Tiny.
+type status struct {
+	Status     string     `json:"status"`
+	CodigoErro FlexString `json:"codigo_erro,omitempty"`
+	Erros      []message  `json:"erros,omitempty"`
+	// Nos métodos de escrita o erro de validação vem só no registro
+	Registros []record `json:"registros,omitempty"`
+}
+
+type message struct {
+	Erro string `json:"erro"`
+}
+
+func (s status) err() error {
+	if len(s.Erros) == 0 {
+		for _, item := range s.Registros {
+			if item.Registro.Status != "OK" && len(item.Registro.Erros) > 0 {
+				return status{CodigoErro: item.Registro.CodigoErro, Erros: item.Registro.Erros}.err()
+			}
+		}
+	}
+
+	code, _ := s.CodigoErro.Int()
+	apiErr := &APIError{Code: code}
+	for _, item := range s.Erros {
+		apiErr.Messages = append(apiErr.Messages, item.Erro)
+	}
+	return apiErr
+}
+
+// call executa o método (ex.: "contato.obter") e decodifica o conteúdo de
+// "retorno" em out.
+func (c *Client) call(ctx context.Context, method string, params url.Values, out any) error {
+	if c.token == "" {
+		return fmt.Errorf("%w: TINY_API_TOKEN não configurado", ErrInvalidToken)
+	}
+
+	if err := c.wait(ctx); err != nil {
+		return err
+	}
+
+	form := url.Values{}
+	for key, values := range params {
+		form[key] = values
+	}
+	form.Set("token", c.token)
+	form.Set("formato", "json")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/"+method+".php", strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("erro ao criar requisição para o Tiny: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("erro ao chamar API Tiny: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		c.pause(retryAfter(resp.Header.Get("Retry-After")))
+		return fmt.Errorf("%w: status %d", ErrRateLimited, resp.StatusCode)
+	}
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("%w: status %d", ErrUnavailable, resp.StatusCode)
+	}
+
+	var envelope struct {
+		Retorno json.RawMessage `json:"retorno"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil || envelope.Retorno == nil {
+		return fmt.Errorf("erro ao decodificar resposta da API Tiny (status %d): %v", resp.StatusCode, err)
+	}
+
+	var result status
+	if err := json.Unmarshal(envelope.Retorno, &result); err != nil {
+		return fmt.Errorf("erro ao decodificar resposta da API Tiny: %v", err)
+	}
+	if result.Status != "OK" {
+		err := result.err()
+		if err.(*APIError).Code == CODE_RATE_LIMITED {
+			c.pause(RATE_LIMIT_COOLDOWN)
+		}
+		return err
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(envelope.Retorno, out); err != nil {
+		return fmt.Errorf("erro ao decodificar resposta da API Tiny: %v", err)
+	}
+	return nil
+}
+
+// wait reserva o próximo horário livre para uma chamada e espera até ele.
+func (c *Client) wait(ctx context.Context) error {
+	c.mu.Lock()
+	at := c.next
+	if now := time.Now(); at.Before(now) {
+		at = now
+	}
+	if c.interval > 0 {
+		c.next = at.Add(c.interval)
+	}
+	c.mu.Unlock()
+
+	return sleep(ctx, time.Until(at))
+}
+
+// pause adia as próximas chamadas por d.
+func (c *Client) pause(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if until := time.Now().Add(d); until.After(c.next) {
+		c.next = until
+	}
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("%w: %v", ErrRateLimited, ctx.Err())
+	case <-timer.C:
+		return nil
+	}
+}
+
+func retryAfter(header string) time.Duration {
+	if seconds, err := strconv.Atoi(header); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return RATE_LIMIT_COOLDOWN
+}
+
+// FlexString aceita string ou número no JSON: o Tiny devolve IDs e códigos
+// ora como texto, ora como número, dependendo do método.
+type FlexString string
+
+func (s *FlexString) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*s = ""
+		return nil
+	}
+
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*s = FlexString(text)
+		return nil
+	}
+
+	var number json.Number
+	if err := json.Unmarshal(data, &number); err != nil {
+		return err
+	}
+	*s = FlexString(number.String())
+	return nil
+}
+
+func (s FlexString) Int() (int, error) {
+	return strconv.Atoi(string(s))
+}
@@ -0,0 +1,210 @@
+package tiny
+
+import (
+	"api/schemas"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// tinyStandIn responde como a API v2 do Tiny, guardando o último form recebido.
+type tinyStandIn struct {
+	server   *httptest.Server
+	lastForm map[string]string
+	calls    int
+}
+
+func newTinyStandIn(t *testing.T, responses map[string]string) *tinyStandIn {
+	t.Helper()
+
+	standIn := &tinyStandIn{}
+	standIn.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		standIn.calls++
+		r.ParseForm()
+		standIn.lastForm = map[string]string{}
+		for key := range r.PostForm {
+			standIn.lastForm[key] = r.PostForm.Get(key)
+		}
+
+		if r.PostForm.Get("token") != "token-teste" {
+			w.Write([]byte(`{"retorno":{"status":"Erro","codigo_erro":2,"erros":[{"erro":"token invalido"}]}}`))
+			return
+		}
+
+		method := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/"), ".php")
+		response, ok := responses[method]
+		if !ok {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(response))
+	}))
+	t.Cleanup(standIn.server.Close)
+
+	return standIn
+}
+
+func (s *tinyStandIn) client(token string) *Client {
+	return NewClient(s.server.URL, token, s.server.Client(), 0)
+}
+
+func TestCreateAndUpdateContact(t *testing.T) {
+	standIn := newTinyStandIn(t, map[string]string{
+		"contato.incluir": `{"retorno":{"status_processamento":3,"status":"OK","registros":[{"registro":{"sequencia":"1","status":"OK","id":46829062}}]}}`,
+		"contato.alterar": `{"retorno":{"status_processamento":3,"status":"OK","registros":[{"registro":{"sequencia":"1","status":"OK","id":"46829062"}}]}}`,
+	})
+	client := standIn.client("token-teste")
+
+	contact := schemas.Contact{Name: "Maria", Email: "maria@example.com", PersonType: "PF", CPF: "52998224725", ZipCode: "80010000"}
+
+	id, err := client.CreateContact(t.Context(), FromClient(contact, ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != "46829062" {
+		t.Errorf("id = %q, want 46829062", id)
+	}
+
+	if err := client.UpdateContact(t.Context(), FromClient(contact, id)); err != nil {
+		t.Fatal(err)
+	}
+	if standIn.lastForm["formato"] != "json" {
+		t.Errorf("formato = %q, want json", standIn.lastForm["formato"])
+	}
+
+	// A alteração leva o CPF e os campos vazios, para limpar o que foi removido
+	var payload struct {
+		Contatos []struct {
+			Contato map[string]any `json:"contato"`
+		} `json:"contatos"`
+	}
+	if err := json.Unmarshal([]byte(standIn.lastForm["contato"]), &payload); err != nil {
+		t.Fatal(err)
+	}
+	sent := payload.Contatos[0].Contato
+	if sent["id"] != "46829062" || sent["cpf_cnpj"] != "529.982.247-25" || sent["cep"] != "80010-000" {
+		t.Errorf("contato = %v", sent)
+	}
+	if value, ok := sent["complemento"]; !ok || value != "" {
+		t.Errorf("complemento = %v, want empty string", value)
+	}
+}
+
+func TestRecordErrorsAreTyped(t *testing.T) {
+	standIn := newTinyStandIn(t, map[string]string{
+		"contato.incluir": `{"retorno":{"status":"Erro","codigo_erro":"31","registros":[{"registro":{"sequencia":"1","status":"Erro","codigo_erro":"31","erros":[{"erro":"CPF invalido"}]}}]}}`,
+	})
+
+	_, err := standIn.client("token-teste").CreateContact(t.Context(), Contact{Nome: "Maria"})
+	if !errors.Is(err, ErrValidation) || Retryable(err) {
+		t.Fatalf("err = %v, want non-retryable ErrValidation", err)
+	}
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != CODE_VALIDATION || len(apiErr.Messages) != 1 {
+		t.Errorf("err = %#v", err)
+	}
+}
+
+func TestInvalidTokenAndUnavailable(t *testing.T) {
+	standIn := newTinyStandIn(t, map[string]string{})
+
+	_, err := standIn.client("outro").GetContact(t.Context(), "1")
+	if !errors.Is(err, ErrInvalidToken) || Retryable(err) {
+		t.Errorf("err = %v, want ErrInvalidToken", err)
+	}
+
+	_, err = standIn.client("").GetContact(t.Context(), "1")
+	if !errors.Is(err, ErrInvalidToken) || standIn.calls != 1 {
+		t.Errorf("err = %v, calls = %d, want ErrInvalidToken without calling Tiny", err, standIn.calls)
+	}
+
+	_, err = standIn.client("token-teste").GetContact(t.Context(), "1")
+	if !errors.Is(err, ErrUnavailable) || !Retryable(err) {
+		t.Errorf("err = %v, want retryable ErrUnavailable", err)
+	}
+}
+
+func TestGetAndSearchContacts(t *testing.T) {
+	standIn := newTinyStandIn(t, map[string]string{
+		"contato.obter":     `{"retorno":{"status":"OK","contato":{"id":"10","nome":"Maria","email":"maria@example.com","cpf_cnpj":"529.982.247-25"}}}`,
+		"contatos.pesquisa": `{"retorno":{"status":"OK","pagina":1,"numero_paginas":"3","contatos":[{"contato":{"id":"10","nome":"Maria"}},{"contato":{"id":11,"nome":"Mariana"}}]}}`,
+	})
+	client := standIn.client("token-teste")
+
+	contact, err := client.GetContact(t.Context(), "10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if contact.ID != "10" || contact.CpfCnpj != "529.982.247-25" {
+		t.Errorf("contact = %+v", contact)
+	}
+
+	result, err := client.SearchContacts(t.Context(), SearchQuery{Pesquisa: "maria"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Contacts) != 2 || result.Contacts[1].ID != "11" || result.Page != 1 || result.Pages != 3 {
+		t.Errorf("result = %+v", result)
+	}
+	if standIn.lastForm["pesquisa"] != "maria" || standIn.lastForm["pagina"] != "1" {
+		t.Errorf("form = %v", standIn.lastForm)
+	}
+}
+
+func TestSearchWithoutResultsIsEmpty(t *testing.T) {
+	standIn := newTinyStandIn(t, map[string]string{
+		"contatos.pesquisa": `{"retorno":{"status":"Erro","codigo_erro":20,"erros":[{"erro":"A consulta nao retornou registros"}]}}`,
+	})
+
+	result, err := standIn.client("token-teste").SearchContacts(t.Context(), SearchQuery{CpfCnpj: "529.982.247-25", Page: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Contacts) != 0 || result.Page != 2 {
+		t.Errorf("result = %+v, want empty page 2", result)
+	}
+}
+
+func TestRateLimitPausesNextCalls(t *testing.T) {
+	standIn := newTinyStandIn(t, map[string]string{
+		"contato.obter": `{"retorno":{"status":"Erro","codigo_erro":6,"erros":[{"erro":"API bloqueada momentaneamente"}]}}`,
+	})
+	client := standIn.client("token-teste")
+
+	_, err := client.GetContact(t.Context(), "1")
+	if !errors.Is(err, ErrRateLimited) || !Retryable(err) {
+		t.Fatalf("err = %v, want retryable ErrRateLimited", err)
+	}
+	if until := time.Until(client.next); until < RATE_LIMIT_COOLDOWN-time.Second {
+		t.Errorf("next call in %v, want about %v", until, RATE_LIMIT_COOLDOWN)
+	}
+
+	// Durante a pausa a chamada espera; com o contexto encerrado ela desiste
+	// sem chegar ao Tiny
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := client.GetContact(ctx, "1"); !errors.Is(err, ErrRateLimited) || standIn.calls != 1 {
+		t.Errorf("err = %v, calls = %d, want ErrRateLimited without a second call", err, standIn.calls)
+	}
+}
+
+func TestClientSpacesRequests(t *testing.T) {
+	client := NewClient("http://tiny.invalid", "token", nil, 600)
+
+	start := time.Now()
+	for range 3 {
+		if err := client.wait(t.Context()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 600 por minuto = uma a cada 100ms; a primeira sai na hora
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Errorf("3 calls took %v, want at least 200ms", elapsed)
+	}
+}
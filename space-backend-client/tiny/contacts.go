@@ -0,0 +1,216 @@
+package tiny
+
+import (
+	"api/documents"
+	"api/schemas"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// Contact é o contato no formato da API do Tiny. Os campos de endereço,
+// contato e cobrança não usam omitempty: um campo limpo no cadastro precisa
+// chegar vazio ao Tiny para ser limpo lá também.
+type Contact struct {
+	Sequencia           int        `json:"sequencia,omitempty"`
+	ID                  FlexString `json:"id,omitempty"`
+	Codigo              string     `json:"codigo,omitempty"`
+	Nome                string     `json:"nome"`
+	Situacao            string     `json:"situacao,omitempty"`
+	Fantasia            string     `json:"fantasia,omitempty"`
+	TipoPessoa          string     `json:"tipo_pessoa,omitempty"`
+	CpfCnpj             string     `json:"cpf_cnpj,omitempty"`
+	Ie                  string     `json:"ie,omitempty"`
+	Rg                  string     `json:"rg,omitempty"`
+	Im                  string     `json:"im,omitempty"`
+	Endereco            string     `json:"endereco"`
+	Numero              string     `json:"numero"`
+	Complemento         string     `json:"complemento"`
+	Bairro              string     `json:"bairro"`
+	Cep                 string     `json:"cep"`
+	Cidade              string     `json:"cidade"`
+	Uf                  string     `json:"uf"`
+	Pais                string     `json:"pais,omitempty"`
+	Contatos            string     `json:"contatos,omitempty"`
+	Fone                string     `json:"fone,omitempty"`
+	Fax                 string     `json:"fax,omitempty"`
+	Celular             string     `json:"celular"`
+	Email               string     `json:"email"`
+	EmailNfe            string     `json:"email_nfe,omitempty"`
+	Site                string     `json:"site,omitempty"`
+	Contribuinte        string     `json:"contribuinte,omitempty"`
+	EnderecoCobranca    string     `json:"endereco_cobranca"`
+	NumeroCobranca      string     `json:"numero_cobranca"`
+	ComplementoCobranca string     `json:"complemento_cobranca"`
+	BairroCobranca      string     `json:"bairro_cobranca"`
+	CepCobranca         string     `json:"cep_cobranca"`
+	CidadeCobranca      string     `json:"cidade_cobranca"`
+	UfCobranca          string     `json:"uf_cobranca"`
+	Obs                 string     `json:"obs,omitempty"`
+}
+
+// FromClient é o único mapeamento do Contact do cadastro para o Tiny, usado
+// na inclusão (tinyID vazio) e na alteração. Sem endereço de cobrança
+// diferente, os campos de cobrança vão vazios.
+func FromClient(contact schemas.Contact, tinyID string) Contact {
+	tinyContact := Contact{
+		ID:          FlexString(tinyID),
+		Nome:        contact.Name,
+		Situacao:    "A",
+		Email:       contact.Email,
+		Celular:     documents.FormatPhone(contact.CellPhone),
+		Cep:         documents.FormatCEP(contact.ZipCode),
+		Endereco:    contact.Address,
+		Numero:      contact.Number,
+		Complemento: contact.Complement,
+		Bairro:      contact.Neighborhood,
+		Cidade:      contact.City,
+		Uf:          contact.State,
+	}
+
+	if contact.PersonType == "PJ" {
+		tinyContact.TipoPessoa = "J"
+		tinyContact.CpfCnpj = documents.FormatCNPJ(contact.CNPJ)
+		tinyContact.Ie = contact.StateRegistration
+	} else if contact.PersonType != "" {
+		tinyContact.TipoPessoa = "F"
+		tinyContact.CpfCnpj = documents.FormatCPF(contact.CPF)
+		tinyContact.Rg = contact.IdentityCard
+	}
+
+	if contact.DifferentBillingAddress {
+		tinyContact.CepCobranca = documents.FormatCEP(contact.BillingZipCode)
+		tinyContact.EnderecoCobranca = contact.BillingAddress
+		tinyContact.NumeroCobranca = contact.BillingNumber
+		tinyContact.ComplementoCobranca = contact.BillingComplement
+		tinyContact.BairroCobranca = contact.BillingNeighborhood
+		tinyContact.CidadeCobranca = contact.BillingCity
+		tinyContact.UfCobranca = contact.BillingState
+	}
+
+	return tinyContact
+}
+
+type contactWrapper struct {
+	Contato Contact `json:"contato"`
+}
+
+// record é o resultado de cada contato enviado em contato.incluir e
+// contato.alterar.
+type record struct {
+	Registro struct {
+		Sequencia  FlexString `json:"sequencia"`
+		Status     string     `json:"status"`
+		ID         FlexString `json:"id,omitempty"`
+		CodigoErro FlexString `json:"codigo_erro,omitempty"`
+		Erros      []message  `json:"erros,omitempty"`
+	} `json:"registro"`
+}
+
+// CreateContact cadastra o contato (contato.incluir) e retorna o ID gerado.
+func (c *Client) CreateContact(ctx context.Context, contact Contact) (string, error) {
+	contact.ID = ""
+	registro, err := c.writeContact(ctx, "contato.incluir", contact)
+	if err != nil {
+		return "", err
+	}
+	if registro.Registro.ID == "" {
+		return "", fmt.Errorf("tiny: contato incluído sem ID no retorno")
+	}
+	return string(registro.Registro.ID), nil
+}
+
+// UpdateContact altera o contato com contact.ID (contato.alterar).
+func (c *Client) UpdateContact(ctx context.Context, contact Contact) error {
+	if contact.ID == "" {
+		return fmt.Errorf("tiny: contato sem ID para alteração")
+	}
+	_, err := c.writeContact(ctx, "contato.alterar", contact)
+	return err
+}
+
+func (c *Client) writeContact(ctx context.Context, method string, contact Contact) (record, error) {
+	contact.Sequencia = 1
+	payload, err := json.Marshal(struct {
+		Contatos []contactWrapper `json:"contatos"`
+	}{Contatos: []contactWrapper{{Contato: contact}}})
+	if err != nil {
+		return record{}, fmt.Errorf("erro ao serializar contato para JSON: %v", err)
+	}
+
+	var result struct {
+		Registros []record `json:"registros"`
+	}
+	if err := c.call(ctx, method, url.Values{"contato": {string(payload)}}, &result); err != nil {
+		return record{}, err
+	}
+	if len(result.Registros) == 0 {
+		return record{}, fmt.Errorf("tiny: %s sem registros no retorno", method)
+	}
+
+	registro := result.Registros[0]
+	if registro.Registro.Status != "OK" {
+		return record{}, status{CodigoErro: registro.Registro.CodigoErro, Erros: registro.Registro.Erros}.err()
+	}
+	return registro, nil
+}
+
+// GetContact busca o contato pelo ID (contato.obter). Retorna um erro
+// correspondente a ErrNotFound se ele não existir.
+func (c *Client) GetContact(ctx context.Context, id string) (Contact, error) {
+	var result struct {
+		Contato Contact `json:"contato"`
+	}
+	if err := c.call(ctx, "contato.obter", url.Values{"id": {id}}, &result); err != nil {
+		return Contact{}, err
+	}
+	return result.Contato, nil
+}
+
+// SearchQuery são os filtros de contatos.pesquisa. Pesquisa busca por nome,
+// código ou email; CpfCnpj deve vir formatado como no cadastro do Tiny.
+type SearchQuery struct {
+	Pesquisa string
+	CpfCnpj  string
+	// Page começa em 1; zero equivale à primeira página
+	Page int
+}
+
+type SearchResult struct {
+	Contacts []Contact
+	Page     int
+	Pages    int
+}
+
+// SearchContacts pesquisa contatos (contatos.pesquisa). Uma pesquisa sem
+// resultados retorna uma lista vazia, não um erro.
+func (c *Client) SearchContacts(ctx context.Context, query SearchQuery) (SearchResult, error) {
+	page := max(query.Page, 1)
+	// pesquisa é obrigatório na API; vazio lista todos os contatos
+	params := url.Values{"pagina": {strconv.Itoa(page)}, "pesquisa": {query.Pesquisa}}
+	if query.CpfCnpj != "" {
+		params.Set("cpf_cnpj", query.CpfCnpj)
+	}
+
+	var result struct {
+		Pagina        FlexString       `json:"pagina"`
+		NumeroPaginas FlexString       `json:"numero_paginas"`
+		Contatos      []contactWrapper `json:"contatos"`
+	}
+	err := c.call(ctx, "contatos.pesquisa", params, &result)
+	if err != nil {
+		if apiErr, ok := err.(*APIError); ok && apiErr.Code == CODE_NO_RECORDS {
+			return SearchResult{Contacts: []Contact{}, Page: page}, nil
+		}
+		return SearchResult{}, err
+	}
+
+	search := SearchResult{Contacts: make([]Contact, 0, len(result.Contatos)), Page: page}
+	search.Pages, _ = result.NumeroPaginas.Int()
+	for _, item := range result.Contatos {
+		search.Contacts = append(search.Contacts, item.Contato)
+	}
+	return search, nil
+}
@@ -0,0 +1,75 @@
+package tiny
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Códigos de erro da API v2 do Tiny (campo codigo_erro).
+const (
+	CODE_TOKEN_MISSING    = 1
+	CODE_TOKEN_INVALID    = 2
+	CODE_API_BLOCKED      = 5
+	CODE_RATE_LIMITED     = 6
+	CODE_NO_RECORDS       = 20
+	CODE_DUPLICATE        = 30
+	CODE_VALIDATION       = 31
+	CODE_NOT_FOUND        = 32
+	CODE_FOUND_DUPLICATED = 33
+	CODE_UNEXPECTED       = 35
+	CODE_MAINTENANCE      = 99
+)
+
+// Erros para comparar com errors.Is. Os APIError devolvidos pelo Client
+// correspondem a eles pelo código.
+var (
+	ErrInvalidToken = errors.New("tiny: token ausente ou inválido")
+	ErrRateLimited  = errors.New("tiny: limite de requisições excedido")
+	ErrNotFound     = errors.New("tiny: registro não encontrado")
+	ErrDuplicate    = errors.New("tiny: registro em duplicidade")
+	ErrValidation   = errors.New("tiny: dados rejeitados na validação")
+	ErrUnavailable  = errors.New("tiny: serviço indisponível")
+)
+
+// APIError é um erro devolvido pelo Tiny no retorno da chamada.
+type APIError struct {
+	Code     int
+	Messages []string
+}
+
+func (e *APIError) Error() string {
+	if len(e.Messages) == 0 {
+		return fmt.Sprintf("tiny: erro %d", e.Code)
+	}
+	return fmt.Sprintf("tiny: erro %d: %s", e.Code, strings.Join(e.Messages, "; "))
+}
+
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrInvalidToken:
+		return e.Code == CODE_TOKEN_MISSING || e.Code == CODE_TOKEN_INVALID
+	case ErrRateLimited:
+		return e.Code == CODE_RATE_LIMITED
+	case ErrNotFound:
+		return e.Code == CODE_NO_RECORDS || e.Code == CODE_NOT_FOUND
+	case ErrDuplicate:
+		return e.Code == CODE_DUPLICATE || e.Code == CODE_FOUND_DUPLICATED
+	case ErrValidation:
+		return e.Code == CODE_VALIDATION
+	case ErrUnavailable:
+		return e.Code == CODE_UNEXPECTED || e.Code == CODE_MAINTENANCE
+	}
+	return false
+}
+
+// Retryable indica se vale tentar de novo a mesma chamada mais tarde: falhas
+// de rede, limite de requisições e indisponibilidade do Tiny. Token inválido
+// e erros de validação continuam falhando até alguém corrigir os dados.
+func Retryable(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return !errors.Is(err, ErrInvalidToken)
+	}
+	return errors.Is(err, ErrRateLimited) || errors.Is(err, ErrUnavailable) || apiErr.Code == CODE_API_BLOCKED
+}
@@ -0,0 +1,170 @@
+package tinysync
+
+import (
+	"api/database"
+	"api/documents"
+	"api/schemas"
+	"api/tiny"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+var (
+	// ErrAmbiguousMatch indica que mais de um contato do Tiny corresponde ao
+	// cliente; criar outro só aumentaria a duplicidade.
+	ErrAmbiguousMatch = errors.New("mais de um contato do Tiny corresponde ao cliente")
+	// ErrLinkedToAnotherClient indica que o contato encontrado no Tiny já está
+	// vinculado a outro cliente.
+	ErrLinkedToAnotherClient = errors.New("o contato do Tiny já está vinculado a outro cliente")
+)
+
+// needsReview diz se a falha depende de uma correção manual: repetir o envio
+// daria o mesmo resultado.
+func needsReview(err error) bool {
+	return errors.Is(err, tiny.ErrValidation) || errors.Is(err, ErrAmbiguousMatch) || errors.Is(err, ErrLinkedToAnotherClient)
+}
+
+// document retorna só os dígitos do CPF ou CNPJ do cliente, conforme o tipo
+// de pessoa.
+func document(contact schemas.Contact) string {
+	if contact.PersonType == "PJ" {
+		return documents.Digits(contact.CNPJ)
+	}
+	return documents.Digits(contact.CPF)
+}
+
+// Match escolhe, entre os contatos encontrados no Tiny, o que corresponde ao
+// cliente: mesmo CPF/CNPJ ou, quando um dos lados não tem documento, mesmo
+// email. Contatos excluídos e com documento diferente são ignorados. Retorna
+// "" sem correspondência e ErrAmbiguousMatch com mais de uma.
+func Match(contact schemas.Contact, candidates []tiny.Contact) (string, error) {
+	ownDocument := document(contact)
+
+	var matched []string
+	for _, candidate := range candidates {
+		if candidate.Situacao == "E" || candidate.ID == "" {
+			continue
+		}
+
+		candidateDocument := documents.Digits(candidate.CpfCnpj)
+		switch {
+		case ownDocument != "" && candidateDocument != "":
+			if ownDocument != candidateDocument {
+				continue
+			}
+		case contact.Email == "" || !strings.EqualFold(contact.Email, strings.TrimSpace(candidate.Email)):
+			continue
+		}
+
+		if id := string(candidate.ID); !slices.Contains(matched, id) {
+			matched = append(matched, id)
+		}
+	}
+
+	switch len(matched) {
+	case 0:
+		return "", nil
+	case 1:
+		return matched[0], nil
+	default:
+		return "", fmt.Errorf("%w: %s", ErrAmbiguousMatch, strings.Join(matched, ", "))
+	}
+}
+
+// Conflicts lista os campos do cliente (chaves JSON) que divergem do contato
+// vinculado no Tiny. Campos vazios em um dos lados não contam como conflito.
+func Conflicts(contact schemas.Contact, tinyContact tiny.Contact) []string {
+	var fields []string
+
+	tinyEmail := strings.TrimSpace(tinyContact.Email)
+	if contact.Email != "" && tinyEmail != "" && !strings.EqualFold(contact.Email, tinyEmail) {
+		fields = append(fields, "email")
+	}
+
+	ownDocument := document(contact)
+	tinyDocument := documents.Digits(tinyContact.CpfCnpj)
+	if ownDocument != "" && tinyDocument != "" && ownDocument != tinyDocument {
+		if contact.PersonType == "PJ" {
+			fields = append(fields, "cnpj")
+		} else {
+			fields = append(fields, "cpf")
+		}
+	}
+
+	return fields
+}
+
+// link procura o contato do cliente no Tiny antes de criar um novo. Retorna o
+// ID encontrado ou "" quando o contato precisa ser criado.
+func link(ctx context.Context, repositories *database.Repositories, syncer Syncer, client schemas.ClientFromDB) (string, error) {
+	candidates, err := syncer.Find(ctx, client.Contact)
+	if err != nil {
+		return "", err
+	}
+
+	tinyID, err := Match(client.Contact, candidates)
+	if err != nil || tinyID == "" {
+		return "", err
+	}
+
+	linked, err := repositories.Clients.FindByTinyID(ctx, tinyID)
+	if err != nil {
+		return "", fmt.Errorf("erro ao buscar clientes vinculados: %v", err)
+	}
+	for _, other := range linked {
+		if other.ID != client.ID {
+			return "", fmt.Errorf("%w: contato %s, cliente %s", ErrLinkedToAnotherClient, tinyID, other.ID.Hex())
+		}
+	}
+
+	return tinyID, nil
+}
+
+// InspectLink confere o vínculo de um cliente verificado com o Tiny e retorna
+// o problema encontrado, ou nil se o vínculo está correto. Erros do Tiny que
+// não sejam "não encontrado" interrompem a conferência.
+func InspectLink(ctx context.Context, repositories *database.Repositories, syncer Syncer, client schemas.ClientFromDB) (*schemas.TinyLinkIssue, error) {
+	issue := &schemas.TinyLinkIssue{
+		ClientID: client.ID.Hex(),
+		Name:     client.Contact.Name,
+		Email:    client.Contact.Email,
+		TinyID:   client.Contact.TinyID,
+	}
+
+	if client.Contact.TinyID == "" {
+		issue.Reason = schemas.TINY_LINK_MISSING
+		return issue, nil
+	}
+
+	linked, err := repositories.Clients.FindByTinyID(ctx, client.Contact.TinyID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar clientes vinculados: %v", err)
+	}
+	for _, other := range linked {
+		if other.ID != client.ID {
+			issue.SharedWith = append(issue.SharedWith, other.ID.Hex())
+		}
+	}
+	if len(issue.SharedWith) > 0 {
+		issue.Reason = schemas.TINY_LINK_SHARED
+		return issue, nil
+	}
+
+	tinyContact, err := syncer.Get(ctx, client.Contact.TinyID)
+	if errors.Is(err, tiny.ErrNotFound) {
+		issue.Reason = schemas.TINY_LINK_NOT_FOUND
+		return issue, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if issue.Fields = Conflicts(client.Contact, tinyContact); len(issue.Fields) > 0 {
+		issue.Reason = schemas.TINY_LINK_CONFLICT
+		return issue, nil
+	}
+	return nil, nil
+}
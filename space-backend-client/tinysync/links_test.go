@@ -0,0 +1,138 @@
+package tinysync
+
+import (
+	"api/database"
+	"api/schemas"
+	"api/tiny"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	contact := schemas.Contact{Email: "ana@example.com", PersonType: "PF", CPF: "52998224725"}
+
+	tests := []struct {
+		name       string
+		candidates []tiny.Contact
+		want       string
+		wantErr    error
+	}{
+		{"none", nil, "", nil},
+		{"same document", []tiny.Contact{{ID: "1", CpfCnpj: "529.982.247-25", Email: "outro@example.com"}}, "1", nil},
+		{"same email without document", []tiny.Contact{{ID: "2", Email: " ANA@example.com"}}, "2", nil},
+		{"same email, other document", []tiny.Contact{{ID: "3", Email: "ana@example.com", CpfCnpj: "111.444.777-35"}}, "", nil},
+		{"deleted", []tiny.Contact{{ID: "4", CpfCnpj: "52998224725", Situacao: "E"}}, "", nil},
+		{"found by both searches", []tiny.Contact{{ID: "5", CpfCnpj: "52998224725"}, {ID: "5", Email: "ana@example.com"}}, "5", nil},
+		{"ambiguous", []tiny.Contact{{ID: "6", CpfCnpj: "52998224725"}, {ID: "7", Email: "ana@example.com"}}, "", ErrAmbiguousMatch},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := Match(contact, test.candidates)
+			if got != test.want || !errors.Is(err, test.wantErr) {
+				t.Errorf("Match() = %q, %v, want %q, %v", got, err, test.want, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestWorkerLinksExistingTinyContact(t *testing.T) {
+	repositories, syncer, worker, client := setupWorker(t, schemas.ClientFromDB{
+		Contact:       schemas.Contact{Name: "Ana", Email: "ana@example.com", PersonType: "PF", CPF: "52998224725"},
+		EmailVerified: true,
+	})
+	syncer.existing = []tiny.Contact{{ID: "42", Nome: "Ana Souza", CpfCnpj: "529.982.247-25"}}
+
+	repositories.TinySyncJobs.Enqueue(t.Context(), client.ID)
+	worker.ProcessNext(t.Context())
+
+	if len(syncer.created) != 0 || len(syncer.updated) != 0 {
+		t.Errorf("created = %d, updated = %v, want the existing contact only linked", len(syncer.created), syncer.updated)
+	}
+	client, _ = repositories.Clients.FindByID(t.Context(), client.ID)
+	if client.Contact.TinyID != "42" {
+		t.Errorf("tiny_id = %q, want 42", client.Contact.TinyID)
+	}
+}
+
+func TestWorkerDoesNotLinkAmbiguousContacts(t *testing.T) {
+	repositories, syncer, worker, client := setupWorker(t, schemas.ClientFromDB{
+		Contact:       schemas.Contact{Name: "Ana", Email: "ana@example.com"},
+		EmailVerified: true,
+	})
+	syncer.existing = []tiny.Contact{{ID: "42", Email: "ana@example.com"}, {ID: "43", Email: "ana@example.com"}}
+	repositories.Clients.(*database.MemoryClientsRepository).Insert(schemas.ClientFromDB{
+		Contact: schemas.Contact{Email: "outra@example.com", TinyID: "50"},
+	})
+
+	repositories.TinySyncJobs.Enqueue(t.Context(), client.ID)
+	worker.ProcessNext(t.Context())
+
+	// Contato já vinculado a outro cliente também vai para revisão
+	syncer.existing = []tiny.Contact{{ID: "50", Email: "ana.souza@example.com"}}
+	other, _ := setupClient(t, repositories, schemas.ClientFromDB{
+		Contact:       schemas.Contact{Name: "Ana", Email: "ana.souza@example.com"},
+		EmailVerified: true,
+	})
+	repositories.TinySyncJobs.Enqueue(t.Context(), other.ID)
+	worker.ProcessNext(t.Context())
+
+	jobs, _ := repositories.TinySyncJobs.List(t.Context(), schemas.TINY_SYNC_DEAD, 0)
+	if len(jobs) != 2 || len(syncer.created) != 0 {
+		t.Fatalf("dead jobs = %+v, created = %d, want both jobs dead and nothing created", jobs, len(syncer.created))
+	}
+}
+
+func TestInspectLink(t *testing.T) {
+	repositories := database.NewMemoryRepositories()
+	syncer := &fakeSyncer{existing: []tiny.Contact{
+		{ID: "1", Email: "ana@example.com", CpfCnpj: "529.982.247-25"},
+		{ID: "2", Email: "bia@outro.com", CpfCnpj: "529.982.247-25"},
+	}}
+
+	tests := []struct {
+		name   string
+		client schemas.ClientFromDB
+		want   string
+		fields []string
+	}{
+		{"linked", schemas.ClientFromDB{Contact: schemas.Contact{Email: "ana@example.com", PersonType: "PF", CPF: "52998224725", TinyID: "1"}}, "", nil},
+		{"missing", schemas.ClientFromDB{Contact: schemas.Contact{Email: "caio@example.com"}}, schemas.TINY_LINK_MISSING, nil},
+		{"not found", schemas.ClientFromDB{Contact: schemas.Contact{Email: "rui@example.com", TinyID: "9"}}, schemas.TINY_LINK_NOT_FOUND, nil},
+		{"conflict", schemas.ClientFromDB{Contact: schemas.Contact{Email: "bia@example.com", PersonType: "PF", CPF: "11144477735", TinyID: "2"}}, schemas.TINY_LINK_CONFLICT, []string{"email", "cpf"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client, _ := setupClient(t, repositories, test.client)
+			issue, err := InspectLink(t.Context(), repositories, syncer, client)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.want == "" {
+				if issue != nil {
+					t.Errorf("issue = %+v, want none", issue)
+				}
+				return
+			}
+			if issue == nil || issue.Reason != test.want || !slices.Equal(issue.Fields, test.fields) {
+				t.Errorf("issue = %+v, want %s %v", issue, test.want, test.fields)
+			}
+		})
+	}
+
+	// Dois clientes com o mesmo tiny_id
+	client, _ := setupClient(t, repositories, schemas.ClientFromDB{Contact: schemas.Contact{Email: "ana2@example.com", TinyID: "1"}})
+	issue, _ := InspectLink(t.Context(), repositories, syncer, client)
+	if issue == nil || issue.Reason != schemas.TINY_LINK_SHARED || len(issue.SharedWith) != 1 {
+		t.Errorf("issue = %+v, want shared with one client", issue)
+	}
+}
+
+func setupClient(t *testing.T, repositories *database.Repositories, client schemas.ClientFromDB) (schemas.ClientFromDB, error) {
+	t.Helper()
+
+	repositories.Clients.(*database.MemoryClientsRepository).Insert(client)
+	return repositories.Clients.FindByEmail(t.Context(), client.Contact.Email)
+}
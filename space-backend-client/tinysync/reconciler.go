@@ -0,0 +1,425 @@
+package tinysync
+
+import (
+	"api/audit"
+	"api/database"
+	"api/documents"
+	"api/schemas"
+	"api/tiny"
+	"api/utils"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// RECONCILE_TIMEOUT limita uma execução completa; com o limite de requisições
+// do Tiny, percorrer todos os contatos pode levar bastante tempo.
+const RECONCILE_TIMEOUT = 6 * time.Hour
+
+// ErrReconcileRunning indica que já há uma reconciliação em andamento nesta
+// instância.
+var ErrReconcileRunning = errors.New("já há uma reconciliação em andamento")
+
+var reconcilePolicies = []string{
+	schemas.TINY_RECONCILE_OURS,
+	schemas.TINY_RECONCILE_THEIRS,
+	schemas.TINY_RECONCILE_REVIEW,
+}
+
+// ValidPolicy diz se a política de reconciliação existe.
+func ValidPolicy(policy string) bool {
+	return slices.Contains(reconcilePolicies, policy)
+}
+
+// contactField é um campo comparado na reconciliação. Os valores são lidos
+// do contato no formato do Tiny (ver tiny.FromClient), então os dois lados
+// são comparados da mesma forma.
+type contactField struct {
+	// key é a chave do campo no cadastro (JSON e BSON)
+	key   string
+	value func(tiny.Contact) string
+	// digits compara só os dígitos (documentos e CEP)
+	digits bool
+	// pull grava no contato do cadastro o valor vindo do Tiny. Sem pull o
+	// campo nunca é trazido do Tiny
+	pull func(contact *schemas.Contact, value string) error
+}
+
+// reconciledFields são os campos presentes no resultado de contatos.pesquisa.
+// O email não é trazido do Tiny porque é o login do cliente.
+var reconciledFields = []contactField{
+	{key: "name", value: func(c tiny.Contact) string { return c.Nome }, pull: func(contact *schemas.Contact, value string) error {
+		contact.Name = value
+		return nil
+	}},
+	{key: "email", value: func(c tiny.Contact) string { return c.Email }},
+	{key: "document", value: func(c tiny.Contact) string { return c.CpfCnpj }, digits: true, pull: pullDocument},
+	{key: "zip_code", value: func(c tiny.Contact) string { return c.Cep }, digits: true, pull: func(contact *schemas.Contact, value string) error {
+		return pullNormalized(&contact.ZipCode, value, documents.NormalizeCEP)
+	}},
+	{key: "address", value: func(c tiny.Contact) string { return c.Endereco }, pull: func(contact *schemas.Contact, value string) error {
+		contact.Address = value
+		return nil
+	}},
+	{key: "number", value: func(c tiny.Contact) string { return c.Numero }, pull: func(contact *schemas.Contact, value string) error {
+		contact.Number = value
+		return nil
+	}},
+	{key: "complement", value: func(c tiny.Contact) string { return c.Complemento }, pull: func(contact *schemas.Contact, value string) error {
+		contact.Complement = value
+		return nil
+	}},
+	{key: "neighborhood", value: func(c tiny.Contact) string { return c.Bairro }, pull: func(contact *schemas.Contact, value string) error {
+		contact.Neighborhood = value
+		return nil
+	}},
+	{key: "city", value: func(c tiny.Contact) string { return c.Cidade }, pull: func(contact *schemas.Contact, value string) error {
+		contact.City = value
+		return nil
+	}},
+	{key: "state", value: func(c tiny.Contact) string { return c.Uf }, pull: func(contact *schemas.Contact, value string) error {
+		return pullNormalized(&contact.State, value, documents.NormalizeUF)
+	}},
+}
+
+func pullNormalized(field *string, value string, normalize func(string) (string, error)) error {
+	if value == "" {
+		*field = ""
+		return nil
+	}
+	normalized, err := normalize(value)
+	if err != nil {
+		return err
+	}
+	*field = normalized
+	return nil
+}
+
+// pullDocument grava o CPF ou o CNPJ conforme o tipo de pessoa do cadastro;
+// sem tipo de pessoa não há onde gravar.
+func pullDocument(contact *schemas.Contact, value string) error {
+	switch contact.PersonType {
+	case "PJ":
+		return pullNormalized(&contact.CNPJ, value, documents.NormalizeCNPJ)
+	case "PF":
+		return pullNormalized(&contact.CPF, value, documents.NormalizeCPF)
+	default:
+		return fmt.Errorf("cliente sem tipo de pessoa")
+	}
+}
+
+// documentKey é a chave do documento no cadastro, conforme o tipo de pessoa.
+func documentKey(contact schemas.Contact) string {
+	if contact.PersonType == "PJ" {
+		return "cnpj"
+	}
+	return "cpf"
+}
+
+func normalizedValue(field contactField, value string) string {
+	if field.digits {
+		return documents.Digits(value)
+	}
+	return strings.TrimSpace(value)
+}
+
+// Drift compara o contato do cadastro com o do Tiny e retorna os campos
+// divergentes. Textos são comparados sem diferenciar maiúsculas.
+func Drift(contact schemas.Contact, theirs tiny.Contact) []schemas.TinyDriftField {
+	ours := tiny.FromClient(contact, "")
+
+	var fields []schemas.TinyDriftField
+	for _, field := range reconciledFields {
+		oursValue, theirsValue := field.value(ours), field.value(theirs)
+		if strings.EqualFold(normalizedValue(field, oursValue), normalizedValue(field, theirsValue)) {
+			continue
+		}
+
+		key := field.key
+		if key == "document" {
+			key = documentKey(contact)
+		}
+		fields = append(fields, schemas.TinyDriftField{Field: key, Ours: oursValue, Theirs: strings.TrimSpace(theirsValue)})
+	}
+	return fields
+}
+
+// pull aplica ao contato os valores do Tiny para os campos divergentes.
+// Retorna erro se algum campo não puder ser trazido; nesse caso nada é
+// aplicado e a divergência fica para revisão.
+func pull(contact schemas.Contact, drift []schemas.TinyDriftField) (schemas.Contact, []string, error) {
+	var keys []string
+	for _, item := range drift {
+		index := slices.IndexFunc(reconciledFields, func(field contactField) bool {
+			return field.key == item.Field || (field.key == "document" && item.Field == documentKey(contact))
+		})
+		field := reconciledFields[index]
+		if field.pull == nil {
+			return contact, nil, fmt.Errorf("o campo %s não é trazido do Tiny", item.Field)
+		}
+		if err := field.pull(&contact, item.Theirs); err != nil {
+			return contact, nil, fmt.Errorf("valor do Tiny inválido em %s: %v", item.Field, err)
+		}
+		keys = append(keys, item.Field)
+	}
+	return contact, keys, nil
+}
+
+// Reconciler percorre os contatos do Tiny, compara cada um com o cliente
+// vinculado pelo tiny_id e grava um relatório das divergências em
+// tiny_reconciliations. Conforme a política, a divergência é resolvida
+// reenviando o cadastro (fila de sincronização), trazendo os valores do Tiny
+// ou apenas registrada para revisão.
+type Reconciler struct {
+	repositories *database.Repositories
+	syncer       Syncer
+	// Policy e DryRun são usados nas execuções agendadas
+	Policy string
+	DryRun bool
+
+	mu      sync.Mutex
+	running bool
+	now     func() time.Time
+}
+
+func NewReconciler(repositories *database.Repositories, syncer Syncer, policy string, dryRun bool) *Reconciler {
+	return &Reconciler{repositories: repositories, syncer: syncer, Policy: policy, DryRun: dryRun, now: time.Now}
+}
+
+// ReconcilerFromEnv lê TINY_RECONCILE_POLICY (padrão review),
+// TINY_RECONCILE_DRY_RUN e TINY_RECONCILE_INTERVAL. Um intervalo zero desliga
+// o agendamento, mas a reconciliação ainda pode ser disparada pelo admin.
+func ReconcilerFromEnv(repositories *database.Repositories, syncer Syncer) (*Reconciler, time.Duration) {
+	policy := os.Getenv(utils.TINY_RECONCILE_POLICY)
+	if policy == "" {
+		policy = schemas.TINY_RECONCILE_REVIEW
+	} else if !ValidPolicy(policy) {
+		log.Printf("[TinySync] TINY_RECONCILE_POLICY inválida (%s), usando %s", policy, schemas.TINY_RECONCILE_REVIEW)
+		policy = schemas.TINY_RECONCILE_REVIEW
+	}
+
+	dryRun, _ := strconv.ParseBool(os.Getenv(utils.TINY_RECONCILE_DRY_RUN))
+
+	var interval time.Duration
+	if raw := os.Getenv(utils.TINY_RECONCILE_INTERVAL); raw != "" {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil || parsed < 0 {
+			log.Printf("[TinySync] TINY_RECONCILE_INTERVAL inválido (%s), agendamento desligado", raw)
+		} else {
+			interval = parsed
+		}
+	}
+
+	return NewReconciler(repositories, syncer, policy, dryRun), interval
+}
+
+// Schedule executa a reconciliação a cada interval até ctx ser cancelado.
+func (r *Reconciler) Schedule(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			runCtx, cancel := context.WithTimeout(ctx, RECONCILE_TIMEOUT)
+			_, err := r.Reconcile(runCtx, r.Policy, r.DryRun)
+			cancel()
+			if err != nil {
+				log.Printf("[TinySync] Reconciliação agendada não executada: %v", err)
+			}
+		}
+	}
+}
+
+// Start dispara uma reconciliação em segundo plano.
+func (r *Reconciler) Start(policy string, dryRun bool) error {
+	if !r.acquire() {
+		return ErrReconcileRunning
+	}
+
+	go func() {
+		defer r.release()
+
+		ctx, cancel := context.WithTimeout(context.Background(), RECONCILE_TIMEOUT)
+		defer cancel()
+		r.run(ctx, policy, dryRun)
+	}()
+	return nil
+}
+
+// Reconcile executa a reconciliação e retorna o relatório gravado. Falhas no
+// meio do caminho ficam em Error, com as divergências encontradas até ali.
+func (r *Reconciler) Reconcile(ctx context.Context, policy string, dryRun bool) (schemas.TinyReconciliation, error) {
+	if !r.acquire() {
+		return schemas.TinyReconciliation{}, ErrReconcileRunning
+	}
+	defer r.release()
+
+	return r.run(ctx, policy, dryRun), nil
+}
+
+func (r *Reconciler) acquire() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.running {
+		return false
+	}
+	r.running = true
+	return true
+}
+
+func (r *Reconciler) release() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.running = false
+}
+
+func (r *Reconciler) run(ctx context.Context, policy string, dryRun bool) schemas.TinyReconciliation {
+	report := schemas.TinyReconciliation{
+		Policy:    policy,
+		DryRun:    dryRun,
+		Drifts:    []schemas.TinyDrift{},
+		StartedAt: r.now(),
+	}
+
+	if err := r.scan(ctx, &report); err != nil {
+		log.Printf("[TinySync] Reconciliação interrompida: %v", err)
+		report.Error = err.Error()
+	}
+	report.FinishedAt = r.now()
+
+	// O relatório é gravado mesmo se ctx já tiver expirado
+	insertCtx, cancel := context.WithTimeout(context.Background(), database.MONGODB_TIMEOUT)
+	defer cancel()
+
+	id, err := r.repositories.TinyReconciliations.Insert(insertCtx, report)
+	if err != nil {
+		log.Printf("[TinySync] Erro ao gravar o relatório da reconciliação: %v", err)
+	}
+	report.ID = id
+
+	log.Printf("[TinySync] Reconciliação (%s, dry-run %t): %d contatos conferidos, %d divergências", policy, dryRun, report.Scanned, len(report.Drifts))
+	return report
+}
+
+// scan percorre as páginas de contatos do Tiny (contatos.pesquisa) e só
+// busca o contato completo (contato.obter) quando o resumo diverge. Contatos sem cliente
+// vinculado (fornecedores, clientes só do balcão) e vínculos duplicados são
+// ignorados; estes aparecem no relatório de vínculos.
+func (r *Reconciler) scan(ctx context.Context, report *schemas.TinyReconciliation) error {
+	for page := 1; ; page++ {
+		result, err := r.syncer.Page(ctx, page)
+		if err != nil {
+			return fmt.Errorf("erro ao buscar a página %d de contatos do Tiny: %w", page, err)
+		}
+
+		for _, theirs := range result.Contacts {
+			if theirs.ID == "" || theirs.Situacao == "E" {
+				continue
+			}
+
+			clients, err := r.repositories.Clients.FindByTinyID(ctx, string(theirs.ID))
+			if err != nil {
+				return fmt.Errorf("erro ao buscar o cliente do contato %s: %w", theirs.ID, err)
+			}
+			// Clientes anonimizados ficam fora: nem o cadastro nem o Tiny
+			// devem ser alterados
+			if len(clients) != 1 || clients[0].AnonymizedAt != nil {
+				continue
+			}
+			report.Scanned++
+
+			if len(Drift(clients[0].Contact, theirs)) == 0 {
+				continue
+			}
+
+			// O resultado da pesquisa é um resumo; a divergência é confirmada
+			// com o contato completo
+			full, err := r.syncer.Get(ctx, string(theirs.ID))
+			if errors.Is(err, tiny.ErrNotFound) {
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("erro ao buscar o contato %s no Tiny: %w", theirs.ID, err)
+			}
+			fields := Drift(clients[0].Contact, full)
+			if len(fields) == 0 {
+				continue
+			}
+
+			drift := schemas.TinyDrift{ClientID: clients[0].ID, TinyID: string(theirs.ID), Fields: fields}
+			r.resolve(ctx, clients[0], &drift, report.Policy, report.DryRun)
+			// O relatório não guarda o documento aberto, que no cadastro fica
+			// cifrado; a resolução acima já usou os valores completos
+			database.MaskDriftDocuments(drift.Fields)
+			report.Drifts = append(report.Drifts, drift)
+		}
+
+		if page >= result.Pages {
+			return nil
+		}
+	}
+}
+
+// resolve aplica a política à divergência. Em dry-run só registra o que
+// seria feito.
+func (r *Reconciler) resolve(ctx context.Context, client schemas.ClientFromDB, drift *schemas.TinyDrift, policy string, dryRun bool) {
+	switch policy {
+	case schemas.TINY_RECONCILE_OURS:
+		drift.Resolution = schemas.TINY_DRIFT_PUSHED
+		if dryRun {
+			return
+		}
+		// O envio segue pela fila, com as mesmas tentativas da sincronização
+		if err := r.repositories.TinySyncJobs.Enqueue(ctx, client.ID); err != nil {
+			drift.Resolution = schemas.TINY_DRIFT_FAILED
+			drift.Error = err.Error()
+		}
+
+	case schemas.TINY_RECONCILE_THEIRS:
+		contact, keys, err := pull(client.Contact, drift.Fields)
+		if err != nil {
+			drift.Resolution = schemas.TINY_DRIFT_FLAGGED
+			drift.Error = err.Error()
+			return
+		}
+		drift.Resolution = schemas.TINY_DRIFT_PULLED
+		if dryRun {
+			return
+		}
+		contact = documents.NormalizeContact(contact)
+		if err := r.repositories.Clients.PatchContact(ctx, client.ID, contact, keys); err != nil {
+			drift.Resolution = schemas.TINY_DRIFT_FAILED
+			drift.Error = err.Error()
+			return
+		}
+		audit.Record(ctx, r.repositories.AuditEvents, nil, audit.Entry{
+			Action:     schemas.AUDIT_ACTION_TINY_RECONCILE,
+			TargetType: schemas.AUDIT_TARGET_CLIENT,
+			TargetID:   client.ID.Hex(),
+			Before:     bson.M{"contact": client.Contact},
+			After:      bson.M{"contact": contact},
+			Metadata:   map[string]any{"policy": policy, "tiny_id": drift.TinyID},
+		})
+
+	default:
+		drift.Resolution = schemas.TINY_DRIFT_FLAGGED
+	}
+}
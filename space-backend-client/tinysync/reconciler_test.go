@@ -0,0 +1,163 @@
+package tinysync
+
+import (
+	"api/database"
+	"api/schemas"
+	"api/tiny"
+	"reflect"
+	"testing"
+)
+
+func setupReconciler(t *testing.T, policy string, dryRun bool) (*database.Repositories, *fakeSyncer, *Reconciler, schemas.ClientFromDB) {
+	t.Helper()
+
+	repositories := database.NewMemoryRepositories()
+	syncer := &fakeSyncer{existing: []tiny.Contact{
+		// Sem cliente vinculado: ignorado
+		{ID: "1", Nome: "Fornecedor"},
+		{ID: "2", Nome: "Ana Souza", Email: "ana@example.com", CpfCnpj: "529.982.247-25", Cep: "80010-000", Cidade: "CURITIBA", Uf: "PR"},
+		{ID: "3", Nome: "Bia", Email: "bia@example.com", Cidade: "Londrina", Uf: "pr"},
+	}}
+
+	ana, _ := setupClient(t, repositories, schemas.ClientFromDB{Contact: schemas.Contact{
+		Name: "Ana", Email: "ana@example.com", PersonType: "PF", CPF: "52998224725", ZipCode: "80010000", City: "Curitiba", State: "PR", TinyID: "2",
+	}})
+	setupClient(t, repositories, schemas.ClientFromDB{Contact: schemas.Contact{
+		Name: "Bia", Email: "bia@example.com", City: "Londrina", State: "PR", TinyID: "3",
+	}})
+
+	return repositories, syncer, NewReconciler(repositories, syncer, policy, dryRun), ana
+}
+
+func TestReconcileReportsDrift(t *testing.T) {
+	repositories, _, reconciler, ana := setupReconciler(t, schemas.TINY_RECONCILE_REVIEW, false)
+
+	report, err := reconciler.Reconcile(t.Context(), reconciler.Policy, reconciler.DryRun)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Caixa e formatação não contam como divergência
+	if report.Scanned != 2 || len(report.Drifts) != 1 || report.Error != "" {
+		t.Fatalf("report = %+v, want 2 scanned and only Ana drifting", report)
+	}
+	drift := report.Drifts[0]
+	if drift.ClientID != ana.ID || drift.Resolution != schemas.TINY_DRIFT_FLAGGED || len(drift.Fields) != 1 || drift.Fields[0] != (schemas.TinyDriftField{Field: "name", Ours: "Ana", Theirs: "Ana Souza"}) {
+		t.Errorf("drift = %+v, want the name flagged", drift)
+	}
+
+	stored, _ := repositories.TinyReconciliations.List(t.Context(), 0)
+	if len(stored) != 1 || stored[0].ID != report.ID {
+		t.Errorf("stored = %+v, want the report", stored)
+	}
+}
+
+func TestReconcileOursWinsEnqueuesSync(t *testing.T) {
+	repositories, _, reconciler, ana := setupReconciler(t, schemas.TINY_RECONCILE_OURS, false)
+
+	report, _ := reconciler.Reconcile(t.Context(), reconciler.Policy, reconciler.DryRun)
+	if report.Drifts[0].Resolution != schemas.TINY_DRIFT_PUSHED {
+		t.Fatalf("resolution = %q, want pushed", report.Drifts[0].Resolution)
+	}
+
+	jobs, _ := repositories.TinySyncJobs.List(t.Context(), schemas.TINY_SYNC_PENDING, 0)
+	if len(jobs) != 1 || jobs[0].ClientID != ana.ID {
+		t.Errorf("jobs = %+v, want a sync job for Ana", jobs)
+	}
+}
+
+func TestReconcileTheirsWinsPullsValues(t *testing.T) {
+	repositories, syncer, reconciler, ana := setupReconciler(t, schemas.TINY_RECONCILE_THEIRS, false)
+	syncer.existing[1].Numero = "100"
+
+	report, _ := reconciler.Reconcile(t.Context(), reconciler.Policy, reconciler.DryRun)
+	if report.Drifts[0].Resolution != schemas.TINY_DRIFT_PULLED {
+		t.Fatalf("drift = %+v, want pulled", report.Drifts[0])
+	}
+
+	ana, _ = repositories.Clients.FindByID(t.Context(), ana.ID)
+	if ana.Contact.Name != "Ana Souza" || ana.Contact.Number != "100" || ana.Contact.City != "Curitiba" {
+		t.Errorf("contact = %+v, want name and number from Tiny", ana.Contact)
+	}
+
+	events := repositories.AuditEvents.(*database.MemoryAuditEventsRepository).Events()
+	if len(events) != 1 || events[0].ActorType != schemas.AUDIT_ACTOR_SYSTEM || events[0].Action != schemas.AUDIT_ACTION_TINY_RECONCILE || events[0].TargetID != ana.ID.Hex() {
+		t.Fatalf("events = %+v, want the pull audited as the system", events)
+	}
+	want := []schemas.AuditChange{
+		{Field: "contact.name", Before: "Ana", After: "Ana Souza"},
+		{Field: "contact.number", Before: nil, After: "100"},
+	}
+	if !reflect.DeepEqual(events[0].Changes, want) {
+		t.Errorf("changes = %+v, want %+v", events[0].Changes, want)
+	}
+
+	// O email nunca vem do Tiny: a divergência fica para revisão
+	syncer.existing[1].Email = "ana.souza@example.com"
+	report, _ = reconciler.Reconcile(t.Context(), reconciler.Policy, reconciler.DryRun)
+	if len(report.Drifts) != 1 || report.Drifts[0].Resolution != schemas.TINY_DRIFT_FLAGGED || report.Drifts[0].Error == "" {
+		t.Errorf("drifts = %+v, want the email flagged", report.Drifts)
+	}
+}
+
+func TestReconcileMasksDocumentsInReport(t *testing.T) {
+	repositories, syncer, reconciler, ana := setupReconciler(t, schemas.TINY_RECONCILE_THEIRS, false)
+	syncer.existing[1].Nome = "Ana"
+	syncer.existing[1].CpfCnpj = "111.444.777-35"
+
+	report, _ := reconciler.Reconcile(t.Context(), reconciler.Policy, reconciler.DryRun)
+	want := schemas.TinyDriftField{Field: "cpf", Ours: "***.***.***-25", Theirs: "***.***.***-35"}
+	if len(report.Drifts) != 1 || len(report.Drifts[0].Fields) != 1 || report.Drifts[0].Fields[0] != want {
+		t.Fatalf("drifts = %+v, want the masked CPF", report.Drifts)
+	}
+
+	stored, _ := repositories.TinyReconciliations.List(t.Context(), 0)
+	if stored[0].Drifts[0].Fields[0] != want {
+		t.Errorf("stored = %+v, want the masked CPF", stored[0].Drifts[0].Fields)
+	}
+
+	// A máscara vale só para o relatório: o valor do Tiny foi trazido inteiro
+	ana, _ = repositories.Clients.FindByID(t.Context(), ana.ID)
+	if ana.Contact.CPF != "11144477735" {
+		t.Errorf("cpf = %q, want the value pulled from Tiny", ana.Contact.CPF)
+	}
+}
+
+func TestReconcileDryRunChangesNothing(t *testing.T) {
+	repositories, _, reconciler, ana := setupReconciler(t, schemas.TINY_RECONCILE_THEIRS, true)
+
+	report, _ := reconciler.Reconcile(t.Context(), reconciler.Policy, reconciler.DryRun)
+	if !report.DryRun || report.Drifts[0].Resolution != schemas.TINY_DRIFT_PULLED {
+		t.Fatalf("report = %+v, want a dry-run pull", report)
+	}
+
+	ana, _ = repositories.Clients.FindByID(t.Context(), ana.ID)
+	if ana.Contact.Name != "Ana" {
+		t.Errorf("name = %q, want unchanged in dry-run", ana.Contact.Name)
+	}
+	if events := repositories.AuditEvents.(*database.MemoryAuditEventsRepository).Events(); len(events) != 0 {
+		t.Errorf("events = %+v, want none in dry-run", events)
+	}
+}
+
+func TestReconcileStopsOnTinyFailure(t *testing.T) {
+	_, syncer, reconciler, _ := setupReconciler(t, schemas.TINY_RECONCILE_REVIEW, false)
+	syncer.err = tiny.ErrUnavailable
+
+	report, err := reconciler.Reconcile(t.Context(), reconciler.Policy, reconciler.DryRun)
+	if err != nil || report.Error == "" {
+		t.Errorf("report = %+v, err = %v, want the failure recorded in the report", report, err)
+	}
+}
+
+func TestReconcileDoesNotOverlap(t *testing.T) {
+	_, _, reconciler, _ := setupReconciler(t, schemas.TINY_RECONCILE_REVIEW, false)
+
+	reconciler.acquire()
+	if _, err := reconciler.Reconcile(t.Context(), reconciler.Policy, reconciler.DryRun); err != ErrReconcileRunning {
+		t.Errorf("err = %v, want ErrReconcileRunning", err)
+	}
+	if err := reconciler.Start(reconciler.Policy, reconciler.DryRun); err != ErrReconcileRunning {
+		t.Errorf("err = %v, want ErrReconcileRunning", err)
+	}
+}
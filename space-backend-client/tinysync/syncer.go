@@ -0,0 +1,51 @@
+package tinysync
+
+import (
+	"api/documents"
+	"api/schemas"
+	"api/tiny"
+	"context"
+)
+
+// TinySyncer envia os contatos pelo cliente da API do Tiny.
+type TinySyncer struct {
+	Client *tiny.Client
+}
+
+func (s TinySyncer) Create(ctx context.Context, contact schemas.Contact) (string, error) {
+	return s.Client.CreateContact(ctx, tiny.FromClient(contact, ""))
+}
+
+func (s TinySyncer) Update(ctx context.Context, contact schemas.Contact, tinyID string) error {
+	return s.Client.UpdateContact(ctx, tiny.FromClient(contact, tinyID))
+}
+
+// Find pesquisa pelo CPF/CNPJ e pelo email do cliente, juntando os resultados
+// das duas buscas (só a primeira página de cada).
+func (s TinySyncer) Find(ctx context.Context, contact schemas.Contact) ([]tiny.Contact, error) {
+	var queries []tiny.SearchQuery
+	if formatted := tiny.FromClient(contact, "").CpfCnpj; documents.Digits(formatted) != "" {
+		queries = append(queries, tiny.SearchQuery{CpfCnpj: formatted})
+	}
+	if contact.Email != "" {
+		queries = append(queries, tiny.SearchQuery{Pesquisa: contact.Email})
+	}
+
+	var contacts []tiny.Contact
+	for _, query := range queries {
+		result, err := s.Client.SearchContacts(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		contacts = append(contacts, result.Contacts...)
+	}
+	return contacts, nil
+}
+
+func (s TinySyncer) Get(ctx context.Context, tinyID string) (tiny.Contact, error) {
+	return s.Client.GetContact(ctx, tinyID)
+}
+
+func (s TinySyncer) Page(ctx context.Context, page int) (tiny.SearchResult, error) {
+	return s.Client.SearchContacts(ctx, tiny.SearchQuery{Page: page})
+}
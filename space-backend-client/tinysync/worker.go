@@ -0,0 +1,178 @@
+// Package tinysync envia ao Tiny os contatos enfileirados na coleção
+// tiny_sync_jobs. Os handlers só gravam o job, na mesma transação da
+// alteração do cliente; o Worker faz a chamada ao Tiny em segundo plano, com
+// novas tentativas em backoff exponencial.
+package tinysync
+
+import (
+	"api/database"
+	"api/schemas"
+	"api/tiny"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+const (
+	// POLL_INTERVAL é a espera entre as buscas quando a fila está vazia.
+	POLL_INTERVAL = 5 * time.Second
+	// JOB_TIMEOUT limita cada envio e também é o prazo da reserva do job.
+	JOB_TIMEOUT = 2 * time.Minute
+
+	// Com 8 tentativas e base de 30s, o job desiste depois de ~2 horas.
+	MAX_ATTEMPTS = 8
+	BACKOFF_BASE = 30 * time.Second
+	BACKOFF_MAX  = 1 * time.Hour
+)
+
+// Syncer é a integração com o Tiny usada pelo Worker.
+type Syncer interface {
+	// Create cadastra o contato e retorna o ID gerado no Tiny.
+	Create(ctx context.Context, contact schemas.Contact) (string, error)
+	// Update altera o contato já cadastrado com tinyID.
+	Update(ctx context.Context, contact schemas.Contact, tinyID string) error
+	// Find pesquisa no Tiny os contatos que podem ser do cliente (mesmo
+	// CPF/CNPJ ou email); a escolha entre eles fica com Match.
+	Find(ctx context.Context, contact schemas.Contact) ([]tiny.Contact, error)
+	// Get busca o contato pelo ID; retorna tiny.ErrNotFound se ele não existe.
+	Get(ctx context.Context, tinyID string) (tiny.Contact, error)
+	// Page retorna uma página (a partir de 1) de todos os contatos do Tiny.
+	Page(ctx context.Context, page int) (tiny.SearchResult, error)
+}
+
+type Worker struct {
+	repositories *database.Repositories
+	syncer       Syncer
+	now          func() time.Time
+}
+
+func NewWorker(repositories *database.Repositories, syncer Syncer) *Worker {
+	return &Worker{repositories: repositories, syncer: syncer, now: time.Now}
+}
+
+// Run processa a fila até ctx ser cancelado.
+func (w *Worker) Run(ctx context.Context) {
+	ticker := time.NewTicker(POLL_INTERVAL)
+	defer ticker.Stop()
+
+	for {
+		for w.ProcessNext(ctx) {
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcessNext processa um job vencido, se houver, e retorna se havia algum.
+func (w *Worker) ProcessNext(ctx context.Context) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	now := w.now()
+	claimCtx, cancel := context.WithTimeout(ctx, database.MONGODB_TIMEOUT)
+	job, err := w.repositories.TinySyncJobs.Claim(claimCtx, now, now.Add(JOB_TIMEOUT))
+	cancel()
+	if err != nil {
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			log.Printf("[TinySync] Erro ao buscar job: %v", err)
+		}
+		return false
+	}
+
+	jobCtx, cancel := context.WithTimeout(ctx, JOB_TIMEOUT)
+	defer cancel()
+
+	err = w.sync(jobCtx, job.ClientID)
+	if err == nil {
+		if err := w.repositories.TinySyncJobs.MarkDone(jobCtx, job.ID); err != nil {
+			log.Printf("[TinySync] Erro ao concluir job %s: %v", job.ID.Hex(), err)
+		}
+		return true
+	}
+
+	attempts := job.Attempts + 1
+	// Contato recusado pela validação do Tiny ou com vínculo ambíguo não muda
+	// sozinho: vai direto para dead e aguarda correção e replay
+	dead := attempts >= MAX_ATTEMPTS || needsReview(err)
+	if dead {
+		log.Printf("[TinySync] Job %s do cliente %s esgotou as tentativas: %v", job.ID.Hex(), job.ClientID.Hex(), err)
+	}
+	if err := w.repositories.TinySyncJobs.MarkFailed(jobCtx, job.ID, err.Error(), now.Add(Backoff(attempts)), dead); err != nil {
+		log.Printf("[TinySync] Erro ao registrar falha do job %s: %v", job.ID.Hex(), err)
+	}
+	return true
+}
+
+// sync envia o contato atual do cliente: altera o contato quando já há
+// TinyID; sem ele, vincula o contato já existente no Tiny (cliente que já
+// comprou por fora do site) ou cadastra um novo.
+func (w *Worker) sync(ctx context.Context, clientID bson.ObjectID) error {
+	client, err := w.repositories.Clients.FindByID(ctx, clientID)
+	if err != nil {
+		// Cliente excluído pela equipe interna: não há mais o que enviar
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil
+		}
+		return fmt.Errorf("erro ao buscar cliente: %v", err)
+	}
+
+	// O Tiny guarda os dados fiscais dos pedidos e não recebe os marcadores
+	// da anonimização
+	if client.AnonymizedAt != nil {
+		return nil
+	}
+
+	// O contato só é criado no Tiny depois da confirmação do email. Quem troca
+	// o email volta a ficar pendente e o Tiny só recebe o contato novo quando a
+	// confirmação enfileirar outro job
+	if !client.EmailVerified {
+		return nil
+	}
+
+	if client.Contact.TinyID != "" {
+		return w.syncer.Update(ctx, client.Contact, client.Contact.TinyID)
+	}
+
+	tinyID, err := link(ctx, w.repositories, w.syncer, client)
+	if err != nil {
+		return err
+	}
+
+	// O contato vinculado não é sobrescrito: diferenças aparecem no relatório
+	// de vínculos para revisão
+	if tinyID != "" {
+		log.Printf("[TinySync] Cliente %s vinculado ao contato %s já existente no Tiny", client.ID.Hex(), tinyID)
+	} else {
+		tinyID, err = w.syncer.Create(ctx, client.Contact)
+		if err != nil {
+			return err
+		}
+		if tinyID == "" {
+			return fmt.Errorf("Tiny não retornou o ID do contato")
+		}
+	}
+
+	contact := client.Contact
+	contact.TinyID = tinyID
+	return w.repositories.Clients.PatchContact(ctx, client.ID, contact, []string{"tiny_id"})
+}
+
+// Backoff é a espera antes da próxima tentativa, dobrando a cada falha até
+// BACKOFF_MAX.
+func Backoff(attempts int) time.Duration {
+	delay := BACKOFF_BASE
+	for i := 1; i < attempts && delay < BACKOFF_MAX; i++ {
+		delay *= 2
+	}
+	return min(delay, BACKOFF_MAX)
+}
@@ -0,0 +1,268 @@
+package tinysync
+
+import (
+	"api/database"
+	"api/schemas"
+	"api/tiny"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type fakeSyncer struct {
+	created []schemas.Contact
+	updated []string
+	err     error
+	// existing são os contatos já cadastrados no Tiny, devolvidos por Find e Get
+	existing []tiny.Contact
+}
+
+func (s *fakeSyncer) Create(ctx context.Context, contact schemas.Contact) (string, error) {
+	if s.err != nil {
+		return "", s.err
+	}
+	s.created = append(s.created, contact)
+	return "777", nil
+}
+
+func (s *fakeSyncer) Update(ctx context.Context, contact schemas.Contact, tinyID string) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.updated = append(s.updated, tinyID)
+	return nil
+}
+
+func (s *fakeSyncer) Find(ctx context.Context, contact schemas.Contact) ([]tiny.Contact, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return s.existing, nil
+}
+
+func (s *fakeSyncer) Get(ctx context.Context, tinyID string) (tiny.Contact, error) {
+	for _, contact := range s.existing {
+		if string(contact.ID) == tinyID {
+			return contact, nil
+		}
+	}
+	return tiny.Contact{}, &tiny.APIError{Code: tiny.CODE_NOT_FOUND}
+}
+
+// Page devolve existing em páginas de dois contatos, para exercitar a paginação.
+func (s *fakeSyncer) Page(ctx context.Context, page int) (tiny.SearchResult, error) {
+	if s.err != nil {
+		return tiny.SearchResult{}, s.err
+	}
+	pages := max((len(s.existing)+1)/2, 1)
+	start := min((page-1)*2, len(s.existing))
+	end := min(start+2, len(s.existing))
+	return tiny.SearchResult{Contacts: s.existing[start:end], Page: page, Pages: pages}, nil
+}
+
+func setupWorker(t *testing.T, client schemas.ClientFromDB) (*database.Repositories, *fakeSyncer, *Worker, schemas.ClientFromDB) {
+	t.Helper()
+
+	repositories := database.NewMemoryRepositories()
+	clientsRepository := repositories.Clients.(*database.MemoryClientsRepository)
+	clientsRepository.Insert(client)
+	client, _ = clientsRepository.FindByEmail(t.Context(), client.Contact.Email)
+
+	syncer := &fakeSyncer{}
+	return repositories, syncer, NewWorker(repositories, syncer), client
+}
+
+func TestWorkerCreatesContactAndStoresTinyID(t *testing.T) {
+	repositories, syncer, worker, client := setupWorker(t, schemas.ClientFromDB{
+		Contact:       schemas.Contact{Name: "Ana", Email: "ana@example.com"},
+		EmailVerified: true,
+	})
+
+	repositories.TinySyncJobs.Enqueue(t.Context(), client.ID)
+	if !worker.ProcessNext(t.Context()) {
+		t.Fatal("expected a job to be processed")
+	}
+	if worker.ProcessNext(t.Context()) {
+		t.Error("queue should be empty after the job is done")
+	}
+
+	if len(syncer.created) != 1 {
+		t.Fatalf("created = %d, want 1", len(syncer.created))
+	}
+	client, _ = repositories.Clients.FindByID(t.Context(), client.ID)
+	if client.Contact.TinyID != "777" {
+		t.Errorf("tiny_id = %q, want 777", client.Contact.TinyID)
+	}
+
+	jobs, _ := repositories.TinySyncJobs.List(t.Context(), schemas.TINY_SYNC_DONE, 0)
+	if len(jobs) != 1 {
+		t.Errorf("done jobs = %d, want 1", len(jobs))
+	}
+}
+
+func TestWorkerUpdatesExistingContact(t *testing.T) {
+	repositories, syncer, worker, client := setupWorker(t, schemas.ClientFromDB{
+		Contact:       schemas.Contact{Name: "Bia", Email: "bia@example.com", TinyID: "55"},
+		EmailVerified: true,
+	})
+
+	repositories.TinySyncJobs.Enqueue(t.Context(), client.ID)
+	worker.ProcessNext(t.Context())
+
+	if len(syncer.updated) != 1 || syncer.updated[0] != "55" || len(syncer.created) != 0 {
+		t.Errorf("updated = %v, created = %d, want one update of 55", syncer.updated, len(syncer.created))
+	}
+}
+
+func TestWorkerRetriesWithBackoffAndDeadLetters(t *testing.T) {
+	repositories, syncer, worker, client := setupWorker(t, schemas.ClientFromDB{
+		Contact:       schemas.Contact{Name: "Caio", Email: "caio@example.com", TinyID: "9"},
+		EmailVerified: true,
+	})
+	syncer.err = errors.New("Tiny fora do ar")
+
+	repositories.TinySyncJobs.Enqueue(t.Context(), client.ID)
+
+	now := time.Now()
+	worker.now = func() time.Time { return now }
+	worker.ProcessNext(t.Context())
+
+	jobs, _ := repositories.TinySyncJobs.List(t.Context(), schemas.TINY_SYNC_PENDING, 0)
+	if len(jobs) != 1 || jobs[0].Attempts != 1 || jobs[0].LastError != "Tiny fora do ar" {
+		t.Fatalf("jobs = %+v, want one pending job with 1 attempt", jobs)
+	}
+	if !jobs[0].NextAttemptAt.Equal(now.Add(BACKOFF_BASE)) {
+		t.Errorf("next_attempt_at = %v, want %v", jobs[0].NextAttemptAt, now.Add(BACKOFF_BASE))
+	}
+
+	// Antes do backoff o job não é pego de novo
+	if worker.ProcessNext(t.Context()) {
+		t.Error("job processed before its next attempt")
+	}
+
+	for range MAX_ATTEMPTS - 1 {
+		now = now.Add(BACKOFF_MAX)
+		worker.ProcessNext(t.Context())
+	}
+
+	jobs, _ = repositories.TinySyncJobs.List(t.Context(), schemas.TINY_SYNC_DEAD, 0)
+	if len(jobs) != 1 || jobs[0].Attempts != MAX_ATTEMPTS {
+		t.Fatalf("dead jobs = %+v, want one with %d attempts", jobs, MAX_ATTEMPTS)
+	}
+
+	now = now.Add(BACKOFF_MAX)
+	if worker.ProcessNext(t.Context()) {
+		t.Error("dead job must not be retried without a replay")
+	}
+
+	syncer.err = nil
+	repositories.TinySyncJobs.Replay(t.Context(), jobs[0].ID)
+	if !worker.ProcessNext(t.Context()) || len(syncer.updated) != 1 {
+		t.Error("replayed job should be processed")
+	}
+}
+
+// changingSyncer simula uma alteração do cliente enquanto o job é enviado.
+type changingSyncer struct {
+	*fakeSyncer
+	change func()
+}
+
+func (s changingSyncer) Update(ctx context.Context, contact schemas.Contact, tinyID string) error {
+	s.change()
+	return s.fakeSyncer.Update(ctx, contact, tinyID)
+}
+
+func TestWorkerFailureFoldsIntoPendingJob(t *testing.T) {
+	repositories, syncer, _, client := setupWorker(t, schemas.ClientFromDB{
+		Contact:       schemas.Contact{Name: "Edu", Email: "edu@example.com", TinyID: "12"},
+		EmailVerified: true,
+	})
+	syncer.err = errors.New("Tiny fora do ar")
+	worker := NewWorker(repositories, changingSyncer{syncer, func() {
+		repositories.TinySyncJobs.Enqueue(t.Context(), client.ID)
+	}})
+
+	repositories.TinySyncJobs.Enqueue(t.Context(), client.ID)
+
+	now := time.Now()
+	worker.now = func() time.Time { return now }
+	worker.ProcessNext(t.Context())
+
+	superseded, _ := repositories.TinySyncJobs.List(t.Context(), schemas.TINY_SYNC_SUPERSEDED, 0)
+	if len(superseded) != 1 || superseded[0].LockedUntil != nil {
+		t.Fatalf("superseded jobs = %+v, want the failed job", superseded)
+	}
+
+	pending, _ := repositories.TinySyncJobs.List(t.Context(), schemas.TINY_SYNC_PENDING, 0)
+	if len(pending) != 1 || pending[0].Attempts != 1 || pending[0].LastError != "Tiny fora do ar" {
+		t.Fatalf("pending jobs = %+v, want one with the failed attempt", pending)
+	}
+	if !pending[0].NextAttemptAt.Equal(now.Add(BACKOFF_BASE)) {
+		t.Errorf("next_attempt_at = %v, want %v", pending[0].NextAttemptAt, now.Add(BACKOFF_BASE))
+	}
+	if processing, _ := repositories.TinySyncJobs.List(t.Context(), schemas.TINY_SYNC_PROCESSING, 0); len(processing) != 0 {
+		t.Errorf("processing jobs = %+v, want none", processing)
+	}
+}
+
+func TestWorkerSkipsUnverifiedClientsWithoutTinyID(t *testing.T) {
+	repositories, syncer, worker, client := setupWorker(t, schemas.ClientFromDB{
+		Contact: schemas.Contact{Name: "Duda", Email: "duda@example.com"},
+	})
+
+	repositories.TinySyncJobs.Enqueue(t.Context(), client.ID)
+	worker.ProcessNext(t.Context())
+
+	if len(syncer.created) != 0 {
+		t.Errorf("created = %d, want 0 before email verification", len(syncer.created))
+	}
+}
+
+func TestWorkerHoldsUnverifiedEmailChanges(t *testing.T) {
+	repositories, syncer, worker, client := setupWorker(t, schemas.ClientFromDB{
+		Contact: schemas.Contact{Name: "Lia", Email: "lia.nova@example.com", TinyID: "31"},
+	})
+
+	repositories.TinySyncJobs.Enqueue(t.Context(), client.ID)
+	worker.ProcessNext(t.Context())
+
+	if len(syncer.updated) != 0 {
+		t.Errorf("updated = %v, want none before the new email is verified", syncer.updated)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{4, 4 * time.Minute},
+		{20, BACKOFF_MAX},
+	}
+
+	for _, tt := range tests {
+		if got := Backoff(tt.attempts); got != tt.want {
+			t.Errorf("Backoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestWorkerDeadLettersRejectedContacts(t *testing.T) {
+	repositories, syncer, worker, client := setupWorker(t, schemas.ClientFromDB{
+		Contact:       schemas.Contact{Name: "Rui", Email: "rui@example.com", TinyID: "9"},
+		EmailVerified: true,
+	})
+	syncer.err = &tiny.APIError{Code: tiny.CODE_VALIDATION, Messages: []string{"CPF invalido"}}
+
+	repositories.TinySyncJobs.Enqueue(t.Context(), client.ID)
+	worker.ProcessNext(t.Context())
+
+	jobs, _ := repositories.TinySyncJobs.List(t.Context(), schemas.TINY_SYNC_DEAD, 0)
+	if len(jobs) != 1 || jobs[0].Attempts != 1 {
+		t.Fatalf("dead jobs = %+v, want one after the first attempt", jobs)
+	}
+}
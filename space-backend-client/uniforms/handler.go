@@ -1,21 +1,23 @@
 package uniforms
 
 import (
+	"api/audit"
 	"api/database"
 	"api/middlewares"
 	"api/schemas"
 	"api/utils"
+	"api/validation"
 	"context"
 	"encoding/json"
 	"net/http"
-	"os"
-	"time"
 
 	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo"
-	"go.mongodb.org/mongo-driver/v2/mongo/options"
 )
 
+// Repositories é injetado pelo main com o client MongoDB compartilhado.
+var Repositories *database.Repositories
+
 func getUniforms(w http.ResponseWriter, r *http.Request) {
 	userId := r.Context().Value(middlewares.UserIDKey)
 	if userId == nil {
@@ -40,20 +42,6 @@ func getUniforms(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(context.Background(), database.MONGODB_TIMEOUT)
 	defer cancel()
 
-	mongoURI := os.Getenv(utils.ENV_MONGODB_URI)
-	opts := options.Client().ApplyURI(mongoURI)
-	client, err := mongo.Connect(opts)
-	if err != nil {
-		w.WriteHeader(http.StatusBadGateway)
-		json.NewEncoder(w).Encode(schemas.ApiResponse{
-			Message: utils.SendInternalError(utils.CANNOT_CONNECT_TO_MONGODB),
-		})
-		return
-	}
-	defer client.Disconnect(ctx)
-
-	uniformsCollection := client.Database(database.GetDB()).Collection("uniforms")
-
 	if budgetIDParam != "" {
 		budgetID, err := utils.ParseIntOrDefault(budgetIDParam, 0)
 		if err != nil {
@@ -64,13 +52,7 @@ func getUniforms(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		filter := bson.D{
-			{Key: "budget_id", Value: budgetID},
-			{Key: "client_id", Value: userIdStr},
-		}
-
-		var uniform schemas.UniformFromDB
-		err = uniformsCollection.FindOne(ctx, filter).Decode(&uniform)
+		uniform, err := Repositories.Uniforms.FindOneByClientAndBudget(ctx, userIdStr, budgetID)
 		if err != nil {
 			if err == mongo.ErrNoDocuments {
 				w.WriteHeader(http.StatusNotFound)
@@ -104,10 +86,7 @@ func getUniforms(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	filter := bson.D{{Key: "client_id", Value: userIdStr}}
-	findOptions := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
-
-	cursor, err := uniformsCollection.Find(ctx, filter, findOptions)
+	uniforms, err := Repositories.Uniforms.FindByClientID(ctx, userIdStr)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(schemas.ApiResponse{
@@ -115,16 +94,6 @@ func getUniforms(w http.ResponseWriter, r *http.Request) {
 		})
 		return
 	}
-	defer cursor.Close(ctx)
-
-	var uniforms []schemas.UniformFromDB
-	if err = cursor.All(ctx, &uniforms); err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(schemas.ApiResponse{
-			Message: utils.SendInternalError(utils.ERROR_TO_TRY_FIND_MONGODB),
-		})
-		return
-	}
 
 	if len(uniforms) == 0 {
 		w.Header().Set("Content-Type", "application/json")
@@ -192,6 +161,11 @@ func updatePlayers(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if errs := validation.Validate(updateRequest); errs != nil {
+		validation.WriteErrors(w, errs)
+		return
+	}
+
 	if len(updateRequest.Updates) == 0 {
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(schemas.ApiResponse{
@@ -203,23 +177,7 @@ func updatePlayers(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(context.Background(), database.MONGODB_TIMEOUT)
 	defer cancel()
 
-	mongoURI := os.Getenv(utils.ENV_MONGODB_URI)
-	opts := options.Client().ApplyURI(mongoURI)
-	client, err := mongo.Connect(opts)
-	if err != nil {
-		w.WriteHeader(http.StatusBadGateway)
-		json.NewEncoder(w).Encode(schemas.ApiResponse{
-			Message: utils.SendInternalError(utils.CANNOT_CONNECT_TO_MONGODB),
-		})
-		return
-	}
-	defer client.Disconnect(ctx)
-
-	uniformsCollection := client.Database(database.GetDB()).Collection("uniforms")
-
-	filter := bson.D{{Key: "_id", Value: objectID}}
-	var existingUniform schemas.UniformFromDB
-	err = uniformsCollection.FindOne(ctx, filter).Decode(&existingUniform)
+	existingUniform, err := Repositories.Uniforms.FindByID(ctx, objectID)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			w.WriteHeader(http.StatusNotFound)
@@ -293,16 +251,15 @@ func updatePlayers(w http.ResponseWriter, r *http.Request) {
 		updatedSketches[sketchIndex].Players = update.Players
 	}
 
-	mongoUpdate := bson.D{
-		{Key: "$set", Value: bson.D{
-			{Key: "sketches", Value: updatedSketches},
-			{Key: "updated_at", Value: time.Now()},
-			{Key: "editable", Value: false},
-		}},
-	}
-
-	result, err := uniformsCollection.UpdateOne(ctx, filter, mongoUpdate)
+	err = Repositories.Uniforms.UpdateSketches(ctx, objectID, updatedSketches, false)
 	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(schemas.ApiResponse{
+				Message: "Uniforme não encontrado",
+			})
+			return
+		}
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(schemas.ApiResponse{
 			Message: utils.SendInternalError(utils.ERROR_TO_TRY_FIND_MONGODB),
@@ -310,16 +267,7 @@ func updatePlayers(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if result.MatchedCount == 0 {
-		w.WriteHeader(http.StatusNotFound)
-		json.NewEncoder(w).Encode(schemas.ApiResponse{
-			Message: "Uniforme não encontrado",
-		})
-		return
-	}
-
-	var updatedUniform schemas.UniformFromDB
-	err = uniformsCollection.FindOne(ctx, filter).Decode(&updatedUniform)
+	updatedUniform, err := Repositories.Uniforms.FindByID(ctx, objectID)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(schemas.ApiResponse{
@@ -328,6 +276,14 @@ func updatePlayers(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	audit.Record(ctx, Repositories.AuditEvents, r, audit.Entry{
+		Action:     schemas.AUDIT_ACTION_UNIFORM_UPDATE,
+		TargetType: schemas.AUDIT_TARGET_UNIFORM,
+		TargetID:   objectID.Hex(),
+		Before:     bson.M{"sketches": existingUniform.Sketches, "editable": existingUniform.Editable},
+		After:      bson.M{"sketches": updatedUniform.Sketches, "editable": updatedUniform.Editable},
+	})
+
 	uniformResponse := schemas.UniformResponse{
 		ID:        updatedUniform.ID.Hex(),
 		ClientID:  updatedUniform.ClientID,
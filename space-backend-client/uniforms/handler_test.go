@@ -1 +1,86 @@
 package uniforms
+
+import (
+	"api/database"
+	"api/middlewares"
+	"api/schemas"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func setupRepositories(t *testing.T) *database.MemoryUniformsRepository {
+	t.Helper()
+
+	repositories := database.NewMemoryRepositories()
+	Repositories = repositories
+	t.Cleanup(func() { Repositories = nil })
+
+	return repositories.Uniforms.(*database.MemoryUniformsRepository)
+}
+
+func authenticatedRequest(method, target string, body []byte, userID string) *http.Request {
+	r := httptest.NewRequest(method, target, bytes.NewReader(body))
+	return r.WithContext(context.WithValue(r.Context(), middlewares.UserIDKey, userID))
+}
+
+func TestUpdatePlayersLocksUniform(t *testing.T) {
+	uniformsRepository := setupRepositories(t)
+
+	clientID := bson.NewObjectID().Hex()
+	uniformID := bson.NewObjectID()
+	uniformsRepository.Insert(schemas.UniformFromDB{
+		ID:       uniformID,
+		ClientID: clientID,
+		BudgetID: 10,
+		Editable: true,
+		Sketches: []schemas.Sketch{{ID: "A", PlayerCount: 2}},
+	})
+
+	body, _ := json.Marshal(schemas.PlayersUpdateRequest{
+		Updates: []schemas.SketchPlayersUpdate{{SketchID: "A", Players: []schemas.Player{{Name: "Ana", Ready: true}}}},
+	})
+
+	w := httptest.NewRecorder()
+	Handler(w, authenticatedRequest(http.MethodPatch, "/v1/uniforms?id="+uniformID.Hex(), body, clientID))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	uniform, _ := uniformsRepository.FindByID(context.Background(), uniformID)
+	if uniform.Editable {
+		t.Error("uniform should not be editable after update")
+	}
+	if len(uniform.Sketches[0].Players) != 1 || uniform.Sketches[0].Players[0].Ready {
+		t.Errorf("unexpected players: %+v", uniform.Sketches[0].Players)
+	}
+}
+
+func TestUpdatePlayersRejectsOtherClient(t *testing.T) {
+	uniformsRepository := setupRepositories(t)
+
+	uniformID := bson.NewObjectID()
+	uniformsRepository.Insert(schemas.UniformFromDB{
+		ID:       uniformID,
+		ClientID: bson.NewObjectID().Hex(),
+		Editable: true,
+		Sketches: []schemas.Sketch{{ID: "A", PlayerCount: 2}},
+	})
+
+	body, _ := json.Marshal(schemas.PlayersUpdateRequest{
+		Updates: []schemas.SketchPlayersUpdate{{SketchID: "A"}},
+	})
+
+	w := httptest.NewRecorder()
+	Handler(w, authenticatedRequest(http.MethodPatch, "/v1/uniforms?id="+uniformID.Hex(), body, bson.NewObjectID().Hex()))
+
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusForbidden)
+	}
+}
@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+)
+
+// Formato das chaves de API: "sak_<prefixo>_<segredo>". O prefixo é público e
+// indexado; o segredo só existe na resposta de criação.
+const (
+	API_KEY_TAG          = "sak"
+	API_KEY_PREFIX_BYTES = 6
+)
+
+// GenerateApiKey retorna a chave completa e o prefixo usado para localizá-la.
+func GenerateApiKey() (key string, prefix string, err error) {
+	buf := make([]byte, API_KEY_PREFIX_BYTES)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	prefix = hex.EncodeToString(buf)
+
+	secret, err := GenerateOpaqueToken()
+	if err != nil {
+		return "", "", err
+	}
+
+	return API_KEY_TAG + "_" + prefix + "_" + secret, prefix, nil
+}
+
+// ApiKeyPrefix extrai o prefixo de uma chave no formato de GenerateApiKey.
+func ApiKeyPrefix(key string) (string, bool) {
+	tag, rest, ok := strings.Cut(key, "_")
+	if !ok || tag != API_KEY_TAG {
+		return "", false
+	}
+
+	prefix, secret, ok := strings.Cut(rest, "_")
+	if !ok || len(prefix) != 2*API_KEY_PREFIX_BYTES || secret == "" {
+		return "", false
+	}
+	return prefix, true
+}
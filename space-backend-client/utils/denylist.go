@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+const DENYLIST_TIMEOUT = 5 * time.Second
+
+var ErrAccessTokenRevoked = errors.New("access token revoked")
+
+// AccessTokenDenylist guarda access tokens revogados antes de expirarem
+// (signout). As chaves vêm de DenylistKeyForToken e DenylistKeyForSession.
+type AccessTokenDenylist interface {
+	Revoke(ctx context.Context, key string, expiresAt time.Time) error
+	IsRevoked(ctx context.Context, keys ...string) (bool, error)
+}
+
+// Denylist é injetado pelo main; quando nil, ValidateAccessKey não consulta
+// revogações.
+var Denylist AccessTokenDenylist
+
+// DenylistKeyForToken revoga um único access token pelo seu jti. Tokens
+// antigos sem jti não podem ser revogados assim: a chave seria a mesma para
+// todos eles.
+func DenylistKeyForToken(jti string) string {
+	return "jti:" + jti
+}
+
+// DenylistKeyForSession revoga todos os access tokens emitidos para uma sessão.
+func DenylistKeyForSession(sessionId string) string {
+	return "sid:" + sessionId
+}
+
+func isAccessTokenRevoked(claims *Claims) (bool, error) {
+	if Denylist == nil {
+		return false, nil
+	}
+
+	var keys []string
+	if claims.ID != "" {
+		keys = append(keys, DenylistKeyForToken(claims.ID))
+	}
+	if claims.SessionId != "" {
+		keys = append(keys, DenylistKeyForSession(claims.SessionId))
+	}
+
+	if len(keys) == 0 {
+		return false, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), DENYLIST_TIMEOUT)
+	defer cancel()
+
+	return Denylist.IsRevoked(ctx, keys...)
+}
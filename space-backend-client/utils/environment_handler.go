@@ -23,12 +23,40 @@ const (
 	EXTCHAT_WEBHOOK_X_API_KEY = "EXTCHAT_WEBHOOK_X_API_KEY"
 	D360_API_KEY              = "D360_API_KEY"
 
+	// Chaves opcionais: podem ficar vazias ou ausentes no .env
+	NOTIFIER                 = "NOTIFIER"
+	SMTP_HOST                = "SMTP_HOST"
+	SMTP_PORT                = "SMTP_PORT"
+	SMTP_USER                = "SMTP_USER"
+	SMTP_PASSWORD            = "SMTP_PASSWORD"
+	SMTP_FROM                = "SMTP_FROM"
+	PASSWORD_RESET_URL       = "PASSWORD_RESET_URL"
+	EMAIL_VERIFICATION_URL   = "EMAIL_VERIFICATION_URL"
+	JWT_KEYS_DIR             = "JWT_KEYS_DIR"
+	JWT_SIGNING_KEY_ID       = "JWT_SIGNING_KEY_ID"
+	ADMIN_KEY_SCOPES         = "ADMIN_KEY_SCOPES"
+	ADMIN_BOOTSTRAP_EMAIL    = "ADMIN_BOOTSTRAP_EMAIL"
+	ADMIN_BOOTSTRAP_PASSWORD = "ADMIN_BOOTSTRAP_PASSWORD"
+	SPACE_ERP_API_KEY        = "SPACE_ERP_API_KEY"
+	CEP_PROVIDER             = "CEP_PROVIDER"
+	VIACEP_URL               = "VIACEP_URL"
+	CEP_FIXTURES_FILE        = "CEP_FIXTURES_FILE"
+	TINY_API_URL             = "TINY_API_URL"
+	TINY_RATE_LIMIT          = "TINY_RATE_LIMIT"
+	TINY_RECONCILE_INTERVAL  = "TINY_RECONCILE_INTERVAL"
+	TINY_RECONCILE_POLICY    = "TINY_RECONCILE_POLICY"
+	TINY_RECONCILE_DRY_RUN   = "TINY_RECONCILE_DRY_RUN"
+	FIELD_ENCRYPTION_KEY     = "FIELD_ENCRYPTION_KEY"
+	TRUSTED_PROXIES          = "TRUSTED_PROXIES"
+
 	ENV_DEVELOPMENT = "development"
 	ENV_RELEASE     = "production"
 )
 
 var allowedKeys = []string{ENV_PORT, ENV_MONGODB_URI, ACCESS_TOKEN_SECRET, REFRESH_TOKEN_SECRET, TOKEN_ISSUER, TOKEN_AUDIENCE, ENV, ADMIN_KEY, TINY_API_TOKEN, SPACE_ERP_URI, EXTCHAT_WEBHOOK_X_API_KEY, D360_API_KEY}
 
+var optionalKeys = []string{NOTIFIER, SMTP_HOST, SMTP_PORT, SMTP_USER, SMTP_PASSWORD, SMTP_FROM, PASSWORD_RESET_URL, EMAIL_VERIFICATION_URL, JWT_KEYS_DIR, JWT_SIGNING_KEY_ID, ADMIN_KEY_SCOPES, ADMIN_BOOTSTRAP_EMAIL, ADMIN_BOOTSTRAP_PASSWORD, SPACE_ERP_API_KEY, CEP_PROVIDER, VIACEP_URL, CEP_FIXTURES_FILE, TINY_API_URL, TINY_RATE_LIMIT, TINY_RECONCILE_INTERVAL, TINY_RECONCILE_POLICY, TINY_RECONCILE_DRY_RUN, FIELD_ENCRYPTION_KEY, TRUSTED_PROXIES}
+
 var allowedEnvValues = []string{ENV_DEVELOPMENT, ENV_RELEASE}
 
 func LoadEnvVariables() {
@@ -95,11 +123,11 @@ func LoadEnvVariables() {
 			}
 		}
 
-		isAllowed := slices.Contains(allowedKeys, key)
+		isAllowed := slices.Contains(allowedKeys, key) || slices.Contains(optionalKeys, key)
 
 		if !isAllowed {
 			panic(fmt.Sprintf("[ENV] Chave '%s' não é permitida. Chaves permitidas: %s",
-				key, strings.Join(allowedKeys[:], ", ")))
+				key, strings.Join(slices.Concat(allowedKeys, optionalKeys), ", ")))
 		}
 
 		if err := os.Setenv(key, value); err != nil {
@@ -31,6 +31,8 @@ const (
 	ERROR_LARAVEL_API_RESPONSE_PARSING
 	ERROR_LARAVEL_API_RESPONSE_STATUS
 	ERROR_ADMIN_KEY_NOT_FOUND
+	ERROR_TO_GENERATE_TOKEN
+	REFRESH_TOKEN_REUSE_DETECTED
 )
 
 func SendInternalError(internalErrorCode int) string {
@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"errors"
 	"os"
 	"time"
 
@@ -10,17 +11,42 @@ import (
 const (
 	ACCESS_TOKEN_EXPIRATION  = 15 * time.Minute
 	REFRESH_TOKEN_EXPIRATION = 168 * time.Hour
+	// Sessões administrativas não têm refresh: o login vale por um expediente
+	ADMIN_TOKEN_EXPIRATION = 8 * time.Hour
+
+	TOKEN_TYPE_ACCESS  = "access"
+	TOKEN_TYPE_REFRESH = "refresh"
+	TOKEN_TYPE_ADMIN   = "admin"
 )
 
+var ErrWrongTokenType = errors.New("wrong token type")
+
 type Claims struct {
 	UserId string `json:"userId"`
+	// SessionId identifica a sessão (dispositivo) que emitiu o token.
+	SessionId string `json:"sid,omitempty"`
+	// EmailVerified só é preenchido no access token; o refresh sempre consulta o banco.
+	EmailVerified bool `json:"email_verified,omitempty"`
+	// TokenType impede que um refresh token seja aceito como access token
+	// quando os dois são assinados pela mesma chave do keyring.
+	TokenType string `json:"typ,omitempty"`
 	jwt.RegisteredClaims
 }
 
-func GenerateAccessKey(userId string) (string, error) {
+func GenerateAccessKey(userId string, sessionId string, emailVerified bool) (string, error) {
+	// O jti permite revogar este token no signout (ver Denylist)
+	tokenId, err := GenerateOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+
 	accessTokenClaims := Claims{
-		userId,
-		jwt.RegisteredClaims{
+		UserId:        userId,
+		SessionId:     sessionId,
+		EmailVerified: emailVerified,
+		TokenType:     TOKEN_TYPE_ACCESS,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenId,
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ACCESS_TOKEN_EXPIRATION)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
@@ -29,19 +55,22 @@ func GenerateAccessKey(userId string) (string, error) {
 		},
 	}
 
-	accessToken := jwt.NewWithClaims(jwt.SigningMethodHS512, accessTokenClaims)
-	accessTokenString, err := accessToken.SignedString([]byte(os.Getenv(ACCESS_TOKEN_SECRET)))
+	return signToken(accessTokenClaims, ACCESS_TOKEN_SECRET)
+}
+
+func GenerateRefreshKey(userId string, sessionId string) (string, error) {
+	// O jti garante que duas rotações no mesmo segundo gerem tokens diferentes
+	tokenId, err := GenerateOpaqueToken()
 	if err != nil {
 		return "", err
 	}
 
-	return accessTokenString, nil
-}
-
-func GenerateRefreshKey(userId string) (string, error) {
 	refreshTokenClaims := Claims{
-		userId,
-		jwt.RegisteredClaims{
+		UserId:    userId,
+		SessionId: sessionId,
+		TokenType: TOKEN_TYPE_REFRESH,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenId,
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(REFRESH_TOKEN_EXPIRATION)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
@@ -50,27 +79,72 @@ func GenerateRefreshKey(userId string) (string, error) {
 		},
 	}
 
-	refreshToken := jwt.NewWithClaims(jwt.SigningMethodHS512, refreshTokenClaims)
-	refreshTokenString, err := refreshToken.SignedString([]byte(os.Getenv(REFRESH_TOKEN_SECRET)))
+	return signToken(refreshTokenClaims, REFRESH_TOKEN_SECRET)
+}
+
+// GenerateAdminKey emite o token de sessão de um usuário de admin_users. O
+// papel não vai no token: o AdminMiddleware consulta o usuário a cada
+// requisição, então mudanças de papel e desativações valem na hora.
+func GenerateAdminKey(adminId string) (string, error) {
+	tokenId, err := GenerateOpaqueToken()
 	if err != nil {
 		return "", err
 	}
 
-	return refreshTokenString, nil
+	adminTokenClaims := Claims{
+		UserId:    adminId,
+		TokenType: TOKEN_TYPE_ADMIN,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenId,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ADMIN_TOKEN_EXPIRATION)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			NotBefore: jwt.NewNumericDate(time.Now()),
+			Issuer:    os.Getenv(TOKEN_ISSUER),
+			Audience:  []string{os.Getenv(TOKEN_AUDIENCE)},
+		},
+	}
+
+	return signToken(adminTokenClaims, ACCESS_TOKEN_SECRET)
 }
 
-func ValidateAccessKey(tokenString string) (*Claims, error) {
+// signToken assina com a chave atual do keyring (EdDSA/RS256 com "kid") ou,
+// sem keyring configurado, em HS512 com o segredo da variável secretKey.
+func signToken(claims Claims, secretKey string) (string, error) {
+	if k := currentKeyring(); k != nil {
+		return k.sign(claims)
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS512, claims)
+	return token.SignedString([]byte(os.Getenv(secretKey)))
+}
+
+// parseToken valida a assinatura e as claims registradas. Tokens com "kid" são
+// conferidos no keyring; tokens sem "kid" foram emitidos em HS512 antes da
+// rotação de chaves e continuam válidos até expirar.
+func parseToken(tokenString string, secretKey string, tokenType string) (*Claims, error) {
 	claims := &Claims{}
 
 	parserOptions := []jwt.ParserOption{
-		jwt.WithValidMethods([]string{jwt.SigningMethodHS512.Alg()}),
+		jwt.WithValidMethods([]string{jwt.SigningMethodEdDSA.Alg(), jwt.SigningMethodRS256.Alg(), jwt.SigningMethodHS512.Alg()}),
 		jwt.WithIssuer(os.Getenv(TOKEN_ISSUER)),
 		jwt.WithAudience(os.Getenv(TOKEN_AUDIENCE)),
 		jwt.WithExpirationRequired(),
 	}
 
 	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (any, error) {
-		return []byte(os.Getenv(ACCESS_TOKEN_SECRET)), nil
+		if _, ok := token.Header["kid"]; ok {
+			k := currentKeyring()
+			if k == nil {
+				return nil, ErrUnknownKeyID
+			}
+			return k.verificationKey(token)
+		}
+
+		secret := os.Getenv(secretKey)
+		if token.Method != jwt.SigningMethodHS512 || secret == "" {
+			return nil, jwt.ErrTokenSignatureInvalid
+		}
+		return []byte(secret), nil
 	}, parserOptions...)
 
 	if err != nil {
@@ -81,29 +155,55 @@ func ValidateAccessKey(tokenString string) (*Claims, error) {
 		return nil, jwt.ErrInvalidKey
 	}
 
+	// Tokens HS512 antigos não têm typ; o segredo distinto já separa os tipos
+	if claims.TokenType != "" && claims.TokenType != tokenType {
+		return nil, ErrWrongTokenType
+	}
+
+	return claims, nil
+}
+
+func ValidateAccessKey(tokenString string) (*Claims, error) {
+	claims, err := parseToken(tokenString, ACCESS_TOKEN_SECRET, TOKEN_TYPE_ACCESS)
+	if err != nil {
+		return nil, err
+	}
+
+	revoked, err := isAccessTokenRevoked(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	if revoked {
+		return nil, ErrAccessTokenRevoked
+	}
+
 	return claims, nil
 }
 
 func ValidateRefreshKey(tokenString string) (*Claims, error) {
-	claims := &Claims{}
+	return parseToken(tokenString, REFRESH_TOKEN_SECRET, TOKEN_TYPE_REFRESH)
+}
 
-	parserOptions := []jwt.ParserOption{
-		jwt.WithValidMethods([]string{jwt.SigningMethodHS512.Alg()}),
-		jwt.WithIssuer(os.Getenv(TOKEN_ISSUER)),
-		jwt.WithAudience(os.Getenv(TOKEN_AUDIENCE)),
-		jwt.WithExpirationRequired(),
+func ValidateAdminKey(tokenString string) (*Claims, error) {
+	claims, err := parseToken(tokenString, ACCESS_TOKEN_SECRET, TOKEN_TYPE_ADMIN)
+	if err != nil {
+		return nil, err
 	}
 
-	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (any, error) {
-		return []byte(os.Getenv(REFRESH_TOKEN_SECRET)), nil
-	}, parserOptions...)
+	// Access tokens antigos de clientes também não têm typ e usam o mesmo
+	// segredo; aqui o tipo é obrigatório.
+	if claims.TokenType != TOKEN_TYPE_ADMIN {
+		return nil, ErrWrongTokenType
+	}
 
+	revoked, err := isAccessTokenRevoked(claims)
 	if err != nil {
 		return nil, err
 	}
 
-	if !token.Valid {
-		return nil, jwt.ErrInvalidKey
+	if revoked {
+		return nil, ErrAccessTokenRevoked
 	}
 
 	return claims, nil
@@ -0,0 +1,263 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Arquivos do diretório JWT_KEYS_DIR: "<kid>.pem" com a chave privada
+// (PKCS#8, Ed25519 ou RSA) ou "<kid>.pub.pem" só com a chave pública, para
+// continuar validando tokens de uma chave já aposentada.
+const (
+	JWT_PRIVATE_KEY_SUFFIX = ".pem"
+	JWT_PUBLIC_KEY_SUFFIX  = ".pub.pem"
+)
+
+var ErrUnknownKeyID = errors.New("unknown jwt key id")
+
+type jwtKey struct {
+	id      string
+	method  jwt.SigningMethod
+	private crypto.Signer
+	public  crypto.PublicKey
+}
+
+// Keyring guarda a chave usada para assinar os tokens e todas as chaves
+// aceitas na validação, identificadas pelo header "kid".
+type Keyring struct {
+	signing *jwtKey
+	keys    map[string]*jwtKey
+}
+
+var (
+	keyringMu sync.RWMutex
+	keyring   *Keyring
+)
+
+// SetKeyring troca o keyring global. Com nil, os tokens voltam a ser
+// assinados em HS512 com ACCESS_TOKEN_SECRET/REFRESH_TOKEN_SECRET.
+func SetKeyring(k *Keyring) {
+	keyringMu.Lock()
+	defer keyringMu.Unlock()
+
+	keyring = k
+}
+
+func currentKeyring() *Keyring {
+	keyringMu.RLock()
+	defer keyringMu.RUnlock()
+
+	return keyring
+}
+
+func NewKeyring() *Keyring {
+	return &Keyring{keys: make(map[string]*jwtKey)}
+}
+
+// LoadKeyringFromEnv carrega JWT_KEYS_DIR e seleciona JWT_SIGNING_KEY_ID para
+// assinar. Retorna nil sem erro quando o diretório não está configurado.
+func LoadKeyringFromEnv() (*Keyring, error) {
+	dir := os.Getenv(JWT_KEYS_DIR)
+	if dir == "" {
+		return nil, nil
+	}
+
+	return LoadKeyring(dir, os.Getenv(JWT_SIGNING_KEY_ID))
+}
+
+func LoadKeyring(dir string, signingKeyID string) (*Keyring, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	k := NewKeyring()
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, JWT_PRIVATE_KEY_SUFFIX) {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+
+		if kid, ok := strings.CutSuffix(name, JWT_PUBLIC_KEY_SUFFIX); ok {
+			err = k.AddPublicKeyPEM(kid, data)
+		} else {
+			err = k.AddPrivateKeyPEM(strings.TrimSuffix(name, JWT_PRIVATE_KEY_SUFFIX), data)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+
+	if err := k.SetSigningKey(signingKeyID); err != nil {
+		return nil, err
+	}
+
+	return k, nil
+}
+
+func (k *Keyring) AddPrivateKeyPEM(kid string, data []byte) error {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return errors.New("invalid PEM")
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return err
+	}
+
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return errors.New("unsupported private key type")
+	}
+
+	return k.AddPrivateKey(kid, signer)
+}
+
+func (k *Keyring) AddPublicKeyPEM(kid string, data []byte) error {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return errors.New("invalid PEM")
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return err
+	}
+
+	return k.AddPublicKey(kid, key)
+}
+
+func (k *Keyring) AddPrivateKey(kid string, signer crypto.Signer) error {
+	if err := k.AddPublicKey(kid, signer.Public()); err != nil {
+		return err
+	}
+
+	k.keys[kid].private = signer
+	return nil
+}
+
+func (k *Keyring) AddPublicKey(kid string, public crypto.PublicKey) error {
+	method, err := signingMethodFor(public)
+	if err != nil {
+		return err
+	}
+
+	// Um "<kid>.pub.pem" ao lado do "<kid>.pem" não descarta a chave privada
+	if existing, ok := k.keys[kid]; ok && existing.private != nil {
+		return nil
+	}
+
+	k.keys[kid] = &jwtKey{id: kid, method: method, public: public}
+	return nil
+}
+
+// SetSigningKey escolhe a chave usada nos novos tokens; ela precisa ter a
+// chave privada carregada.
+func (k *Keyring) SetSigningKey(kid string) error {
+	key, ok := k.keys[kid]
+	if !ok || key.private == nil {
+		return fmt.Errorf("signing key %q not found", kid)
+	}
+
+	k.signing = key
+	return nil
+}
+
+func (k *Keyring) sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(k.signing.method, claims)
+	token.Header["kid"] = k.signing.id
+
+	return token.SignedString(k.signing.private)
+}
+
+func (k *Keyring) verificationKey(token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	key, ok := k.keys[kid]
+	if !ok {
+		return nil, ErrUnknownKeyID
+	}
+
+	// Impede que um token troque o algoritmo da chave registrada
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, jwt.ErrTokenSignatureInvalid
+	}
+
+	return key.public, nil
+}
+
+// JWKS retorna as chaves públicas no formato do RFC 7517, ordenadas por kid.
+func (k *Keyring) JWKS() map[string]any {
+	kids := make([]string, 0, len(k.keys))
+	for kid := range k.keys {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+
+	keys := make([]map[string]string, 0, len(kids))
+	for _, kid := range kids {
+		key := k.keys[kid]
+		jwk := map[string]string{
+			"kid": key.id,
+			"alg": key.method.Alg(),
+			"use": "sig",
+		}
+
+		switch public := key.public.(type) {
+		case ed25519.PublicKey:
+			jwk["kty"] = "OKP"
+			jwk["crv"] = "Ed25519"
+			jwk["x"] = base64.RawURLEncoding.EncodeToString(public)
+		case *rsa.PublicKey:
+			jwk["kty"] = "RSA"
+			jwk["n"] = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk["e"] = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		}
+
+		keys = append(keys, jwk)
+	}
+
+	return map[string]any{"keys": keys}
+}
+
+// CurrentJWKS retorna o JWKS do keyring global, ou uma lista vazia quando os
+// tokens ainda são assinados com segredo compartilhado.
+func CurrentJWKS() map[string]any {
+	k := currentKeyring()
+	if k == nil {
+		return map[string]any{"keys": []map[string]string{}}
+	}
+
+	return k.JWKS()
+}
+
+func signingMethodFor(public crypto.PublicKey) (jwt.SigningMethod, error) {
+	switch public.(type) {
+	case ed25519.PublicKey:
+		return jwt.SigningMethodEdDSA, nil
+	case *rsa.PublicKey:
+		return jwt.SigningMethodRS256, nil
+	default:
+		return nil, errors.New("unsupported public key type")
+	}
+}
@@ -0,0 +1,153 @@
+package utils
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"strings"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func newTestKeyring(t *testing.T) *Keyring {
+	t.Helper()
+	t.Setenv("ACCESS_TOKEN_SECRET", "access")
+	t.Setenv("REFRESH_TOKEN_SECRET", "refresh")
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	k := NewKeyring()
+	if err := k.AddPrivateKey("2026-01", edKey); err != nil {
+		t.Fatal(err)
+	}
+	if err := k.AddPrivateKey("2026-07", rsaKey); err != nil {
+		t.Fatal(err)
+	}
+	if err := k.SetSigningKey("2026-01"); err != nil {
+		t.Fatal(err)
+	}
+
+	SetKeyring(k)
+	t.Cleanup(func() { SetKeyring(nil) })
+
+	return k
+}
+
+func tokenHeader(t *testing.T, tokenString string) map[string]any {
+	t.Helper()
+
+	token, _, err := jwt.NewParser().ParseUnverified(tokenString, &Claims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token.Header
+}
+
+func TestKeyRotationKeepsOldTokensValid(t *testing.T) {
+	k := newTestKeyring(t)
+
+	oldToken, err := GenerateAccessKey("user", "session", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header := tokenHeader(t, oldToken); header["kid"] != "2026-01" || header["alg"] != "EdDSA" {
+		t.Fatalf("header = %v", header)
+	}
+
+	if err := k.SetSigningKey("2026-07"); err != nil {
+		t.Fatal(err)
+	}
+
+	newToken, err := GenerateAccessKey("user", "session", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header := tokenHeader(t, newToken); header["kid"] != "2026-07" || header["alg"] != "RS256" {
+		t.Fatalf("header = %v", header)
+	}
+
+	for _, token := range []string{oldToken, newToken} {
+		if _, err := ValidateAccessKey(token); err != nil {
+			t.Errorf("ValidateAccessKey: %v", err)
+		}
+	}
+}
+
+func TestLegacyHS512TokensRemainValid(t *testing.T) {
+	t.Setenv("ACCESS_TOKEN_SECRET", "access")
+
+	legacy, err := GenerateAccessKey("user", "session", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newTestKeyring(t)
+
+	if _, err := ValidateAccessKey(legacy); err != nil {
+		t.Errorf("legacy token rejected: %v", err)
+	}
+}
+
+func TestRefreshTokenIsNotAcceptedAsAccessToken(t *testing.T) {
+	newTestKeyring(t)
+
+	refresh, err := GenerateRefreshKey("user", "session")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ValidateAccessKey(refresh); err == nil {
+		t.Error("refresh token accepted as access token")
+	}
+	if _, err := ValidateRefreshKey(refresh); err != nil {
+		t.Errorf("ValidateRefreshKey: %v", err)
+	}
+}
+
+func TestUnknownKeyIDIsRejected(t *testing.T) {
+	newTestKeyring(t)
+
+	token, err := GenerateAccessKey("user", "session", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Chave removida do keyring depois de aposentada
+	SetKeyring(NewKeyring())
+
+	if _, err := ValidateAccessKey(token); err == nil {
+		t.Error("token with unknown kid accepted")
+	}
+}
+
+func TestJWKSPublishesPublicKeys(t *testing.T) {
+	k := newTestKeyring(t)
+
+	keys := k.JWKS()["keys"].([]map[string]string)
+	if len(keys) != 2 {
+		t.Fatalf("len(keys) = %d, want 2", len(keys))
+	}
+
+	if keys[0]["kid"] != "2026-01" || keys[0]["kty"] != "OKP" || keys[0]["crv"] != "Ed25519" || keys[0]["x"] == "" {
+		t.Errorf("ed25519 jwk = %v", keys[0])
+	}
+	if keys[1]["kid"] != "2026-07" || keys[1]["kty"] != "RSA" || keys[1]["e"] != "AQAB" || keys[1]["n"] == "" {
+		t.Errorf("rsa jwk = %v", keys[1])
+	}
+
+	for _, jwk := range keys {
+		for field := range jwk {
+			if strings.EqualFold(field, "d") {
+				t.Errorf("jwk %s exposes private key", jwk["kid"])
+			}
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIPIgnoresSpoofedForwardedFor(t *testing.T) {
+	proxies, err := ParseTrustedProxies("10.0.0.0/8, 192.168.1.5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	TrustedProxies = proxies
+	t.Cleanup(func() { TrustedProxies = nil })
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  string
+		want       string
+	}{
+		{"sem proxy", "203.0.113.7:4000", "", "203.0.113.7"},
+		{"header de conexão direta é ignorado", "203.0.113.7:4000", "1.2.3.4", "203.0.113.7"},
+		{"último hop do proxy confiável", "10.0.0.2:4000", "1.2.3.4, 198.51.100.9", "198.51.100.9"},
+		{"proxies encadeados", "10.0.0.2:4000", "198.51.100.9, 192.168.1.5", "198.51.100.9"},
+		{"proxy sem header", "10.0.0.2:4000", "", "10.0.0.2"},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.RemoteAddr = tt.remoteAddr
+		if tt.forwarded != "" {
+			r.Header.Set("X-Forwarded-For", tt.forwarded)
+		}
+
+		if got := ClientIP(r); got != tt.want {
+			t.Errorf("%s: ClientIP = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseTrustedProxiesRejectsInvalidEntries(t *testing.T) {
+	if _, err := ParseTrustedProxies("10.0.0.1, proxy.local"); err == nil {
+		t.Error("expected an error for a hostname")
+	}
+}
@@ -0,0 +1,28 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+const OPAQUE_TOKEN_BYTES = 32
+
+// GenerateOpaqueToken gera um token aleatório seguro para ser enviado ao
+// cliente (reset de senha, verificação...). Apenas o hash deve ser salvo.
+func GenerateOpaqueToken() (string, error) {
+	buf := make([]byte, OPAQUE_TOKEN_BYTES)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// HashToken retorna o SHA-256 em hexadecimal do token, usado para
+// persistência e comparação.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
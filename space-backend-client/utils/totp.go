@@ -0,0 +1,131 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Parâmetros padrão do RFC 6238, os únicos aceitos pela maioria dos apps
+// autenticadores (Google Authenticator, Authy...).
+const (
+	TOTP_ISSUER       = "Arte Arena"
+	TOTP_SECRET_BYTES = 20
+	TOTP_DIGITS       = 6
+	TOTP_PERIOD       = 30 * time.Second
+	// Passos aceitos antes e depois do atual, para tolerar relógios desajustados
+	TOTP_SKEW = 1
+
+	RECOVERY_CODES_COUNT = 10
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+func GenerateTOTPSecret() (string, error) {
+	buf := make([]byte, TOTP_SECRET_BYTES)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+
+	return totpEncoding.EncodeToString(buf), nil
+}
+
+// TOTPURI monta a URI otpauth:// usada para gerar o QR code no frontend.
+func TOTPURI(secret string, accountName string) string {
+	label := url.PathEscape(TOTP_ISSUER + ":" + accountName)
+
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", TOTP_ISSUER)
+	query.Set("digits", fmt.Sprint(TOTP_DIGITS))
+	query.Set("period", fmt.Sprint(int(TOTP_PERIOD.Seconds())))
+
+	return "otpauth://totp/" + label + "?" + query.Encode()
+}
+
+// TOTPStep retorna o número do passo de 30s correspondente ao instante.
+func TOTPStep(t time.Time) int64 {
+	return t.Unix() / int64(TOTP_PERIOD.Seconds())
+}
+
+// TOTPCode calcula o código de um passo (RFC 4226, truncamento dinâmico).
+func TOTPCode(secret string, step int64) (string, error) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", err
+	}
+
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(step))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	modulo := uint32(1)
+	for range TOTP_DIGITS {
+		modulo *= 10
+	}
+
+	return fmt.Sprintf("%0*d", TOTP_DIGITS, value%modulo), nil
+}
+
+// ValidateTOTP procura o código nos passos vizinhos de now e retorna o passo
+// encontrado. Passos menores ou iguais a lastUsedStep são recusados para que
+// um mesmo código não possa ser reutilizado.
+func ValidateTOTP(secret string, code string, now time.Time, lastUsedStep int64) (int64, bool) {
+	code = strings.TrimSpace(code)
+	if len(code) != TOTP_DIGITS {
+		return 0, false
+	}
+
+	current := TOTPStep(now)
+	for step := current - TOTP_SKEW; step <= current+TOTP_SKEW; step++ {
+		if step <= lastUsedStep {
+			continue
+		}
+
+		expected, err := TOTPCode(secret, step)
+		if err != nil {
+			return 0, false
+		}
+
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+
+	return 0, false
+}
+
+// GenerateRecoveryCodes gera códigos de uso único no formato xxxxx-xxxxx.
+// Assim como os tokens opacos, apenas o HashToken de cada um deve ser salvo.
+func GenerateRecoveryCodes() ([]string, error) {
+	codes := make([]string, 0, RECOVERY_CODES_COUNT)
+	for range RECOVERY_CODES_COUNT {
+		buf := make([]byte, 6)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, err
+		}
+
+		code := strings.ToLower(totpEncoding.EncodeToString(buf))[:10]
+		codes = append(codes, code[:5]+"-"+code[5:])
+	}
+
+	return codes, nil
+}
+
+// NormalizeRecoveryCode aceita o código com ou sem hífen e em qualquer caixa.
+func NormalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+}
@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+)
+
+// Vetores do apêndice D do RFC 4226 (segredo "12345678901234567890").
+func TestTOTPCodeMatchesRFC4226(t *testing.T) {
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+	want := []string{"755224", "287082", "359152", "969429", "338314", "254676", "287922", "162583", "399871", "520489"}
+
+	for step, expected := range want {
+		code, err := TOTPCode(secret, int64(step))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if code != expected {
+			t.Errorf("step %d: code = %s, want %s", step, code, expected)
+		}
+	}
+}
+
+func TestValidateTOTPRejectsReplay(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	code, _ := TOTPCode(secret, TOTPStep(now))
+
+	step, ok := ValidateTOTP(secret, code, now, 0)
+	if !ok {
+		t.Fatal("valid code was rejected")
+	}
+
+	if _, ok := ValidateTOTP(secret, code, now, step); ok {
+		t.Error("code was accepted twice")
+	}
+}
@@ -0,0 +1,310 @@
+// Package validation valida os tipos de requisição de schemas a partir da tag
+// `validate` dos campos, devolvendo a lista de erros por campo.
+//
+// Regras aceitas, separadas por vírgula:
+//
+//	required        campo obrigatório (string não vazia, número diferente de
+//	                zero, lista com itens, ponteiro não nulo)
+//	min=N, max=N    tamanho em caracteres (string), valor (número) ou
+//	                quantidade de itens (lista)
+//	gt=N            número maior que N
+//	oneof=a b c     um dos valores listados
+//	email, name, password
+//	cpf, cnpj, cep, uf, phone  (ver o pacote documents)
+//
+// Strings vazias sem required não são validadas. Ponteiros nulos só falham com
+// required; quando informados, o valor não pode ser vazio. Em listas de strings, as
+// regras que não são de tamanho valem para cada item. Structs aninhadas (e
+// listas delas) são validadas recursivamente, e tipos que implementam
+// Validatable acrescentam as próprias regras entre campos.
+package validation
+
+import (
+	"api/documents"
+	"api/schemas"
+	"api/utils"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const INVALID_DATA_MESSAGE = "Dados inválidos"
+
+// Validatable é implementado pelos tipos com regras que envolvem mais de um
+// campo. Validate roda depois das tags e os caminhos retornados são relativos
+// ao próprio tipo.
+type Validatable interface {
+	Validate() []schemas.FieldError
+}
+
+var timeType = reflect.TypeFor[time.Time]()
+
+// Validate retorna os erros encontrados em v, ou nil se for válido. v deve ser
+// uma struct ou ponteiro para struct.
+func Validate(v any) []schemas.FieldError {
+	errs := []schemas.FieldError{}
+	validateValue(reflect.ValueOf(v), "", nil, &errs)
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// WriteErrors responde 400 com os erros por campo.
+func WriteErrors(w http.ResponseWriter, errs []schemas.FieldError) {
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(schemas.ApiResponse{
+		Message: INVALID_DATA_MESSAGE,
+		Errors:  errs,
+	})
+}
+
+type rule struct {
+	name  string
+	param string
+}
+
+func parseRules(tag string) []rule {
+	rules := []rule{}
+	for part := range strings.SplitSeq(tag, ",") {
+		if part = strings.TrimSpace(part); part == "" {
+			continue
+		}
+		name, param, _ := strings.Cut(part, "=")
+		rules = append(rules, rule{name: name, param: param})
+	}
+	return rules
+}
+
+func hasRule(rules []rule, name string) bool {
+	return slices.ContainsFunc(rules, func(r rule) bool { return r.name == name })
+}
+
+func validateValue(v reflect.Value, path string, rules []rule, errs *[]schemas.FieldError) {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			if hasRule(rules, "required") {
+				addError(errs, path, "Campo obrigatório")
+			}
+			return
+		}
+		// Um campo opcional informado não pode vir vazio
+		if !hasRule(rules, "required") {
+			rules = append(slices.Clone(rules), rule{name: "required"})
+		}
+		validateValue(v.Elem(), path, rules, errs)
+
+	case reflect.Struct:
+		if v.Type() == timeType {
+			return
+		}
+		validateStruct(v, path, errs)
+
+	case reflect.String:
+		validateString(v.String(), path, rules, errs)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		validateInt(v.Int(), path, rules, errs)
+
+	case reflect.Slice, reflect.Array:
+		validateSlice(v, path, rules, errs)
+	}
+}
+
+func validateStruct(v reflect.Value, path string, errs *[]schemas.FieldError) {
+	t := v.Type()
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name := fieldName(field)
+		if name == "-" {
+			continue
+		}
+
+		fieldPath := path
+		if !field.Anonymous {
+			fieldPath = joinPath(path, name)
+		}
+		validateValue(v.Field(i), fieldPath, parseRules(field.Tag.Get("validate")), errs)
+	}
+
+	if validatable, ok := v.Interface().(Validatable); ok {
+		for _, err := range validatable.Validate() {
+			addError(errs, joinPath(path, err.Field), err.Message)
+		}
+	}
+}
+
+func validateString(s string, path string, rules []rule, errs *[]schemas.FieldError) {
+	if s == "" {
+		if hasRule(rules, "required") {
+			addError(errs, path, "Campo obrigatório")
+		}
+		return
+	}
+
+	for _, r := range rules {
+		if message := checkString(s, r); message != "" {
+			addError(errs, path, message)
+			return
+		}
+	}
+}
+
+func checkString(s string, r rule) string {
+	switch r.name {
+	case "required":
+		return ""
+	case "min":
+		if utf8.RuneCountInString(s) < intParam(r) {
+			return fmt.Sprintf("Deve ter pelo menos %d caracteres", intParam(r))
+		}
+	case "max":
+		if utf8.RuneCountInString(s) > intParam(r) {
+			return fmt.Sprintf("Deve ter no máximo %d caracteres", intParam(r))
+		}
+	case "oneof":
+		options := strings.Fields(r.param)
+		if !slices.Contains(options, s) {
+			return "Deve ser um dos valores: " + strings.Join(options, ", ")
+		}
+	case "email":
+		if utils.ValidateEmail(s) != nil {
+			return "Email inválido"
+		}
+	case "name":
+		if utils.ValidateName(s) != nil {
+			return fmt.Sprintf("Deve ter no máximo %d caracteres", utils.MaxNameLength)
+		}
+	case "password":
+		if utils.ValidatePassword(s) != nil {
+			return fmt.Sprintf("Deve ter entre %d e %d caracteres", utils.MinPasswordLength, utils.MaxPasswordLength)
+		}
+	case "cpf":
+		if _, err := documents.NormalizeCPF(s); err != nil {
+			return "CPF inválido"
+		}
+	case "cnpj":
+		if _, err := documents.NormalizeCNPJ(s); err != nil {
+			return "CNPJ inválido"
+		}
+	case "cep":
+		if _, err := documents.NormalizeCEP(s); err != nil {
+			return "CEP inválido"
+		}
+	case "uf":
+		if _, err := documents.NormalizeUF(s); err != nil {
+			return "UF inválida"
+		}
+	case "phone":
+		if _, err := documents.NormalizePhone(s); err != nil {
+			return "Telefone inválido"
+		}
+	default:
+		panic("validation: regra desconhecida para string: " + r.name)
+	}
+	return ""
+}
+
+func validateInt(n int64, path string, rules []rule, errs *[]schemas.FieldError) {
+	for _, r := range rules {
+		message := ""
+		switch r.name {
+		case "required":
+			if n == 0 {
+				message = "Campo obrigatório"
+			}
+		case "gt":
+			if n <= int64(intParam(r)) {
+				message = fmt.Sprintf("Deve ser maior que %d", intParam(r))
+			}
+		case "min":
+			if n < int64(intParam(r)) {
+				message = fmt.Sprintf("Deve ser no mínimo %d", intParam(r))
+			}
+		case "max":
+			if n > int64(intParam(r)) {
+				message = fmt.Sprintf("Deve ser no máximo %d", intParam(r))
+			}
+		default:
+			panic("validation: regra desconhecida para número: " + r.name)
+		}
+
+		if message != "" {
+			addError(errs, path, message)
+			return
+		}
+	}
+}
+
+func validateSlice(v reflect.Value, path string, rules []rule, errs *[]schemas.FieldError) {
+	itemRules := []rule{}
+	for _, r := range rules {
+		message := ""
+		switch r.name {
+		case "required":
+			if v.Len() == 0 {
+				message = "Campo obrigatório"
+			}
+		case "min":
+			if v.Len() < intParam(r) {
+				message = fmt.Sprintf("Deve ter pelo menos %d itens", intParam(r))
+			}
+		case "max":
+			if v.Len() > intParam(r) {
+				message = fmt.Sprintf("Deve ter no máximo %d itens", intParam(r))
+			}
+		default:
+			itemRules = append(itemRules, r)
+		}
+
+		if message != "" {
+			addError(errs, path, message)
+			return
+		}
+	}
+
+	for i := range v.Len() {
+		validateValue(v.Index(i), path+"["+strconv.Itoa(i)+"]", itemRules, errs)
+	}
+}
+
+func intParam(r rule) int {
+	n, err := strconv.Atoi(r.param)
+	if err != nil {
+		panic("validation: parâmetro inválido na regra " + r.name + "=" + r.param)
+	}
+	return n
+}
+
+func fieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+func joinPath(prefix string, name string) string {
+	if prefix == "" {
+		return name
+	}
+	if name == "" {
+		return prefix
+	}
+	return prefix + "." + name
+}
+
+func addError(errs *[]schemas.FieldError, field string, message string) {
+	*errs = append(*errs, schemas.FieldError{Field: field, Message: message})
+}
@@ -0,0 +1,123 @@
+package validation
+
+import (
+	"api/schemas"
+	"reflect"
+	"testing"
+)
+
+func fields(errs []schemas.FieldError) []string {
+	names := []string{}
+	for _, err := range errs {
+		names = append(names, err.Field)
+	}
+	return names
+}
+
+func TestValidateClientUpdate(t *testing.T) {
+	valid := schemas.ClientUpdateRequest{
+		Email:      "time@example.com",
+		PersonType: "PF",
+		CPF:        "123.456.789-09",
+		CellPhone:  "(41) 99999-8888",
+		ZipCode:    "80000-000",
+		State:      "PR",
+	}
+	if errs := Validate(valid); errs != nil {
+		t.Fatalf("Validate(valid) = %+v, want nil", errs)
+	}
+
+	invalid := schemas.ClientUpdateRequest{
+		Email:      "time@",
+		PersonType: "X",
+		CPF:        "123",
+		CNPJ:       "12.345.678/0001",
+		CellPhone:  "9999",
+		ZipCode:    "80000",
+		State:      "XX",
+	}
+	want := []string{"email", "person_type", "cpf", "cell_phone", "zip_code", "state", "cnpj"}
+	if got := fields(Validate(invalid)); !reflect.DeepEqual(got, want) {
+		t.Errorf("fields = %v, want %v", got, want)
+	}
+}
+
+func TestValidateNestedPaths(t *testing.T) {
+	req := schemas.PlayersUpdateRequest{
+		Updates: []schemas.SketchPlayersUpdate{
+			{SketchID: "a", Players: []schemas.Player{{Name: "Ok"}}},
+			{Players: []schemas.Player{{Name: "Ok"}, {ShirtSize: "MUITO-GRANDE-DEMAIS"}}},
+		},
+	}
+
+	want := []string{"updates[1].sketch_id", "updates[1].players[1].shirt_size"}
+	if got := fields(Validate(req)); !reflect.DeepEqual(got, want) {
+		t.Errorf("fields = %v, want %v", got, want)
+	}
+}
+
+func TestValidateOptionalPointers(t *testing.T) {
+	if errs := Validate(schemas.AdminUserUpdateRequest{}); errs != nil {
+		t.Errorf("empty update: %+v, want nil", errs)
+	}
+
+	empty, short, role := "", "curta", "dono"
+	req := schemas.AdminUserUpdateRequest{Password: &empty}
+	if got := fields(Validate(req)); !reflect.DeepEqual(got, []string{"password"}) {
+		t.Errorf("empty password: fields = %v", got)
+	}
+
+	req = schemas.AdminUserUpdateRequest{Password: &short, Role: &role}
+	if got := fields(Validate(req)); !reflect.DeepEqual(got, []string{"role", "password"}) {
+		t.Errorf("short password and unknown role: fields = %v", got)
+	}
+}
+
+func TestValidateCrossFieldRules(t *testing.T) {
+	errs := Validate(schemas.SigninTwoFactorRequest{ChallengeToken: "abc"})
+	if got := fields(errs); !reflect.DeepEqual(got, []string{"code"}) {
+		t.Errorf("fields = %v, want [code]", got)
+	}
+
+	if errs := Validate(schemas.SigninTwoFactorRequest{ChallengeToken: "abc", RecoveryCode: "x"}); errs != nil {
+		t.Errorf("recovery code: %+v, want nil", errs)
+	}
+}
+
+// As tags de todos os tipos de requisição precisam ser regras conhecidas;
+// uma regra inválida faria Validate entrar em pânico na primeira requisição.
+func TestRequestTagsAreValid(t *testing.T) {
+	filled := schemas.Sketch{ID: "a", Players: []schemas.Player{{Name: "x"}}}
+	for _, req := range []any{
+		schemas.AdminUniformCreateRequest{ClientEmail: "x", BudgetID: 1, Sketches: []schemas.Sketch{filled}},
+		schemas.UpdatePlayersDataRequest{BudgetID: 1, Players: []schemas.Player{{Name: "x", Gender: "x"}}},
+		schemas.AdminClientUnlockRequest{Email: "x"},
+		schemas.AdminSigninRequest{Email: "x", Password: "x"},
+		schemas.AdminUserCreateRequest{Name: "x", Email: "x", Password: "x", Role: "x"},
+		schemas.ApiKeyCreateRequest{Name: "x", Scopes: []string{"x"}, ExpiresInDays: 1},
+		schemas.AuthTokenRefreshRequest{RefreshToken: "x"},
+		schemas.PasswordForgotRequest{Email: "x"},
+		schemas.PasswordResetRequest{Token: "x", Password: "x"},
+		schemas.EmailVerifyRequest{Token: "x"},
+		schemas.EmailVerifyResendRequest{Email: "x"},
+		schemas.TwoFactorCodeRequest{Code: "x"},
+		schemas.SigninTwoFactorRequest{ChallengeToken: "x", Code: "x"},
+		schemas.ClientLoginRequest{Email: "x", Password: "x"},
+		schemas.ClientCreateRequest{Name: "x", Email: "x", Password: "x"},
+		schemas.ClientAddBudgetRequest{Email: "x", BudgetID: 1},
+		schemas.ClientsByBudgetIDsRequest{BudgetIDs: []int{1}},
+		schemas.UniformCreateRequest{ClientID: "x", BudgetID: 1, Sketches: []schemas.Sketch{filled}},
+		schemas.UniformUpdateRequest{BudgetID: 1, Sketches: []schemas.Sketch{filled}},
+		schemas.PlayersUpdateRequest{Updates: []schemas.SketchPlayersUpdate{{SketchID: "x", Players: filled.Players}}},
+	} {
+		Validate(req)
+	}
+
+	Validate(schemas.ClientUpdateRequest{
+		Name: "x", Email: "x", Password: "x", PersonType: "x", IdentityCard: "x", CPF: "x", CellPhone: "x",
+		ZipCode: "x", Address: "x", Number: "x", Complement: "x", Neighborhood: "x", City: "x", State: "x",
+		CompanyName: "x", CNPJ: "x", StateRegistration: "x", BillingZipCode: "x", BillingAddress: "x",
+		BillingNumber: "x", BillingComplement: "x", BillingNeighborhood: "x", BillingCity: "x",
+		BillingState: "x", Status: "x",
+	})
+}